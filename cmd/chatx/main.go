@@ -2,11 +2,28 @@
 // It is responsible for bootstrapping and running the app.
 package main
 
-import "chatX/internal/app"
+import (
+	"chatX/internal/app"
+	"os"
+)
+
+// defaultProbeURL is the endpoint probed by "chatX healthcheck" without an argument.
+const defaultProbeURL = "http://localhost:8080/readyz"
 
 // main initializes the application and starts its execution lifecycle.
+//
+// Run as "chatX healthcheck [url]", it instead probes a health endpoint
+// of a running instance and exits with 0 if it is healthy.
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		url := defaultProbeURL
+		if len(os.Args) > 2 {
+			url = os.Args[2]
+		}
+		os.Exit(app.Probe(url))
+	}
+
 	app.Boot().Run()
 
 }
@@ -3,19 +3,28 @@
 package app
 
 import (
+	"chatX/internal/blob"
+	"chatX/internal/broker"
 	"chatX/internal/cache"
 	"chatX/internal/config"
+	"chatX/internal/eventbus"
 	"chatX/internal/handler"
+	"chatX/internal/health"
 	"chatX/internal/logger"
+	"chatX/internal/metrics"
+	"chatX/internal/notifier"
 	"chatX/internal/repository"
 	"chatX/internal/server"
 	"chatX/internal/service"
+	"chatX/internal/tracing"
 	"context"
 	"fmt"
 	"log"
+	"net/netip"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/pressly/goose/v3"
 	"gorm.io/gorm"
@@ -24,13 +33,23 @@ import (
 // App represents the main application container.
 // It holds all core dependencies and controls the application lifecycle.
 type App struct {
-	logger  logger.Logger      // Application-wide logger
-	logFile *os.File           // Log file handler
-	server  server.Server      // HTTP server instance
-	ctx     context.Context    // Root application context
-	cancel  context.CancelFunc // Context cancellation function
-	cache   cache.Cache        // Cache layer implementation
-	storage repository.Storage // Persistent storage layer
+	logger   logger.Logger      // Application-wide logger
+	logFile  *os.File           // Log file handler
+	server   server.Server      // HTTP server instance
+	ctx      context.Context    // Root application context
+	cancel   context.CancelFunc // Context cancellation function
+	cache    cache.Cache        // Cache layer implementation
+	storage  repository.Storage // Persistent storage layer
+	service  service.Service    // Business logic layer
+	broker   broker.Broker      // In-process event broker
+	notifier notifier.Notifier  // In-process long-polling notifier
+	bus      eventbus.EventBus  // Cross-instance cache invalidation bus
+	busCtx   context.Context    // Context of the event bus listener, outliving the root context until the server has shut down
+	busStop  context.CancelFunc // Cancels the context of the event bus listener
+	busDone  chan struct{}      // Closed once the event bus listener has stopped
+	gcDone   chan struct{}      // Closed once the idempotency key collector has stopped
+	tracing  tracing.Provider   // OpenTelemetry tracer provider
+	drain    time.Duration      // Time between reporting not ready and shutting down the server
 }
 
 // Boot initializes the application by loading configuration,
@@ -44,64 +63,112 @@ func Boot() *App {
 
 	logger, logFile := logger.NewLogger(config.Logger)
 
-	db, err := bootstrapDB(logger, config.Storage)
+	if config.Service.TokenSecret == "" {
+		logger.LogFatal("app — AUTH_SECRET is not set", nil, "layer", "app")
+	}
+
+	if config.RateLimit.Enabled && !validRateBudget(config.RateLimit.Reads, config.RateLimit.Writes) {
+		logger.LogFatal("app — rate limit budgets must have a positive rate and burst", nil, "layer", "app")
+	}
+
+	if !validProxies(config.Server.TrustedProxies) {
+		logger.LogFatal("app — trusted proxies must be IP addresses or CIDR ranges", nil, "layer", "app")
+	}
+
+	tracing := tracing.NewProvider(logger, config.Tracing)
+
+	db, schemaVersion, err := bootstrapDB(logger, config.Storage)
 	if err != nil {
 		logger.LogFatal("app — failed to bootstrap database", err, "layer", "app")
 	}
 
-	return wireApp(db, logger, logFile, config)
+	return wireApp(db, schemaVersion, logger, logFile, tracing, config)
 
 }
 
-// bootstrapDB initializes the database connection,
-// applies migrations, and returns a configured gorm.DB instance.
-func bootstrapDB(logger logger.Logger, config config.Storage) (*gorm.DB, error) {
+// bootstrapDB initializes the database connection, applies migrations,
+// and returns a configured gorm.DB instance along with the schema version
+// of the latest migration, which readiness checks expect the database to be at.
+func bootstrapDB(logger logger.Logger, config config.Storage) (*gorm.DB, int64, error) {
 
 	gormDB, err := repository.ConnectDB(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to DB via gorm: %w", err)
+		return nil, 0, fmt.Errorf("failed to connect to DB via gorm: %w", err)
 	}
 
 	logger.LogInfo("app — connected to database", "layer", "app")
 
 	if err := goose.SetDialect(config.Dialect); err != nil {
-		return nil, fmt.Errorf("failed to set goose dialect: %w", err)
+		return nil, 0, fmt.Errorf("failed to set goose dialect: %w", err)
 	}
 
 	db, err := gormDB.DB()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get sql.DB from gorm: %w", err)
+		return nil, 0, fmt.Errorf("failed to get sql.DB from gorm: %w", err)
 	}
 
 	if err := goose.Up(db, config.MigrationsDir); err != nil {
-		return nil, fmt.Errorf("failed to apply goose migrations: %w", err)
+		return nil, 0, fmt.Errorf("failed to apply goose migrations: %w", err)
+	}
+
+	migrations, err := goose.CollectMigrations(config.MigrationsDir, 0, goose.MaxVersion)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to collect goose migrations: %w", err)
 	}
 
-	logger.Debug("app — migrations applied", "layer", "app")
+	latest, err := migrations.Last()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to find latest goose migration: %w", err)
+	}
 
-	return gormDB, nil
+	logger.Debug("app — migrations applied", "version", latest.Version, "layer", "app")
+
+	return gormDB, latest.Version, nil
 
 }
 
 // wireApp constructs the App instance by wiring together
 // all infrastructure, domain services, handlers, and the server.
-func wireApp(db *gorm.DB, logger logger.Logger, logFile *os.File, config config.Config) *App {
+func wireApp(db *gorm.DB, schemaVersion int64, logger logger.Logger, logFile *os.File, tracing tracing.Provider, config config.Config) *App {
 
 	ctx, cancel := newContext(logger)
 	storge := repository.NewStorage(logger, config.Storage, db)
 	cache := cache.NewCache(logger, config.Cache)
-	service := service.NewService(logger, config.Service, cache, storge)
-	handler := handler.NewHandler(logger, config.Logger.RequestLogging, service)
+	broker := broker.NewBroker(logger, config.Broker)
+	notifier := notifier.NewNotifier(logger)
+	bus := eventbus.NewEventBus(logger, config.EventBus, config.Storage, db)
+	blobs := blob.NewBlobStore(logger, config.Blob)
+	service := service.NewService(logger, config.Service, cache, storge, broker, notifier, bus, blobs)
+	metrics := metrics.NewMetrics(cache, storge, service)
+	health := health.NewChecker(logger, config.Server, storge, schemaVersion, ctx.Done())
+	handler := handler.NewHandler(logger, config.Logger.RequestLogging, config.Server.TrustedProxies, config.RateLimit, config.Service, metrics, health, service)
 	server := server.NewServer(logger, config.Server, handler)
 
+	server.RegisterOnShutdown(broker.Close)
+	server.RegisterOnShutdown(notifier.Close)
+
+	// requests still served while draining and shutting down change chats,
+	// so the caches keep being invalidated until the server has stopped
+	busCtx, busStop := context.WithCancel(context.Background())
+
 	return &App{
-		logger:  logger,
-		logFile: logFile,
-		server:  server,
-		ctx:     ctx,
-		cancel:  cancel,
-		cache:   cache,
-		storage: storge,
+		logger:   logger,
+		logFile:  logFile,
+		server:   server,
+		ctx:      ctx,
+		cancel:   cancel,
+		cache:    cache,
+		storage:  storge,
+		service:  service,
+		broker:   broker,
+		notifier: notifier,
+		bus:      bus,
+		busCtx:   busCtx,
+		busStop:  busStop,
+		busDone:  make(chan struct{}),
+		gcDone:   make(chan struct{}),
+		tracing:  tracing,
+		drain:    config.Server.DrainDelay,
 	}
 
 }
@@ -128,10 +195,26 @@ func newContext(logger logger.Logger) (context.Context, context.CancelFunc) {
 
 }
 
-// Run starts the HTTP server and blocks until
-// the application context is cancelled.
+// Run starts the HTTP server, the event bus listener and the idempotency key
+// collector and blocks until the application context is cancelled.
+// The event bus is not listened to with a shared cache.
 func (a *App) Run() {
 
+	go func() {
+		defer close(a.busDone)
+		// a shared cache already holds the changes of all instances; evicting from it
+		// on their behalf, or purging it on every reconnect, would only throw them away
+		if a.cache.Shared() {
+			return
+		}
+		a.bus.Listen(a.busCtx, a.cache.Delete, a.cache.Purge)
+	}()
+
+	go func() {
+		defer close(a.gcDone)
+		a.service.CollectIdempotencyKeys(a.ctx)
+	}()
+
 	go func() {
 		if err := a.server.Run(); err != nil {
 			a.logger.LogFatal("server run failed", err, "layer", "app")
@@ -147,13 +230,51 @@ func (a *App) Run() {
 // stop gracefully shuts down all application resources.
 func (a *App) stop() {
 
+	// readiness has reported not ready since the context was cancelled;
+	// keep serving while load balancers stop routing requests here
+	if a.drain > 0 {
+		a.logger.LogInfo("app — draining traffic before shutdown", "delay", a.drain.String(), "layer", "app")
+		time.Sleep(a.drain)
+	}
+
 	a.server.Shutdown()
 
+	a.broker.Close()
+	a.notifier.Close()
+
+	a.busStop()
+	<-a.busDone // the listener must not touch the cache after it is closed
 	a.cache.Close()
+
+	<-a.gcDone // the collector must not use the storage after it is closed
 	a.storage.Close()
 
+	a.tracing.Shutdown()
+
 	if a.logFile != nil && a.logFile != os.Stdout {
 		_ = a.logFile.Close()
 	}
 
 }
+
+// validRateBudget reports whether all the given rate limit budgets have a positive rate and burst.
+func validRateBudget(budgets ...config.RateBudget) bool {
+	for _, budget := range budgets {
+		if budget.Rate <= 0 || budget.Burst <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// validProxies reports whether every trusted proxy is an IP address or a CIDR range.
+func validProxies(proxies []string) bool {
+	for _, proxy := range proxies {
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			if _, err := netip.ParseAddr(proxy); err != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
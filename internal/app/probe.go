@@ -0,0 +1,37 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// probeTimeout bounds the time a health probe waits for the response.
+const probeTimeout = 3 * time.Second
+
+// Probe requests a health endpoint of a running instance, such as
+// http://localhost:8080/readyz, and returns the exit code of the probe:
+// 0 if the endpoint responded 200 OK, and 1 otherwise.
+//
+// It lets container health checks probe the service from an image
+// that ships no HTTP client of its own.
+func Probe(url string) int {
+
+	client := http.Client{Timeout: probeTimeout}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "probe — request failed: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "probe — %s responded %s\n", url, resp.Status)
+		return 1
+	}
+
+	return 0
+
+}
@@ -0,0 +1,30 @@
+// Package blob provides an interface and factory function
+// for storing message attachments outside the database.
+package blob
+
+import (
+	"chatX/internal/blob/local"
+	"chatX/internal/blob/s3"
+	"chatX/internal/config"
+	"chatX/internal/logger"
+	"context"
+	"io"
+)
+
+// BlobStore defines the interface for storing attachment contents by key.
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error // Put stores size bytes of content under the key, replacing any previous blob.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)                                   // Get opens the blob stored under the key. Returns ErrBlobNotFound if there is none.
+	Delete(ctx context.Context, key string) error                                                 // Delete removes the blob stored under the key; deleting a missing blob is not an error.
+}
+
+// NewBlobStore creates a new BlobStore implementation based on configuration.
+//
+// The "s3" backend stores blobs in a bucket of an S3-compatible service;
+// any other backend stores them in a local directory.
+func NewBlobStore(logger logger.Logger, config config.Blob) BlobStore {
+	if config.Backend == "s3" {
+		return s3.NewStore(logger, config)
+	}
+	return local.NewStore(logger, config)
+}
@@ -0,0 +1,116 @@
+// Package local provides a BlobStore implementation
+// that keeps blobs as files in a local directory.
+package local
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/errs"
+	"chatX/internal/logger"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Store keeps every blob in a file named after its key below the root directory.
+type Store struct {
+	root   string        // Root directory of all blobs
+	logger logger.Logger // Logger instance
+}
+
+// NewStore creates a new Store rooted at the configured directory, which is created on first write.
+func NewStore(logger logger.Logger, config config.Blob) *Store {
+	return &Store{root: config.Directory, logger: logger}
+}
+
+// path maps a key to a file below the root directory, rejecting keys that would escape it.
+func (s *Store) path(key string) (string, error) {
+	if !filepath.IsLocal(key) {
+		return "", fmt.Errorf("local blob store: invalid key %q", key)
+	}
+	return filepath.Join(s.root, key), nil
+}
+
+// Put writes the content to a temporary file and renames it into place,
+// so that readers never observe a partially written blob.
+func (s *Store) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name()) // no-op once renamed
+
+	written, err := io.Copy(file, io.LimitReader(content, size))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if written != size {
+		return fmt.Errorf("local blob store: expected %d bytes, got %d", size, written)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return err
+	}
+
+	s.logger.Debug("blob — stored", "key", key, "size", size, "layer", "blob.local")
+	return nil
+
+}
+
+// Get opens the file of the blob for reading.
+func (s *Store) Get(_ context.Context, key string) (io.ReadCloser, error) {
+
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, errs.ErrBlobNotFound
+		}
+		return nil, err
+	}
+
+	return file, nil
+
+}
+
+// Delete removes the file of the blob.
+func (s *Store) Delete(_ context.Context, key string) error {
+
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	s.logger.Debug("blob — deleted", "key", key, "layer", "blob.local")
+	return nil
+
+}
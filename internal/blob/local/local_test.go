@@ -0,0 +1,80 @@
+package local
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/errs"
+	"chatX/internal/logger/mocks"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func setupStore(t *testing.T) *Store {
+
+	controller := gomock.NewController(t)
+	logger := mocks.NewMockLogger(controller)
+
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogError(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	return NewStore(logger, config.Blob{Directory: filepath.Join(t.TempDir(), "blobs")})
+
+}
+
+func TestStore_PutGetDelete(t *testing.T) {
+
+	store := setupStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "attachments/abc", strings.NewReader("hello"), 5, "text/plain"))
+
+	content, err := store.Get(ctx, "attachments/abc")
+	require.NoError(t, err)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.NoError(t, content.Close())
+	require.Equal(t, "hello", string(data))
+
+	require.NoError(t, store.Delete(ctx, "attachments/abc"))
+	require.NoError(t, store.Delete(ctx, "attachments/abc"))
+
+	_, err = store.Get(ctx, "attachments/abc")
+	require.ErrorIs(t, err, errs.ErrBlobNotFound)
+
+}
+
+func TestStore_Put_ShortContent_LeavesNoBlob(t *testing.T) {
+
+	store := setupStore(t)
+	ctx := context.Background()
+
+	require.Error(t, store.Put(ctx, "short", strings.NewReader("hel"), 5, "text/plain"))
+
+	_, err := store.Get(ctx, "short")
+	require.ErrorIs(t, err, errs.ErrBlobNotFound)
+
+	entries, err := os.ReadDir(store.root)
+	require.NoError(t, err)
+	require.Empty(t, entries, "temporary files must be removed")
+
+}
+
+func TestStore_RejectsKeysOutsideRoot(t *testing.T) {
+
+	store := setupStore(t)
+	ctx := context.Background()
+
+	require.Error(t, store.Put(ctx, "../escape", strings.NewReader("x"), 1, "text/plain"))
+	_, err := store.Get(ctx, "/etc/passwd")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, errs.ErrBlobNotFound)
+
+}
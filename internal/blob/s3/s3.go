@@ -0,0 +1,143 @@
+// Package s3 provides a BlobStore implementation backed by
+// an S3-compatible object storage service such as AWS S3 or MinIO.
+package s3
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/errs"
+	"chatX/internal/logger"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultRegion = "us-east-1" // defaultRegion is assumed when none is configured; MinIO accepts it as well
+
+// Store keeps every blob as an object of a single bucket, addressed path-style.
+type Store struct {
+	client   *http.Client  // HTTP client sending the requests
+	endpoint string        // Base URL of the service without a trailing slash
+	bucket   string        // Bucket holding the objects
+	signer   signer        // Signer of outgoing requests
+	logger   logger.Logger // Logger instance
+}
+
+// NewStore creates a new Store for the configured endpoint and bucket.
+func NewStore(logger logger.Logger, config config.Blob) *Store {
+
+	region := config.Region
+	if region == "" {
+		region = defaultRegion
+	}
+
+	return &Store{
+		client:   &http.Client{},
+		endpoint: strings.TrimRight(config.Endpoint, "/"),
+		bucket:   config.Bucket,
+		signer:   signer{accessKey: config.AccessKey, secretKey: config.SecretKey, region: region, service: "s3"},
+		logger:   logger,
+	}
+
+}
+
+// objectURL returns the path-style URL of the object stored under the key.
+func (s *Store) objectURL(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return s.endpoint + "/" + url.PathEscape(s.bucket) + "/" + strings.Join(segments, "/")
+}
+
+// do signs and sends a request for the object stored under the key.
+func (s *Store) do(ctx context.Context, method, key string, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	if body != nil {
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	s.signer.sign(req, unsignedPayload, time.Now())
+
+	return s.client.Do(req)
+
+}
+
+// Put uploads the content as an object with the given content type.
+func (s *Store) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+
+	resp, err := s.do(ctx, http.MethodPut, key, io.LimitReader(content, size), size, http.Header{"Content-Type": {contentType}})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(http.MethodPut, key, resp)
+	}
+
+	s.logger.Debug("blob — stored", "key", key, "size", size, "layer", "blob.s3")
+	return nil
+
+}
+
+// Get downloads the object; the caller must close the returned body.
+func (s *Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, errs.ErrBlobNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, responseError(http.MethodGet, key, resp)
+	}
+
+}
+
+// Delete removes the object. S3 answers deletes of missing objects with success as well.
+func (s *Store) Delete(ctx context.Context, key string) error {
+
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return responseError(http.MethodDelete, key, resp)
+	}
+
+	s.logger.Debug("blob — deleted", "key", key, "layer", "blob.s3")
+	return nil
+
+}
+
+// responseError describes an unexpected response, including the start of the error document.
+func responseError(method, key string, resp *http.Response) error {
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("s3 blob store: %s %s: %s: %s", method, key, resp.Status, strings.TrimSpace(string(detail)))
+}
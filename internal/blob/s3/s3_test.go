@@ -0,0 +1,131 @@
+package s3
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/errs"
+	"chatX/internal/logger/mocks"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// standIn is a minimal in-memory S3-compatible service serving path-style object requests.
+type standIn struct {
+	mu      sync.Mutex
+	objects map[string][]byte // object contents by bucket and key path
+	types   map[string]string // content types by bucket and key path
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), signingAlgorithm+" Credential=AK/") ||
+		r.Header.Get("X-Amz-Content-Sha256") != unsignedPayload || r.Header.Get("X-Amz-Date") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		s.objects[r.URL.Path] = data
+		s.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		data, ok := s.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		_, _ = w.Write(data)
+	case http.MethodDelete:
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+}
+
+func setupStore(t *testing.T, accessKey string) (*Store, *standIn) {
+
+	controller := gomock.NewController(t)
+	logger := mocks.NewMockLogger(controller)
+
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogError(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	service := &standIn{objects: make(map[string][]byte), types: make(map[string]string)}
+	server := httptest.NewServer(service)
+	t.Cleanup(server.Close)
+
+	return NewStore(logger, config.Blob{Endpoint: server.URL + "/", Bucket: "chatx", AccessKey: accessKey, SecretKey: "secret"}), service
+
+}
+
+func TestStore_PutGetDelete(t *testing.T) {
+
+	store, service := setupStore(t, "AK")
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "attachments/abc", strings.NewReader("hello, world"), 5, "text/plain"))
+	require.Equal(t, "hello", string(service.objects["/chatx/attachments/abc"]))
+	require.Equal(t, "text/plain", service.types["/chatx/attachments/abc"])
+
+	content, err := store.Get(ctx, "attachments/abc")
+	require.NoError(t, err)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.NoError(t, content.Close())
+	require.Equal(t, "hello", string(data))
+
+	require.NoError(t, store.Delete(ctx, "attachments/abc"))
+
+	_, err = store.Get(ctx, "attachments/abc")
+	require.ErrorIs(t, err, errs.ErrBlobNotFound)
+
+}
+
+func TestStore_Put_EmptyBlob(t *testing.T) {
+
+	store, service := setupStore(t, "AK")
+
+	require.NoError(t, store.Put(context.Background(), "empty", strings.NewReader(""), 0, "text/plain"))
+	require.Contains(t, service.objects, "/chatx/empty")
+
+}
+
+func TestStore_UnexpectedStatus_ReturnsError(t *testing.T) {
+
+	store, _ := setupStore(t, "wrong")
+
+	err := store.Put(context.Background(), "key", strings.NewReader("x"), 1, "text/plain")
+	require.ErrorContains(t, err, "403")
+
+}
+
+// TestSigner_MatchesReferenceSignature checks the signer against the get-vanilla
+// case of the AWS Signature Version 4 test suite.
+func TestSigner_MatchesReferenceSignature(t *testing.T) {
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+
+	signer{accessKey: "AKIDEXAMPLE", secretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", region: "us-east-1", service: "service"}.
+		sign(req, hashHex(nil), time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	require.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+
+}
@@ -0,0 +1,99 @@
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	signingAlgorithm = "AWS4-HMAC-SHA256" // signingAlgorithm is the only algorithm of Signature Version 4
+	unsignedPayload  = "UNSIGNED-PAYLOAD" // unsignedPayload lets streamed bodies go unhashed; TLS protects them in transit
+	amzDateFormat    = "20060102T150405Z" // amzDateFormat is the timestamp format of X-Amz-Date
+)
+
+// signer signs requests with AWS Signature Version 4.
+type signer struct {
+	accessKey string // Access key ID
+	secretKey string // Secret access key
+	region    string // Region of the service
+	service   string // Signed service name, "s3" for object storage
+}
+
+// sign adds the X-Amz-Date and Authorization headers to the request.
+//
+// The Host header and all X-Amz-* headers already set on the request are signed,
+// together with the payload hash passed in.
+func (s signer) sign(req *http.Request, payloadHash string, now time.Time) {
+
+	now = now.UTC()
+	date := now.Format(amzDateFormat)
+	req.Header.Set("X-Amz-Date", date)
+
+	headers := map[string]string{"host": req.Host}
+	if req.Host == "" {
+		headers["host"] = req.URL.Host
+	}
+	for name, values := range req.Header {
+		if name := strings.ToLower(name); strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date[:8], s.region, s.service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{signingAlgorithm, date, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date[:8])
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", signingAlgorithm+" Credential="+s.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+
+}
+
+// canonicalQuery encodes query parameters sorted by name, as Signature Version 4 requires.
+func canonicalQuery(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+// hashHex returns the hex-encoded SHA-256 hash of the data.
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the data under the key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,22 @@
+// Package broker provides an interface and factory function
+// for delivering chat events to in-process subscribers.
+package broker
+
+import (
+	"chatX/internal/broker/memory"
+	"chatX/internal/config"
+	"chatX/internal/logger"
+	"chatX/internal/models"
+)
+
+// Broker defines the interface for fanning out chat events to subscribers.
+type Broker interface {
+	Publish(event models.Event)                         // Publish delivers the event to all subscribers of its chat.
+	Subscribe(chatID int) (<-chan models.Event, func()) // Subscribe returns a channel of chat events and a function to unsubscribe. The channel is closed when the subscription ends.
+	Close()                                             // Close ends all subscriptions and rejects new ones.
+}
+
+// NewBroker creates a new Broker implementation based on configuration.
+func NewBroker(logger logger.Logger, config config.Broker) Broker {
+	return memory.NewHub(logger, config)
+}
@@ -0,0 +1,140 @@
+// Package memory provides an in-process event broker that fans out
+// chat events to subscribers through buffered channels.
+package memory
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/logger"
+	"chatX/internal/models"
+	"sync"
+)
+
+// subscriber holds the buffered channel of a single subscription.
+type subscriber struct {
+	events chan models.Event // Buffered channel of pending events
+}
+
+// Hub is a thread-safe in-process broker for chat events.
+//
+// Every subscriber owns a buffered channel. Publishing never blocks: a subscriber
+// whose buffer is full is considered a slow consumer and is disconnected by closing
+// its channel.
+type Hub struct {
+	mu          sync.Mutex                       // Mutex for concurrent access
+	subscribers map[int]map[*subscriber]struct{} // Subscribers grouped by chat ID
+	closed      bool                             // Whether the hub has been closed
+	config      config.Broker                    // Broker configuration
+	logger      logger.Logger                    // Logger instance
+}
+
+// defaultSendBuffer is the number of events buffered per subscriber when none is configured.
+const defaultSendBuffer = 64
+
+// NewHub creates a new Hub instance with the given logger and config.
+//
+// A SendBuffer that is not positive, e.g. because it is not configured, is replaced with
+// a default, since without a buffer every subscriber would be disconnected on its first event.
+func NewHub(logger logger.Logger, config config.Broker) *Hub {
+
+	if config.SendBuffer <= 0 {
+		config.SendBuffer = defaultSendBuffer
+	}
+
+	return &Hub{
+		subscribers: make(map[int]map[*subscriber]struct{}),
+		config:      config,
+		logger:      logger,
+	}
+
+}
+
+// Subscribe registers a new subscriber for the chat.
+//
+// It returns the subscriber's event channel and a function that ends the subscription.
+// If the hub is already closed, the returned channel is closed immediately.
+func (h *Hub) Subscribe(chatID int) (<-chan models.Event, func()) {
+
+	sub := &subscriber{events: make(chan models.Event, h.config.SendBuffer)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(sub.events)
+		return sub.events, func() {}
+	}
+
+	if h.subscribers[chatID] == nil {
+		h.subscribers[chatID] = make(map[*subscriber]struct{})
+	}
+	h.subscribers[chatID][sub] = struct{}{}
+
+	h.logger.Debug("broker — subscriber added", "chatID", chatID, "layer", "broker.memory")
+
+	return sub.events, func() { h.unsubscribe(chatID, sub) }
+
+}
+
+// unsubscribe removes the subscriber from the chat and closes its channel.
+// It is a no-op if the subscriber has already been removed.
+func (h *Hub) unsubscribe(chatID int, sub *subscriber) {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[chatID][sub]; ok {
+		h.remove(chatID, sub)
+		h.logger.Debug("broker — subscriber removed", "chatID", chatID, "layer", "broker.memory")
+	}
+
+}
+
+// remove deletes the subscriber from the map and closes its channel.
+// Must be called with the mutex held.
+func (h *Hub) remove(chatID int, sub *subscriber) {
+	delete(h.subscribers[chatID], sub)
+	if len(h.subscribers[chatID]) == 0 {
+		delete(h.subscribers, chatID)
+	}
+	close(sub.events)
+}
+
+// Publish delivers the event to every subscriber of its chat without blocking.
+// Subscribers with a full buffer are disconnected.
+func (h *Hub) Publish(event models.Event) {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[event.ChatID] {
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(event.ChatID, sub)
+			h.logger.LogWarn("broker — slow subscriber disconnected", "chatID", event.ChatID, "layer", "broker.memory")
+		}
+	}
+
+}
+
+// Close ends all subscriptions and rejects new ones. It is safe to call more than once.
+func (h *Hub) Close() {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	h.closed = true
+
+	for chatID, subs := range h.subscribers {
+		for sub := range subs {
+			h.remove(chatID, sub)
+		}
+	}
+
+	h.logger.LogInfo("broker — all subscriptions closed", "layer", "broker.memory")
+
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/logger/mocks"
+	"chatX/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func setupHub(t *testing.T, sendBuffer int) *Hub {
+
+	controller := gomock.NewController(t)
+	logger := mocks.NewMockLogger(controller)
+
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogError(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogFatal(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	return NewHub(logger, config.Broker{SendBuffer: sendBuffer})
+
+}
+
+func testEvent(chatID int, messageID int) models.Event {
+	return models.Event{Type: models.EventMessageCreated, ChatID: chatID, Message: models.Message{ID: messageID, ChatID: chatID}}
+}
+
+func TestHub_Publish_DeliversToChatSubscribers(t *testing.T) {
+
+	hub := setupHub(t, 4)
+
+	first, _ := hub.Subscribe(1)
+	second, _ := hub.Subscribe(1)
+	other, _ := hub.Subscribe(2)
+
+	hub.Publish(testEvent(1, 10))
+
+	require.Equal(t, 10, (<-first).Message.ID)
+	require.Equal(t, 10, (<-second).Message.ID)
+	require.Len(t, other, 0)
+
+}
+
+func TestHub_Unsubscribe_ClosesChannel(t *testing.T) {
+
+	hub := setupHub(t, 4)
+
+	events, unsubscribe := hub.Subscribe(1)
+	unsubscribe()
+	unsubscribe()
+
+	_, ok := <-events
+	require.False(t, ok)
+	require.Len(t, hub.subscribers, 0)
+
+	hub.Publish(testEvent(1, 10))
+
+}
+
+func TestHub_Publish_DisconnectsSlowSubscriber(t *testing.T) {
+
+	hub := setupHub(t, 1)
+
+	slow, _ := hub.Subscribe(1)
+	fast, _ := hub.Subscribe(1)
+
+	hub.Publish(testEvent(1, 10))
+	<-fast
+	hub.Publish(testEvent(1, 11))
+
+	require.Equal(t, 10, (<-slow).Message.ID)
+	_, ok := <-slow
+	require.False(t, ok)
+
+	require.Equal(t, 11, (<-fast).Message.ID)
+
+}
+
+func TestNewHub_ClampsSendBuffer(t *testing.T) {
+
+	hub := setupHub(t, 0)
+	require.Equal(t, defaultSendBuffer, hub.config.SendBuffer)
+
+	events, _ := hub.Subscribe(1)
+	hub.Publish(testEvent(1, 10))
+
+	require.Equal(t, 10, (<-events).Message.ID)
+
+}
+
+func TestHub_Close(t *testing.T) {
+
+	hub := setupHub(t, 4)
+
+	events, unsubscribe := hub.Subscribe(1)
+
+	hub.Close()
+	hub.Close()
+
+	_, ok := <-events
+	require.False(t, ok)
+	unsubscribe()
+
+	late, _ := hub.Subscribe(1)
+	_, ok = <-late
+	require.False(t, ok)
+
+}
@@ -4,20 +4,43 @@ package cache
 
 import (
 	"chatX/internal/cache/memory"
+	"chatX/internal/cache/redis"
 	"chatX/internal/config"
 	"chatX/internal/logger"
 	"chatX/internal/models"
+	"context"
 )
 
 // Cache defines the interface for a chat cache.
+//
+// Append and Update change a cached chat in place of evicting it, so writes to an active
+// chat do not force the next read to storage. They do nothing if the chat is not cached,
+// and evict it if the change would exceed the message limit of the cache.
+//
+// A chat deleted, or left uncached by Append or Update, is kept from being stored by Put for
+// the tombstone TTL of the cache, so a load that read it before the write does not cache it stale.
+//
+// A shared cache is changed by all instances at once, so it must not be invalidated on
+// behalf of other instances: their eviction would drop the change an instance just made.
 type Cache interface {
-	Get(key int) (models.Chat, error) // Get retrieves a chat by key. Returns ErrCacheMiss if not found.
-	Put(key int, value models.Chat)   // Put stores a chat in the cache by key.
-	Delete(key int)                   // Delete removes a chat from the cache by key.
-	Close()                           // Close releases all cache resources.
+	Get(ctx context.Context, key int) (models.Chat, error)                  // Get retrieves a chat by key. Returns ErrCacheMiss if not found.
+	Put(ctx context.Context, key int, value models.Chat)                    // Put stores a chat in the cache by key.
+	Append(ctx context.Context, key int, message models.Message, limit int) // Append adds a new message to a cached chat, keeping at most limit messages.
+	Update(ctx context.Context, key int, message models.Message)            // Update replaces an edited message in a cached chat.
+	Delete(key int)                                                         // Delete removes a chat from the cache by key.
+	Purge()                                                                 // Purge removes all chats from the cache.
+	Stats() models.CacheStats                                               // Stats returns a snapshot of the cache usage counters.
+	Shared() bool                                                           // Shared reports whether all instances use the same cached chats.
+	Close()                                                                 // Close releases all cache resources.
 }
 
 // NewCache creates a new Cache implementation based on configuration.
+//
+// The "redis" backend shares chats between instances through a Redis-compatible
+// server; any other backend keeps them in a sharded LRU cache in the memory of each instance.
 func NewCache(logger logger.Logger, config config.Cache) Cache {
-	return memory.NewLRUCache(logger, config)
+	if config.Backend == "redis" {
+		return redis.NewCache(logger, config)
+	}
+	return memory.NewShardedCache(logger, config)
 }
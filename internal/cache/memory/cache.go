@@ -1,5 +1,5 @@
 // Package memory provides in-memory cache implementations,
-// including an LRU cache for storing chats.
+// including an LRU cache for storing chats and a sharded variant of it.
 package memory
 
 import (
@@ -7,15 +7,27 @@ import (
 	"chatX/internal/errs"
 	"chatX/internal/logger"
 	"chatX/internal/models"
+	"context"
+	"math/rand/v2"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer creates the spans of cache operations.
+var tracer = otel.Tracer("chatX/internal/cache/memory")
+
 // Node represents a doubly-linked list node for LRUCache.
 type Node struct {
-	Key  int         // Cache key (chat ID)
-	Val  models.Chat // Cached chat
-	Next *Node       // Pointer to the next node
-	Prev *Node       // Pointer to the previous node
+	Key     int         // Cache key (chat ID)
+	Val     models.Chat // Cached chat
+	Expires time.Time   // Expiry timestamp, zero if the chat does not expire
+	Next    *Node       // Pointer to the next node
+	Prev    *Node       // Pointer to the previous node
 }
 
 // newNode creates a new linked-list node for the given key and chat.
@@ -24,27 +36,56 @@ func newNode(key int, value models.Chat) *Node {
 }
 
 // LRUCache is a thread-safe in-memory LRU cache for chats.
+//
+// With a TTL configured, chats expire after it: an expired chat is dropped when it is
+// looked up, and a background janitor sweeps expired chats that are never looked up again.
+//
+// With a tombstone TTL configured, a chat that is deleted, or written while not cached,
+// leaves a tombstone for that long, and Put does not store the chat until it has expired.
+// A load that read the chat from storage before the write therefore cannot cache it stale.
 type LRUCache struct {
-	mu     sync.RWMutex  // Mutex for concurrent access
-	head   *Node         // Dummy head node
-	tail   *Node         // Dummy tail node
-	hm     map[int]*Node // Map of keys to nodes
-	config config.Cache  // Cache configuration
-	logger logger.Logger // Logger instance
+	mu         sync.RWMutex      // Mutex for concurrent access
+	head       *Node             // Dummy head node
+	tail       *Node             // Dummy tail node
+	hm         map[int]*Node     // Map of keys to nodes
+	tombstones map[int]time.Time // Expiry timestamps of the tombstones by key
+	buried     int               // Number of tombstones above which expired ones are dropped
+	config     config.Cache      // Cache configuration
+	now        func() time.Time  // Clock, replaced in tests
+	janitor    *janitor          // Background sweep of expired chats, nil without TTL
+	logger     logger.Logger     // Logger instance
+
+	hits      atomic.Uint64 // Number of lookups that found a chat
+	misses    atomic.Uint64 // Number of lookups that did not find a chat
+	evictions atomic.Uint64 // Number of chats evicted at capacity
 }
 
 // NewLRUCache creates a new LRUCache instance with the given logger and config.
+//
+// If the cache is enabled and has a TTL, its janitor runs until Close.
 func NewLRUCache(logger logger.Logger, config config.Cache) *LRUCache {
+	c := newLRUCache(logger, config)
+	if config.Capacity > 0 && config.TTL > 0 {
+		c.janitor = newJanitor(config.TTL, c.sweep)
+	}
+	return c
+}
+
+// newLRUCache creates a new LRUCache instance without a janitor.
+func newLRUCache(logger logger.Logger, config config.Cache) *LRUCache {
 	head := newNode(0, models.Chat{})
 	tail := newNode(0, models.Chat{})
 	head.Next = tail
 	tail.Prev = head
 	return &LRUCache{
-		head:   head,
-		tail:   tail,
-		hm:     make(map[int]*Node, config.Capacity),
-		config: config,
-		logger: logger,
+		head:       head,
+		tail:       tail,
+		hm:         make(map[int]*Node, config.Capacity),
+		tombstones: make(map[int]time.Time),
+		buried:     config.Capacity,
+		config:     config,
+		now:        time.Now,
+		logger:     logger,
 	}
 }
 
@@ -67,29 +108,47 @@ func (c *LRUCache) insert(node *Node) {
 }
 
 // Get retrieves a chat from the cache by key and moves it to the front (most recently used).
-func (c *LRUCache) Get(key int) (models.Chat, error) {
+//
+// Promotion reorders the list, so lookups take the exclusive lock.
+func (c *LRUCache) Get(ctx context.Context, key int) (models.Chat, error) {
+
+	_, span := tracer.Start(ctx, "cache.Get", trace.WithAttributes(attribute.Int("chat.id", key)))
+	defer span.End()
 
 	if c.config.Capacity <= 0 {
+		c.misses.Add(1)
 		return models.Chat{}, errs.ErrCacheMiss
 	}
 
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if node, ok := c.hm[key]; ok {
+	if node, ok := c.hm[key]; ok && c.expired(node, c.now()) {
+		c.remove(node)
+		c.logger.Debug("cache — chat expired", "chatID", key, "layer", "cache.memory")
+	} else if ok {
 		c.logger.Debug("cache — chat found", "chatID", key, "layer", "cache.memory")
 		c.remove(node)
 		c.insert(node)
+		c.hits.Add(1)
+		span.SetAttributes(attribute.Bool("cache.hit", true))
 		return node.Val, nil
 	}
 	c.logger.Debug("cache — chat not found", "chatID", key, "layer", "cache.memory")
+	c.misses.Add(1)
+	span.SetAttributes(attribute.Bool("cache.hit", false))
 
 	return models.Chat{}, errs.ErrCacheMiss
 
 }
 
 // Put stores a chat in the cache. Evicts least-recently-used chat if capacity is exceeded.
-func (c *LRUCache) Put(key int, value models.Chat) {
+//
+// A chat with a tombstone that has not expired is not stored.
+func (c *LRUCache) Put(ctx context.Context, key int, value models.Chat) {
+
+	_, span := tracer.Start(ctx, "cache.Put", trace.WithAttributes(attribute.Int("chat.id", key)))
+	defer span.End()
 
 	if c.config.Capacity <= 0 {
 		return
@@ -103,6 +162,14 @@ func (c *LRUCache) Put(key int, value models.Chat) {
 		return
 	}
 
+	if expires, ok := c.tombstones[key]; ok {
+		if c.now().Before(expires) {
+			c.logger.Debug("cache — chat not cached: written while loading", "chatID", key, "layer", "cache.memory")
+			return
+		}
+		delete(c.tombstones, key)
+	}
+
 	if node, ok := c.hm[key]; ok {
 		c.remove(node)
 	}
@@ -111,15 +178,81 @@ func (c *LRUCache) Put(key int, value models.Chat) {
 		c.logger.Debug("cache — maximum capacity reached", "layer", "cache.memory")
 		lru := c.tail.Prev
 		c.remove(lru)
+		c.evictions.Add(1)
 		c.logger.Debug("cache — LRU chat deleted", "chatID", lru.Key, "layer", "cache.memory")
 	}
 
-	c.insert(newNode(key, value))
+	node := newNode(key, value)
+	if c.config.TTL > 0 {
+		node.Expires = c.now().Add(c.ttl())
+	}
+
+	c.insert(node)
 	c.logger.Debug("cache — chat saved", "chatID", key, "layer", "cache.memory")
 
 }
 
-// Delete removes a chat from the cache by key.
+// Append adds a new message to a cached chat, keeping at most limit messages, and moves
+// the chat to the front. A chat left with more messages than the cache holds is evicted.
+//
+// The expiry of the chat is kept, so it is still reloaded from storage once its TTL passes.
+func (c *LRUCache) Append(ctx context.Context, key int, message models.Message, limit int) {
+	c.update(ctx, "cache.Append", key, func(chat models.Chat) (models.Chat, bool) {
+		return chat.WithNewMessage(message, limit), true
+	})
+}
+
+// Update replaces an edited message in a cached chat and moves the chat to the front.
+//
+// The expiry of the chat is kept, so it is still reloaded from storage once its TTL passes.
+func (c *LRUCache) Update(ctx context.Context, key int, message models.Message) {
+	c.update(ctx, "cache.Update", key, func(chat models.Chat) (models.Chat, bool) {
+		return chat.WithEditedMessage(message)
+	})
+}
+
+// update applies a change to a cached chat that has not expired.
+//
+// The change returns the new chat and whether it differs from the cached one.
+// A chat that is not cached, or that the change leaves over the message limit, is buried.
+func (c *LRUCache) update(ctx context.Context, name string, key int, change func(models.Chat) (models.Chat, bool)) {
+
+	_, span := tracer.Start(ctx, name, trace.WithAttributes(attribute.Int("chat.id", key)))
+	defer span.End()
+
+	if c.config.Capacity <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	node, ok := c.hm[key]
+	if !ok || c.expired(node, c.now()) {
+		c.bury(key)
+		return
+	}
+
+	chat, changed := change(node.Val)
+	if !changed {
+		return
+	}
+
+	c.remove(node)
+
+	if len(chat.Messages) > c.config.MaxMessages {
+		c.bury(key)
+		c.logger.Debug("cache — chat deleted: message limit exceeded", "chatID", key, "layer", "cache.memory")
+		return
+	}
+
+	node.Val = chat
+	c.insert(node)
+	c.logger.Debug("cache — chat updated", "chatID", key, "layer", "cache.memory")
+
+}
+
+// Delete removes a chat from the cache by key and buries it.
 func (c *LRUCache) Delete(key int) {
 
 	if c.config.Capacity <= 0 {
@@ -129,6 +262,8 @@ func (c *LRUCache) Delete(key int) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.bury(key)
+
 	if node, ok := c.hm[key]; ok {
 		c.remove(node)
 		c.logger.Debug("cache — chat deleted", "chatID", key, "layer", "cache.memory")
@@ -136,16 +271,138 @@ func (c *LRUCache) Delete(key int) {
 
 }
 
-// Close releases all resources used by the cache.
-func (c *LRUCache) Close() {
+// Purge removes all chats from the cache. The cache stays usable.
+func (c *LRUCache) Purge() {
+
+	if c.config.Capacity <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	for k := range c.hm {
-		delete(c.hm, k)
+	clear(c.hm)
+	clear(c.tombstones)
+	c.head.Next = c.tail
+	c.tail.Prev = c.head
+
+	c.logger.Debug("cache — all chats deleted", "layer", "cache.memory")
+
+}
+
+// Shared reports false: the chats live in the memory of this instance only.
+func (c *LRUCache) Shared() bool {
+	return false
+}
+
+// Stats returns a snapshot of the cache usage counters.
+func (c *LRUCache) Stats() models.CacheStats {
+
+	c.mu.RLock()
+	size := len(c.hm)
+	c.mu.RUnlock()
+
+	return models.CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Size:      size,
+	}
+
+}
+
+// Close stops the janitor and releases all resources used by the cache.
+func (c *LRUCache) Close() {
+	if c.janitor != nil {
+		c.janitor.Stop()
 	}
+	c.release()
+	c.logger.LogInfo("cache — resources released", "layer", "cache.memory")
+}
+
+// release drops all chats and the list of the cache.
+func (c *LRUCache) release() {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.hm)
+	clear(c.tombstones)
 
 	c.head = nil
 	c.tail = nil
 
-	c.logger.LogInfo("cache — resources released", "layer", "cache.memory")
+}
+
+// ttl returns the lifetime of a chat being stored: the configured TTL,
+// shortened by a random share of up to TTLJitter of it.
+func (c *LRUCache) ttl() time.Duration {
+	ttl := c.config.TTL
+	if jitter := time.Duration(c.config.TTLJitter * float64(ttl)); jitter > 0 {
+		ttl -= rand.N(jitter)
+	}
+	return ttl
+}
+
+// expired reports whether a cached chat has expired at the given time.
+func (c *LRUCache) expired(node *Node, now time.Time) bool {
+	return !node.Expires.IsZero() && !now.Before(node.Expires)
+}
+
+// bury leaves a tombstone for a chat, if tombstones are enabled. The caller holds the lock.
+//
+// Expired tombstones are dropped once the tombstones have doubled in number since the last time,
+// so a burst of writes to chats that are not cached does not keep them all.
+func (c *LRUCache) bury(key int) {
+
+	if c.config.TombstoneTTL <= 0 {
+		return
+	}
+
+	now := c.now()
+	c.tombstones[key] = now.Add(c.config.TombstoneTTL)
+
+	if len(c.tombstones) > c.buried {
+		c.unbury(now)
+		c.buried = max(2*len(c.tombstones), c.config.Capacity)
+	}
+
+}
+
+// unbury drops the tombstones that have expired at the given time. The caller holds the lock.
+func (c *LRUCache) unbury(now time.Time) {
+	for key, expires := range c.tombstones {
+		if !now.Before(expires) {
+			delete(c.tombstones, key)
+		}
+	}
+}
+
+// sweep removes all expired chats from the cache.
+func (c *LRUCache) sweep() {
+	if expired := c.expire(); expired > 0 {
+		c.logger.Debug("cache — expired chats deleted", "count", expired, "layer", "cache.memory")
+	}
+}
+
+// expire removes all expired chats and tombstones from the cache and returns the number of chats.
+func (c *LRUCache) expire() int {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	expired := 0
+
+	c.unbury(now)
+
+	for _, node := range c.hm {
+		if c.expired(node, now) {
+			c.remove(node)
+			expired++
+		}
+	}
+
+	return expired
 
 }
@@ -5,9 +5,17 @@ import (
 	"chatX/internal/errs"
 	"chatX/internal/logger/mocks"
 	"chatX/internal/models"
+	"context"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.uber.org/mock/gomock"
 )
 
@@ -36,36 +44,36 @@ func setupCache(t *testing.T, cap, maxMsgs int) *LRUCache {
 
 func TestLRUCache_Get_Disabled(t *testing.T) {
 	cache := setupCache(t, 0, 10)
-	_, err := cache.Get(1)
+	_, err := cache.Get(context.Background(), 1)
 	require.ErrorIs(t, err, errs.ErrCacheMiss)
 }
 
 func TestLRUCache_Get_Miss(t *testing.T) {
 	cache := setupCache(t, 2, 10)
-	_, err := cache.Get(42)
+	_, err := cache.Get(context.Background(), 42)
 	require.ErrorIs(t, err, errs.ErrCacheMiss)
 }
 
 func TestLRUCache_PutAndGet_OK(t *testing.T) {
 	cache := setupCache(t, 2, 10)
 	chat := testChat(1, 1)
-	cache.Put(1, chat)
-	got, err := cache.Get(1)
+	cache.Put(context.Background(), 1, chat)
+	got, err := cache.Get(context.Background(), 1)
 	require.NoError(t, err)
 	require.Equal(t, chat, got)
 }
 
 func TestLRUCache_Put_Disabled(t *testing.T) {
 	cache := setupCache(t, 0, 10)
-	cache.Put(1, testChat(1, 1))
-	_, err := cache.Get(1)
+	cache.Put(context.Background(), 1, testChat(1, 1))
+	_, err := cache.Get(context.Background(), 1)
 	require.ErrorIs(t, err, errs.ErrCacheMiss)
 }
 
 func TestLRUCache_Put_MessageLimitExceeded(t *testing.T) {
 	cache := setupCache(t, 2, 1)
-	cache.Put(1, testChat(1, 2))
-	_, err := cache.Get(1)
+	cache.Put(context.Background(), 1, testChat(1, 2))
+	_, err := cache.Get(context.Background(), 1)
 	require.ErrorIs(t, err, errs.ErrCacheMiss)
 }
 
@@ -73,10 +81,10 @@ func TestLRUCache_Put_Overwrite(t *testing.T) {
 
 	cache := setupCache(t, 2, 10)
 
-	cache.Put(1, testChat(1, 1))
-	cache.Put(1, testChat(1, 2))
+	cache.Put(context.Background(), 1, testChat(1, 1))
+	cache.Put(context.Background(), 1, testChat(1, 2))
 
-	chat, err := cache.Get(1)
+	chat, err := cache.Get(context.Background(), 1)
 
 	require.NoError(t, err)
 	require.Len(t, chat.Messages, 2)
@@ -87,31 +95,57 @@ func TestLRUCache_Put_LRUEviction(t *testing.T) {
 
 	cache := setupCache(t, 2, 10)
 
-	cache.Put(1, testChat(1, 1))
-	cache.Put(2, testChat(2, 1))
+	cache.Put(context.Background(), 1, testChat(1, 1))
+	cache.Put(context.Background(), 2, testChat(2, 1))
 
-	_, _ = cache.Get(1)
-	cache.Put(3, testChat(3, 1))
+	_, _ = cache.Get(context.Background(), 1)
+	cache.Put(context.Background(), 3, testChat(3, 1))
 
-	_, err := cache.Get(2)
+	_, err := cache.Get(context.Background(), 2)
 	require.ErrorIs(t, err, errs.ErrCacheMiss)
 
-	_, err = cache.Get(1)
+	_, err = cache.Get(context.Background(), 1)
 	require.NoError(t, err)
 
-	_, err = cache.Get(3)
+	_, err = cache.Get(context.Background(), 3)
 	require.NoError(t, err)
 
 }
 
+func TestLRUCache_Stats(t *testing.T) {
+
+	cache := setupCache(t, 2, 10)
+
+	cache.Put(context.Background(), 1, testChat(1, 1))
+	cache.Put(context.Background(), 2, testChat(2, 1))
+	cache.Put(context.Background(), 3, testChat(3, 1))
+
+	_, _ = cache.Get(context.Background(), 3)
+	_, _ = cache.Get(context.Background(), 1)
+	cache.Delete(2)
+
+	require.Equal(t, models.CacheStats{Hits: 1, Misses: 1, Evictions: 1, Size: 1}, cache.Stats())
+
+}
+
+func TestLRUCache_Stats_Disabled(t *testing.T) {
+
+	cache := setupCache(t, 0, 10)
+
+	_, _ = cache.Get(context.Background(), 1)
+
+	require.Equal(t, models.CacheStats{Misses: 1}, cache.Stats())
+
+}
+
 func TestLRUCache_Delete_OK(t *testing.T) {
 
 	cache := setupCache(t, 2, 10)
 
-	cache.Put(1, testChat(1, 1))
+	cache.Put(context.Background(), 1, testChat(1, 1))
 	cache.Delete(1)
 
-	_, err := cache.Get(1)
+	_, err := cache.Get(context.Background(), 1)
 	require.ErrorIs(t, err, errs.ErrCacheMiss)
 
 }
@@ -126,12 +160,32 @@ func TestLRUCache_Delete_Disabled(t *testing.T) {
 	cache.Delete(1)
 }
 
+func TestLRUCache_Purge(t *testing.T) {
+
+	cache := setupCache(t, 2, 10)
+
+	cache.Put(context.Background(), 1, testChat(1, 1))
+	cache.Put(context.Background(), 2, testChat(2, 1))
+
+	cache.Purge()
+
+	_, err := cache.Get(context.Background(), 1)
+	require.ErrorIs(t, err, errs.ErrCacheMiss)
+	require.Len(t, cache.hm, 0)
+
+	cache.Put(context.Background(), 3, testChat(3, 1))
+
+	_, err = cache.Get(context.Background(), 3)
+	require.NoError(t, err)
+
+}
+
 func TestLRUCache_Close(t *testing.T) {
 
 	cache := setupCache(t, 2, 10)
 
-	cache.Put(1, testChat(1, 1))
-	cache.Put(2, testChat(2, 1))
+	cache.Put(context.Background(), 1, testChat(1, 1))
+	cache.Put(context.Background(), 2, testChat(2, 1))
 
 	cache.Close()
 
@@ -140,3 +194,671 @@ func TestLRUCache_Close(t *testing.T) {
 	require.Len(t, cache.hm, 0)
 
 }
+
+func TestLRUCache_Get_RecordsSpan(t *testing.T) {
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	cache := setupCache(t, 2, 10)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	_, _ = cache.Get(ctx, 1)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, "cache.Get", spans[0].Name())
+	require.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	require.Contains(t, spans[0].Attributes(), attribute.Bool("cache.hit", false))
+
+}
+
+func setupShardedCache(t *testing.T, cap, shards int) *ShardedCache {
+
+	controller := gomock.NewController(t)
+	logger := mocks.NewMockLogger(controller)
+
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+
+	config := testCacheConfig(cap, 10)
+	config.Shards = shards
+
+	return NewShardedCache(logger, config)
+
+}
+
+func TestNewShardedCache_Segments(t *testing.T) {
+
+	tests := []struct {
+		capacity   int
+		shards     int
+		capacities []int
+	}{
+		{capacity: 10, shards: 4, capacities: []int{3, 3, 2, 2}},
+		{capacity: 10, shards: 3, capacities: []int{3, 3, 2, 2}},
+		{capacity: 3, shards: 8, capacities: []int{2, 1}},
+		{capacity: 0, shards: 8, capacities: []int{0}},
+		{capacity: 10, shards: 1, capacities: []int{10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("capacity=%d,shards=%d", tt.capacity, tt.shards), func(t *testing.T) {
+			cache := setupShardedCache(t, tt.capacity, tt.shards)
+			capacities := make([]int, len(cache.segments))
+			for i, segment := range cache.segments {
+				capacities[i] = segment.config.Capacity
+			}
+			require.Equal(t, tt.capacities, capacities)
+		})
+	}
+
+}
+
+func TestShardedCache_SpreadsSequentialKeys(t *testing.T) {
+
+	cache := setupShardedCache(t, 64, 4)
+
+	used := make(map[*LRUCache]int)
+	for key := 1; key <= 64; key++ {
+		used[cache.segment(key)]++
+	}
+
+	require.Len(t, used, 4)
+	for _, count := range used {
+		require.InDelta(t, 16, count, 2)
+	}
+
+}
+
+func TestShardedCache_PutGetDelete(t *testing.T) {
+
+	cache := setupShardedCache(t, 16, 4)
+
+	for key := 1; key <= 8; key++ {
+		cache.Put(context.Background(), key, testChat(key, 1))
+	}
+	cache.Delete(3)
+
+	for key := 1; key <= 8; key++ {
+		chat, err := cache.Get(context.Background(), key)
+		if key == 3 {
+			require.ErrorIs(t, err, errs.ErrCacheMiss)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, key, chat.ID)
+	}
+
+	require.Equal(t, models.CacheStats{Hits: 7, Misses: 1, Size: 7}, cache.Stats())
+
+}
+
+func TestShardedCache_EvictsWithinSegment(t *testing.T) {
+
+	cache := setupShardedCache(t, 4, 4)
+
+	// Every segment holds a single chat; find two keys of the same segment.
+	second := 2
+	for cache.segment(second) != cache.segment(1) {
+		second++
+	}
+
+	cache.Put(context.Background(), 1, testChat(1, 1))
+	cache.Put(context.Background(), second, testChat(second, 1))
+
+	_, err := cache.Get(context.Background(), 1)
+	require.ErrorIs(t, err, errs.ErrCacheMiss)
+	_, err = cache.Get(context.Background(), second)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), cache.Stats().Evictions)
+
+}
+
+func TestShardedCache_Purge(t *testing.T) {
+
+	cache := setupShardedCache(t, 16, 4)
+
+	for key := 1; key <= 8; key++ {
+		cache.Put(context.Background(), key, testChat(key, 1))
+	}
+	cache.Purge()
+
+	require.Zero(t, cache.Stats().Size)
+
+	cache.Put(context.Background(), 1, testChat(1, 1))
+	_, err := cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+
+}
+
+func TestShardedCache_Close(t *testing.T) {
+
+	cache := setupShardedCache(t, 16, 4)
+	cache.Put(context.Background(), 1, testChat(1, 1))
+
+	cache.Close()
+
+	for _, segment := range cache.segments {
+		require.Nil(t, segment.head)
+		require.Len(t, segment.hm, 0)
+	}
+
+}
+
+// discardLogger is a Logger that drops all records, keeping the mock out of stress tests and benchmarks.
+type discardLogger struct{}
+
+func (discardLogger) LogFatal(string, error, ...any)                         {}
+func (discardLogger) LogError(string, error, ...any)                         {}
+func (discardLogger) LogWarn(string, ...any)                                 {}
+func (discardLogger) LogInfo(string, ...any)                                 {}
+func (discardLogger) Debug(string, ...any)                                   {}
+func (discardLogger) LogErrorContext(context.Context, string, error, ...any) {}
+func (discardLogger) LogWarnContext(context.Context, string, ...any)         {}
+func (discardLogger) LogInfoContext(context.Context, string, ...any)         {}
+func (discardLogger) DebugContext(context.Context, string, ...any)           {}
+
+// chatCache is the part of the cache interface exercised by the stress tests and benchmarks.
+type chatCache interface {
+	Get(ctx context.Context, key int) (models.Chat, error)
+	Put(ctx context.Context, key int, value models.Chat)
+	Delete(key int)
+	Stats() models.CacheStats
+}
+
+// caches returns both implementations with the given capacity, for tests and benchmarks run against each.
+func caches(capacity int) map[string]func() chatCache {
+	config := config.Cache{Capacity: capacity, MaxMessages: 10, Shards: 16}
+	return map[string]func() chatCache{
+		"LRUCache":     func() chatCache { return NewLRUCache(discardLogger{}, config) },
+		"ShardedCache": func() chatCache { return NewShardedCache(discardLogger{}, config) },
+	}
+}
+
+// TestCache_ConcurrentAccess hammers a cache from many goroutines; run with -race to detect unsynchronized access.
+func TestCache_ConcurrentAccess(t *testing.T) {
+
+	const (
+		workers    = 16
+		operations = 2000
+		keys       = 64
+	)
+
+	for name, newCache := range caches(32) {
+		t.Run(name, func(t *testing.T) {
+
+			cache := newCache()
+			for key := range keys {
+				cache.Put(context.Background(), key, testChat(key, 1))
+			}
+
+			// Lookups alone must not race with each other, even though they promote chats.
+			var wg sync.WaitGroup
+			for w := range workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range operations {
+						_, _ = cache.Get(context.Background(), (w+i)%keys)
+					}
+				}()
+			}
+			wg.Wait()
+
+			for w := range workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range operations {
+						key := (w*operations + i) % keys
+						switch i % 8 {
+						case 0:
+							cache.Put(context.Background(), key, testChat(key, 1))
+						case 1:
+							cache.Delete(key)
+						default:
+							if chat, err := cache.Get(context.Background(), key); err == nil && chat.ID != key {
+								t.Errorf("got chat %d for key %d", chat.ID, key)
+							}
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			stats := cache.Stats()
+			require.Equal(t, uint64(workers*operations+workers*operations*6/8), stats.Hits+stats.Misses)
+			require.LessOrEqual(t, stats.Size, 32)
+
+		})
+	}
+
+}
+
+// benchmarkCache runs a parallel workload against both implementations, with the given share of reads in percent.
+func benchmarkCache(b *testing.B, reads int) {
+
+	const keys = 4096
+
+	for name, newCache := range caches(keys / 2) {
+		b.Run(name, func(b *testing.B) {
+
+			cache := newCache()
+			for key := range keys / 2 {
+				cache.Put(context.Background(), key, testChat(key, 1))
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := (i * 7919) % keys
+					if i%100 < reads {
+						_, _ = cache.Get(context.Background(), key)
+					} else {
+						cache.Put(context.Background(), key, testChat(key, 1))
+					}
+					i++
+				}
+			})
+
+		})
+	}
+
+}
+
+func BenchmarkCache_ReadHeavy(b *testing.B) {
+	benchmarkCache(b, 90)
+}
+
+func BenchmarkCache_Mixed(b *testing.B) {
+	benchmarkCache(b, 50)
+}
+
+// clock is a manually advanced time source.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func setupExpiringCache(t *testing.T, ttl time.Duration, jitter float64) (*LRUCache, *clock) {
+
+	controller := gomock.NewController(t)
+	logger := mocks.NewMockLogger(controller)
+
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+
+	config := testCacheConfig(4, 10)
+	config.TTL = ttl
+	config.TTLJitter = jitter
+
+	clock := &clock{now: time.Unix(1_700_000_000, 0)}
+
+	cache := NewLRUCache(logger, config)
+	cache.now = clock.Now
+	t.Cleanup(cache.Close)
+
+	return cache, clock
+
+}
+
+func TestLRUCache_Get_Expired(t *testing.T) {
+
+	cache, clock := setupExpiringCache(t, time.Minute, 0)
+
+	cache.Put(context.Background(), 1, testChat(1, 1))
+
+	clock.Advance(time.Minute - time.Second)
+	_, err := cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+
+	clock.Advance(time.Second)
+	_, err = cache.Get(context.Background(), 1)
+	require.ErrorIs(t, err, errs.ErrCacheMiss)
+	require.Equal(t, models.CacheStats{Hits: 1, Misses: 1}, cache.Stats())
+
+}
+
+func TestLRUCache_Put_RenewsExpiry(t *testing.T) {
+
+	cache, clock := setupExpiringCache(t, time.Minute, 0)
+
+	cache.Put(context.Background(), 1, testChat(1, 1))
+	clock.Advance(30 * time.Second)
+	cache.Put(context.Background(), 1, testChat(1, 1))
+	clock.Advance(45 * time.Second)
+
+	_, err := cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+
+}
+
+func TestLRUCache_Put_Jitter(t *testing.T) {
+
+	cache, clock := setupExpiringCache(t, time.Minute, 0.5)
+
+	expiries := make(map[time.Time]bool)
+	for key := 1; key <= 4; key++ {
+		cache.Put(context.Background(), key, testChat(key, 1))
+		expires := cache.hm[key].Expires
+		require.False(t, expires.Before(clock.now.Add(30*time.Second)))
+		require.False(t, expires.After(clock.now.Add(time.Minute)))
+		expiries[expires] = true
+	}
+
+	require.Greater(t, len(expiries), 1)
+
+}
+
+func TestLRUCache_Expire(t *testing.T) {
+
+	cache, clock := setupExpiringCache(t, time.Minute, 0)
+
+	cache.Put(context.Background(), 1, testChat(1, 1))
+	clock.Advance(30 * time.Second)
+	cache.Put(context.Background(), 2, testChat(2, 1))
+	clock.Advance(30 * time.Second)
+
+	require.Equal(t, 1, cache.expire())
+	require.Equal(t, 1, cache.Stats().Size)
+
+	_, err := cache.Get(context.Background(), 2)
+	require.NoError(t, err)
+
+}
+
+func TestLRUCache_Janitor(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	logger := mocks.NewMockLogger(controller)
+
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+
+	config := testCacheConfig(4, 10)
+	config.TTL = 10 * time.Millisecond
+
+	cache := NewLRUCache(logger, config)
+	cache.Put(context.Background(), 1, testChat(1, 1))
+
+	require.Eventually(t, func() bool { return cache.Stats().Size == 0 }, time.Second, 5*time.Millisecond)
+
+	cache.Close()
+	require.NotPanics(t, cache.janitor.Stop)
+
+	select {
+	case <-cache.janitor.done:
+	default:
+		t.Fatal("janitor still running after Close")
+	}
+
+}
+
+func TestLRUCache_Janitor_NoTTL(t *testing.T) {
+	cache := setupCache(t, 2, 10)
+	require.Nil(t, cache.janitor)
+}
+
+func TestShardedCache_Janitor(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	logger := mocks.NewMockLogger(controller)
+
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+
+	config := testCacheConfig(16, 10)
+	config.Shards = 4
+	config.TTL = 10 * time.Millisecond
+
+	cache := NewShardedCache(logger, config)
+	for _, segment := range cache.segments {
+		require.Nil(t, segment.janitor)
+	}
+
+	for key := 1; key <= 8; key++ {
+		cache.Put(context.Background(), key, testChat(key, 1))
+	}
+
+	require.Eventually(t, func() bool { return cache.Stats().Size == 0 }, time.Second, 5*time.Millisecond)
+
+	cache.Close()
+
+	select {
+	case <-cache.janitor.done:
+	default:
+		t.Fatal("janitor still running after Close")
+	}
+
+}
+
+// testMessages returns a chat with messages 1 to n, newest first, created a minute apart.
+func testMessages(id int, n int) models.Chat {
+	start := time.Unix(1_700_000_000, 0)
+	chat := models.Chat{ID: id, Messages: make([]models.Message, n)}
+	for i := range chat.Messages {
+		messageID := n - i
+		chat.Messages[i] = models.Message{ID: messageID, ChatID: id, CreatedAt: start.Add(time.Duration(messageID) * time.Minute)}
+	}
+	return chat
+}
+
+// messageIDs returns the IDs of the messages of a chat in order.
+func messageIDs(chat models.Chat) []int {
+	ids := make([]int, len(chat.Messages))
+	for i, m := range chat.Messages {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func TestLRUCache_Append(t *testing.T) {
+
+	cache := setupCache(t, 2, 10)
+
+	chat := testMessages(1, 3)
+	cache.Put(context.Background(), 1, chat)
+
+	newest := models.Message{ID: 4, ChatID: 1, CreatedAt: chat.Messages[0].CreatedAt.Add(time.Minute)}
+	cache.Append(context.Background(), 1, newest, 100)
+
+	got, err := cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, []int{4, 3, 2, 1}, messageIDs(got))
+	require.Equal(t, []int{3, 2, 1}, messageIDs(chat), "messages handed out before must not change")
+
+}
+
+func TestLRUCache_Append_KeepsOrder(t *testing.T) {
+
+	cache := setupCache(t, 2, 10)
+
+	chat := testMessages(1, 3)
+	cache.Put(context.Background(), 1, chat)
+
+	// a message stamped by a lagging clock lands among the older messages
+	late := models.Message{ID: 4, ChatID: 1, CreatedAt: chat.Messages[1].CreatedAt.Add(time.Second)}
+	cache.Append(context.Background(), 1, late, 100)
+
+	got, err := cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 4, 2, 1}, messageIDs(got))
+
+}
+
+func TestLRUCache_Append_Limit(t *testing.T) {
+
+	cache := setupCache(t, 2, 10)
+
+	chat := testMessages(1, 3)
+	cache.Put(context.Background(), 1, chat)
+
+	cache.Append(context.Background(), 1, models.Message{ID: 4, ChatID: 1, CreatedAt: chat.Messages[0].CreatedAt.Add(time.Minute)}, 3)
+	// older than every message of a full page, so older messages may be missing in between
+	cache.Append(context.Background(), 1, models.Message{ID: 5, ChatID: 1}, 3)
+
+	got, err := cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, []int{4, 3, 2}, messageIDs(got))
+
+}
+
+func TestLRUCache_Append_CountsReply(t *testing.T) {
+
+	cache := setupCache(t, 2, 10)
+
+	chat := testMessages(1, 2)
+	cache.Put(context.Background(), 1, chat)
+
+	parentID := 1
+	cache.Append(context.Background(), 1, models.Message{ID: 3, ChatID: 1, ParentID: &parentID, CreatedAt: chat.Messages[0].CreatedAt.Add(time.Minute)}, 100)
+
+	got, err := cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 0, 1}, []int{got.Messages[0].ReplyCount, got.Messages[1].ReplyCount, got.Messages[2].ReplyCount})
+	require.Zero(t, chat.Messages[1].ReplyCount)
+
+}
+
+func TestLRUCache_Append_MessageLimitExceeded(t *testing.T) {
+
+	cache := setupCache(t, 2, 3)
+
+	chat := testMessages(1, 3)
+	cache.Put(context.Background(), 1, chat)
+	cache.Append(context.Background(), 1, models.Message{ID: 4, ChatID: 1, CreatedAt: chat.Messages[0].CreatedAt.Add(time.Minute)}, 100)
+
+	_, err := cache.Get(context.Background(), 1)
+	require.ErrorIs(t, err, errs.ErrCacheMiss)
+	require.Zero(t, cache.Stats().Size)
+
+}
+
+func TestLRUCache_Append_NotCached(t *testing.T) {
+
+	cache := setupCache(t, 2, 10)
+
+	cache.Append(context.Background(), 1, models.Message{ID: 1, ChatID: 1}, 100)
+
+	_, err := cache.Get(context.Background(), 1)
+	require.ErrorIs(t, err, errs.ErrCacheMiss)
+
+}
+
+func TestLRUCache_Append_NotCached_LeavesTombstone(t *testing.T) {
+
+	cache, clock := setupExpiringCache(t, 0, 0)
+	cache.config.TombstoneTTL = time.Second
+
+	// a load reads the chat, a message is appended to it, then the load stores what it read
+	stale := testMessages(1, 1)
+	cache.Append(context.Background(), 1, models.Message{ID: 2, ChatID: 1, CreatedAt: clock.now}, 100)
+	cache.Put(context.Background(), 1, stale)
+
+	_, err := cache.Get(context.Background(), 1)
+	require.ErrorIs(t, err, errs.ErrCacheMiss)
+
+	clock.Advance(time.Second)
+	cache.Put(context.Background(), 1, stale)
+
+	_, err = cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Empty(t, cache.tombstones)
+
+}
+
+func TestLRUCache_Delete_LeavesTombstone(t *testing.T) {
+
+	cache, _ := setupExpiringCache(t, 0, 0)
+	cache.config.TombstoneTTL = time.Second
+
+	cache.Put(context.Background(), 1, testChat(1, 1))
+	cache.Delete(1)
+	cache.Put(context.Background(), 1, testChat(1, 1))
+
+	_, err := cache.Get(context.Background(), 1)
+	require.ErrorIs(t, err, errs.ErrCacheMiss)
+
+}
+
+func TestLRUCache_Tombstones_Dropped(t *testing.T) {
+
+	cache, clock := setupExpiringCache(t, 0, 0)
+	cache.config.TombstoneTTL = time.Second
+
+	for key := range 100 {
+		cache.Delete(key)
+	}
+	clock.Advance(time.Second)
+	for key := 100; key < 300; key++ {
+		cache.Delete(key)
+	}
+
+	require.Len(t, cache.tombstones, 200)
+
+}
+
+func TestLRUCache_Append_Expired(t *testing.T) {
+
+	cache, clock := setupExpiringCache(t, time.Minute, 0)
+
+	cache.Put(context.Background(), 1, testMessages(1, 1))
+	clock.Advance(time.Minute)
+	cache.Append(context.Background(), 1, models.Message{ID: 2, ChatID: 1, CreatedAt: clock.now}, 100)
+
+	_, err := cache.Get(context.Background(), 1)
+	require.ErrorIs(t, err, errs.ErrCacheMiss)
+
+}
+
+func TestLRUCache_Update(t *testing.T) {
+
+	cache := setupCache(t, 2, 10)
+
+	chat := testMessages(1, 3)
+	cache.Put(context.Background(), 1, chat)
+
+	edited := chat.Messages[1]
+	edited.Text = "edited"
+	editedAt := edited.CreatedAt.Add(time.Hour)
+	edited.EditedAt = &editedAt
+	cache.Update(context.Background(), 1, edited)
+	cache.Update(context.Background(), 1, models.Message{ID: 42, ChatID: 1, Text: "unknown"})
+
+	got, err := cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 2, 1}, messageIDs(got))
+	require.Equal(t, edited, got.Messages[1])
+	require.Empty(t, chat.Messages[1].Text)
+
+}
+
+func TestShardedCache_AppendAndUpdate(t *testing.T) {
+
+	cache := setupShardedCache(t, 16, 4)
+
+	for key := 1; key <= 4; key++ {
+		cache.Put(context.Background(), key, testMessages(key, 1))
+	}
+
+	for key := 1; key <= 4; key++ {
+		cache.Append(context.Background(), key, models.Message{ID: 2, ChatID: key, CreatedAt: time.Unix(1_800_000_000, 0)}, 100)
+		cache.Update(context.Background(), key, models.Message{ID: 1, ChatID: key, Text: "edited"})
+	}
+
+	for key := 1; key <= 4; key++ {
+		chat, err := cache.Get(context.Background(), key)
+		require.NoError(t, err)
+		require.Equal(t, []int{2, 1}, messageIDs(chat))
+		require.Equal(t, "edited", chat.Messages[1].Text)
+	}
+
+}
@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"sync"
+	"time"
+)
+
+// janitor runs a sweep at a fixed interval in a background goroutine until stopped.
+type janitor struct {
+	stop chan struct{} // Closed to stop the goroutine
+	done chan struct{} // Closed once the goroutine has returned
+	once sync.Once     // Guards closing stop
+}
+
+// newJanitor starts a janitor running sweep every interval. The interval must be positive.
+func newJanitor(interval time.Duration, sweep func()) *janitor {
+
+	j := &janitor{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go func() {
+
+		defer close(j.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-j.stop:
+				return
+			case <-ticker.C:
+				sweep()
+			}
+		}
+
+	}()
+
+	return j
+
+}
+
+// Stop stops the janitor and waits for a running sweep to finish. It is safe to call more than once.
+func (j *janitor) Stop() {
+	j.once.Do(func() { close(j.stop) })
+	<-j.done
+}
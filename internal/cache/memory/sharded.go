@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/logger"
+	"chatX/internal/models"
+	"context"
+	"math/bits"
+	"runtime"
+)
+
+// ShardedCache is a thread-safe in-memory cache for chats split into independent LRU segments.
+//
+// Every chat is kept in the segment selected by the hash of its ID, and each segment has
+// its own lock, so operations on chats of different segments do not contend. Recency is
+// tracked per segment: a full segment evicts its own least recently used chat, even if
+// another segment holds an older one.
+//
+// With a TTL configured, a single janitor sweeps expired chats from all segments.
+type ShardedCache struct {
+	segments []*LRUCache   // Independent LRU segments, a power of two in number
+	shift    uint          // Right shift turning a hashed key into a segment index
+	janitor  *janitor      // Background sweep of expired chats, nil without TTL
+	logger   logger.Logger // Logger instance
+}
+
+// NewShardedCache creates a new ShardedCache instance with the given logger and config.
+//
+// The number of segments is config.Shards, or the number of CPUs if it is 0, rounded up
+// to a power of two and reduced so that every segment holds at least one chat.
+// The capacity is divided between the segments.
+func NewShardedCache(logger logger.Logger, config config.Cache) *ShardedCache {
+
+	n := config.Shards
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	n = 1 << bits.Len(uint(n-1))
+	for n > 1 && n > config.Capacity {
+		n /= 2
+	}
+
+	segments := make([]*LRUCache, n)
+	for i := range segments {
+		segment := config
+		segment.Capacity = config.Capacity / n
+		if i < config.Capacity%n {
+			segment.Capacity++
+		}
+		segments[i] = newLRUCache(logger, segment)
+	}
+
+	c := &ShardedCache{
+		segments: segments,
+		shift:    uint(64 - bits.TrailingZeros(uint(n))),
+		logger:   logger,
+	}
+
+	if config.Capacity > 0 && config.TTL > 0 {
+		c.janitor = newJanitor(config.TTL, c.sweep)
+	}
+
+	return c
+
+}
+
+// segment returns the segment holding the given key.
+//
+// Keys are spread with Fibonacci hashing, so sequential chat IDs land in different segments.
+func (c *ShardedCache) segment(key int) *LRUCache {
+	if len(c.segments) == 1 {
+		return c.segments[0]
+	}
+	return c.segments[(uint64(key)*0x9E3779B97F4A7C15)>>c.shift]
+}
+
+// Get retrieves a chat from its segment by key and marks it as most recently used there.
+func (c *ShardedCache) Get(ctx context.Context, key int) (models.Chat, error) {
+	return c.segment(key).Get(ctx, key)
+}
+
+// Put stores a chat in its segment, evicting the least recently used chat of the segment if it is full.
+func (c *ShardedCache) Put(ctx context.Context, key int, value models.Chat) {
+	c.segment(key).Put(ctx, key, value)
+}
+
+// Append adds a new message to a chat cached in its segment, keeping at most limit messages.
+func (c *ShardedCache) Append(ctx context.Context, key int, message models.Message, limit int) {
+	c.segment(key).Append(ctx, key, message, limit)
+}
+
+// Update replaces an edited message in a chat cached in its segment.
+func (c *ShardedCache) Update(ctx context.Context, key int, message models.Message) {
+	c.segment(key).Update(ctx, key, message)
+}
+
+// Delete removes a chat from its segment by key.
+func (c *ShardedCache) Delete(key int) {
+	c.segment(key).Delete(key)
+}
+
+// Purge removes all chats from all segments. The cache stays usable.
+func (c *ShardedCache) Purge() {
+	for _, segment := range c.segments {
+		segment.Purge()
+	}
+}
+
+// Shared reports false: the chats live in the memory of this instance only.
+func (c *ShardedCache) Shared() bool {
+	return false
+}
+
+// Stats returns the usage counters summed over all segments.
+func (c *ShardedCache) Stats() models.CacheStats {
+
+	var stats models.CacheStats
+
+	for _, segment := range c.segments {
+		s := segment.Stats()
+		stats.Hits += s.Hits
+		stats.Misses += s.Misses
+		stats.Evictions += s.Evictions
+		stats.Size += s.Size
+	}
+
+	return stats
+
+}
+
+// Close stops the janitor and releases all resources used by the cache.
+func (c *ShardedCache) Close() {
+
+	if c.janitor != nil {
+		c.janitor.Stop()
+	}
+
+	for _, segment := range c.segments {
+		segment.release()
+	}
+
+	c.logger.LogInfo("cache — resources released", "layer", "cache.memory")
+
+}
+
+// sweep removes expired chats from all segments, one segment at a time.
+func (c *ShardedCache) sweep() {
+
+	expired := 0
+	for _, segment := range c.segments {
+		expired += segment.expire()
+	}
+
+	if expired > 0 {
+		c.logger.Debug("cache — expired chats deleted", "count", expired, "layer", "cache.memory")
+	}
+
+}
@@ -11,6 +11,7 @@ package mocks
 
 import (
 	models "chatX/internal/models"
+	context "context"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -40,6 +41,18 @@ func (m *MockCache) EXPECT() *MockCacheMockRecorder {
 	return m.recorder
 }
 
+// Append mocks base method.
+func (m *MockCache) Append(ctx context.Context, key int, message models.Message, limit int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Append", ctx, key, message, limit)
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockCacheMockRecorder) Append(ctx, key, message, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockCache)(nil).Append), ctx, key, message, limit)
+}
+
 // Close mocks base method.
 func (m *MockCache) Close() {
 	m.ctrl.T.Helper()
@@ -65,28 +78,80 @@ func (mr *MockCacheMockRecorder) Delete(key any) *gomock.Call {
 }
 
 // Get mocks base method.
-func (m *MockCache) Get(key int) (models.Chat, error) {
+func (m *MockCache) Get(ctx context.Context, key int) (models.Chat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", key)
+	ret := m.ctrl.Call(m, "Get", ctx, key)
 	ret0, _ := ret[0].(models.Chat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockCacheMockRecorder) Get(key any) *gomock.Call {
+func (mr *MockCacheMockRecorder) Get(ctx, key any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCache)(nil).Get), key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCache)(nil).Get), ctx, key)
+}
+
+// Purge mocks base method.
+func (m *MockCache) Purge() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Purge")
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockCacheMockRecorder) Purge() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockCache)(nil).Purge))
 }
 
 // Put mocks base method.
-func (m *MockCache) Put(key int, value models.Chat) {
+func (m *MockCache) Put(ctx context.Context, key int, value models.Chat) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Put", key, value)
+	m.ctrl.Call(m, "Put", ctx, key, value)
 }
 
 // Put indicates an expected call of Put.
-func (mr *MockCacheMockRecorder) Put(key, value any) *gomock.Call {
+func (mr *MockCacheMockRecorder) Put(ctx, key, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockCache)(nil).Put), ctx, key, value)
+}
+
+// Shared mocks base method.
+func (m *MockCache) Shared() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Shared")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Shared indicates an expected call of Shared.
+func (mr *MockCacheMockRecorder) Shared() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shared", reflect.TypeOf((*MockCache)(nil).Shared))
+}
+
+// Stats mocks base method.
+func (m *MockCache) Stats() models.CacheStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(models.CacheStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockCacheMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockCache)(nil).Stats))
+}
+
+// Update mocks base method.
+func (m *MockCache) Update(ctx context.Context, key int, message models.Message) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Update", ctx, key, message)
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockCacheMockRecorder) Update(ctx, key, message any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockCache)(nil).Put), key, value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCache)(nil).Update), ctx, key, message)
 }
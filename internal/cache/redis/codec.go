@@ -0,0 +1,302 @@
+package redis
+
+import (
+	"chatX/internal/models"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// codecVersion is the first byte of every encoded chat. Chats encoded
+// with another version are treated as cache misses and reloaded.
+const codecVersion = 1
+
+// zeroUnix is the Unix time of the zero time.Time.
+var zeroUnix = time.Time{}.Unix()
+
+// errCorrupt reports an encoded chat that cannot be decoded.
+var errCorrupt = errors.New("redis: corrupt cached chat")
+
+// encoder appends values to a buffer in a compact binary format:
+// integers as varints, strings and slices prefixed with their length.
+type encoder struct {
+	buf []byte
+}
+
+// int writes a signed varint.
+func (e *encoder) int(v int64) {
+	e.buf = binary.AppendVarint(e.buf, v)
+}
+
+// uint writes an unsigned varint.
+func (e *encoder) uint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+// string writes a string prefixed with its length.
+func (e *encoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// bool writes a boolean as a single byte.
+func (e *encoder) bool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+// time appends a timestamp as seconds and nanoseconds since the Unix epoch.
+func (e *encoder) time(t time.Time) {
+	e.int(t.Unix())
+	e.uint(uint64(t.Nanosecond()))
+}
+
+// length appends the length of a slice, keeping nil and empty slices apart.
+func (e *encoder) length(n int, isNil bool) {
+	if isNil {
+		e.uint(0)
+		return
+	}
+	e.uint(uint64(n) + 1)
+}
+
+// decoder reads values written by encoder. The first failure is kept in err
+// and makes all further reads return zero values.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+// fail records a corrupt input and stops further reads.
+func (d *decoder) fail() {
+	if d.err == nil {
+		d.err = errCorrupt
+	}
+	d.buf = nil
+}
+
+// int reads a signed varint.
+func (d *decoder) int() int64 {
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+// uint reads an unsigned varint.
+func (d *decoder) uint() uint64 {
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+// string reads a string prefixed with its length.
+func (d *decoder) string() string {
+	n := d.uint()
+	if n > uint64(len(d.buf)) {
+		d.fail()
+		return ""
+	}
+	s := string(d.buf[:n])
+	d.buf = d.buf[n:]
+	return s
+}
+
+// bool reads a boolean written as a single byte.
+func (d *decoder) bool() bool {
+	if len(d.buf) == 0 {
+		d.fail()
+		return false
+	}
+	v := d.buf[0] == 1
+	d.buf = d.buf[1:]
+	return v
+}
+
+// time reads a timestamp in local time, as the storage returns it.
+// The zero time is kept as is.
+func (d *decoder) time() time.Time {
+	sec := d.int()
+	nsec := d.uint()
+	if sec == zeroUnix && nsec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, int64(nsec))
+}
+
+// length reads the length of a slice; isNil reports a nil slice.
+// Lengths larger than the remaining input are rejected.
+func (d *decoder) length() (n int, isNil bool) {
+	v := d.uint()
+	if v == 0 {
+		return 0, true
+	}
+	if v-1 > uint64(len(d.buf)) {
+		d.fail()
+		return 0, true
+	}
+	return int(v - 1), false
+}
+
+// encodeChat serializes a chat with its messages. UnreadCount is
+// specific to the requesting user and is not stored.
+func encodeChat(chat models.Chat) []byte {
+
+	e := encoder{buf: make([]byte, 0, 64+len(chat.Messages)*64)}
+
+	e.buf = append(e.buf, codecVersion)
+	e.int(int64(chat.ID))
+	e.string(chat.Title)
+	e.time(chat.CreatedAt)
+	e.time(chat.UpdatedAt)
+
+	e.length(len(chat.Messages), chat.Messages == nil)
+	for _, m := range chat.Messages {
+		encodeMessage(&e, m)
+	}
+
+	return e.buf
+
+}
+
+// encodeMessage serializes a message with its reactions and attachments.
+func encodeMessage(e *encoder, m models.Message) {
+
+	e.int(int64(m.ID))
+	e.int(int64(m.ChatID))
+	e.string(m.Text)
+	e.time(m.CreatedAt)
+
+	e.bool(m.EditedAt != nil)
+	if m.EditedAt != nil {
+		e.time(*m.EditedAt)
+	}
+
+	e.bool(m.AuthorID != nil)
+	if m.AuthorID != nil {
+		e.int(int64(*m.AuthorID))
+	}
+
+	e.bool(m.ParentID != nil)
+	if m.ParentID != nil {
+		e.int(int64(*m.ParentID))
+	}
+
+	e.int(int64(m.ReplyCount))
+
+	e.length(len(m.Reactions), m.Reactions == nil)
+	for _, r := range m.Reactions {
+		e.string(r.Emoji)
+		e.int(int64(r.Count))
+	}
+
+	e.length(len(m.Attachments), m.Attachments == nil)
+	for _, a := range m.Attachments {
+		e.int(int64(a.ID))
+		e.int(int64(a.MessageID))
+		e.string(a.BlobKey)
+		e.string(a.Filename)
+		e.string(a.ContentType)
+		e.int(a.Size)
+		e.time(a.CreatedAt)
+	}
+
+}
+
+// decodeChat deserializes a chat written by encodeChat.
+func decodeChat(data []byte) (models.Chat, error) {
+
+	if len(data) == 0 || data[0] != codecVersion {
+		return models.Chat{}, errCorrupt
+	}
+
+	d := decoder{buf: data[1:]}
+
+	chat := models.Chat{
+		ID:        int(d.int()),
+		Title:     d.string(),
+		CreatedAt: d.time(),
+		UpdatedAt: d.time(),
+	}
+
+	if n, isNil := d.length(); !isNil {
+		chat.Messages = make([]models.Message, n)
+		for i := range chat.Messages {
+			chat.Messages[i] = decodeMessage(&d)
+		}
+	}
+
+	if d.err == nil && len(d.buf) != 0 {
+		d.fail()
+	}
+
+	if d.err != nil {
+		return models.Chat{}, d.err
+	}
+
+	return chat, nil
+
+}
+
+// decodeMessage deserializes a message written by encodeMessage.
+func decodeMessage(d *decoder) models.Message {
+
+	m := models.Message{
+		ID:        int(d.int()),
+		ChatID:    int(d.int()),
+		Text:      d.string(),
+		CreatedAt: d.time(),
+	}
+
+	if d.bool() {
+		editedAt := d.time()
+		m.EditedAt = &editedAt
+	}
+
+	if d.bool() {
+		authorID := int(d.int())
+		m.AuthorID = &authorID
+	}
+
+	if d.bool() {
+		parentID := int(d.int())
+		m.ParentID = &parentID
+	}
+
+	m.ReplyCount = int(d.int())
+
+	if n, isNil := d.length(); !isNil {
+		m.Reactions = make(models.Reactions, n)
+		for i := range m.Reactions {
+			m.Reactions[i] = models.ReactionCount{Emoji: d.string(), Count: int(d.int())}
+		}
+	}
+
+	if n, isNil := d.length(); !isNil {
+		m.Attachments = make(models.Attachments, n)
+		for i := range m.Attachments {
+			m.Attachments[i] = models.Attachment{
+				ID:          int(d.int()),
+				MessageID:   int(d.int()),
+				BlobKey:     d.string(),
+				Filename:    d.string(),
+				ContentType: d.string(),
+				Size:        d.int(),
+				CreatedAt:   d.time(),
+			}
+		}
+	}
+
+	return m
+
+}
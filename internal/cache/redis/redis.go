@@ -0,0 +1,494 @@
+// Package redis provides a chat cache shared between instances
+// through a Redis-compatible server.
+package redis
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/errs"
+	"chatX/internal/logger"
+	"chatX/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of cache operations.
+var tracer = otel.Tracer("chatX/internal/cache/redis")
+
+// scanCount is the number of keys requested per SCAN call when purging.
+const scanCount = "100"
+
+// Cache is a thread-safe chat cache kept in a Redis-compatible server.
+//
+// Chats are stored under KeyPrefix followed by the chat ID, in the compact
+// binary format of encodeChat, and expire after the configured TTL. Capacity
+// is left to the eviction policy of the server. Failures of the server are
+// logged and turn lookups into misses, so the service falls back to storage.
+//
+// With a tombstone TTL configured, a chat that is deleted, or written while not cached,
+// is replaced by an empty value expiring after it, and Put only stores a chat whose key
+// is not set. A load of any instance that read the chat from storage before the write
+// therefore cannot cache it stale.
+type Cache struct {
+	idle   chan *conn    // Idle connections ready for reuse
+	closed atomic.Bool   // Set once the cache is closed
+	config config.Cache  // Cache configuration
+	logger logger.Logger // Logger instance
+
+	hits   atomic.Uint64 // Number of lookups that found a chat
+	misses atomic.Uint64 // Number of lookups that did not find a chat
+}
+
+// NewCache creates a new Cache instance. Connections are established on first use.
+func NewCache(logger logger.Logger, config config.Cache) *Cache {
+	return &Cache{
+		idle:   make(chan *conn, max(config.Redis.PoolSize, 1)),
+		config: config,
+		logger: logger,
+	}
+}
+
+// key returns the key of a cached chat.
+func (c *Cache) key(chatID int) []byte {
+	return strconv.AppendInt([]byte(c.config.Redis.KeyPrefix), int64(chatID), 10)
+}
+
+// Get retrieves a chat from the server by key.
+func (c *Cache) Get(ctx context.Context, key int) (models.Chat, error) {
+
+	ctx, span := tracer.Start(ctx, "cache.Get", trace.WithAttributes(attribute.Int("chat.id", key)))
+	defer span.End()
+
+	reply, err := c.do(ctx, []byte("GET"), c.key(key))
+	if err != nil {
+		c.logger.LogWarnContext(ctx, "cache — failed to get chat", "chatID", key, "err", err.Error(), "layer", "cache.redis")
+		c.misses.Add(1)
+		span.SetAttributes(attribute.Bool("cache.hit", false))
+		return models.Chat{}, errs.ErrCacheMiss
+	}
+
+	data, ok := reply.([]byte)
+	if !ok || len(data) == 0 {
+		c.logger.DebugContext(ctx, "cache — chat not found", "chatID", key, "layer", "cache.redis")
+		c.misses.Add(1)
+		span.SetAttributes(attribute.Bool("cache.hit", false))
+		return models.Chat{}, errs.ErrCacheMiss
+	}
+
+	chat, err := decodeChat(data)
+	if err != nil {
+		c.logger.LogWarnContext(ctx, "cache — failed to decode chat", "chatID", key, "err", err.Error(), "layer", "cache.redis")
+		c.misses.Add(1)
+		span.SetAttributes(attribute.Bool("cache.hit", false))
+		return models.Chat{}, errs.ErrCacheMiss
+	}
+
+	c.logger.DebugContext(ctx, "cache — chat found", "chatID", key, "layer", "cache.redis")
+	c.hits.Add(1)
+	span.SetAttributes(attribute.Bool("cache.hit", true))
+
+	return chat, nil
+
+}
+
+// Put stores a chat on the server, expiring it after the configured TTL.
+//
+// With tombstones enabled, the chat is only stored if its key is not set, so neither
+// a tombstone nor a chat cached by another instance, and kept up to date since, is replaced.
+func (c *Cache) Put(ctx context.Context, key int, value models.Chat) {
+
+	ctx, span := tracer.Start(ctx, "cache.Put", trace.WithAttributes(attribute.Int("chat.id", key)))
+	defer span.End()
+
+	if len(value.Messages) > c.config.MaxMessages {
+		c.logger.DebugContext(ctx, "cache — chat not cached: message limit exceeded", "layer", "cache.redis")
+		return
+	}
+
+	args := [][]byte{[]byte("SET"), c.key(key), encodeChat(value)}
+	if c.config.TTL > 0 {
+		args = append(args, []byte("PX"), strconv.AppendInt(nil, max(c.ttl().Milliseconds(), 1), 10))
+	}
+	if c.config.TombstoneTTL > 0 {
+		args = append(args, []byte("NX"))
+	}
+
+	reply, err := c.do(ctx, args...)
+	if err != nil {
+		c.logger.LogWarnContext(ctx, "cache — failed to save chat", "chatID", key, "err", err.Error(), "layer", "cache.redis")
+		return
+	}
+
+	// SET with NX replies nil if the key is already set
+	if reply == nil {
+		c.logger.DebugContext(ctx, "cache — chat not cached: already set", "chatID", key, "layer", "cache.redis")
+		return
+	}
+
+	c.logger.DebugContext(ctx, "cache — chat saved", "chatID", key, "layer", "cache.redis")
+
+}
+
+// Append adds a new message to a cached chat, keeping at most limit messages.
+func (c *Cache) Append(ctx context.Context, key int, message models.Message, limit int) {
+	c.update(ctx, "cache.Append", key, func(chat models.Chat) (models.Chat, bool) {
+		return chat.WithNewMessage(message, limit), true
+	})
+}
+
+// Update replaces an edited message in a cached chat.
+func (c *Cache) Update(ctx context.Context, key int, message models.Message) {
+	c.update(ctx, "cache.Update", key, func(chat models.Chat) (models.Chat, bool) {
+		return chat.WithEditedMessage(message)
+	})
+}
+
+// update applies a change to a cached chat, keeping its remaining TTL.
+//
+// The chat is read and written back in a transaction watching its key, so a concurrent
+// write by another instance aborts the transaction. A chat that cannot be updated, because
+// of such a conflict, a failure or the message limit, is deleted and reloaded on the next read.
+// So is a chat that is not cached while tombstones are enabled, which leaves its tombstone.
+func (c *Cache) update(ctx context.Context, name string, key int, change func(models.Chat) (models.Chat, bool)) {
+
+	ctx, span := tracer.Start(ctx, name, trace.WithAttributes(attribute.Int("chat.id", key)))
+	defer span.End()
+
+	updated, err := c.transact(ctx, key, change)
+	if err != nil {
+		c.logger.LogWarnContext(ctx, "cache — failed to update chat", "chatID", key, "err", err.Error(), "layer", "cache.redis")
+	}
+
+	if !updated {
+		c.Delete(key)
+		return
+	}
+
+	c.logger.DebugContext(ctx, "cache — chat updated", "chatID", key, "layer", "cache.redis")
+
+}
+
+// transact runs the transaction of update and reports whether the chat is up to date,
+// which includes a chat that the change leaves as it is, and a chat that is not cached
+// unless it must be buried.
+func (c *Cache) transact(ctx context.Context, key int, change func(models.Chat) (models.Chat, bool)) (bool, error) {
+
+	cn, err := c.session(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	// the connection holds the state of the transaction, so it is never reused after a failure
+	updated, err := func() (bool, error) {
+
+		k := c.key(key)
+
+		if _, err := cn.do([]byte("WATCH"), k); err != nil {
+			return false, err
+		}
+
+		reply, err := cn.do([]byte("GET"), k)
+		if err != nil {
+			return false, err
+		}
+
+		data, ok := reply.([]byte)
+		if !ok || len(data) == 0 {
+			_, err := cn.do([]byte("UNWATCH"))
+			return err == nil && c.config.TombstoneTTL <= 0, err
+		}
+
+		chat, err := decodeChat(data)
+		if err != nil {
+			return false, err
+		}
+
+		chat, changed := change(chat)
+		if !changed {
+			_, err := cn.do([]byte("UNWATCH"))
+			return err == nil, err
+		}
+
+		if len(chat.Messages) > c.config.MaxMessages {
+			_, err := cn.do([]byte("UNWATCH"))
+			return false, err
+		}
+
+		if _, err := cn.do([]byte("MULTI")); err != nil {
+			return false, err
+		}
+		if _, err := cn.do([]byte("SET"), k, encodeChat(chat), []byte("KEEPTTL")); err != nil {
+			return false, err
+		}
+
+		// EXEC replies nil if the watched key was written since WATCH
+		reply, err = cn.do([]byte("EXEC"))
+		return reply != nil, err
+
+	}()
+
+	if err != nil {
+		_ = cn.Close()
+		return false, err
+	}
+
+	c.release(cn)
+
+	return updated, nil
+
+}
+
+// Delete removes a chat from the server by key, replacing it by a tombstone if they are enabled.
+//
+// A failure leaves a stale chat on the server until it expires, so it is logged as an error.
+func (c *Cache) Delete(key int) {
+
+	args := [][]byte{[]byte("DEL"), c.key(key)}
+	if c.config.TombstoneTTL > 0 {
+		args = [][]byte{[]byte("SET"), c.key(key), nil, []byte("PX"), strconv.AppendInt(nil, max(c.config.TombstoneTTL.Milliseconds(), 1), 10)}
+	}
+
+	if _, err := c.do(context.Background(), args...); err != nil {
+		c.logger.LogError("cache — failed to delete chat", err, "chatID", key, "layer", "cache.redis")
+		return
+	}
+
+	c.logger.Debug("cache — chat deleted", "chatID", key, "layer", "cache.redis")
+
+}
+
+// Purge removes all chats under the key prefix from the server.
+//
+// The server is shared, so this drops the chats cached by all instances.
+func (c *Cache) Purge() {
+
+	pattern := []byte(c.config.Redis.KeyPrefix + "*")
+	cursor := []byte("0")
+	deleted := 0
+
+	for {
+
+		reply, err := c.do(context.Background(), []byte("SCAN"), cursor, []byte("MATCH"), pattern, []byte("COUNT"), []byte(scanCount))
+		if err != nil {
+			c.logger.LogError("cache — failed to scan chats", err, "layer", "cache.redis")
+			return
+		}
+
+		next, keys, err := scanReply(reply)
+		if err != nil {
+			c.logger.LogError("cache — failed to scan chats", err, "layer", "cache.redis")
+			return
+		}
+
+		if len(keys) > 0 {
+			if _, err := c.do(context.Background(), append([][]byte{[]byte("DEL")}, keys...)...); err != nil {
+				c.logger.LogError("cache — failed to delete chats", err, "layer", "cache.redis")
+				return
+			}
+			deleted += len(keys)
+		}
+
+		if string(next) == "0" {
+			break
+		}
+		cursor = next
+
+	}
+
+	c.logger.Debug("cache — all chats deleted", "count", deleted, "layer", "cache.redis")
+
+}
+
+// Shared reports true: all instances read and write the same chats on the server.
+func (c *Cache) Shared() bool {
+	return true
+}
+
+// Stats returns a snapshot of the cache usage counters.
+//
+// Size and evictions are tracked by the server itself and are reported as zero.
+func (c *Cache) Stats() models.CacheStats {
+	return models.CacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+// Close closes all idle connections. Connections in use are closed when released.
+func (c *Cache) Close() {
+
+	c.closed.Store(true)
+
+	for {
+		select {
+		case cn := <-c.idle:
+			_ = cn.Close()
+		default:
+			c.logger.LogInfo("cache — resources released", "layer", "cache.redis")
+			return
+		}
+	}
+
+}
+
+// do sends a command on a pooled connection and returns the reply.
+//
+// The command is bounded by the IO timeout and the deadline of the context.
+// A connection that fails is closed instead of being returned to the pool;
+// error replies leave the connection usable.
+func (c *Cache) do(ctx context.Context, args ...[]byte) (any, error) {
+
+	cn, err := c.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := cn.do(args...)
+	if err != nil {
+		var serverErr serverError
+		if !errors.As(err, &serverErr) {
+			_ = cn.Close()
+			return nil, err
+		}
+	}
+
+	c.release(cn)
+
+	return reply, err
+
+}
+
+// session takes a pooled connection for a command or transaction, bounded by the
+// IO timeout and the deadline of the context. The caller must release or close it.
+func (c *Cache) session(ctx context.Context) (*conn, error) {
+
+	if c.closed.Load() {
+		return nil, errors.New("redis: cache is closed")
+	}
+
+	cn, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(c.config.Redis.IOTimeout)
+	if d, ok := ctx.Deadline(); ok && (c.config.Redis.IOTimeout <= 0 || d.Before(deadline)) {
+		deadline = d
+	} else if c.config.Redis.IOTimeout <= 0 {
+		deadline = time.Time{}
+	}
+
+	if err := cn.SetDeadline(deadline); err != nil {
+		_ = cn.Close()
+		return nil, err
+	}
+
+	return cn, nil
+
+}
+
+// acquire takes an idle connection from the pool or establishes a new one.
+func (c *Cache) acquire(ctx context.Context) (*conn, error) {
+
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: c.config.Redis.DialTimeout}
+
+	nc, err := dialer.DialContext(ctx, "tcp", c.config.Redis.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	cn := newConn(nc)
+
+	if c.config.Redis.DialTimeout > 0 {
+		_ = cn.SetDeadline(time.Now().Add(c.config.Redis.DialTimeout))
+	}
+
+	if c.config.Redis.Password != "" {
+		if _, err := cn.do([]byte("AUTH"), []byte(c.config.Redis.Password)); err != nil {
+			_ = cn.Close()
+			return nil, fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if c.config.Redis.DB != 0 {
+		if _, err := cn.do([]byte("SELECT"), strconv.AppendInt(nil, int64(c.config.Redis.DB), 10)); err != nil {
+			_ = cn.Close()
+			return nil, fmt.Errorf("failed to select database: %w", err)
+		}
+	}
+
+	return cn, nil
+
+}
+
+// release returns a connection to the pool, closing it if the pool is full or the cache is closed.
+func (c *Cache) release(cn *conn) {
+
+	if c.closed.Load() {
+		_ = cn.Close()
+		return
+	}
+
+	select {
+	case c.idle <- cn:
+	default:
+		_ = cn.Close()
+	}
+
+}
+
+// ttl returns the lifetime of a chat being stored: the configured TTL,
+// shortened by a random share of up to TTLJitter of it.
+func (c *Cache) ttl() time.Duration {
+	ttl := c.config.TTL
+	if jitter := time.Duration(c.config.TTLJitter * float64(ttl)); jitter > 0 {
+		ttl -= rand.N(jitter)
+	}
+	return ttl
+}
+
+// scanReply splits a SCAN reply into the next cursor and the keys found.
+func scanReply(reply any) ([]byte, [][]byte, error) {
+
+	items, ok := reply.([]any)
+	if !ok || len(items) != 2 {
+		return nil, nil, errProtocol
+	}
+
+	cursor, ok := items[0].([]byte)
+	if !ok {
+		return nil, nil, errProtocol
+	}
+
+	found, ok := items[1].([]any)
+	if !ok {
+		return nil, nil, errProtocol
+	}
+
+	keys := make([][]byte, 0, len(found))
+	for _, item := range found {
+		key, ok := item.([]byte)
+		if !ok {
+			return nil, nil, errProtocol
+		}
+		keys = append(keys, key)
+	}
+
+	return cursor, keys, nil
+
+}
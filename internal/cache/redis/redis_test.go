@@ -0,0 +1,674 @@
+package redis
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/errs"
+	"chatX/internal/logger/mocks"
+	"chatX/internal/models"
+	"context"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// standIn is an in-process server implementing the subset of Redis used by the cache.
+type standIn struct {
+	listener net.Listener
+	password string
+
+	mu       sync.Mutex
+	data     map[string][]byte
+	expires  map[string]time.Time
+	versions map[string]int // bumped on every write of a key, for WATCH
+}
+
+// session is the state of a connection to the stand-in.
+type session struct {
+	authed  bool
+	watched map[string]int // versions of the watched keys
+	queued  [][]string     // commands queued since MULTI, nil outside a transaction
+}
+
+func startStandIn(t *testing.T, password string) *standIn {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &standIn{
+		listener: listener,
+		password: password,
+		data:     make(map[string][]byte),
+		expires:  make(map[string]time.Time),
+		versions: make(map[string]int),
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(newConn(c))
+		}
+	}()
+
+	return s
+
+}
+
+func (s *standIn) serve(c *conn) {
+
+	defer c.Close()
+
+	session := &session{authed: s.password == ""}
+
+	for {
+
+		request, err := c.read()
+		if err != nil {
+			return
+		}
+
+		items, _ := request.([]any)
+		args := make([]string, len(items))
+		for i, item := range items {
+			arg, _ := item.([]byte)
+			args[i] = string(arg)
+		}
+
+		var reply string
+		if len(args) > 0 && !session.authed && !strings.EqualFold(args[0], "AUTH") {
+			reply = "-NOAUTH Authentication required.\r\n"
+		} else {
+			reply = s.transact(args, session)
+		}
+
+		if _, err := c.Write([]byte(reply)); err != nil {
+			return
+		}
+
+	}
+
+}
+
+// transact handles the transaction commands of a session and runs the others.
+func (s *standIn) transact(args []string, session *session) string {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, at := range s.expires {
+		if !time.Now().Before(at) {
+			s.delete(key)
+		}
+	}
+
+	switch command := strings.ToUpper(args[0]); {
+
+	case command == "WATCH":
+		session.watched = make(map[string]int)
+		for _, key := range args[1:] {
+			session.watched[key] = s.versions[key]
+		}
+		return "+OK\r\n"
+
+	case command == "UNWATCH":
+		session.watched = nil
+		return "+OK\r\n"
+
+	case command == "MULTI":
+		session.queued = [][]string{}
+		return "+OK\r\n"
+
+	case command == "EXEC":
+		queued, watched := session.queued, session.watched
+		session.queued, session.watched = nil, nil
+		for key, version := range watched {
+			if s.versions[key] != version {
+				return "*-1\r\n"
+			}
+		}
+		replies := "*" + strconv.Itoa(len(queued)) + "\r\n"
+		for _, args := range queued {
+			replies += s.handle(args, session)
+		}
+		return replies
+
+	case session.queued != nil:
+		session.queued = append(session.queued, args)
+		return "+QUEUED\r\n"
+
+	default:
+		return s.handle(args, session)
+
+	}
+
+}
+
+// delete removes a key, reporting whether it existed.
+func (s *standIn) delete(key string) bool {
+	if _, ok := s.data[key]; !ok {
+		return false
+	}
+	delete(s.data, key)
+	delete(s.expires, key)
+	s.versions[key]++
+	return true
+}
+
+func (s *standIn) handle(args []string, session *session) string {
+
+	switch strings.ToUpper(args[0]) {
+
+	case "AUTH":
+		if args[1] != s.password {
+			return "-WRONGPASS invalid password\r\n"
+		}
+		session.authed = true
+		return "+OK\r\n"
+
+	case "SELECT", "PING":
+		return "+OK\r\n"
+
+	case "GET":
+		value, ok := s.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(string(value))
+
+	case "SET":
+		keepTTL, expires := false, time.Time{}
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "KEEPTTL":
+				keepTTL = true
+			case "PX":
+				i++
+				ms, _ := strconv.Atoi(args[i])
+				expires = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			case "NX":
+				if _, ok := s.data[args[1]]; ok {
+					return "$-1\r\n"
+				}
+			}
+		}
+		s.data[args[1]] = []byte(args[2])
+		s.versions[args[1]]++
+		switch {
+		case keepTTL:
+		case !expires.IsZero():
+			s.expires[args[1]] = expires
+		default:
+			delete(s.expires, args[1])
+		}
+		return "+OK\r\n"
+
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if s.delete(key) {
+				deleted++
+			}
+		}
+		return ":" + strconv.Itoa(deleted) + "\r\n"
+
+	case "SCAN":
+		// The whole keyspace is returned in one page.
+		var keys []string
+		for key := range s.data {
+			if ok, _ := path.Match(args[3], key); ok {
+				keys = append(keys, bulk(key))
+			}
+		}
+		return "*2\r\n" + bulk("0") + "*" + strconv.Itoa(len(keys)) + "\r\n" + strings.Join(keys, "")
+
+	default:
+		return "-ERR unknown command '" + args[0] + "'\r\n"
+
+	}
+
+}
+
+func (s *standIn) set(key string, value []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	s.versions[key]++
+}
+
+func (s *standIn) expiry(key string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expires[key]
+}
+
+func (s *standIn) has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.data[key]
+	return ok
+}
+
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+func testCacheConfig(address string) config.Cache {
+	return config.Cache{
+		Backend:     "redis",
+		MaxMessages: 10,
+		TTL:         time.Minute,
+		Redis: config.Redis{
+			Address:     address,
+			KeyPrefix:   "chatx:chat:",
+			PoolSize:    2,
+			DialTimeout: time.Second,
+			IOTimeout:   time.Second,
+		},
+	}
+}
+
+func setupCache(t *testing.T, config config.Cache) *Cache {
+
+	controller := gomock.NewController(t)
+	logger := mocks.NewMockLogger(controller)
+
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().DebugContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogInfoContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogWarnContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogError(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogErrorContext(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	cache := NewCache(logger, config)
+	t.Cleanup(cache.Close)
+
+	return cache
+
+}
+
+func testChat(id int) models.Chat {
+
+	authorID, parentID := 7, 3
+	createdAt := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.Local)
+	editedAt := createdAt.Add(time.Minute)
+
+	return models.Chat{
+		ID:        id,
+		Title:     "Release planning",
+		CreatedAt: createdAt,
+		UpdatedAt: editedAt,
+		Messages: []models.Message{
+			{
+				ID:         4,
+				ChatID:     id,
+				Text:       "Ship it",
+				CreatedAt:  editedAt,
+				EditedAt:   &editedAt,
+				AuthorID:   &authorID,
+				ParentID:   &parentID,
+				Reactions:  models.Reactions{{Emoji: "👍", Count: 2}},
+				ReplyCount: 0,
+				Attachments: models.Attachments{{
+					ID: 1, MessageID: 4, BlobKey: "ab/cdef", Filename: "plan.pdf",
+					ContentType: "application/pdf", Size: 2048, CreatedAt: editedAt,
+				}},
+			},
+			{ID: 3, ChatID: id, Text: "", CreatedAt: createdAt, ReplyCount: 1, Reactions: models.Reactions{}},
+		},
+	}
+
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+
+	for _, chat := range []models.Chat{testChat(1), {ID: 2, Title: "Empty"}, {ID: 3, Messages: []models.Message{}}} {
+		got, err := decodeChat(encodeChat(chat))
+		require.NoError(t, err)
+		require.Equal(t, chat, got)
+	}
+
+}
+
+func TestCodec_DropsUnreadCount(t *testing.T) {
+
+	chat := testChat(1)
+	chat.UnreadCount = 5
+
+	got, err := decodeChat(encodeChat(chat))
+	require.NoError(t, err)
+	require.Zero(t, got.UnreadCount)
+
+}
+
+func TestCodec_Corrupt(t *testing.T) {
+
+	data := encodeChat(testChat(1))
+
+	for _, corrupt := range [][]byte{nil, {codecVersion + 1}, data[:len(data)/2], append(data, 0)} {
+		_, err := decodeChat(corrupt)
+		require.ErrorIs(t, err, errCorrupt)
+	}
+
+}
+
+func TestCache_PutAndGet_OK(t *testing.T) {
+
+	server := startStandIn(t, "")
+	cache := setupCache(t, testCacheConfig(server.listener.Addr().String()))
+
+	chat := testChat(1)
+	cache.Put(context.Background(), 1, chat)
+	require.True(t, server.has("chatx:chat:1"))
+
+	got, err := cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, chat, got)
+	require.Equal(t, models.CacheStats{Hits: 1}, cache.Stats())
+
+}
+
+func TestCache_Get_Miss(t *testing.T) {
+
+	server := startStandIn(t, "")
+	cache := setupCache(t, testCacheConfig(server.listener.Addr().String()))
+
+	_, err := cache.Get(context.Background(), 42)
+	require.ErrorIs(t, err, errs.ErrCacheMiss)
+	require.Equal(t, models.CacheStats{Misses: 1}, cache.Stats())
+
+}
+
+func TestCache_Get_CorruptValue(t *testing.T) {
+
+	server := startStandIn(t, "")
+	cache := setupCache(t, testCacheConfig(server.listener.Addr().String()))
+
+	server.set("chatx:chat:1", []byte("not a chat"))
+
+	_, err := cache.Get(context.Background(), 1)
+	require.ErrorIs(t, err, errs.ErrCacheMiss)
+
+}
+
+func TestCache_Get_ServerDown(t *testing.T) {
+
+	server := startStandIn(t, "")
+	address := server.listener.Addr().String()
+	require.NoError(t, server.listener.Close())
+
+	cache := setupCache(t, testCacheConfig(address))
+
+	_, err := cache.Get(context.Background(), 1)
+	require.ErrorIs(t, err, errs.ErrCacheMiss)
+
+}
+
+func TestCache_Put_MessageLimitExceeded(t *testing.T) {
+
+	server := startStandIn(t, "")
+	config := testCacheConfig(server.listener.Addr().String())
+	config.MaxMessages = 1
+	cache := setupCache(t, config)
+
+	cache.Put(context.Background(), 1, testChat(1))
+	require.False(t, server.has("chatx:chat:1"))
+
+}
+
+func TestCache_Put_Expires(t *testing.T) {
+
+	server := startStandIn(t, "")
+	config := testCacheConfig(server.listener.Addr().String())
+	config.TTL = 20 * time.Millisecond
+	cache := setupCache(t, config)
+
+	cache.Put(context.Background(), 1, testChat(1))
+	_, err := cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+
+	time.Sleep(40 * time.Millisecond)
+
+	_, err = cache.Get(context.Background(), 1)
+	require.ErrorIs(t, err, errs.ErrCacheMiss)
+
+}
+
+func TestCache_Delete(t *testing.T) {
+
+	server := startStandIn(t, "")
+	cache := setupCache(t, testCacheConfig(server.listener.Addr().String()))
+
+	cache.Put(context.Background(), 1, testChat(1))
+	cache.Put(context.Background(), 2, testChat(2))
+	cache.Delete(1)
+
+	require.False(t, server.has("chatx:chat:1"))
+	require.True(t, server.has("chatx:chat:2"))
+
+}
+
+func TestCache_Purge(t *testing.T) {
+
+	server := startStandIn(t, "")
+	cache := setupCache(t, testCacheConfig(server.listener.Addr().String()))
+
+	server.set("other:1", []byte("kept"))
+	cache.Put(context.Background(), 1, testChat(1))
+	cache.Put(context.Background(), 2, testChat(2))
+	cache.Purge()
+
+	require.False(t, server.has("chatx:chat:1"))
+	require.False(t, server.has("chatx:chat:2"))
+	require.True(t, server.has("other:1"))
+
+}
+
+func TestCache_Auth(t *testing.T) {
+
+	server := startStandIn(t, "secret")
+	config := testCacheConfig(server.listener.Addr().String())
+
+	cache := setupCache(t, config)
+	cache.Put(context.Background(), 1, testChat(1))
+	require.False(t, server.has("chatx:chat:1"))
+
+	config.Redis.Password = "secret"
+	cache = setupCache(t, config)
+	cache.Put(context.Background(), 1, testChat(1))
+	_, err := cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+
+}
+
+func TestCache_Concurrent(t *testing.T) {
+
+	server := startStandIn(t, "")
+	cache := setupCache(t, testCacheConfig(server.listener.Addr().String()))
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 20 {
+				cache.Put(context.Background(), i*100+j, testChat(i*100+j))
+				_, err := cache.Get(context.Background(), i*100+j)
+				require.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, uint64(160), cache.Stats().Hits)
+
+}
+
+func TestCache_Append_KeepsTTL(t *testing.T) {
+
+	server := startStandIn(t, "")
+	cache := setupCache(t, testCacheConfig(server.listener.Addr().String()))
+
+	chat := testChat(1)
+	cache.Put(context.Background(), 1, chat)
+	expiry := server.expiry("chatx:chat:1")
+
+	message := models.Message{ID: 5, ChatID: 1, Text: "newest", CreatedAt: chat.Messages[0].CreatedAt.Add(time.Minute)}
+	cache.Append(context.Background(), 1, message, 100)
+
+	got, err := cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, got.Messages, 3)
+	require.Equal(t, message, got.Messages[0])
+	require.Equal(t, expiry, server.expiry("chatx:chat:1"))
+
+}
+
+func TestCache_Append_MessageLimitExceeded(t *testing.T) {
+
+	server := startStandIn(t, "")
+	config := testCacheConfig(server.listener.Addr().String())
+	config.MaxMessages = 2
+	cache := setupCache(t, config)
+
+	chat := testChat(1)
+	cache.Put(context.Background(), 1, chat)
+	cache.Append(context.Background(), 1, models.Message{ID: 5, ChatID: 1, CreatedAt: chat.Messages[0].CreatedAt.Add(time.Minute)}, 100)
+
+	require.False(t, server.has("chatx:chat:1"))
+
+}
+
+func TestCache_Append_NotCached(t *testing.T) {
+
+	server := startStandIn(t, "")
+	cache := setupCache(t, testCacheConfig(server.listener.Addr().String()))
+
+	cache.Append(context.Background(), 1, models.Message{ID: 1, ChatID: 1}, 100)
+
+	require.False(t, server.has("chatx:chat:1"))
+
+	// the connection is still usable after the transaction was abandoned
+	cache.Put(context.Background(), 1, testChat(1))
+	require.True(t, server.has("chatx:chat:1"))
+
+}
+
+func TestCache_Append_NotCached_LeavesTombstone(t *testing.T) {
+
+	server := startStandIn(t, "")
+	config := testCacheConfig(server.listener.Addr().String())
+	config.TombstoneTTL = 30 * time.Millisecond
+	cache := setupCache(t, config)
+
+	// a load reads the chat, another instance appends a message to it, then the load stores what it read
+	stale := testChat(1)
+	cache.Append(context.Background(), 1, models.Message{ID: 5, ChatID: 1}, 100)
+	cache.Put(context.Background(), 1, stale)
+
+	_, err := cache.Get(context.Background(), 1)
+	require.ErrorIs(t, err, errs.ErrCacheMiss)
+
+	time.Sleep(60 * time.Millisecond)
+
+	cache.Put(context.Background(), 1, stale)
+	_, err = cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+
+}
+
+func TestCache_Delete_LeavesTombstone(t *testing.T) {
+
+	server := startStandIn(t, "")
+	config := testCacheConfig(server.listener.Addr().String())
+	config.TombstoneTTL = time.Minute
+	cache := setupCache(t, config)
+
+	cache.Put(context.Background(), 1, testChat(1))
+	cache.Delete(1)
+	cache.Put(context.Background(), 1, testChat(1))
+
+	_, err := cache.Get(context.Background(), 1)
+	require.ErrorIs(t, err, errs.ErrCacheMiss)
+	require.False(t, server.expiry("chatx:chat:1").IsZero())
+
+}
+
+func TestCache_Put_KeepsCachedChat(t *testing.T) {
+
+	server := startStandIn(t, "")
+	config := testCacheConfig(server.listener.Addr().String())
+	config.TombstoneTTL = time.Minute
+	cache := setupCache(t, config)
+
+	chat := testChat(1)
+	cache.Put(context.Background(), 1, chat)
+
+	renamed := chat
+	renamed.Title = "renamed"
+	cache.Put(context.Background(), 1, renamed)
+
+	got, err := cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, chat.Title, got.Title)
+
+}
+
+func TestCache_Update(t *testing.T) {
+
+	server := startStandIn(t, "")
+	cache := setupCache(t, testCacheConfig(server.listener.Addr().String()))
+
+	chat := testChat(1)
+	cache.Put(context.Background(), 1, chat)
+
+	edited := chat.Messages[1]
+	edited.Text = "edited"
+	cache.Update(context.Background(), 1, edited)
+
+	got, err := cache.Get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, edited, got.Messages[1])
+
+}
+
+func TestCache_Update_ConcurrentWrite(t *testing.T) {
+
+	server := startStandIn(t, "")
+	cache := setupCache(t, testCacheConfig(server.listener.Addr().String()))
+
+	cache.Put(context.Background(), 1, testChat(1))
+
+	// another instance writes the chat while the transaction is in progress
+	cache.update(context.Background(), "cache.Update", 1, func(chat models.Chat) (models.Chat, bool) {
+		server.set("chatx:chat:1", encodeChat(chat))
+		chat.Title = "renamed"
+		return chat, true
+	})
+
+	require.False(t, server.has("chatx:chat:1"))
+
+}
+
+func TestCache_Update_CorruptValue(t *testing.T) {
+
+	server := startStandIn(t, "")
+	cache := setupCache(t, testCacheConfig(server.listener.Addr().String()))
+
+	server.set("chatx:chat:1", []byte("not a chat"))
+	cache.Update(context.Background(), 1, models.Message{ID: 1, ChatID: 1})
+
+	require.False(t, server.has("chatx:chat:1"))
+
+}
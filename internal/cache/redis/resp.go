@@ -0,0 +1,154 @@
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// errProtocol reports a reply that does not follow the RESP protocol.
+var errProtocol = errors.New("redis: malformed reply")
+
+// serverError is an error reply sent by the server.
+type serverError string
+
+// Error returns the message of the error reply.
+func (e serverError) Error() string {
+	return "redis: " + string(e)
+}
+
+// conn is a connection to a Redis-compatible server speaking RESP2.
+type conn struct {
+	net.Conn               // Underlying network connection
+	r        *bufio.Reader // Buffered reader of replies
+	w        *bufio.Writer // Buffered writer of commands
+}
+
+// newConn wraps a network connection.
+func newConn(c net.Conn) *conn {
+	return &conn{Conn: c, r: bufio.NewReader(c), w: bufio.NewWriter(c)}
+}
+
+// do sends a command and reads its reply.
+//
+// Replies are decoded as string (simple string), int64 (integer), []byte (bulk string),
+// []any (array) or nil (null bulk string or array). Error replies are returned as serverError.
+func (c *conn) do(args ...[]byte) (any, error) {
+
+	if err := c.write(args); err != nil {
+		return nil, err
+	}
+
+	return c.read()
+
+}
+
+// write sends a command as an array of bulk strings.
+func (c *conn) write(args [][]byte) error {
+
+	buf := c.w.AvailableBuffer()
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+
+	if _, err := c.w.Write(buf); err != nil {
+		return err
+	}
+
+	return c.w.Flush()
+
+}
+
+// read reads a single reply.
+func (c *conn) read() (any, error) {
+
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		return nil, errProtocol
+	}
+
+	switch line[0] {
+
+	case '+':
+		return string(line[1:]), nil
+
+	case '-':
+		return nil, serverError(line[1:])
+
+	case ':':
+		n, err := strconv.ParseInt(string(line[1:]), 10, 64)
+		if err != nil {
+			return nil, errProtocol
+		}
+		return n, nil
+
+	case '$':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil || n < -1 {
+			return nil, errProtocol
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		if data[n] != '\r' || data[n+1] != '\n' {
+			return nil, errProtocol
+		}
+		return data[:n], nil
+
+	case '*':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil || n < -1 {
+			return nil, errProtocol
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+
+	default:
+		return nil, fmt.Errorf("%w: unexpected type %q", errProtocol, line[0])
+
+	}
+
+}
+
+// readLine reads a line terminated by CRLF and returns it without the terminator.
+func (c *conn) readLine() ([]byte, error) {
+
+	line, err := c.r.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, errProtocol
+	}
+
+	return line[:len(line)-2], nil
+
+}
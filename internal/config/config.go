@@ -14,11 +14,16 @@ import (
 
 // Config aggregates all application configurations.
 type Config struct {
-	Logger  Logger  `mapstructure:"logger"`   // Logger configuration
-	Server  Server  `mapstructure:"server"`   // HTTP server configuration
-	Service Service `mapstructure:"service"`  // Application service limits
-	Cache   Cache   `mapstructure:"cache"`    // In-memory cache configuration
-	Storage Storage `mapstructure:"database"` // Database configuration
+	Logger    Logger    `mapstructure:"logger"`     // Logger configuration
+	Server    Server    `mapstructure:"server"`     // HTTP server configuration
+	Service   Service   `mapstructure:"service"`    // Application service limits
+	Cache     Cache     `mapstructure:"cache"`      // In-memory cache configuration
+	Broker    Broker    `mapstructure:"broker"`     // In-process event broker configuration
+	EventBus  EventBus  `mapstructure:"event_bus"`  // Cross-instance event bus configuration
+	Blob      Blob      `mapstructure:"blob"`       // Attachment blob storage configuration
+	RateLimit RateLimit `mapstructure:"rate_limit"` // Per-client request rate limits
+	Tracing   Tracing   `mapstructure:"tracing"`    // OpenTelemetry tracing configuration
+	Storage   Storage   `mapstructure:"database"`   // Database configuration
 }
 
 // Logger contains settings for logging behavior.
@@ -35,14 +40,28 @@ type Server struct {
 	WriteTimeout    time.Duration `mapstructure:"write_timeout"`    // Maximum write timeout
 	MaxHeaderBytes  int           `mapstructure:"max_header_bytes"` // Maximum size of request headers
 	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"` // Graceful shutdown timeout
+	DrainDelay      time.Duration `mapstructure:"drain_delay"`      // Time between reporting not ready and shutting down
+	ReadyTimeout    time.Duration `mapstructure:"ready_timeout"`    // Timeout of the readiness checks
+	TrustedProxies  []string      `mapstructure:"trusted_proxies"`  // IPs or CIDR ranges of the proxies whose forwarding headers set the client IP; none by default
 }
 
 // Service contains business logic constraints.
 type Service struct {
-	MaxMessageLength int `mapstructure:"max_message_length"` // Max length of a message
-	MaxTitleLength   int `mapstructure:"max_title_length"`   // Max length of a title
-	GetLimitMax      int `mapstructure:"get_limit_max"`      // Maximum GET limit
-	GetLimitDefault  int `mapstructure:"get_limit_default"`  // Default GET limit
+	MaxMessageLength  int           `mapstructure:"max_message_length"`  // Max length of a message
+	MaxTitleLength    int           `mapstructure:"max_title_length"`    // Max length of a title
+	GetLimitMax       int           `mapstructure:"get_limit_max"`       // Maximum GET limit
+	GetLimitDefault   int           `mapstructure:"get_limit_default"`   // Default GET limit
+	MaxWait           time.Duration `mapstructure:"max_wait"`            // Maximum long-polling wait for new messages
+	ReplayGrace       time.Duration `mapstructure:"replay_grace"`        // How long before the last delivered message a resumed stream looks for late commits
+	MinPasswordLength int           `mapstructure:"min_password_length"` // Minimum length of a user password
+	TokenTTL          time.Duration `mapstructure:"token_ttl"`           // Lifetime of issued bearer tokens
+	StreamTokenTTL    time.Duration `mapstructure:"stream_token_ttl"`    // Lifetime of tokens passed in the query of event stream requests
+	TokenSecret       string        `mapstructure:"token_secret"`        // Secret used to sign bearer tokens, read from AUTH_SECRET
+	MaxAttachments    int           `mapstructure:"max_attachments"`     // Maximum number of attachments per message
+	MaxAttachmentSize int64         `mapstructure:"max_attachment_size"` // Maximum size of a single attachment in bytes
+	AllowedMediaTypes []string      `mapstructure:"allowed_media_types"` // Sniffed media types accepted for attachments; "type/*" matches a whole type
+	IdempotencyTTL    time.Duration `mapstructure:"idempotency_ttl"`     // How long responses to requests with an Idempotency-Key are replayed
+	IdempotencyGC     time.Duration `mapstructure:"idempotency_gc"`      // Interval between deletions of expired idempotency keys
 }
 
 // Storage contains database connection settings.
@@ -60,10 +79,72 @@ type Storage struct {
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`          // Connection max lifetime
 }
 
-// Cache contains in-memory caching settings.
+// Cache contains chat caching settings.
 type Cache struct {
-	Capacity    int `mapstructure:"capacity"`     // Maximum number of chats to cache
-	MaxMessages int `mapstructure:"max_messages"` // Maximum messages per cached chat
+	Backend      string        `mapstructure:"backend"`       // Cache backend: "memory" or "redis"
+	Capacity     int           `mapstructure:"capacity"`      // Maximum number of chats to cache; 0 disables the memory backend
+	Shards       int           `mapstructure:"shards"`        // Number of independent LRU segments of the memory backend; 0 picks one per CPU
+	MaxMessages  int           `mapstructure:"max_messages"`  // Maximum messages per cached chat
+	TTL          time.Duration `mapstructure:"ttl"`           // Time a chat stays cached before it is reloaded; 0 keeps it until evicted
+	TTLJitter    float64       `mapstructure:"ttl_jitter"`    // Largest share of the TTL randomly cut from the lifetime of each chat; 0 disables jitter
+	TombstoneTTL time.Duration `mapstructure:"tombstone_ttl"` // Time a chat written while not cached, or deleted, cannot be stored by a load that may predate the write; 0 disables tombstones
+	Redis        Redis         `mapstructure:"redis"`         // Settings of the redis backend
+}
+
+// Redis contains settings of a connection to a Redis-compatible server.
+type Redis struct {
+	Address     string        `mapstructure:"address"`      // Address of the server as host:port
+	Password    string        `mapstructure:"password"`     // Password of the server, read from REDIS_PASSWORD
+	DB          int           `mapstructure:"db"`           // Database number
+	KeyPrefix   string        `mapstructure:"key_prefix"`   // Prefix of the keys of cached chats
+	PoolSize    int           `mapstructure:"pool_size"`    // Maximum number of idle connections kept open
+	DialTimeout time.Duration `mapstructure:"dial_timeout"` // Timeout of establishing a connection
+	IOTimeout   time.Duration `mapstructure:"io_timeout"`   // Timeout of a single command
+}
+
+// Broker contains in-process event delivery settings.
+type Broker struct {
+	SendBuffer int `mapstructure:"send_buffer"` // Events buffered per subscriber before it is disconnected as a slow consumer
+}
+
+// EventBus contains cross-instance event bus settings.
+type EventBus struct {
+	Channel      string        `mapstructure:"channel"`       // Postgres NOTIFY channel shared by all instances
+	ReconnectMin time.Duration `mapstructure:"reconnect_min"` // Initial delay before reconnecting a lost listener
+	ReconnectMax time.Duration `mapstructure:"reconnect_max"` // Maximum delay between reconnect attempts
+}
+
+// Blob contains attachment blob storage settings.
+type Blob struct {
+	Backend   string `mapstructure:"backend"`    // Storage backend: "local" or "s3"
+	Directory string `mapstructure:"directory"`  // Root directory of the local backend
+	Endpoint  string `mapstructure:"endpoint"`   // Base URL of the S3-compatible service
+	Bucket    string `mapstructure:"bucket"`     // Bucket holding the blobs
+	Region    string `mapstructure:"region"`     // Region used to sign S3 requests
+	AccessKey string `mapstructure:"access_key"` // S3 access key ID, read from BLOB_ACCESS_KEY
+	SecretKey string `mapstructure:"secret_key"` // S3 secret access key, read from BLOB_SECRET_KEY
+}
+
+// Tracing contains OpenTelemetry tracing settings.
+type Tracing struct {
+	Enabled     bool    `mapstructure:"enabled"`      // Export spans to an OTLP collector
+	Endpoint    string  `mapstructure:"endpoint"`     // Address of the OTLP/HTTP collector as host:port
+	Insecure    bool    `mapstructure:"insecure"`     // Send spans over plain HTTP instead of HTTPS
+	ServiceName string  `mapstructure:"service_name"` // Service name attached to all spans
+	SampleRatio float64 `mapstructure:"sample_ratio"` // Fraction of new traces that are sampled, from 0 to 1
+}
+
+// RateLimit contains per-client request rate limiting settings.
+type RateLimit struct {
+	Enabled bool       `mapstructure:"enabled"` // Enable rate limiting
+	Reads   RateBudget `mapstructure:"reads"`   // Budget of GET, HEAD and OPTIONS requests
+	Writes  RateBudget `mapstructure:"writes"`  // Budget of all other requests
+}
+
+// RateBudget contains the token bucket settings of a rate limit.
+type RateBudget struct {
+	Rate  float64 `mapstructure:"rate"`  // Requests allowed per second on average
+	Burst int     `mapstructure:"burst"` // Requests allowed in a burst
 }
 
 // Load reads configuration from Viper, .env, and environment variables.
@@ -82,11 +163,16 @@ func Load() (Config, error) {
 	}
 
 	config := Config{
-		Logger:  loggerConfig(),
-		Server:  serverConfig(),
-		Service: serviceConfig(),
-		Cache:   cacheConfig(),
-		Storage: storageConfig(),
+		Logger:    loggerConfig(),
+		Server:    serverConfig(),
+		Service:   serviceConfig(),
+		Cache:     cacheConfig(),
+		Broker:    brokerConfig(),
+		EventBus:  eventBusConfig(),
+		Blob:      blobConfig(),
+		RateLimit: rateLimitConfig(),
+		Tracing:   tracingConfig(),
+		Storage:   storageConfig(),
 	}
 
 	loadEnvs(&config)
@@ -112,24 +198,103 @@ func serverConfig() Server {
 		WriteTimeout:    viper.GetDuration("server.write_timeout"),
 		MaxHeaderBytes:  viper.GetInt("server.max_header_bytes"),
 		ShutdownTimeout: viper.GetDuration("server.shutdown_timeout"),
+		DrainDelay:      viper.GetDuration("server.drain_delay"),
+		ReadyTimeout:    viper.GetDuration("server.ready_timeout"),
+		TrustedProxies:  viper.GetStringSlice("server.trusted_proxies"),
 	}
 }
 
 // serviceConfig loads service constraints from Viper.
 func serviceConfig() Service {
 	return Service{
-		MaxMessageLength: viper.GetInt("service.max_message_length"),
-		MaxTitleLength:   viper.GetInt("service.max_title_length"),
-		GetLimitMax:      viper.GetInt("service.get_limit_max"),
-		GetLimitDefault:  viper.GetInt("service.get_limit_default"),
+		MaxMessageLength:  viper.GetInt("service.max_message_length"),
+		MaxTitleLength:    viper.GetInt("service.max_title_length"),
+		GetLimitMax:       viper.GetInt("service.get_limit_max"),
+		GetLimitDefault:   viper.GetInt("service.get_limit_default"),
+		MaxWait:           viper.GetDuration("service.max_wait"),
+		ReplayGrace:       viper.GetDuration("service.replay_grace"),
+		MinPasswordLength: viper.GetInt("service.min_password_length"),
+		TokenTTL:          viper.GetDuration("service.token_ttl"),
+		StreamTokenTTL:    viper.GetDuration("service.stream_token_ttl"),
+		MaxAttachments:    viper.GetInt("service.max_attachments"),
+		MaxAttachmentSize: viper.GetInt64("service.max_attachment_size"),
+		AllowedMediaTypes: viper.GetStringSlice("service.allowed_media_types"),
+		IdempotencyTTL:    viper.GetDuration("service.idempotency_ttl"),
+		IdempotencyGC:     viper.GetDuration("service.idempotency_gc"),
 	}
 }
 
 // cacheConfig loads cache configuration from Viper.
 func cacheConfig() Cache {
 	return Cache{
-		Capacity:    viper.GetInt("cache.capacity"),
-		MaxMessages: viper.GetInt("cache.max_messages"),
+		Backend:      viper.GetString("cache.backend"),
+		Capacity:     viper.GetInt("cache.capacity"),
+		Shards:       viper.GetInt("cache.shards"),
+		MaxMessages:  viper.GetInt("cache.max_messages"),
+		TTL:          viper.GetDuration("cache.ttl"),
+		TTLJitter:    viper.GetFloat64("cache.ttl_jitter"),
+		TombstoneTTL: viper.GetDuration("cache.tombstone_ttl"),
+		Redis: Redis{
+			Address:     viper.GetString("cache.redis.address"),
+			DB:          viper.GetInt("cache.redis.db"),
+			KeyPrefix:   viper.GetString("cache.redis.key_prefix"),
+			PoolSize:    viper.GetInt("cache.redis.pool_size"),
+			DialTimeout: viper.GetDuration("cache.redis.dial_timeout"),
+			IOTimeout:   viper.GetDuration("cache.redis.io_timeout"),
+		},
+	}
+}
+
+// brokerConfig loads event broker configuration from Viper.
+func brokerConfig() Broker {
+	return Broker{
+		SendBuffer: viper.GetInt("broker.send_buffer"),
+	}
+}
+
+// eventBusConfig loads cross-instance event bus configuration from Viper.
+func eventBusConfig() EventBus {
+	return EventBus{
+		Channel:      viper.GetString("event_bus.channel"),
+		ReconnectMin: viper.GetDuration("event_bus.reconnect_min"),
+		ReconnectMax: viper.GetDuration("event_bus.reconnect_max"),
+	}
+}
+
+// tracingConfig loads OpenTelemetry tracing configuration from Viper.
+func tracingConfig() Tracing {
+	return Tracing{
+		Enabled:     viper.GetBool("tracing.enabled"),
+		Endpoint:    viper.GetString("tracing.endpoint"),
+		Insecure:    viper.GetBool("tracing.insecure"),
+		ServiceName: viper.GetString("tracing.service_name"),
+		SampleRatio: viper.GetFloat64("tracing.sample_ratio"),
+	}
+}
+
+// rateLimitConfig loads request rate limiting configuration from Viper.
+func rateLimitConfig() RateLimit {
+	return RateLimit{
+		Enabled: viper.GetBool("rate_limit.enabled"),
+		Reads: RateBudget{
+			Rate:  viper.GetFloat64("rate_limit.reads.rate"),
+			Burst: viper.GetInt("rate_limit.reads.burst"),
+		},
+		Writes: RateBudget{
+			Rate:  viper.GetFloat64("rate_limit.writes.rate"),
+			Burst: viper.GetInt("rate_limit.writes.burst"),
+		},
+	}
+}
+
+// blobConfig loads attachment blob storage configuration from Viper.
+func blobConfig() Blob {
+	return Blob{
+		Backend:   viper.GetString("blob.backend"),
+		Directory: viper.GetString("blob.directory"),
+		Endpoint:  viper.GetString("blob.endpoint"),
+		Bucket:    viper.GetString("blob.bucket"),
+		Region:    viper.GetString("blob.region"),
 	}
 }
 
@@ -152,4 +317,8 @@ func storageConfig() Storage {
 func loadEnvs(conf *Config) {
 	conf.Storage.Username = os.Getenv("DB_USER")
 	conf.Storage.Password = os.Getenv("DB_PASSWORD")
+	conf.Service.TokenSecret = os.Getenv("AUTH_SECRET")
+	conf.Blob.AccessKey = os.Getenv("BLOB_ACCESS_KEY")
+	conf.Blob.SecretKey = os.Getenv("BLOB_SECRET_KEY")
+	conf.Cache.Redis.Password = os.Getenv("REDIS_PASSWORD")
 }
@@ -5,16 +5,58 @@ package errs
 import "errors"
 
 var (
-	ErrInvalidJSON    = errors.New("invalid JSON format")                         // invalid JSON format
-	ErrInternal       = errors.New("internal server error")                       // internal server error
-	ErrTitleEmpty     = errors.New("chat title cannot be empty")                  // chat title cannot be empty
-	ErrTitleTooLong   = errors.New("chat title exceeds maximum length")           // chat title exceeds maximum length
-	ErrMessageEmpty   = errors.New("message text cannot be empty")                // message text cannot be empty
-	ErrMessageTooLong = errors.New("message text exceeds maximum length")         // message text exceeds maximum length
-	ErrInvalidChatID  = errors.New("invalid chat ID; must be a positive integer") // invalid chat ID; must be a positive integer
-	ErrChatNotFound   = errors.New("chat not found")                              // chat not found
-	ErrLimitTooSmall  = errors.New("limit cannot be negative")                    // limit cannot be negative
-	ErrLimitTooLarge  = errors.New("number of messages exceeds service limit")    // number of messages exceeds service limit
-	ErrInvalidLimit   = errors.New("invalid limit; must be an integer")           // invalid limit; must be a positive integer
-	ErrCacheMiss      = errors.New("cache miss")                                  // cache miss
+	ErrInvalidJSON              = errors.New("invalid JSON format")                                               // invalid JSON format
+	ErrInternal                 = errors.New("internal server error")                                             // internal server error
+	ErrTitleEmpty               = errors.New("chat title cannot be empty")                                        // chat title cannot be empty
+	ErrTitleTooLong             = errors.New("chat title exceeds maximum length")                                 // chat title exceeds maximum length
+	ErrMessageEmpty             = errors.New("message text cannot be empty")                                      // message text cannot be empty
+	ErrMessageTooLong           = errors.New("message text exceeds maximum length")                               // message text exceeds maximum length
+	ErrInvalidChatID            = errors.New("invalid chat ID; must be a positive integer")                       // invalid chat ID; must be a positive integer
+	ErrInvalidMessageID         = errors.New("invalid message ID; must be a positive integer")                    // invalid message ID; must be a positive integer
+	ErrMessageNotFound          = errors.New("message not found")                                                 // message not found
+	ErrInvalidParent            = errors.New("invalid parent_id; must be a message of the same chat")             // invalid parent_id; must be a message of the same chat
+	ErrInvalidEmoji             = errors.New("invalid emoji; must be 1-32 bytes without spaces")                  // invalid emoji; must be 1-32 bytes without spaces
+	ErrReactionNotFound         = errors.New("reaction not found")                                                // reaction not found
+	ErrInvalidForm              = errors.New("invalid multipart form")                                            // invalid multipart form
+	ErrInvalidAttachmentID      = errors.New("invalid attachment ID; must be a positive integer")                 // invalid attachment ID; must be a positive integer
+	ErrTooManyAttachments       = errors.New("number of attachments exceeds service limit")                       // number of attachments exceeds service limit
+	ErrAttachmentTooLarge       = errors.New("attachment exceeds maximum size")                                   // attachment exceeds maximum size
+	ErrUnsupportedMediaType     = errors.New("unsupported attachment type")                                       // unsupported attachment type
+	ErrBodyTooLarge             = errors.New("request body exceeds maximum size")                                 // request body exceeds maximum size
+	ErrAttachmentNotFound       = errors.New("attachment not found")                                              // attachment not found
+	ErrSearchQueryEmpty         = errors.New("search query cannot be empty")                                      // search query cannot be empty
+	ErrSearchQueryTooLong       = errors.New("search query exceeds maximum length")                               // search query exceeds maximum length
+	ErrInvalidEventID           = errors.New("invalid Last-Event-ID; must be an event ID of the stream")          // invalid Last-Event-ID; must be an event ID of the stream
+	ErrInvalidAfterID           = errors.New("invalid after_id; must be a non-negative integer")                  // invalid after_id; must be a non-negative integer
+	ErrChatNotFound             = errors.New("chat not found")                                                    // chat not found
+	ErrLimitTooSmall            = errors.New("limit cannot be negative")                                          // limit cannot be negative
+	ErrLimitTooLarge            = errors.New("number of messages exceeds service limit")                          // number of messages exceeds service limit
+	ErrInvalidLimit             = errors.New("invalid limit; must be an integer")                                 // invalid limit; must be a positive integer
+	ErrInvalidCursor            = errors.New("invalid cursor")                                                    // invalid cursor
+	ErrCursorConflict           = errors.New("before and after cursors cannot be combined")                       // before and after cursors cannot be combined
+	ErrInvalidSort              = errors.New("invalid sort; must be created_at or last_activity")                 // invalid sort; must be created_at or last_activity
+	ErrInvalidTimestamp         = errors.New("invalid timestamp; must be in RFC 3339 format")                     // invalid timestamp; must be in RFC 3339 format
+	ErrInvalidTimeRange         = errors.New("created_after must be earlier than created_before")                 // created_after must be earlier than created_before
+	ErrInvalidWait              = errors.New("invalid wait; must be a duration such as 30s")                      // invalid wait; must be a duration such as 30s
+	ErrWaitTooLong              = errors.New("wait exceeds maximum duration")                                     // wait exceeds maximum duration
+	ErrUsernameInvalid          = errors.New("username must be 3-32 letters, digits, '_' or '-'")                 // username must be 3-32 letters, digits, '_' or '-'
+	ErrPasswordTooShort         = errors.New("password is too short")                                             // password is too short
+	ErrPasswordTooLong          = errors.New("password cannot exceed 72 bytes")                                   // password cannot exceed 72 bytes
+	ErrUsernameTaken            = errors.New("username is already taken")                                         // username is already taken
+	ErrUserNotFound             = errors.New("user not found")                                                    // user not found
+	ErrInvalidCredentials       = errors.New("invalid username or password")                                      // invalid username or password
+	ErrUnauthorized             = errors.New("missing or invalid bearer token")                                   // missing or invalid bearer token
+	ErrForbidden                = errors.New("insufficient permissions in this chat")                             // insufficient permissions in this chat
+	ErrInvalidUserID            = errors.New("invalid user ID; must be a positive integer")                       // invalid user ID; must be a positive integer
+	ErrInvalidRole              = errors.New("invalid role; must be admin, member or read-only")                  // invalid role; must be admin, member or read-only
+	ErrMemberExists             = errors.New("user is already a member of this chat")                             // user is already a member of this chat
+	ErrMemberNotFound           = errors.New("member not found")                                                  // member not found
+	ErrInvalidIdempotencyKey    = errors.New("invalid Idempotency-Key; must be 1-255 printable ASCII characters") // invalid Idempotency-Key; must be 1-255 printable ASCII characters
+	ErrIdempotencyKeyReused     = errors.New("Idempotency-Key was already used for a different request")          // Idempotency-Key was already used for a different request
+	ErrIdempotencyKeyInProgress = errors.New("a request with this Idempotency-Key is still in progress")          // a request with this Idempotency-Key is still in progress
+	ErrRateLimited              = errors.New("too many requests; retry later")                                    // too many requests; retry later
+	ErrBlobNotFound             = errors.New("blob not found")                                                    // blob not found
+	ErrCacheMiss                = errors.New("cache miss")                                                        // cache miss
+	ErrShuttingDown             = errors.New("service is shutting down")                                          // service is shutting down
+	ErrSchemaOutdated           = errors.New("database schema is older than expected")                            // database schema is older than expected
 )
@@ -0,0 +1,24 @@
+// Package eventbus provides an interface and factory function
+// for broadcasting chat changes to all running application instances.
+package eventbus
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/eventbus/postgres"
+	"chatX/internal/logger"
+	"chatX/internal/repository"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// EventBus defines the interface for cross-instance cache invalidation.
+type EventBus interface {
+	Publish(ctx context.Context, chatID int) error                         // Publish notifies all other instances that the chat has changed.
+	Listen(ctx context.Context, invalidate func(chatID int), reset func()) // Listen delivers changes made by other instances until ctx is cancelled, reconnecting on connection loss.
+}
+
+// NewEventBus creates a new EventBus implementation backed by Postgres LISTEN/NOTIFY.
+func NewEventBus(logger logger.Logger, config config.EventBus, storage config.Storage, db *gorm.DB) EventBus {
+	return postgres.NewBus(logger, config, repository.DSN(storage), db)
+}
@@ -0,0 +1,164 @@
+// Package postgres provides a cross-instance event bus built on
+// Postgres LISTEN/NOTIFY.
+package postgres
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/logger"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// notification is the JSON payload sent over the NOTIFY channel.
+type notification struct {
+	Origin string `json:"origin"`  // ID of the instance that made the change
+	ChatID int    `json:"chat_id"` // ID of the changed chat
+}
+
+// Bus broadcasts chat changes between application instances.
+//
+// Notifications are sent through the shared GORM connection pool, while listening
+// uses a dedicated connection that is re-established with exponential backoff
+// whenever it is lost. Every instance tags its notifications with a random ID so
+// that it can skip its own changes, which it has already applied locally.
+type Bus struct {
+	id     string          // Random ID of this instance
+	dsn    string          // Connection string of the listening connection
+	db     *gorm.DB        // Database used to send notifications
+	config config.EventBus // Event bus configuration
+	logger logger.Logger   // Logger instance
+}
+
+// defaultReconnectMin is the initial reconnect delay used when none is configured.
+const defaultReconnectMin = 500 * time.Millisecond
+
+// NewBus creates a new Bus instance with the given logger, config, DSN and database.
+//
+// A ReconnectMin that is not positive, e.g. because it is not configured, is replaced
+// with a default so that a lost listener is not reconnected in a tight loop, and
+// ReconnectMax is raised to at least ReconnectMin.
+func NewBus(logger logger.Logger, config config.EventBus, dsn string, db *gorm.DB) *Bus {
+
+	if config.ReconnectMin <= 0 {
+		config.ReconnectMin = defaultReconnectMin
+	}
+	config.ReconnectMax = max(config.ReconnectMax, config.ReconnectMin)
+
+	return &Bus{
+		id:     newInstanceID(),
+		dsn:    dsn,
+		db:     db,
+		config: config,
+		logger: logger,
+	}
+
+}
+
+// newInstanceID returns a random hex-encoded identifier of this instance.
+func newInstanceID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Publish notifies all other instances that the chat has changed.
+func (b *Bus) Publish(ctx context.Context, chatID int) error {
+
+	payload, err := json.Marshal(notification{Origin: b.id, ChatID: chatID})
+	if err != nil {
+		return err
+	}
+
+	return b.db.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", b.config.Channel, string(payload)).Error
+
+}
+
+// Listen calls invalidate for every chat changed by another instance until ctx is cancelled.
+//
+// Notifications sent while the listener is disconnected are lost, so reset is called
+// after every successful (re)connect to drop any state that may have become stale.
+// Reconnect attempts are delayed from ReconnectMin, doubling up to ReconnectMax.
+func (b *Bus) Listen(ctx context.Context, invalidate func(chatID int), reset func()) {
+
+	delay := b.config.ReconnectMin
+
+	for {
+
+		connected, err := b.listen(ctx, invalidate, reset)
+		if ctx.Err() != nil {
+			b.logger.LogInfo("eventbus — listener stopped", "layer", "eventbus.postgres")
+			return
+		}
+
+		if connected {
+			delay = b.config.ReconnectMin
+		}
+
+		b.logger.LogWarn("eventbus — listener disconnected, reconnecting", "err", err.Error(), "retry_in", delay.String(), "layer", "eventbus.postgres")
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			b.logger.LogInfo("eventbus — listener stopped", "layer", "eventbus.postgres")
+			return
+		}
+
+		delay = min(delay*2, b.config.ReconnectMax)
+
+	}
+
+}
+
+// listen opens a dedicated connection, subscribes to the channel and handles
+// notifications until the connection fails or ctx is cancelled.
+//
+// Reports whether the subscription was established before the failure.
+func (b *Bus) listen(ctx context.Context, invalidate func(chatID int), reset func()) (bool, error) {
+
+	conn, err := pgx.Connect(ctx, b.dsn)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{b.config.Channel}.Sanitize()); err != nil {
+		return false, err
+	}
+
+	b.logger.LogInfo("eventbus — listening for changes", "channel", b.config.Channel, "layer", "eventbus.postgres")
+	reset()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+		b.handle(n.Payload, invalidate)
+	}
+
+}
+
+// handle decodes a notification payload and invalidates the chat unless the
+// change was made by this instance.
+func (b *Bus) handle(payload string, invalidate func(chatID int)) {
+
+	var n notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		b.logger.LogWarn("eventbus — malformed notification ignored", "payload", payload, "layer", "eventbus.postgres")
+		return
+	}
+
+	if n.Origin == b.id {
+		return
+	}
+
+	b.logger.Debug("eventbus — chat changed on another instance", "chatID", n.ChatID, "origin", n.Origin, "layer", "eventbus.postgres")
+	invalidate(n.ChatID)
+
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/logger/mocks"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func setupBus(t *testing.T, dsn string) (*Bus, *mocks.MockLogger) {
+
+	controller := gomock.NewController(t)
+	logger := mocks.NewMockLogger(controller)
+
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := config.EventBus{Channel: "chatx_test", ReconnectMin: time.Millisecond, ReconnectMax: 4 * time.Millisecond}
+
+	return NewBus(logger, cfg, dsn, nil), logger
+
+}
+
+func TestBus_Handle_InvalidatesForeignChanges(t *testing.T) {
+
+	bus, _ := setupBus(t, "")
+
+	var invalidated []int
+	invalidate := func(chatID int) { invalidated = append(invalidated, chatID) }
+
+	bus.handle(`{"origin":"other","chat_id":5}`, invalidate)
+	bus.handle(`{"origin":"`+bus.id+`","chat_id":6}`, invalidate)
+
+	require.Equal(t, []int{5}, invalidated)
+
+}
+
+func TestBus_Handle_IgnoresMalformedPayload(t *testing.T) {
+
+	bus, logger := setupBus(t, "")
+
+	logger.EXPECT().LogWarn(gomock.Any(), gomock.Any()).Times(1)
+
+	bus.handle("not json", func(int) { t.Fatal("unexpected invalidation") })
+
+}
+
+func TestBus_Listen_ReconnectsUntilCancelled(t *testing.T) {
+
+	bus, logger := setupBus(t, "host=127.0.0.1 port=1 user=test dbname=test sslmode=disable connect_timeout=1")
+
+	var attempts atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+
+	logger.EXPECT().LogWarn(gomock.Any(), gomock.Any()).Do(func(string, ...any) {
+		if attempts.Add(1) == 3 {
+			cancel()
+		}
+	}).MinTimes(3)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		bus.Listen(ctx, func(int) {}, func() { t.Error("unexpected reset") })
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not stop")
+	}
+
+}
+
+func TestNewBus_ClampsReconnectDelays(t *testing.T) {
+
+	bus := NewBus(nil, config.EventBus{Channel: "chatx_test"}, "", nil)
+
+	require.Equal(t, defaultReconnectMin, bus.config.ReconnectMin)
+	require.Equal(t, defaultReconnectMin, bus.config.ReconnectMax)
+
+	bus = NewBus(nil, config.EventBus{Channel: "chatx_test", ReconnectMin: -time.Second, ReconnectMax: 30 * time.Second}, "", nil)
+
+	require.Equal(t, defaultReconnectMin, bus.config.ReconnectMin)
+	require.Equal(t, 30*time.Second, bus.config.ReconnectMax)
+
+}
+
+func TestNewBus_UniqueInstanceIDs(t *testing.T) {
+
+	first, _ := setupBus(t, "")
+	second, _ := setupBus(t, "")
+
+	require.NotEqual(t, first.id, second.id)
+	require.Len(t, first.id, 16)
+
+}
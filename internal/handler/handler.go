@@ -4,52 +4,137 @@ package handler
 
 import (
 	_ "chatX/docs"
+	"chatX/internal/config"
 	v1 "chatX/internal/handler/v1"
+	"chatX/internal/health"
 	"chatX/internal/logger"
+	"chatX/internal/metrics"
+	"chatX/internal/ratelimit"
 	"chatX/internal/service"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 // NewHandler creates a new HTTP handler with Gin,
 // registers API routes for version 1 of the chat API,
+// exposes metrics in Prometheus text format at /metrics,
+// serves liveness and readiness probes at /healthz and /readyz,
 // and sets up Swagger documentation.
 //
 // Parameters:
 //   - logger: application logger instance
 //   - requestLogging: enable detailed request logging if true
+//   - trustedProxies: IPs or CIDR ranges of the proxies allowed to set the client IP
+//   - rateLimit: per-client request rate limits of the API routes
+//   - limits: service limits bounding the size of request bodies
+//   - metrics: collector of request and application metrics
+//   - health: readiness checks behind /readyz
 //   - service: business logic service layer
 //
 // Returns an http.Handler ready to be served.
-func NewHandler(logger logger.Logger, requestLogging bool, service service.Service) http.Handler {
+func NewHandler(logger logger.Logger, requestLogging bool, trustedProxies []string, rateLimit config.RateLimit, limits config.Service, metrics metrics.Metrics, health health.Checker, service service.Service) http.Handler {
 
 	handler := gin.New()
+
+	// forwarding headers of other clients are ignored, so they cannot pick the key of their rate limit;
+	// the proxies are validated at boot, and an invalid list trusts none
+	if err := handler.SetTrustedProxies(trustedProxies); err != nil {
+		_ = handler.SetTrustedProxies(nil)
+	}
+
 	handler.Use(gin.Recovery())
+	handler.Use(otelgin.Middleware(serviceName, otelgin.WithFilter(traced)))
+	handler.Use(instrument(metrics))
 
 	if requestLogging {
 		handler.Use(middleware(logger))
 	}
 
-	apiV1 := handler.Group("/api/v1/chats")
-	handlerV1 := v1.NewHandler(service)
+	handlerV1 := v1.NewHandler(service, maxBodySize(limits), limits.ReplayGrace)
+
+	limit := func(c *gin.Context) { c.Next() }
+	if rateLimit.Enabled {
+		limit = v1.RateLimit(
+			ratelimit.NewLimiter(logger, rateLimit.Reads),
+			ratelimit.NewLimiter(logger, rateLimit.Writes),
+		)
+	}
+
+	authV1 := handler.Group("/api/v1/auth", limit)
+	authV1.POST("/register", handlerV1.Register)
+	authV1.POST("/login", handlerV1.Login)
+	authV1.POST("/stream-token", handlerV1.Authenticate, handlerV1.IssueStreamToken)
 
-	apiV1.POST("/", handlerV1.CreateChat)
-	apiV1.POST("/:id/messages/", handlerV1.CreateMessage)
+	apiV1 := handler.Group("/api/v1/chats", handlerV1.Authenticate, limit)
 
+	apiV1.POST("/", handlerV1.Idempotent, handlerV1.CreateChat)
+	apiV1.POST("/:id/messages/", handlerV1.Idempotent, handlerV1.CreateMessage)
+	apiV1.PATCH("/:id/messages/:msgId", handlerV1.UpdateMessage)
+	apiV1.DELETE("/:id/messages/:msgId", handlerV1.DeleteMessage)
+	apiV1.POST("/:id/messages/:msgId/reactions", handlerV1.AddReaction)
+	apiV1.DELETE("/:id/messages/:msgId/reactions/:emoji", handlerV1.RemoveReaction)
+	apiV1.POST("/:id/read", handlerV1.MarkRead)
+	apiV1.POST("/:id/members", handlerV1.AddMember)
+	apiV1.DELETE("/:id/members/:userId", handlerV1.RemoveMember)
+
+	apiV1.GET("/", handlerV1.ListChats)
 	apiV1.GET("/:id", handlerV1.GetChat)
+	apiV1.GET("/:id/messages", handlerV1.WaitForMessages)
+	apiV1.GET("/:id/messages/:msgId/thread", handlerV1.GetThread)
+	apiV1.GET("/:id/attachments/:attachmentId", handlerV1.GetAttachment)
+	apiV1.GET("/:id/members", handlerV1.ListMembers)
+	apiV1.PATCH("/:id", handlerV1.UpdateChat)
 	apiV1.DELETE("/:id", handlerV1.DeleteChat)
 
+	// browsers cannot send the Authorization header with WebSocket and EventSource requests
+	streamV1 := handler.Group("/api/v1/chats", handlerV1.AuthenticateStream, limit)
+	streamV1.GET("/:id/ws", handlerV1.SubscribeChat)
+	streamV1.GET("/:id/events", handlerV1.StreamChat)
+
+	searchV1 := handler.Group("/api/v1/search", handlerV1.Authenticate, limit)
+	searchV1.GET("", handlerV1.SearchMessages)
+
+	handler.GET("/metrics", gin.WrapH(metrics.Handler()))
+	handler.GET("/healthz", healthz)
+	handler.GET("/readyz", readyz(health))
 	handler.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	return handler
 
 }
 
+// formOverhead is the room left in a request body for the form fields and part headers
+// around the attachments of a message.
+const formOverhead = 1 << 20
+
+// maxBodySize returns the largest request body the API reads: a message with the most and
+// largest attachments the service allows, its longest text and the framing around them.
+func maxBodySize(limits config.Service) int64 {
+	return int64(limits.MaxAttachments)*limits.MaxAttachmentSize + int64(utf8.UTFMax*limits.MaxMessageLength) + formOverhead
+}
+
+// serviceName names the server in the spans of incoming requests.
+const serviceName = "chatx"
+
+// traced reports whether a request gets a server span. Scrapes of the metrics,
+// health probes and requests for the Swagger UI are not traced.
+func traced(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/metrics", "/healthz", "/readyz":
+		return false
+	}
+	return !strings.HasPrefix(r.URL.Path, "/swagger/")
+}
+
 // middleware returns a Gin middleware that logs requests and response metadata.
 //
 // Logs include HTTP method, path, query parameters, latency, status code,
@@ -62,7 +147,7 @@ func middleware(logger logger.Logger) gin.HandlerFunc {
 		start := time.Now()
 
 		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
+		query := redactQuery(c.Request.URL)
 
 		c.Next()
 
@@ -84,15 +169,80 @@ func middleware(logger logger.Logger) gin.HandlerFunc {
 
 		msg := fmt.Sprintf("handler — received %s request to %s", c.Request.Method, path)
 
+		ctx := c.Request.Context()
+
 		switch status {
 		case 500:
-			logger.LogError(msg, nil, fields...)
-		case 400, 503:
-			logger.LogWarn(msg, fields...)
+			logger.LogErrorContext(ctx, msg, nil, fields...)
+		case 400, 429, 503:
+			logger.LogWarnContext(ctx, msg, fields...)
 		default:
-			logger.LogInfo(msg, fields...)
+			logger.LogInfoContext(ctx, msg, fields...)
 		}
 
 	}
 
 }
+
+// redactQuery returns the raw query of a URL with the value of a stream token
+// replaced, so that logged queries cannot be used to open event streams.
+func redactQuery(u *url.URL) string {
+
+	values := u.Query()
+	if !values.Has("access_token") {
+		return u.RawQuery
+	}
+
+	values.Set("access_token", "REDACTED")
+	return values.Encode()
+
+}
+
+// instrument returns a Gin middleware that records the method, route, status
+// and latency of every request. Requests matching no route are recorded under
+// a single "unmatched" route, and methods other than the standard ones under
+// "other", to keep the number of series bounded. Event streams are counted
+// without latency, which would be the lifetime of the stream.
+func instrument(metrics metrics.Metrics) gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		if streaming(route) {
+			metrics.ObserveStream(methodLabel(c.Request.Method), route, c.Writer.Status())
+			return
+		}
+
+		metrics.ObserveRequest(methodLabel(c.Request.Method), route, c.Writer.Status(), time.Since(start))
+
+	}
+
+}
+
+// methodLabel returns the method of a request as recorded in metrics: clients may send
+// any token as method, so methods other than the standard ones are recorded as "other".
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
+
+// streaming reports whether a route serves an event stream, over WebSocket or SSE.
+func streaming(route string) bool {
+	switch route {
+	case "/api/v1/chats/:id/ws", "/api/v1/chats/:id/events":
+		return true
+	}
+	return false
+}
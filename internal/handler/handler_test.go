@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/logger/mocks"
+	"chatX/internal/metrics/prometheus"
+	"chatX/internal/models"
+	serviceMocks "chatX/internal/service/mocks"
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// ready is a readiness checker that always reports ready.
+type ready struct{}
+
+func (ready) Ready(context.Context) models.Readiness {
+	return models.Readiness{}
+}
+
+func setupHandler(t *testing.T, trustedProxies []string) http.Handler {
+
+	gin.SetMode(gin.TestMode)
+
+	controller := gomock.NewController(t)
+	logger := mocks.NewMockLogger(controller)
+
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().DebugContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogWarnContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	// a single request per client, so the second request of a client is limited
+	rateLimit := config.RateLimit{
+		Enabled: true,
+		Reads:   config.RateBudget{Rate: 0.001, Burst: 1},
+		Writes:  config.RateBudget{Rate: 0.001, Burst: 1},
+	}
+
+	metrics := prometheus.NewMetrics(
+		func() models.CacheStats { return models.CacheStats{} },
+		func() sql.DBStats { return sql.DBStats{} },
+		func() models.ServiceStats { return models.ServiceStats{} },
+	)
+
+	return NewHandler(logger, false, trustedProxies, rateLimit, config.Service{}, metrics, ready{}, serviceMocks.NewMockService(controller))
+
+}
+
+// register sends an empty registration from the given peer with the given X-Forwarded-For header.
+func register(handler http.Handler, remoteAddr, forwardedFor string) int {
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	return w.Code
+
+}
+
+func TestNewHandler_RateLimit_IgnoresSpoofedForwardedFor(t *testing.T) {
+
+	handler := setupHandler(t, nil)
+
+	require.Equal(t, http.StatusBadRequest, register(handler, "203.0.113.7:40000", "198.51.100.1"))
+	require.Equal(t, http.StatusTooManyRequests, register(handler, "203.0.113.7:40001", "198.51.100.2"))
+
+}
+
+func TestNewHandler_RateLimit_TrustedProxySetsClient(t *testing.T) {
+
+	handler := setupHandler(t, []string{"203.0.113.0/24"})
+
+	require.Equal(t, http.StatusBadRequest, register(handler, "203.0.113.7:40000", "198.51.100.1"))
+	require.Equal(t, http.StatusBadRequest, register(handler, "203.0.113.7:40001", "198.51.100.2"))
+	require.Equal(t, http.StatusTooManyRequests, register(handler, "203.0.113.8:40000", "198.51.100.1"))
+
+}
+
+// scrape returns the metrics served by the handler in Prometheus text format.
+func scrape(t *testing.T, handler http.Handler) string {
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	return w.Body.String()
+
+}
+
+func TestNewHandler_Metrics_RecordsUnknownMethodsAsOther(t *testing.T) {
+
+	handler := setupHandler(t, nil)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("BREW", "/api/v1/chats/", nil))
+
+	body := scrape(t, handler)
+
+	require.Contains(t, body, `chatx_http_requests_total{method="other",route="unmatched",status="404"} 1`)
+	require.NotContains(t, body, `method="BREW"`)
+
+}
+
+func TestNewHandler_Metrics_CountsStreamsWithoutLatency(t *testing.T) {
+
+	handler := setupHandler(t, nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/chats/1/events", nil))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	body := scrape(t, handler)
+
+	require.Contains(t, body, `chatx_http_requests_total{method="GET",route="/api/v1/chats/:id/events",status="401"} 1`)
+	require.NotContains(t, body, `chatx_http_request_duration_seconds_count{method="GET",route="/api/v1/chats/:id/events"`)
+
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"chatX/internal/health"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthz reports that the process is alive and able to serve HTTP requests.
+func healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readyz returns a handler reporting whether the application is ready to serve traffic.
+//
+// Responds 200 OK if all readiness checks pass and 503 Service Unavailable otherwise,
+// with the outcome of every check in the body.
+func readyz(checker health.Checker) gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+
+		readiness := checker.Ready(c.Request.Context())
+
+		if !readiness.Ready {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "checks": readiness.Checks})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": readiness.Checks})
+
+	}
+
+}
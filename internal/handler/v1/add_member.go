@@ -0,0 +1,43 @@
+package v1
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddMember handles POST /chats/:id/members requests.
+//
+// Expects JSON body with MemberRequestDTO; the role defaults to member. Returns the new member
+// as MemberResponseDTO. Responds with ErrInvalidJSON if JSON parsing fails, ErrInvalidUserID
+// or ErrInvalidRole if the body is invalid, or ErrForbidden if the caller may not grant the role.
+func (h *Handler) AddMember(c *gin.Context) {
+
+	var dto MemberRequestDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		respondError(c, errs.ErrInvalidJSON)
+		return
+	}
+
+	chatID, err := parseChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if dto.UserID <= 0 {
+		respondError(c, errs.ErrInvalidUserID)
+		return
+	}
+
+	member, err := h.service.AddMember(c.Request.Context(), currentUserID(c), models.ChatMember{ChatID: chatID, UserID: dto.UserID, Role: models.Role(dto.Role)})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, mapMemberToDTO(member))
+
+}
@@ -0,0 +1,43 @@
+package v1
+
+import (
+	"chatX/internal/errs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddReaction handles POST /chats/:id/messages/:msgId/reactions requests.
+//
+// Expects JSON body with ReactionRequestDTO. Returns the message with its updated reaction
+// counts as MessageResponseDTO. Responds with ErrInvalidJSON if JSON parsing fails,
+// ErrInvalidEmoji if the emoji is invalid, or ErrMessageNotFound if the chat has no such message.
+func (h *Handler) AddReaction(c *gin.Context) {
+
+	var dto ReactionRequestDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		respondError(c, errs.ErrInvalidJSON)
+		return
+	}
+
+	chatID, err := parseChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	messageID, err := parseMessageID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	message, err := h.service.AddReaction(c.Request.Context(), currentUserID(c), chatID, messageID, dto.Emoji)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, mapMessageToDTO(message))
+
+}
@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"chatX/internal/errs"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Authenticate is a middleware that requires a valid bearer token.
+//
+// The token is read from the "Authorization: Bearer <token>" header and verified by
+// the service. On success the user ID is stored in the context for the handlers;
+// otherwise the request is aborted with ErrUnauthorized.
+func (h *Handler) Authenticate(c *gin.Context) {
+
+	scheme, token, ok := strings.Cut(c.GetHeader(authorizationHeader), " ")
+	if !ok || !strings.EqualFold(scheme, tokenType) || token == "" {
+		respondUnauthorized(c, errs.ErrUnauthorized)
+		return
+	}
+
+	authenticate(c, token, h.service.Authenticate)
+
+}
+
+// AuthenticateStream is a middleware that authenticates requests for event streams.
+//
+// Browsers cannot set the Authorization header on WebSocket and EventSource requests,
+// so besides that header it accepts a bearer token offered as the WebSocket subprotocol
+// following "bearer", e.g. new WebSocket(url, ["bearer", token]), and a stream token
+// issued by IssueStreamToken in the access_token query parameter. Bearer tokens are not
+// accepted in the URL, where they would end up in logs and browser history.
+func (h *Handler) AuthenticateStream(c *gin.Context) {
+
+	if c.GetHeader(authorizationHeader) != "" {
+		h.Authenticate(c)
+		return
+	}
+
+	if token, ok := protocolToken(c); ok {
+		authenticate(c, token, h.service.Authenticate)
+		return
+	}
+
+	if token := c.Query(accessTokenKey); token != "" {
+		authenticate(c, token, h.service.AuthenticateStream)
+		return
+	}
+
+	respondUnauthorized(c, errs.ErrUnauthorized)
+
+}
+
+// authenticate verifies the token and stores the ID of its user in the context for
+// the handlers; otherwise the request is aborted with the error of verify.
+func authenticate(c *gin.Context, token string, verify func(ctx context.Context, token string) (int, error)) {
+
+	userID, err := verify(c.Request.Context(), token)
+	if err != nil {
+		respondUnauthorized(c, err)
+		return
+	}
+
+	c.Set(userIDKey, userID)
+	c.Next()
+
+}
+
+// protocolToken returns the token offered as the WebSocket subprotocol following "bearer".
+func protocolToken(c *gin.Context) (string, bool) {
+
+	protocols := websocket.Subprotocols(c.Request)
+
+	i := slices.Index(protocols, bearerProtocol)
+	if i < 0 || i+1 == len(protocols) {
+		return "", false
+	}
+
+	return protocols[i+1], true
+
+}
+
+// respondUnauthorized sends an error response with a WWW-Authenticate challenge.
+func respondUnauthorized(c *gin.Context, err error) {
+	c.Header("WWW-Authenticate", tokenType)
+	respondError(c, err)
+}
+
+// currentUserID returns the ID of the authenticated user set by Authenticate.
+//
+// Returns 0 if the request was not authenticated; no user has that ID,
+// so the service denies access to every chat.
+func currentUserID(c *gin.Context) int {
+	return c.GetInt(userIDKey)
+}
@@ -20,15 +20,12 @@ func (h *Handler) CreateChat(c *gin.Context) {
 		return
 	}
 
-	chat, err := h.service.CreateChat(c.Request.Context(), models.Chat{Title: dto.Title})
+	chat, err := h.service.CreateChat(c.Request.Context(), currentUserID(c), models.Chat{Title: dto.Title})
 	if err != nil {
 		respondError(c, err)
 		return
 	}
 
-	respondOK(c, ChatResponseDTO{
-		ID:        chat.ID,
-		Title:     chat.Title,
-		CreatedAt: chat.CreatedAt})
+	respondOK(c, mapChatToDTO(chat))
 
 }
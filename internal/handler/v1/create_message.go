@@ -3,19 +3,49 @@ package v1
 import (
 	"chatX/internal/errs"
 	"chatX/internal/models"
+	"mime/multipart"
+	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 // CreateMessage handles POST /chats/:id/messages requests.
 //
-// Expects JSON body with MessageRequestDTO. Returns the created message as MessageResponseDTO.
-// Responds with ErrInvalidJSON if JSON parsing fails or error if chat ID is invalid.
+// Expects JSON body with MessageRequestDTO; a parent_id makes the message a reply in the thread
+// of that message. A multipart/form-data body carries the same fields as form values and the
+// files to attach under "files". Returns the created message as MessageResponseDTO.
+// The author is the authenticated user, never a value from the request body.
+// Responds with ErrInvalidJSON or ErrInvalidForm if body parsing fails, ErrAttachmentTooLarge if
+// the form exceeds the handler's maximum body size before its files are checked, or error if chat ID is invalid.
 func (h *Handler) CreateMessage(c *gin.Context) {
 
 	var dto MessageRequestDTO
+	var uploads []models.Upload
 
-	if err := c.ShouldBindJSON(&dto); err != nil {
+	if c.ContentType() == binding.MIMEMultipartPOSTForm {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBodySize)
+		form, err := c.MultipartForm()
+		if err != nil {
+			respondError(c, bodyError(err, errs.ErrAttachmentTooLarge, errs.ErrInvalidForm))
+			return
+		}
+		defer form.RemoveAll()
+
+		if dto, err = parseMessageForm(form); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		files, closeFiles, err := openUploads(form.File[filesKey])
+		defer closeFiles()
+		if err != nil {
+			respondError(c, errs.ErrInvalidForm)
+			return
+		}
+		uploads = files
+	} else if err := c.ShouldBindJSON(&dto); err != nil {
 		respondError(c, errs.ErrInvalidJSON)
 		return
 	}
@@ -26,16 +56,61 @@ func (h *Handler) CreateMessage(c *gin.Context) {
 		return
 	}
 
-	msg, err := h.service.CreateMessage(c.Request.Context(), models.Message{ChatID: chatID, Text: dto.Text})
+	msg, err := h.service.CreateMessage(c.Request.Context(), currentUserID(c), models.Message{ChatID: chatID, Text: dto.Text, ParentID: dto.ParentID}, uploads...)
 	if err != nil {
 		respondError(c, err)
 		return
 	}
 
-	respondOK(c, MessageResponseDTO{
-		ID:        msg.ID,
-		ChatID:    msg.ChatID,
-		Text:      msg.Text,
-		CreatedAt: msg.CreatedAt})
+	respondOK(c, mapMessageToDTO(msg))
+
+}
+
+// parseMessageForm reads the fields of MessageRequestDTO from form values.
+//
+// Returns ErrInvalidParent if the parent ID is not an integer.
+func parseMessageForm(form *multipart.Form) (MessageRequestDTO, error) {
+
+	var dto MessageRequestDTO
+
+	if values := form.Value[textKey]; len(values) > 0 {
+		dto.Text = values[0]
+	}
+
+	if values := form.Value[parentIDKey]; len(values) > 0 && values[0] != "" {
+		parentID, err := strconv.Atoi(values[0])
+		if err != nil {
+			return MessageRequestDTO{}, errs.ErrInvalidParent
+		}
+		dto.ParentID = &parentID
+	}
+
+	return dto, nil
+
+}
+
+// openUploads opens the uploaded files of a multipart form.
+//
+// The returned function closes all opened files and must be called even on error.
+func openUploads(headers []*multipart.FileHeader) ([]models.Upload, func(), error) {
+
+	uploads := make([]models.Upload, 0, len(headers))
+	files := make([]multipart.File, 0, len(headers))
+	closeFiles := func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}
+
+	for _, header := range headers {
+		file, err := header.Open()
+		if err != nil {
+			return nil, closeFiles, err
+		}
+		files = append(files, file)
+		uploads = append(uploads, models.Upload{Filename: header.Filename, Size: header.Size, Content: file})
+	}
+
+	return uploads, closeFiles, nil
 
 }
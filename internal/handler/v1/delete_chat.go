@@ -14,7 +14,7 @@ func (h *Handler) DeleteChat(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.DeleteChat(c.Request.Context(), chatID); err != nil {
+	if err := h.service.DeleteChat(c.Request.Context(), currentUserID(c), chatID); err != nil {
 		respondError(c, err)
 		return
 	}
@@ -0,0 +1,30 @@
+package v1
+
+import "github.com/gin-gonic/gin"
+
+// DeleteMessage handles DELETE /chats/:id/messages/:msgId requests.
+//
+// Deletes the message identified by the path parameters.
+// Responds with statusDeleted on success or an error if deletion fails.
+func (h *Handler) DeleteMessage(c *gin.Context) {
+
+	chatID, err := parseChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	messageID, err := parseMessageID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := h.service.DeleteMessage(c.Request.Context(), currentUserID(c), chatID, messageID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, statusDeleted)
+
+}
@@ -7,32 +7,164 @@ type ChatRequestDTO struct {
 	Title string `json:"title" example:"The best chat ever!!!"`
 }
 
-// ChatResponseDTO represents the response body when a chat is created.
+// ChatResponseDTO represents the response body when a chat is created or renamed.
 type ChatResponseDTO struct {
 	ID        int       `json:"id" example:"1"`
 	Title     string    `json:"title" example:"The best chat ever!!!"`
 	CreatedAt time.Time `json:"created_at" example:"2025-01-16T12:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2025-01-16T12:00:00Z"`
 }
 
 // MessageRequestDTO represents the request body for creating a new message.
 type MessageRequestDTO struct {
-	Text string `json:"text" example:"Hello there!"`
+	Text     string `json:"text" example:"Hello there!"`
+	ParentID *int   `json:"parent_id,omitempty" example:"10"`
 }
 
 // MessageResponseDTO represents the response body for a single message.
 type MessageResponseDTO struct {
-	ID        int       `json:"id" example:"10"`
-	ChatID    int       `json:"chat_id" example:"1"`
-	Text      string    `json:"text" example:"Hi!"`
-	CreatedAt time.Time `json:"created_at" example:"2025-01-16T12:01:00Z"`
+	ID          int                `json:"id" example:"10"`
+	ChatID      int                `json:"chat_id" example:"1"`
+	Text        string             `json:"text" example:"Hi!"`
+	CreatedAt   time.Time          `json:"created_at" example:"2025-01-16T12:01:00Z"`
+	EditedAt    *time.Time         `json:"edited_at,omitempty" example:"2025-01-16T12:05:00Z"`
+	AuthorID    *int               `json:"author_id,omitempty" example:"3"`
+	ParentID    *int               `json:"parent_id,omitempty" example:"9"`
+	ReplyCount  int                `json:"reply_count" example:"2"`
+	Reactions   []ReactionCountDTO `json:"reactions"`
+	Attachments []AttachmentDTO    `json:"attachments,omitempty"`
+}
+
+// AttachmentDTO represents a file attached to a message.
+type AttachmentDTO struct {
+	ID          int    `json:"id" example:"4"`
+	Filename    string `json:"filename" example:"photo.png"`
+	ContentType string `json:"content_type" example:"image/png"`
+	Size        int64  `json:"size" example:"48213"`
+	URL         string `json:"url" example:"/api/v1/chats/1/attachments/4"`
+}
+
+// ReactionRequestDTO represents the request body for reacting to a message.
+type ReactionRequestDTO struct {
+	Emoji string `json:"emoji" example:"👍"`
+}
+
+// ReactionCountDTO represents the number of users who reacted to a message with an emoji.
+type ReactionCountDTO struct {
+	Emoji string `json:"emoji" example:"👍"`
+	Count int    `json:"count" example:"2"`
+}
+
+// CredentialsRequestDTO represents the request body for registration and login.
+type CredentialsRequestDTO struct {
+	Username string `json:"username" example:"neo"`
+	Password string `json:"password" example:"follow-the-white-rabbit"`
+}
+
+// UserResponseDTO represents the response body for a registered user.
+type UserResponseDTO struct {
+	ID        int       `json:"id" example:"3"`
+	Username  string    `json:"username" example:"neo"`
+	CreatedAt time.Time `json:"created_at" example:"2025-01-16T11:00:00Z"`
+}
+
+// TokenResponseDTO represents the response body for a successful login.
+type TokenResponseDTO struct {
+	AccessToken string    `json:"access_token" example:"3.1737115200.3q2-7wAAAAA"`
+	TokenType   string    `json:"token_type" example:"Bearer"`
+	ExpiresAt   time.Time `json:"expires_at" example:"2025-01-17T12:00:00Z"`
+}
+
+// MemberRequestDTO represents the request body for adding a chat member.
+type MemberRequestDTO struct {
+	UserID int    `json:"user_id" example:"4"`
+	Role   string `json:"role" example:"member"`
+}
+
+// ReadRequestDTO represents the request body for marking chat messages as read.
+type ReadRequestDTO struct {
+	MessageID int `json:"message_id" example:"10"`
+}
+
+// ReadReceiptResponseDTO represents the read marker of the user in a chat.
+type ReadReceiptResponseDTO struct {
+	ChatID            int       `json:"chat_id" example:"1"`
+	LastReadMessageID int       `json:"last_read_message_id" example:"10"`
+	UnreadCount       int       `json:"unread_count" example:"0"`
+	UpdatedAt         time.Time `json:"updated_at" example:"2025-01-16T12:02:00Z"`
+}
+
+// MemberResponseDTO represents a member of a chat.
+type MemberResponseDTO struct {
+	UserID   int       `json:"user_id" example:"4"`
+	Username string    `json:"username,omitempty" example:"trinity"`
+	Role     string    `json:"role" example:"member"`
+	JoinedAt time.Time `json:"joined_at" example:"2025-01-16T12:10:00Z"`
+}
+
+// MemberListResponseDTO represents all members of a chat.
+type MemberListResponseDTO struct {
+	Members []MemberResponseDTO `json:"members"`
 }
 
 // ChatWithMessagesResponseDTO represents a chat along with its messages.
 type ChatWithMessagesResponseDTO struct {
-	ID        int                  `json:"id" example:"1"`
-	Title     string               `json:"title" example:"The best chat ever!!!"`
-	CreatedAt time.Time            `json:"created_at" example:"2025-01-16T12:00:00Z"`
-	Messages  []MessageResponseDTO `json:"messages"`
+	ID          int                  `json:"id" example:"1"`
+	Title       string               `json:"title" example:"The best chat ever!!!"`
+	CreatedAt   time.Time            `json:"created_at" example:"2025-01-16T12:00:00Z"`
+	UpdatedAt   time.Time            `json:"updated_at" example:"2025-01-16T12:00:00Z"`
+	UnreadCount int                  `json:"unread_count" example:"3"`
+	Messages    []MessageResponseDTO `json:"messages"`
+	NextCursor  string               `json:"next_cursor" example:"MTczNzAyODg2MDAwMDAwMDoxMA"`
+}
+
+// MessageListResponseDTO represents a list of new messages, by ID, along with the cursor of the next poll.
+type MessageListResponseDTO struct {
+	Messages   []MessageResponseDTO `json:"messages"`
+	NextCursor string               `json:"next_cursor" example:"MTI6MTAsMTE"`
+}
+
+// ThreadResponseDTO represents a message along with a page of its replies.
+type ThreadResponseDTO struct {
+	Message    MessageResponseDTO   `json:"message"`
+	Replies    []MessageResponseDTO `json:"replies"`
+	NextCursor string               `json:"next_cursor" example:"MTczNzAyODg2MDAwMDAwMDoxMg"`
+}
+
+// ChatSummaryResponseDTO represents a chat overview in chat listings.
+type ChatSummaryResponseDTO struct {
+	ID            int        `json:"id" example:"1"`
+	Title         string     `json:"title" example:"The best chat ever!!!"`
+	CreatedAt     time.Time  `json:"created_at" example:"2025-01-16T12:00:00Z"`
+	MessageCount  int        `json:"message_count" example:"42"`
+	LastMessageAt *time.Time `json:"last_message_at" example:"2025-01-16T12:01:00Z"`
+	UnreadCount   int        `json:"unread_count" example:"3"`
+}
+
+// ChatListResponseDTO represents a page of chat summaries.
+type ChatListResponseDTO struct {
+	Chats      []ChatSummaryResponseDTO `json:"chats"`
+	NextCursor string                   `json:"next_cursor" example:"MTczNzAyODgwMDAwMDAwMDox"`
+}
+
+// SearchHitDTO represents a message matching a search query.
+type SearchHitDTO struct {
+	Message MessageResponseDTO `json:"message"`
+	Rank    float32            `json:"rank" example:"0.0607927"`
+	Snippet string             `json:"snippet" example:"see you at the <mark>release</mark> party"`
+}
+
+// SearchResponseDTO represents a page of search hits, most relevant first.
+type SearchResponseDTO struct {
+	Hits       []SearchHitDTO `json:"hits"`
+	NextCursor string         `json:"next_cursor" example:"MC4wNjA3OTI3OjQy"`
+}
+
+// EventResponseDTO represents a real-time chat event.
+type EventResponseDTO struct {
+	Type    string              `json:"type" example:"message.created"`
+	ChatID  int                 `json:"chat_id" example:"1"`
+	Message *MessageResponseDTO `json:"message,omitempty"`
 }
 
 // OKResponse represents a generic success response with a typed result.
@@ -60,6 +192,116 @@ type InvalidLimitErrorResponse struct {
 	Error string `json:"error" example:"invalid limit; must be an integer"`
 }
 
+// InvalidCursorErrorResponse represents a response for invalid cursor input.
+type InvalidCursorErrorResponse struct {
+	Error string `json:"error" example:"invalid cursor"`
+}
+
+// InvalidEventIDErrorResponse represents a response for invalid Last-Event-ID input.
+type InvalidEventIDErrorResponse struct {
+	Error string `json:"error" example:"invalid Last-Event-ID; must be an event ID of the stream"`
+}
+
+// InvalidAfterIDErrorResponse represents a response for invalid after_id input.
+type InvalidAfterIDErrorResponse struct {
+	Error string `json:"error" example:"invalid after_id; must be a non-negative integer"`
+}
+
+// InvalidUserIDErrorResponse represents a response for invalid user ID input.
+type InvalidUserIDErrorResponse struct {
+	Error string `json:"error" example:"invalid user ID; must be a positive integer"`
+}
+
+// InvalidRoleErrorResponse represents a response for an invalid member role.
+type InvalidRoleErrorResponse struct {
+	Error string `json:"error" example:"invalid role; must be admin, member or read-only"`
+}
+
+// InvalidMessageIDErrorResponse represents a response for invalid message ID input.
+type InvalidMessageIDErrorResponse struct {
+	Error string `json:"error" example:"invalid message ID; must be a positive integer"`
+}
+
+// MessageNotFoundErrorResponse represents a response when a message is not found.
+type MessageNotFoundErrorResponse struct {
+	Error string `json:"error" example:"message not found"`
+}
+
+// UnauthorizedErrorResponse represents a response for a missing or invalid bearer token.
+type UnauthorizedErrorResponse struct {
+	Error string `json:"error" example:"missing or invalid bearer token"`
+}
+
+// ForbiddenErrorResponse represents a response when the user's chat role does not permit the request.
+type ForbiddenErrorResponse struct {
+	Error string `json:"error" example:"insufficient permissions in this chat"`
+}
+
+// InvalidCredentialsErrorResponse represents a response for a failed login.
+type InvalidCredentialsErrorResponse struct {
+	Error string `json:"error" example:"invalid username or password"`
+}
+
+// UsernameTakenErrorResponse represents a response when a username is already registered.
+type UsernameTakenErrorResponse struct {
+	Error string `json:"error" example:"username is already taken"`
+}
+
+// MemberExistsErrorResponse represents a response when the user is already a chat member.
+type MemberExistsErrorResponse struct {
+	Error string `json:"error" example:"user is already a member of this chat"`
+}
+
+// IdempotencyConflictErrorResponse represents a response when an Idempotency-Key is reused for a different request.
+type IdempotencyConflictErrorResponse struct {
+	Error string `json:"error" example:"Idempotency-Key was already used for a different request"`
+}
+
+// InvalidEmojiErrorResponse represents a response for an invalid reaction emoji.
+type InvalidEmojiErrorResponse struct {
+	Error string `json:"error" example:"invalid emoji; must be 1-32 bytes without spaces"`
+}
+
+// ReactionNotFoundErrorResponse represents a response when a reaction is not found.
+type ReactionNotFoundErrorResponse struct {
+	Error string `json:"error" example:"reaction not found"`
+}
+
+// InvalidFormErrorResponse represents a response for a malformed multipart form.
+type InvalidFormErrorResponse struct {
+	Error string `json:"error" example:"invalid multipart form"`
+}
+
+// InvalidAttachmentIDErrorResponse represents a response for an invalid attachment ID.
+type InvalidAttachmentIDErrorResponse struct {
+	Error string `json:"error" example:"invalid attachment ID; must be a positive integer"`
+}
+
+// AttachmentTooLargeErrorResponse represents a response for an attachment exceeding the size limit.
+type AttachmentTooLargeErrorResponse struct {
+	Error string `json:"error" example:"attachment exceeds maximum size"`
+}
+
+// UnsupportedMediaTypeErrorResponse represents a response for an attachment of a rejected media type.
+type UnsupportedMediaTypeErrorResponse struct {
+	Error string `json:"error" example:"unsupported attachment type"`
+}
+
+// AttachmentNotFoundErrorResponse represents a response when an attachment is not found.
+type AttachmentNotFoundErrorResponse struct {
+	Error string `json:"error" example:"attachment not found"`
+}
+
+// MemberNotFoundErrorResponse represents a response when a chat member is not found.
+type MemberNotFoundErrorResponse struct {
+	Error string `json:"error" example:"member not found"`
+}
+
+// UserNotFoundErrorResponse represents a response when a user is not found.
+type UserNotFoundErrorResponse struct {
+	Error string `json:"error" example:"user not found"`
+}
+
 // ChatNotFoundErrorResponse represents a response when a chat is not found.
 type ChatNotFoundErrorResponse struct {
 	Error string `json:"error" example:"chat not found"`
@@ -69,3 +311,8 @@ type ChatNotFoundErrorResponse struct {
 type InternalServerErrorResponse struct {
 	Error string `json:"error" example:"internal server error"`
 }
+
+// RateLimitErrorResponse represents a response when a client exceeds its request rate limit.
+type RateLimitErrorResponse struct {
+	Error string `json:"error" example:"too many requests; retry later"`
+}
@@ -0,0 +1,46 @@
+package v1
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAttachment handles GET /chats/:id/attachments/:attachmentId requests.
+//
+// Streams the content of the attachment with its sniffed content type and size, and offers
+// it as a download under its original file name. Responds with ErrInvalidAttachmentID if the
+// ID is invalid or ErrAttachmentNotFound if no message of the chat has such an attachment.
+func (h *Handler) GetAttachment(c *gin.Context) {
+
+	chatID, err := parseChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	attachmentID, err := parseAttachmentID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	attachment, content, err := h.service.GetAttachment(c.Request.Context(), currentUserID(c), chatID, attachmentID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer content.Close()
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    disposition,
+		"X-Content-Type-Options": "nosniff",
+	})
+
+}
@@ -5,8 +5,10 @@ import "github.com/gin-gonic/gin"
 // GetChat handles GET /chats/:id requests.
 //
 // Retrieves a chat by its ID along with messages, optionally limited by query parameter "limit".
+// Older or newer messages are paged with the "before" or "after" query parameter set to the
+// next_cursor of a previous response.
 // Responds with ChatWithMessagesResponseDTO on success or an appropriate error if the chat is not found,
-// the chat ID is invalid, or other service errors occur.
+// the chat ID or cursor is invalid, or other service errors occur.
 func (h *Handler) GetChat(c *gin.Context) {
 
 	chatID, err := parseChatID(c)
@@ -15,16 +17,19 @@ func (h *Handler) GetChat(c *gin.Context) {
 		return
 	}
 
-	chat, err := h.service.GetChat(c.Request.Context(), chatID, c.Query(limitKey))
+	chat, cursor, err := h.service.GetChat(c.Request.Context(), currentUserID(c), chatID, c.Query(limitKey), c.Query(beforeKey), c.Query(afterKey))
 	if err != nil {
 		respondError(c, err)
 		return
 	}
 
 	respondOK(c, ChatWithMessagesResponseDTO{
-		ID:        chat.ID,
-		Title:     chat.Title,
-		CreatedAt: chat.CreatedAt,
-		Messages:  mapMessagesToDTO(chat.Messages)})
+		ID:          chat.ID,
+		Title:       chat.Title,
+		CreatedAt:   chat.CreatedAt,
+		UpdatedAt:   chat.UpdatedAt,
+		UnreadCount: chat.UnreadCount,
+		Messages:    mapMessagesToDTO(chat.Messages),
+		NextCursor:  cursor})
 
 }
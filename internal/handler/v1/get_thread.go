@@ -0,0 +1,36 @@
+package v1
+
+import "github.com/gin-gonic/gin"
+
+// GetThread handles GET /chats/:id/messages/:msgId/thread requests.
+//
+// Returns the message with up to "limit" of its replies, oldest first, as ThreadResponseDTO.
+// The next page is requested with the "cursor" query parameter set to the next_cursor
+// of a previous response.
+// Responds with an appropriate error if the IDs or query are invalid or the message does not exist.
+func (h *Handler) GetThread(c *gin.Context) {
+
+	chatID, err := parseChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	messageID, err := parseMessageID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	thread, cursor, err := h.service.GetThread(c.Request.Context(), currentUserID(c), chatID, messageID, c.Query(limitKey), c.Query(cursorKey))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, ThreadResponseDTO{
+		Message:    mapMessageToDTO(thread.Message),
+		Replies:    mapMessagesToDTO(thread.Replies),
+		NextCursor: cursor})
+
+}
@@ -3,18 +3,52 @@ package v1
 
 import (
 	"chatX/internal/service"
+	"time"
 )
 
-const idKey = "id"              // Context key for chat ID
-const limitKey = "limit"        // Context key for GET limit
-const statusDeleted = "deleted" // Response string for deleted chats
+const idKey = "id"                                       // Context key for chat ID
+const msgIDKey = "msgId"                                 // Context key for message ID
+const limitKey = "limit"                                 // Context key for GET limit
+const beforeKey = "before"                               // Context key for the cursor of older messages
+const afterKey = "after"                                 // Context key for the cursor of newer messages
+const titleKey = "title"                                 // Context key for the chat title filter
+const createdAfterKey = "created_after"                  // Context key for the lower creation time bound
+const createdBeforeKey = "created_before"                // Context key for the upper creation time bound
+const sortKey = "sort"                                   // Context key for the chat listing order
+const cursorKey = "cursor"                               // Context key for the cursor of the next chat page
+const afterIDKey = "after_id"                            // Context key for the ID of the last known message
+const waitKey = "wait"                                   // Context key for the long-polling wait duration
+const queryKey = "q"                                     // Context key for the search query
+const chatIDKey = "chat_id"                              // Context key for the chat to search in
+const statusDeleted = "deleted"                          // Response string for deleted chats and messages
+const userIDKey = "userID"                               // Context key for the authenticated user ID
+const memberIDKey = "userId"                             // Context key for the user ID of a chat member
+const emojiKey = "emoji"                                 // Context key for the reaction emoji
+const attachmentIDKey = "attachmentId"                   // Context key for attachment ID
+const textKey = "text"                                   // Form key for the message text
+const parentIDKey = "parent_id"                          // Form key for the ID of the replied message
+const filesKey = "files"                                 // Form key for files attached to a message
+const authorizationHeader = "Authorization"              // Header carrying the bearer token
+const tokenType = "Bearer"                               // Authorization scheme of issued tokens
+const bearerProtocol = "bearer"                          // WebSocket subprotocol followed by a bearer token
+const accessTokenKey = "access_token"                    // Context key for the stream token of an event stream
+const idempotencyKeyHeader = "Idempotency-Key"           // Header carrying the client-chosen key of a retriable request
+const idempotentReplayedHeader = "Idempotent-Replayed"   // Header marking a replayed response
+const rateLimitLimitHeader = "X-RateLimit-Limit"         // Header carrying the request budget of a client
+const rateLimitRemainingHeader = "X-RateLimit-Remaining" // Header carrying the requests a client may still make right away
+const rateLimitResetHeader = "X-RateLimit-Reset"         // Header carrying the seconds until the budget is fully restored
+const retryAfterHeader = "Retry-After"                   // Header carrying the seconds until a rejected client may retry
 
 // Handler contains API v1 handlers and holds the service layer.
 type Handler struct {
-	service service.Service
+	service     service.Service // Service layer
+	maxBodySize int64           // Largest request body read, in bytes
+	replayGrace time.Duration   // How long before the last delivered message a resumed stream looks for late commits
 }
 
 // NewHandler creates a new v1 API handler with the given service layer.
-func NewHandler(service service.Service) *Handler {
-	return &Handler{service: service}
+// Request bodies longer than maxBodySize bytes are rejected; replayGrace is the
+// replay grace of the service, which bounds the message IDs carried in SSE event IDs.
+func NewHandler(service service.Service, maxBodySize int64, replayGrace time.Duration) *Handler {
+	return &Handler{service: service, maxBodySize: maxBodySize, replayGrace: replayGrace}
 }
@@ -1,10 +1,15 @@
 package v1
 
 import (
+	"bytes"
 	"chatX/internal/errs"
 	"chatX/internal/models"
 	"chatX/internal/service/mocks"
+	"context"
+	"encoding/json"
 	"errors"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -12,20 +17,49 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
 
+const testUserID = 3
+
+const testMaxBodySize = 1 << 20
+
+const testReplayGrace = 5 * time.Second
+
 func setupRouter(h *Handler) *gin.Engine {
 
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 
-	router.POST("/chats", h.CreateChat)
-	router.POST("/chats/:id/messages", h.CreateMessage)
+	// stands in for Authenticate, which has tests of its own
+	router.Use(func(c *gin.Context) { c.Set(userIDKey, testUserID) })
+
+	router.POST("/auth/register", h.Register)
+	router.POST("/auth/login", h.Login)
+
+	router.POST("/chats", h.Idempotent, h.CreateChat)
+	router.POST("/chats/:id/messages", h.Idempotent, h.CreateMessage)
+	router.PATCH("/chats/:id/messages/:msgId", h.UpdateMessage)
+	router.DELETE("/chats/:id/messages/:msgId", h.DeleteMessage)
+	router.PATCH("/chats/:id", h.UpdateChat)
 	router.DELETE("/chats/:id", h.DeleteChat)
+	router.GET("/chats", h.ListChats)
 	router.GET("/chats/:id", h.GetChat)
+	router.GET("/chats/:id/messages", h.WaitForMessages)
+	router.GET("/chats/:id/messages/:msgId/thread", h.GetThread)
+	router.GET("/chats/:id/attachments/:attachmentId", h.GetAttachment)
+	router.POST("/chats/:id/messages/:msgId/reactions", h.AddReaction)
+	router.DELETE("/chats/:id/messages/:msgId/reactions/:emoji", h.RemoveReaction)
+	router.GET("/chats/:id/ws", h.SubscribeChat)
+	router.GET("/chats/:id/events", h.StreamChat)
+	router.POST("/chats/:id/read", h.MarkRead)
+	router.POST("/chats/:id/members", h.AddMember)
+	router.GET("/chats/:id/members", h.ListMembers)
+	router.DELETE("/chats/:id/members/:userId", h.RemoveMember)
+	router.GET("/search", h.SearchMessages)
 
 	return router
 
@@ -37,10 +71,10 @@ func TestHandler_CreateChat_OK(t *testing.T) {
 	defer controller.Finish()
 
 	service := mocks.NewMockService(controller)
-	handler := NewHandler(service)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
 	router := setupRouter(handler)
 
-	service.EXPECT().CreateChat(gomock.Any(), models.Chat{Title: "qweqwe"}).Return(models.Chat{ID: 1, Title: "qweqwe", CreatedAt: time.Now()}, nil)
+	service.EXPECT().CreateChat(gomock.Any(), testUserID, models.Chat{Title: "qweqwe"}).Return(models.Chat{ID: 1, Title: "qweqwe", CreatedAt: time.Now()}, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/chats", strings.NewReader(`{"title":"qweqwe"}`))
 	req.Header.Set("Content-Type", "application/json")
@@ -60,7 +94,7 @@ func TestHandler_CreateChat_InvalidJSON(t *testing.T) {
 	defer controller.Finish()
 
 	service := mocks.NewMockService(controller)
-	handler := NewHandler(service)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
 	router := setupRouter(handler)
 
 	req := httptest.NewRequest(http.MethodPost, "/chats", strings.NewReader("{invalid json"))
@@ -74,16 +108,61 @@ func TestHandler_CreateChat_InvalidJSON(t *testing.T) {
 
 }
 
+func TestHandler_UpdateChat_OK(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().UpdateChat(gomock.Any(), testUserID, models.Chat{ID: 1, Title: "renamed"}).
+		Return(models.Chat{ID: 1, Title: "renamed", CreatedAt: time.Now(), UpdatedAt: time.Now()}, nil)
+
+	req := httptest.NewRequest(http.MethodPatch, "/chats/1", strings.NewReader(`{"title":"renamed"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"renamed"`)
+	assert.Contains(t, w.Body.String(), `"updated_at"`)
+
+}
+
+func TestHandler_UpdateChat_NotFound(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().UpdateChat(gomock.Any(), testUserID, models.Chat{ID: 1, Title: "renamed"}).Return(models.Chat{}, errs.ErrChatNotFound)
+
+	req := httptest.NewRequest(http.MethodPatch, "/chats/1", strings.NewReader(`{"title":"renamed"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+}
+
 func TestHandler_CreateMessage_OK(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
 	service := mocks.NewMockService(controller)
-	handler := NewHandler(service)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
 	router := setupRouter(handler)
 
-	service.EXPECT().CreateMessage(gomock.Any(), models.Message{ChatID: 1, Text: "aboba"}).
+	service.EXPECT().CreateMessage(gomock.Any(), testUserID, models.Message{ChatID: 1, Text: "aboba"}).
 		Return(models.Message{ID: 10, ChatID: 1, Text: "aboba", CreatedAt: time.Now()}, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", strings.NewReader(`{"text":"aboba"}`))
@@ -97,256 +176,1821 @@ func TestHandler_CreateMessage_OK(t *testing.T) {
 
 }
 
-func TestHandler_CreateMessage_InvalidChatID(t *testing.T) {
+func TestHandler_CreateMessage_Reply(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
 	service := mocks.NewMockService(controller)
-	handler := NewHandler(service)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
 	router := setupRouter(handler)
 
-	req := httptest.NewRequest(http.MethodPost, "/chats/abc/messages", strings.NewReader(`{"text":"aboba"}`))
+	parentID := 9
+
+	service.EXPECT().CreateMessage(gomock.Any(), testUserID, models.Message{ChatID: 1, Text: "agreed", ParentID: &parentID}).
+		Return(models.Message{ID: 10, ChatID: 1, Text: "agreed", ParentID: &parentID, CreatedAt: time.Now()}, nil)
+	service.EXPECT().CreateMessage(gomock.Any(), testUserID, gomock.Any()).Return(models.Message{}, errs.ErrInvalidParent)
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", strings.NewReader(`{"text":"agreed","parent_id":9}`))
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"parent_id":9`)
+	assert.Contains(t, w.Body.String(), `"reply_count":0`)
+
+	req = httptest.NewRequest(http.MethodPost, "/chats/1/messages", strings.NewReader(`{"text":"agreed","parent_id":42}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Contains(t, w.Body.String(), errs.ErrInvalidChatID.Error())
+	assert.Contains(t, w.Body.String(), errs.ErrInvalidParent.Error())
 
 }
 
-func TestHandler_DeleteChat_OK(t *testing.T) {
+func TestHandler_CreateMessage_Multipart(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
 	service := mocks.NewMockService(controller)
-	handler := NewHandler(service)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
 	router := setupRouter(handler)
 
-	service.EXPECT().DeleteChat(gomock.Any(), 1).Return(nil)
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	require.NoError(t, form.WriteField("text", "look"))
+	require.NoError(t, form.WriteField("parent_id", "9"))
+	file, err := form.CreateFormFile("files", "photo.png")
+	require.NoError(t, err)
+	_, err = file.Write([]byte("pixels"))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	parentID := 9
+
+	service.EXPECT().CreateMessage(gomock.Any(), testUserID, models.Message{ChatID: 1, Text: "look", ParentID: &parentID}, gomock.Any()).
+		DoAndReturn(func(_ any, _ int, message models.Message, uploads ...models.Upload) (models.Message, error) {
+			require.Len(t, uploads, 1)
+			assert.Equal(t, "photo.png", uploads[0].Filename)
+			assert.EqualValues(t, 6, uploads[0].Size)
+			data, err := io.ReadAll(uploads[0].Content)
+			assert.NoError(t, err)
+			assert.Equal(t, "pixels", string(data))
+			message.ID = 10
+			message.Attachments = models.Attachments{{ID: 4, MessageID: 10, Filename: "photo.png", ContentType: "image/png", Size: 6}}
+			return message, nil
+		})
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
 
-	req := httptest.NewRequest(http.MethodDelete, "/chats/1", nil)
 	w := httptest.NewRecorder()
-
 	router.ServeHTTP(w, req)
+
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"attachments":[{"id":4,"filename":"photo.png","content_type":"image/png","size":6,"url":"/api/v1/chats/1/attachments/4"}]`)
 
 }
 
-func TestHandler_DeleteChat_NotFound(t *testing.T) {
+func TestHandler_CreateMessage_AttachmentErrors(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
 	service := mocks.NewMockService(controller)
-	handler := NewHandler(service)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
 	router := setupRouter(handler)
 
-	service.EXPECT().DeleteChat(gomock.Any(), 1).Return(errs.ErrChatNotFound)
+	gomock.InOrder(
+		service.EXPECT().CreateMessage(gomock.Any(), testUserID, gomock.Any(), gomock.Any()).Return(models.Message{}, errs.ErrAttachmentTooLarge),
+		service.EXPECT().CreateMessage(gomock.Any(), testUserID, gomock.Any(), gomock.Any()).Return(models.Message{}, errs.ErrUnsupportedMediaType),
+	)
+
+	for _, status := range []int{http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType} {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		file, err := form.CreateFormFile("files", "file.bin")
+		require.NoError(t, err)
+		_, _ = file.Write([]byte{0, 1, 2})
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, status, w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", strings.NewReader("not a form"))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
 
-	req := httptest.NewRequest(http.MethodDelete, "/chats/1", nil)
 	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+}
 
+func TestHandler_CreateMessage_FormTooLarge(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, 64, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().CreateMessage(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("files", "file.bin")
+	require.NoError(t, err)
+	_, err = file.Write(bytes.Repeat([]byte{1}, 128))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
-	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), errs.ErrAttachmentTooLarge.Error())
 
 }
 
-func TestHandler_GetChat_OK(t *testing.T) {
+func TestHandler_GetAttachment_StreamsContent(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
 	service := mocks.NewMockService(controller)
-	handler := NewHandler(service)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
 	router := setupRouter(handler)
 
-	service.EXPECT().GetChat(gomock.Any(), 1, "").
-		Return(models.Chat{ID: 1, Title: "chat", Messages: []models.Message{{ID: 1, ChatID: 1, Text: "aboba"}}}, nil)
+	attachment := models.Attachment{ID: 4, Filename: "résumé.pdf", ContentType: "application/pdf", Size: 8}
+	service.EXPECT().GetAttachment(gomock.Any(), testUserID, 1, 4).Return(attachment, io.NopCloser(strings.NewReader("%PDF-1.7")), nil)
 
-	req := httptest.NewRequest(http.MethodGet, "/chats/1", nil)
+	req := httptest.NewRequest(http.MethodGet, "/chats/1/attachments/4", nil)
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
-	assert.Contains(t, w.Body.String(), `"messages"`)
+	assert.Equal(t, "%PDF-1.7", w.Body.String())
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Equal(t, "8", w.Header().Get("Content-Length"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "attachment; filename*=utf-8''r%C3%A9sum%C3%A9.pdf", w.Header().Get("Content-Disposition"))
 
 }
 
-func TestCreateChat_ServiceError(t *testing.T) {
-
-	gin.SetMode(gin.TestMode)
+func TestHandler_GetAttachment_Errors(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc := mocks.NewMockService(controller)
-	h := NewHandler(svc)
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
 
-	svc.EXPECT().CreateChat(gomock.Any(), gomock.Any()).Return(models.Chat{}, errs.ErrTitleEmpty)
+	service.EXPECT().GetAttachment(gomock.Any(), testUserID, 1, 5).Return(models.Attachment{}, nil, errs.ErrAttachmentNotFound)
 
-	router := gin.New()
-	router.POST("/chats", h.CreateChat)
+	req := httptest.NewRequest(http.MethodGet, "/chats/1/attachments/5", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
-	body := `{"title":"   "}`
-	req := httptest.NewRequest(http.MethodPost, "/chats", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
+	req = httptest.NewRequest(http.MethodGet, "/chats/1/attachments/x", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+}
+
+func TestHandler_GetThread_OK(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
 
+	parentID := 5
+	thread := models.Thread{
+		Message: models.Message{ID: 5, ChatID: 1, Text: "question", ReplyCount: 1},
+		Replies: []models.Message{{ID: 6, ChatID: 1, Text: "answer", ParentID: &parentID}},
+	}
+
+	service.EXPECT().GetThread(gomock.Any(), testUserID, 1, 5, "1", "abc").Return(thread, "next", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1/messages/5/thread?limit=1&cursor=abc", nil)
 	w := httptest.NewRecorder()
+
 	router.ServeHTTP(w, req)
 
-	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"reply_count":1`)
+	assert.Contains(t, w.Body.String(), `"replies":[{"id":6`)
+	assert.Contains(t, w.Body.String(), `"next_cursor":"next"`)
 
 }
 
-func TestHandler_CreateMessage_InvalidJSON(t *testing.T) {
-
-	gin.SetMode(gin.TestMode)
+func TestHandler_GetThread_InvalidMessageID(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc := mocks.NewMockService(controller)
-	h := NewHandler(svc)
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
 
-	router := gin.New()
-	router.POST("/chats/:id/messages", h.CreateMessage)
+	req := httptest.NewRequest(http.MethodGet, "/chats/1/messages/x/thread", nil)
+	w := httptest.NewRecorder()
 
-	req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", strings.NewReader(`{invalid json`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), errs.ErrInvalidMessageID.Error())
+
+}
+
+func TestHandler_CreateMessage_InvalidChatID(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/abc/messages", strings.NewReader(`{"text":"aboba"}`))
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	require.Equal(t, http.StatusBadRequest, w.Code)
-	require.Contains(t, w.Body.String(), errs.ErrInvalidJSON.Error())
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), errs.ErrInvalidChatID.Error())
 
 }
 
-func TestHandler_CreateMessage_ServiceError(t *testing.T) {
-
-	gin.SetMode(gin.TestMode)
+func TestHandler_UpdateMessage_OK(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc := mocks.NewMockService(controller)
-	h := NewHandler(svc)
-
-	expectedErr := errs.ErrMessageEmpty
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
 
-	svc.EXPECT().CreateMessage(gomock.Any(), models.Message{ChatID: 1, Text: ""}).Return(models.Message{}, expectedErr)
+	editedAt := time.Now()
 
-	router := gin.New()
-	router.POST("/chats/:id/messages", h.CreateMessage)
+	service.EXPECT().UpdateMessage(gomock.Any(), testUserID, models.Message{ID: 10, ChatID: 1, Text: "edited"}).
+		Return(models.Message{ID: 10, ChatID: 1, Text: "edited", EditedAt: &editedAt}, nil)
 
-	req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", strings.NewReader(`{"text":""}`))
+	req := httptest.NewRequest(http.MethodPatch, "/chats/1/messages/10", strings.NewReader(`{"text":"edited"}`))
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	require.Equal(t, http.StatusBadRequest, w.Code)
-	require.Contains(t, w.Body.String(), expectedErr.Error())
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"edited_at"`)
 
 }
 
-func TestHandler_DeleteChat_InvalidChatID(t *testing.T) {
-
-	gin.SetMode(gin.TestMode)
+func TestHandler_UpdateMessage_InvalidMessageID(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc := mocks.NewMockService(controller)
-	h := NewHandler(svc)
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
 
-	router := gin.New()
-	router.DELETE("/chats/:id", h.DeleteChat)
+	req := httptest.NewRequest(http.MethodPatch, "/chats/1/messages/abc", strings.NewReader(`{"text":"edited"}`))
+	req.Header.Set("Content-Type", "application/json")
 
-	req := httptest.NewRequest(http.MethodDelete, "/chats/abc", nil)
 	w := httptest.NewRecorder()
-
 	router.ServeHTTP(w, req)
 
-	require.Equal(t, http.StatusBadRequest, w.Code)
-	require.Contains(t, w.Body.String(), errs.ErrInvalidChatID.Error())
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), errs.ErrInvalidMessageID.Error())
 
 }
 
-func TestHandler_GetChat_InvalidChatID(t *testing.T) {
-
-	gin.SetMode(gin.TestMode)
+func TestHandler_DeleteMessage_OK(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc := mocks.NewMockService(controller)
-	h := NewHandler(svc)
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
 
-	router := gin.New()
-	router.GET("/chats/:id", h.GetChat)
+	service.EXPECT().DeleteMessage(gomock.Any(), testUserID, 1, 10).Return(nil)
 
-	req := httptest.NewRequest(http.MethodGet, "/chats/xyz", nil)
+	req := httptest.NewRequest(http.MethodDelete, "/chats/1/messages/10", nil)
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
-
-	require.Equal(t, http.StatusBadRequest, w.Code)
-	require.Contains(t, w.Body.String(), errs.ErrInvalidChatID.Error())
+	assert.Equal(t, http.StatusOK, w.Code)
 
 }
 
-func TestHandler_GetChat_NotFound(t *testing.T) {
+func TestHandler_DeleteMessage_NotFound(t *testing.T) {
 
-	gin.SetMode(gin.TestMode)
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().DeleteMessage(gomock.Any(), testUserID, 1, 10).Return(errs.ErrMessageNotFound)
+
+	req := httptest.NewRequest(http.MethodDelete, "/chats/1/messages/10", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+}
+
+func TestHandler_AddReaction_OK(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc := mocks.NewMockService(controller)
-	h := NewHandler(svc)
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
 
-	svc.EXPECT().GetChat(gomock.Any(), 1, "").Return(models.Chat{}, errs.ErrChatNotFound)
+	message := models.Message{ID: 10, ChatID: 1, Text: "Hi!", Reactions: models.Reactions{{Emoji: "👍", Count: 2}}}
 
-	router := gin.New()
-	router.GET("/chats/:id", h.GetChat)
+	service.EXPECT().AddReaction(gomock.Any(), testUserID, 1, 10, "👍").Return(message, nil)
 
-	req := httptest.NewRequest(http.MethodGet, "/chats/1", nil)
+	req := httptest.NewRequest(http.MethodPost, "/chats/1/messages/10/reactions", strings.NewReader(`{"emoji":"👍"}`))
+	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
 
-	require.Equal(t, http.StatusNotFound, w.Code)
-	require.Contains(t, w.Body.String(), errs.ErrChatNotFound.Error())
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"reactions":[{"emoji":"👍","count":2}]`)
 
 }
 
-func TestHandler_GetChat_InternalError(t *testing.T) {
+func TestHandler_AddReaction_InvalidEmoji(t *testing.T) {
 
-	gin.SetMode(gin.TestMode)
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().AddReaction(gomock.Any(), testUserID, 1, 10, "").Return(models.Message{}, errs.ErrInvalidEmoji)
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/1/messages/10/reactions", strings.NewReader(`{"emoji":""}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+}
+
+func TestHandler_RemoveReaction_OK(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc := mocks.NewMockService(controller)
-	h := NewHandler(svc)
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
 
-	svc.EXPECT().GetChat(gomock.Any(), 1, "").Return(models.Chat{}, errors.New("db is down"))
+	service.EXPECT().RemoveReaction(gomock.Any(), testUserID, 1, 10, "👍").Return(nil)
 
-	router := gin.New()
-	router.GET("/chats/:id", h.GetChat)
+	req := httptest.NewRequest(http.MethodDelete, "/chats/1/messages/10/reactions/%F0%9F%91%8D", nil)
+	w := httptest.NewRecorder()
 
-	req := httptest.NewRequest(http.MethodGet, "/chats/1", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+}
+
+func TestHandler_RemoveReaction_NotFound(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().RemoveReaction(gomock.Any(), testUserID, 1, 10, "👍").Return(errs.ErrReactionNotFound)
+
+	req := httptest.NewRequest(http.MethodDelete, "/chats/1/messages/10/reactions/%F0%9F%91%8D", nil)
 	w := httptest.NewRecorder()
 
 	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
-	require.Equal(t, http.StatusInternalServerError, w.Code)
-	require.Contains(t, w.Body.String(), errs.ErrInternal.Error())
+}
+
+func TestHandler_DeleteChat_OK(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().DeleteChat(gomock.Any(), testUserID, 1).Return(nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/chats/1", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+}
+
+func TestHandler_DeleteChat_NotFound(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().DeleteChat(gomock.Any(), testUserID, 1).Return(errs.ErrChatNotFound)
+
+	req := httptest.NewRequest(http.MethodDelete, "/chats/1", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+}
+
+func TestHandler_GetChat_OK(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().GetChat(gomock.Any(), testUserID, 1, "", "", "").
+		Return(models.Chat{ID: 1, Title: "chat", UnreadCount: 1, Messages: []models.Message{{ID: 1, ChatID: 1, Text: "aboba"}}}, "", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"messages"`)
+	assert.Contains(t, w.Body.String(), `"unread_count":1`)
+
+}
+
+func TestHandler_GetChat_Cursor(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().GetChat(gomock.Any(), testUserID, 1, "2", "abc", "").
+		Return(models.Chat{ID: 1, Title: "chat"}, "def", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1?limit=2&before=abc", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"next_cursor":"def"`)
+
+}
+
+func TestHandler_GetChat_InvalidCursor(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().GetChat(gomock.Any(), testUserID, 1, "", "", "???").Return(models.Chat{}, "", errs.ErrInvalidCursor)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1?after=???", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), errs.ErrInvalidCursor.Error())
+
+}
+
+func TestHandler_ListChats_OK(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	createdAfter := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := models.ChatFilter{Title: "best", CreatedAfter: createdAfter, Sort: models.SortByLastActivity}
+
+	service.EXPECT().ListChats(gomock.Any(), testUserID, filter, "5", "abc").
+		Return([]models.ChatSummary{{ID: 1, Title: "best chat", MessageCount: 3, UnreadCount: 2}}, "def", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats?title=best&created_after=2025-01-01T00:00:00Z&sort=last_activity&limit=5&cursor=abc", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"message_count":3`)
+	assert.Contains(t, w.Body.String(), `"unread_count":2`)
+	assert.Contains(t, w.Body.String(), `"next_cursor":"def"`)
+
+}
+
+func TestHandler_ListChats_InvalidTimestamp(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats?created_before=yesterday", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), errs.ErrInvalidTimestamp.Error())
+
+}
+
+func TestCreateChat_ServiceError(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc := mocks.NewMockService(controller)
+	h := NewHandler(svc, testMaxBodySize, testReplayGrace)
+
+	svc.EXPECT().CreateChat(gomock.Any(), testUserID, gomock.Any()).Return(models.Chat{}, errs.ErrTitleEmpty)
+
+	router := setupRouter(h)
+
+	body := `{"title":"   "}`
+	req := httptest.NewRequest(http.MethodPost, "/chats", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+}
+
+func TestHandler_CreateMessage_InvalidJSON(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc := mocks.NewMockService(controller)
+	h := NewHandler(svc, testMaxBodySize, testReplayGrace)
+
+	router := setupRouter(h)
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", strings.NewReader(`{invalid json`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), errs.ErrInvalidJSON.Error())
+
+}
+
+func TestHandler_CreateMessage_ServiceError(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc := mocks.NewMockService(controller)
+	h := NewHandler(svc, testMaxBodySize, testReplayGrace)
+
+	expectedErr := errs.ErrMessageEmpty
+
+	svc.EXPECT().CreateMessage(gomock.Any(), testUserID, models.Message{ChatID: 1, Text: ""}).Return(models.Message{}, expectedErr)
+
+	router := setupRouter(h)
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", strings.NewReader(`{"text":""}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), expectedErr.Error())
+
+}
+
+func TestHandler_DeleteChat_InvalidChatID(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc := mocks.NewMockService(controller)
+	h := NewHandler(svc, testMaxBodySize, testReplayGrace)
+
+	router := setupRouter(h)
+
+	req := httptest.NewRequest(http.MethodDelete, "/chats/abc", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), errs.ErrInvalidChatID.Error())
+
+}
+
+func TestHandler_GetChat_InvalidChatID(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc := mocks.NewMockService(controller)
+	h := NewHandler(svc, testMaxBodySize, testReplayGrace)
+
+	router := setupRouter(h)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/xyz", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), errs.ErrInvalidChatID.Error())
+
+}
+
+func TestHandler_GetChat_NotFound(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc := mocks.NewMockService(controller)
+	h := NewHandler(svc, testMaxBodySize, testReplayGrace)
+
+	svc.EXPECT().GetChat(gomock.Any(), testUserID, 1, "", "", "").Return(models.Chat{}, "", errs.ErrChatNotFound)
+
+	router := setupRouter(h)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Contains(t, w.Body.String(), errs.ErrChatNotFound.Error())
+
+}
+
+func TestHandler_GetChat_InternalError(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc := mocks.NewMockService(controller)
+	h := NewHandler(svc, testMaxBodySize, testReplayGrace)
+
+	svc.EXPECT().GetChat(gomock.Any(), testUserID, 1, "", "", "").Return(models.Chat{}, "", errors.New("db is down"))
+
+	router := setupRouter(h)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Contains(t, w.Body.String(), errs.ErrInternal.Error())
+
+}
+
+func TestHandler_SubscribeChat_StreamsEvents(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	server := httptest.NewServer(setupRouter(handler))
+	defer server.Close()
+
+	events := make(chan models.Event, 1)
+	unsubscribed := make(chan struct{})
+
+	service.EXPECT().SubscribeChat(gomock.Any(), testUserID, 1).Return((<-chan models.Event)(events), func() { close(unsubscribed) }, nil)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/chats/1/ws", nil)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	events <- models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: models.Message{ID: 10, ChatID: 1, Text: "aboba"}}
+
+	var dto EventResponseDTO
+	require.NoError(t, conn.ReadJSON(&dto))
+	require.Equal(t, "message.created", dto.Type)
+	require.Equal(t, "aboba", dto.Message.Text)
+
+	close(events)
+
+	_, _, err = conn.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+
+	select {
+	case <-unsubscribed:
+	case <-time.After(time.Second):
+		t.Fatal("subscription was not released")
+	}
+
+}
+
+func TestHandler_SubscribeChat_NotFound(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().SubscribeChat(gomock.Any(), testUserID, 1).Return(nil, nil, errs.ErrChatNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1/ws", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+}
+
+func TestHandler_StreamChat_ReplaysMissedMessages(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	events := make(chan models.Event, 3)
+	events <- models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: models.Message{ID: 7, ChatID: 1, Text: "seven"}}
+	events <- models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: models.Message{ID: 8, ChatID: 1, Text: "eight"}}
+	events <- models.Event{Type: models.EventChatDeleted, ChatID: 1}
+
+	gomock.InOrder(
+		service.EXPECT().SubscribeChat(gomock.Any(), testUserID, 1).Return((<-chan models.Event)(events), func() {}, nil),
+		service.EXPECT().ReplayMessages(gomock.Any(), testUserID, 1, 5).Return([]models.Message{{ID: 6, ChatID: 1, Text: "six"}, {ID: 7, ChatID: 1, Text: "seven"}}, nil),
+		service.EXPECT().GetMessagesAfter(gomock.Any(), testUserID, 1, 7).Return([]models.Message{}, nil),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1/events", nil)
+	req.Header.Set("Last-Event-ID", "5")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream"))
+
+	body := w.Body.String()
+	require.Equal(t, 1, strings.Count(body, `"seven"`))
+	require.Contains(t, body, "id:6:5\nevent:message.created\n")
+	require.Contains(t, body, "id:7:5,6\nevent:message.created\n")
+	require.Contains(t, body, "id:8:5,6,7\nevent:message.created\n")
+	require.Contains(t, body, "id:8:5,6,7\nevent:chat.deleted\n")
+	require.Less(t, strings.Index(body, `"six"`), strings.Index(body, `"eight"`))
+
+}
+
+func TestHandler_StreamChat_DeliversMessagesCommittedOutOfOrder(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	// message 4 committed after message 5 was delivered, message 8 after message 9
+	events := make(chan models.Event, 4)
+	events <- models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: models.Message{ID: 9, ChatID: 1, Text: "nine"}}
+	events <- models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: models.Message{ID: 8, ChatID: 1, Text: "eight"}}
+	events <- models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: models.Message{ID: 9, ChatID: 1, Text: "nine"}}
+	events <- models.Event{Type: models.EventChatDeleted, ChatID: 1}
+
+	gomock.InOrder(
+		service.EXPECT().SubscribeChat(gomock.Any(), testUserID, 1).Return((<-chan models.Event)(events), func() {}, nil),
+		service.EXPECT().ReplayMessages(gomock.Any(), testUserID, 1, 5).Return([]models.Message{{ID: 4, ChatID: 1, Text: "four"}}, nil),
+		service.EXPECT().GetMessagesAfter(gomock.Any(), testUserID, 1, 5).Return([]models.Message{}, nil),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1/events", nil)
+	req.Header.Set("Last-Event-ID", "5")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	require.Contains(t, body, "id:5:4\nevent:message.created\n")
+	require.Contains(t, body, `"four"`)
+	require.Equal(t, 1, strings.Count(body, `"nine"`))
+	require.Contains(t, body, `"eight"`)
+	require.Contains(t, body, "id:9:4,5\nevent:message.created\n")
+	require.Equal(t, 2, strings.Count(body, "id:9:4,5,8\n"))
+	require.Less(t, strings.Index(body, `"nine"`), strings.Index(body, `"eight"`))
+
+}
+
+func TestHandler_StreamChat_ResumeSkipsSeenMessages(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	events := make(chan models.Event, 1)
+	events <- models.Event{Type: models.EventChatDeleted, ChatID: 1}
+
+	// the replay covers messages 7 and 8 the client has and message 6 it missed
+	gomock.InOrder(
+		service.EXPECT().SubscribeChat(gomock.Any(), testUserID, 1).Return((<-chan models.Event)(events), func() {}, nil),
+		service.EXPECT().ReplayMessages(gomock.Any(), testUserID, 1, 9).Return([]models.Message{
+			{ID: 6, ChatID: 1, Text: "six"},
+			{ID: 7, ChatID: 1, Text: "seven"},
+			{ID: 8, ChatID: 1, Text: "eight"},
+			{ID: 10, ChatID: 1, Text: "ten"},
+		}, nil),
+		service.EXPECT().GetMessagesAfter(gomock.Any(), testUserID, 1, 10).Return([]models.Message{}, nil),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1/events", nil)
+	req.Header.Set("Last-Event-ID", "9:7,8")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	require.NotContains(t, body, `"seven"`)
+	require.NotContains(t, body, `"eight"`)
+	require.Contains(t, body, "id:9:7,8,6\nevent:message.created\n")
+	require.Contains(t, body, "id:10:7,8,6,9\nevent:message.created\n")
+	require.Less(t, strings.Index(body, `"six"`), strings.Index(body, `"ten"`))
+
+}
+
+func TestEventStream_DropsSeenOutsideGrace(t *testing.T) {
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	stream := newEventStream(c, models.PollCursor{}, testReplayGrace)
+
+	start := time.Now()
+	stream.track(models.Message{ID: 1, CreatedAt: start})
+	stream.track(models.Message{ID: 3, CreatedAt: start.Add(time.Second)})
+	stream.track(models.Message{ID: 2, CreatedAt: start})
+	assert.Equal(t, "3:1,2", stream.eventID())
+
+	stream.track(models.Message{ID: 4, CreatedAt: start.Add(testReplayGrace + time.Second)})
+	assert.Equal(t, "4:3", stream.eventID())
+
+	stream.track(models.Message{ID: 5, CreatedAt: start.Add(time.Hour)})
+	assert.Equal(t, "5", stream.eventID())
+
+}
+
+func TestEventStream_RemembersBoundedWindow(t *testing.T) {
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	stream := newEventStream(c, models.PollCursor{}, testReplayGrace)
+
+	for id := 1; id <= sseDeliveredWindow+1; id++ {
+		stream.remember(id)
+	}
+
+	assert.Len(t, stream.delivered, sseDeliveredWindow)
+	assert.NotContains(t, stream.delivered, 1)
+	assert.Contains(t, stream.delivered, 2)
+	assert.Contains(t, stream.delivered, sseDeliveredWindow+1)
+
+}
+
+func TestHandler_StreamChat_InvalidLastEventID(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	// a non-integer, a seen ID not below the last one, and an empty seen list
+	for _, lastEventID := range []string{"abc", "5:5", "5:"} {
+		req := httptest.NewRequest(http.MethodGet, "/chats/1/events", nil)
+		req.Header.Set("Last-Event-ID", lastEventID)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusBadRequest, w.Code, lastEventID)
+	}
+
+}
+
+func TestHandler_WaitForMessages_OK(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().WaitForMessages(gomock.Any(), testUserID, 1, 5, "", "30s").Return([]models.Message{{ID: 6, ChatID: 1, Text: "hi"}}, "Njo", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1/messages?after_id=5&wait=30s", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"text":"hi"`)
+	require.Contains(t, w.Body.String(), `"next_cursor":"Njo"`)
+
+}
+
+func TestHandler_WaitForMessages_Cursor(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().WaitForMessages(gomock.Any(), testUserID, 1, 0, "Njoz", "30s").Return([]models.Message{}, "Njoz", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1/messages?cursor=Njoz&wait=30s", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"result":{"messages":[],"next_cursor":"Njoz"}}`, w.Body.String())
+
+}
+
+func TestHandler_WaitForMessages_Timeout_EmptyList(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().WaitForMessages(gomock.Any(), testUserID, 1, 0, "", "1s").Return([]models.Message{}, "MDo", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1/messages?wait=1s", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"result":{"messages":[],"next_cursor":"MDo"}}`, w.Body.String())
+
+}
+
+func TestHandler_WaitForMessages_InvalidAfterID(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1/messages?after_id=-1", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+}
+
+func TestHandler_Register_OK(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().Register(gomock.Any(), "neo", "follow-the-rabbit").
+		Return(models.User{ID: 3, Username: "neo", PasswordHash: "secret-hash", CreatedAt: time.Now()}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(`{"username":"neo","password":"follow-the-rabbit"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"username":"neo"`)
+	require.NotContains(t, w.Body.String(), "secret-hash")
+
+}
+
+func TestHandler_Register_UsernameTaken(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().Register(gomock.Any(), "neo", "follow-the-rabbit").Return(models.User{}, errs.ErrUsernameTaken)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(`{"username":"neo","password":"follow-the-rabbit"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusConflict, w.Code)
+
+}
+
+func TestHandler_Login_OK(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().Login(gomock.Any(), "neo", "follow-the-rabbit").Return(models.Token{Value: "3.123.sig", ExpiresAt: time.Now()}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"neo","password":"follow-the-rabbit"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"access_token":"3.123.sig"`)
+	require.Contains(t, w.Body.String(), `"token_type":"Bearer"`)
+
+}
+
+func TestHandler_Login_InvalidCredentials(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().Login(gomock.Any(), "neo", "wrong").Return(models.Token{}, errs.ErrInvalidCredentials)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"neo","password":"wrong"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+}
+
+func TestHandler_Authenticate_MissingToken(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/chats/:id/messages", handler.Authenticate, handler.CreateMessage)
+
+	for _, header := range []string{"", "Basic bmVvOnJhYmJpdA==", "Bearer "} {
+
+		req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", strings.NewReader(`{"text":"aboba"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusUnauthorized, w.Code, header)
+		require.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+
+	}
+
+}
+
+func TestHandler_AuthenticateStream_WebSocketProtocolToken(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/chats/:id/ws", handler.AuthenticateStream, handler.SubscribeChat)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	events := make(chan models.Event)
+
+	service.EXPECT().Authenticate(gomock.Any(), "good-token").Return(3, nil)
+	service.EXPECT().SubscribeChat(gomock.Any(), 3, 1).Return((<-chan models.Event)(events), func() {}, nil)
+
+	dialer := websocket.Dialer{Subprotocols: []string{bearerProtocol, "good-token"}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/chats/1/ws", nil)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	// browsers fail the handshake unless one of the offered subprotocols is selected
+	assert.Equal(t, bearerProtocol, conn.Subprotocol())
+
+	close(events)
+
+}
+
+func TestHandler_AuthenticateStream_QueryToken(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/chats/:id/events", handler.AuthenticateStream, handler.StreamChat)
+
+	events := make(chan models.Event)
+	close(events)
+
+	service.EXPECT().AuthenticateStream(gomock.Any(), "stream-token").Return(3, nil)
+	service.EXPECT().AuthenticateStream(gomock.Any(), "bearer-token").Return(0, errs.ErrUnauthorized)
+	service.EXPECT().SubscribeChat(gomock.Any(), 3, 1).Return((<-chan models.Event)(events), func() {}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1/events?access_token=stream-token", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	for _, target := range []string{"/chats/1/events?access_token=bearer-token", "/chats/1/events"} {
+		req = httptest.NewRequest(http.MethodGet, target, nil)
+		w = httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusUnauthorized, w.Code, target)
+		require.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+	}
+
+}
+
+func TestHandler_IssueStreamToken(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set(userIDKey, testUserID) })
+	router.POST("/auth/stream-token", handler.IssueStreamToken)
+
+	expiresAt := time.Date(2025, 1, 17, 12, 1, 0, 0, time.UTC)
+
+	service.EXPECT().IssueStreamToken(gomock.Any(), testUserID).Return(models.Token{Value: "stream.3.1737115260.sig", ExpiresAt: expiresAt}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/stream-token", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"access_token":"stream.3.1737115260.sig"`)
+
+}
+
+func TestHandler_Authenticate_PassesUserToService(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/chats/:id/messages", handler.Authenticate, handler.CreateMessage)
+
+	authorID := 3
+
+	service.EXPECT().Authenticate(gomock.Any(), "good-token").Return(authorID, nil)
+	service.EXPECT().Authenticate(gomock.Any(), "bad-token").Return(0, errs.ErrUnauthorized)
+	service.EXPECT().CreateMessage(gomock.Any(), authorID, models.Message{ChatID: 1, Text: "aboba"}).
+		Return(models.Message{ID: 10, ChatID: 1, Text: "aboba", AuthorID: &authorID}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", strings.NewReader(`{"text":"aboba","author_id":99}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer good-token")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"author_id":3`)
+
+	req = httptest.NewRequest(http.MethodPost, "/chats/1/messages", strings.NewReader(`{"text":"aboba"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer bad-token")
+	w = httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+}
+
+func TestHandler_AddMember_OK(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().AddMember(gomock.Any(), testUserID, models.ChatMember{ChatID: 1, UserID: 4, Role: models.RoleReadOnly}).
+		Return(models.ChatMember{ChatID: 1, UserID: 4, Role: models.RoleReadOnly, CreatedAt: time.Now()}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/1/members", strings.NewReader(`{"user_id":4,"role":"read-only"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"user_id":4`)
+	assert.Contains(t, w.Body.String(), `"role":"read-only"`)
+
+}
+
+func TestHandler_AddMember_Errors(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().AddMember(gomock.Any(), testUserID, gomock.Any()).Return(models.ChatMember{}, errs.ErrForbidden)
+
+	tests := []struct {
+		body   string
+		status int
+		err    error
+	}{
+		{`{"user_id":0}`, http.StatusBadRequest, errs.ErrInvalidUserID},
+		{`{"user_id":4,"role":"admin"}`, http.StatusForbidden, errs.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+
+		req := httptest.NewRequest(http.MethodPost, "/chats/1/members", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.status, w.Code)
+		assert.Contains(t, w.Body.String(), tt.err.Error())
+
+	}
+
+}
+
+func TestHandler_ListMembers_OK(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().ListMembers(gomock.Any(), testUserID, 1).
+		Return([]models.ChatMember{{ChatID: 1, UserID: testUserID, Username: "neo", Role: models.RoleOwner}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1/members", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"username":"neo"`)
+	assert.Contains(t, w.Body.String(), `"role":"owner"`)
+
+}
+
+func TestHandler_RemoveMember(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().RemoveMember(gomock.Any(), testUserID, 1, 4).Return(nil)
+	service.EXPECT().RemoveMember(gomock.Any(), testUserID, 1, 5).Return(errs.ErrMemberNotFound)
+
+	req := httptest.NewRequest(http.MethodDelete, "/chats/1/members/4", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), statusDeleted)
+
+	req = httptest.NewRequest(http.MethodDelete, "/chats/1/members/5", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/chats/1/members/abc", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), errs.ErrInvalidUserID.Error())
+
+}
+
+func TestHandler_SearchMessages_OK(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	hits := []models.SearchHit{{
+		Message: models.Message{ID: 42, ChatID: 3, Text: "release party", CreatedAt: time.Now()},
+		Rank:    0.5,
+		Snippet: "<mark>release</mark> party",
+	}}
+
+	service.EXPECT().SearchMessages(gomock.Any(), testUserID, models.SearchQuery{Text: "release", ChatID: 3}, "10", "").
+		Return(hits, "next", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=release&chat_id=3&limit=10", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	var res struct{ Result SearchResponseDTO }
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Len(t, res.Result.Hits, 1)
+	assert.Equal(t, 42, res.Result.Hits[0].Message.ID)
+	assert.Equal(t, "<mark>release</mark> party", res.Result.Hits[0].Snippet)
+	assert.Equal(t, "next", res.Result.NextCursor)
+
+}
+
+func TestHandler_SearchMessages_Errors(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().SearchMessages(gomock.Any(), testUserID, models.SearchQuery{}, "", "").Return(nil, "", errs.ErrSearchQueryEmpty)
+
+	tests := []struct {
+		url    string
+		status int
+		err    error
+	}{
+		{"/search?q=hi&chat_id=abc", http.StatusBadRequest, errs.ErrInvalidChatID},
+		{"/search?q=hi&chat_id=-1", http.StatusBadRequest, errs.ErrInvalidChatID},
+		{"/search", http.StatusBadRequest, errs.ErrSearchQueryEmpty},
+	}
+
+	for _, tt := range tests {
+
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.status, w.Code)
+		assert.Contains(t, w.Body.String(), tt.err.Error())
+
+	}
+
+}
+
+func TestHandler_MarkRead_OK(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().MarkRead(gomock.Any(), testUserID, 1, 10).
+		Return(models.ReadReceipt{ChatID: 1, UserID: testUserID, LastReadMessageID: 12, UnreadCount: 0, UpdatedAt: time.Now()}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/1/read", strings.NewReader(`{"message_id":10}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"last_read_message_id":12`)
+	assert.Contains(t, w.Body.String(), `"unread_count":0`)
+
+}
+
+func TestHandler_MarkRead_Errors(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().MarkRead(gomock.Any(), testUserID, 1, 404).Return(models.ReadReceipt{}, errs.ErrMessageNotFound)
+
+	tests := []struct {
+		body   string
+		status int
+		err    error
+	}{
+		{`{"message_id":`, http.StatusBadRequest, errs.ErrInvalidJSON},
+		{`{}`, http.StatusBadRequest, errs.ErrInvalidMessageID},
+		{`{"message_id":404}`, http.StatusNotFound, errs.ErrMessageNotFound},
+	}
+
+	for _, tt := range tests {
+
+		req := httptest.NewRequest(http.MethodPost, "/chats/1/read", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.status, w.Code)
+		assert.Contains(t, w.Body.String(), tt.err.Error())
+
+	}
+
+}
+
+func TestHandler_Idempotent_StoresResponse(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	var hash string
+
+	service.EXPECT().BeginIdempotent(gomock.Any(), testUserID, "retry-1", gomock.Any()).
+		DoAndReturn(func(_ context.Context, userID int, key, requestHash string) (models.IdempotencyKey, bool, error) {
+			hash = requestHash
+			return models.IdempotencyKey{UserID: userID, Key: key, RequestHash: requestHash}, true, nil
+		})
+	service.EXPECT().CreateChat(gomock.Any(), testUserID, models.Chat{Title: "qweqwe"}).Return(models.Chat{ID: 1, Title: "qweqwe"}, nil)
+	service.EXPECT().CompleteIdempotent(gomock.Any(), gomock.Any()).Do(func(_ context.Context, record models.IdempotencyKey) {
+		assert.Equal(t, hash, record.RequestHash)
+		assert.Equal(t, http.StatusOK, record.StatusCode)
+		assert.Contains(t, string(record.ResponseBody), `"title":"qweqwe"`)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/chats", strings.NewReader(`{"title":"qweqwe"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(idempotencyKeyHeader, "retry-1")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"qweqwe"`)
+	assert.Empty(t, w.Header().Get(idempotentReplayedHeader))
+
+	// the request hash covers the path and the body
+	service.EXPECT().BeginIdempotent(gomock.Any(), testUserID, "retry-2", gomock.Not(hash)).Return(models.IdempotencyKey{}, false, errs.ErrIdempotencyKeyReused)
+
+	req = httptest.NewRequest(http.MethodPost, "/chats", strings.NewReader(`{"title":"other"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(idempotencyKeyHeader, "retry-2")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), errs.ErrIdempotencyKeyReused.Error())
+
+}
+
+func TestHandler_Idempotent_MultipartHashIgnoresBoundary(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	var hashes []string
+
+	service.EXPECT().BeginIdempotent(gomock.Any(), testUserID, "retry-1", gomock.Any()).
+		DoAndReturn(func(_ context.Context, userID int, key, requestHash string) (models.IdempotencyKey, bool, error) {
+			hashes = append(hashes, requestHash)
+			return models.IdempotencyKey{}, false, errs.ErrIdempotencyKeyInProgress
+		}).Times(3)
+
+	send := func(boundary, content string) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		require.NoError(t, form.SetBoundary(boundary))
+		require.NoError(t, form.WriteField("text", "look"))
+		file, err := form.CreateFormFile("files", "photo.png")
+		require.NoError(t, err)
+		_, err = file.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set(idempotencyKeyHeader, "retry-1")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	}
+
+	send("first-boundary", "pixels")
+	send("second-boundary", "pixels")
+	send("second-boundary", "other pixels")
+
+	require.Len(t, hashes, 3)
+	assert.Equal(t, hashes[0], hashes[1])
+	assert.NotEqual(t, hashes[1], hashes[2])
+
+}
+
+func TestHandler_Idempotent_BodyTooLarge(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, 16, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().BeginIdempotent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	req := httptest.NewRequest(http.MethodPost, "/chats", strings.NewReader(`{"title":"a title longer than the limit"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(idempotencyKeyHeader, "retry-1")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), errs.ErrBodyTooLarge.Error())
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("files", "photo.png")
+	require.NoError(t, err)
+	_, err = file.Write(bytes.Repeat([]byte{1}, 64))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req = httptest.NewRequest(http.MethodPost, "/chats/1/messages", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set(idempotencyKeyHeader, "retry-1")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), errs.ErrAttachmentTooLarge.Error())
+
+}
+
+func TestHandler_Idempotent_ReplaysResponse(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	stored := models.IdempotencyKey{StatusCode: http.StatusOK, ResponseBody: []byte(`{"result":{"id":10}}`)}
+
+	service.EXPECT().BeginIdempotent(gomock.Any(), testUserID, "retry-1", gomock.Any()).Return(stored, false, nil)
+	service.EXPECT().CreateMessage(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	service.EXPECT().CompleteIdempotent(gomock.Any(), gomock.Any()).Times(0)
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", strings.NewReader(`{"text":"Hi!"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(idempotencyKeyHeader, "retry-1")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"result":{"id":10}}`, w.Body.String())
+	assert.Equal(t, "true", w.Header().Get(idempotentReplayedHeader))
+
+}
+
+func TestHandler_Idempotent_ServerErrorIsNotStored(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := mocks.NewMockService(controller)
+	handler := NewHandler(service, testMaxBodySize, testReplayGrace)
+	router := setupRouter(handler)
+
+	service.EXPECT().BeginIdempotent(gomock.Any(), testUserID, "retry-1", gomock.Any()).Return(models.IdempotencyKey{Key: "retry-1"}, true, nil)
+	service.EXPECT().CreateChat(gomock.Any(), testUserID, gomock.Any()).Return(models.Chat{}, errors.New("db is down"))
+	service.EXPECT().CompleteIdempotent(gomock.Any(), gomock.Any()).Do(func(_ context.Context, record models.IdempotencyKey) {
+		assert.Equal(t, http.StatusInternalServerError, record.StatusCode)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/chats", strings.NewReader(`{"title":"qweqwe"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(idempotencyKeyHeader, "retry-1")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+}
+
+// stubLimiter is a ratelimit.Limiter returning a fixed status and recording the keys it was asked about.
+type stubLimiter struct {
+	status models.RateLimit
+	keys   []string
+}
+
+func (l *stubLimiter) Allow(key string) models.RateLimit {
+	l.keys = append(l.keys, key)
+	return l.status
+}
+
+func TestHandler_RateLimit_SetsHeaders(t *testing.T) {
+
+	reads := &stubLimiter{status: models.RateLimit{Allowed: true, Limit: 40, Remaining: 39, Reset: 50 * time.Millisecond}}
+	writes := &stubLimiter{}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set(userIDKey, testUserID) }, RateLimit(reads, writes))
+	router.GET("/chats", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/chats", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "40", w.Header().Get(rateLimitLimitHeader))
+	assert.Equal(t, "39", w.Header().Get(rateLimitRemainingHeader))
+	assert.Equal(t, "1", w.Header().Get(rateLimitResetHeader))
+	assert.Empty(t, w.Header().Get(retryAfterHeader))
+	assert.Equal(t, []string{"user:3"}, reads.keys)
+	assert.Empty(t, writes.keys)
+
+}
+
+func TestHandler_RateLimit_RejectsOverBudget(t *testing.T) {
+
+	reads := &stubLimiter{}
+	writes := &stubLimiter{status: models.RateLimit{Limit: 10, RetryAfter: 1500 * time.Millisecond, Reset: 5 * time.Second}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RateLimit(reads, writes))
+	router.POST("/auth/login", func(c *gin.Context) { t.Fatal("handler must not run") })
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+	req.RemoteAddr = "192.0.2.7:1234"
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get(retryAfterHeader))
+	assert.Equal(t, "0", w.Header().Get(rateLimitRemainingHeader))
+	assert.Equal(t, "5", w.Header().Get(rateLimitResetHeader))
+	assert.Contains(t, w.Body.String(), errs.ErrRateLimited.Error())
+	assert.Equal(t, []string{"ip:192.0.2.7"}, writes.keys)
+	assert.Empty(t, reads.keys)
 
 }
@@ -0,0 +1,167 @@
+package v1
+
+import (
+	"bytes"
+	"chatX/internal/errs"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Idempotent is a middleware that makes retries of a creating request safe.
+//
+// Requests without an Idempotency-Key header pass through unchanged. Otherwise the key is
+// reserved for the user together with a hash of the request (see requestHash), the request
+// is processed and its response stored. A retry with the same key and an identical request
+// replays the stored response with the Idempotent-Replayed header instead of processing it
+// again. A key reused for a different request is rejected with ErrIdempotencyKeyReused, and
+// a retry arriving while the first request is still processed with ErrIdempotencyKeyInProgress.
+// Server errors are not stored, so that a retry after one processes the request again.
+// Bodies longer than the handler's maximum are rejected with ErrAttachmentTooLarge for
+// multipart forms and ErrBodyTooLarge otherwise.
+func (h *Handler) Idempotent(c *gin.Context) {
+
+	key := c.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		c.Next()
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBodySize)
+
+	sum, err := requestHash(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	record, fresh, err := h.service.BeginIdempotent(c.Request.Context(), currentUserID(c), key, sum)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if !fresh {
+		c.Header(idempotentReplayedHeader, "true")
+		c.Data(record.StatusCode, gin.MIMEJSON+"; charset=utf-8", record.ResponseBody)
+		c.Abort()
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+
+	// a panicking handler never returns here; its key is released as a server error
+	record.StatusCode = http.StatusInternalServerError
+	defer func() {
+		h.service.CompleteIdempotent(context.WithoutCancel(c.Request.Context()), record)
+	}()
+
+	c.Next()
+
+	record.StatusCode = recorder.Status()
+	record.ResponseBody = recorder.body.Bytes()
+
+}
+
+// requestHash returns the hex-encoded SHA-256 hash identifying a request by its method, path and body.
+//
+// A multipart form is parsed and hashed by its values and, per file, the name, type and hash of
+// the content, so a retry encoding the same form with a different boundary hashes the same.
+// The parsed form stays cached on the request for the handler. Any other body is hashed as is
+// and restored for the handler.
+func requestHash(c *gin.Context) (string, error) {
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+
+	if c.ContentType() != binding.MIMEMultipartPOSTForm {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", bodyError(err, errs.ErrBodyTooLarge, errs.ErrInvalidJSON)
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash.Write(body)
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return "", bodyError(err, errs.ErrAttachmentTooLarge, errs.ErrInvalidForm)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(form.Value)) {
+		for _, value := range form.Value[key] {
+			fmt.Fprintf(hash, "%q=%q\n", key, value)
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(form.File)) {
+		for _, header := range form.File[key] {
+			content, err := fileHash(header)
+			if err != nil {
+				return "", errs.ErrInvalidForm
+			}
+			fmt.Fprintf(hash, "%q:%q;%q;%x\n", key, header.Filename, header.Header.Get("Content-Type"), content)
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+
+}
+
+// fileHash returns the SHA-256 hash of the content of an uploaded file.
+func fileHash(header *multipart.FileHeader) ([]byte, error) {
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+
+	return hash.Sum(nil), nil
+
+}
+
+// bodyError maps a failure to read a request body to tooLarge if the body exceeded
+// its maximum size, and to invalid otherwise.
+func bodyError(err error, tooLarge, invalid error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return tooLarge
+	}
+	return invalid
+}
+
+// responseRecorder is a gin.ResponseWriter that keeps a copy of the response body.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write writes the data to the response and keeps a copy of it.
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+// WriteString writes the string to the response and keeps a copy of it.
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,22 @@
+package v1
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// IssueStreamToken handles POST /auth/stream-token requests.
+//
+// Returns a short-lived token of the authenticated user as TokenResponseDTO. Browser
+// clients pass it as the access_token query parameter of GET /chats/:id/events, since
+// EventSource cannot send the Authorization header.
+func (h *Handler) IssueStreamToken(c *gin.Context) {
+
+	token, err := h.service.IssueStreamToken(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, TokenResponseDTO{AccessToken: token.Value, TokenType: tokenType, ExpiresAt: token.ExpiresAt})
+
+}
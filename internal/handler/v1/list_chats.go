@@ -0,0 +1,47 @@
+package v1
+
+import (
+	"chatX/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListChats handles GET /chats requests.
+//
+// Lists chat summaries, optionally filtered by the "title" substring and the "created_after"
+// and "created_before" RFC 3339 timestamps, ordered by "sort" (created_at or last_activity)
+// and limited by "limit". The next page is requested with the "cursor" query parameter set
+// to the next_cursor of a previous response.
+// Responds with ChatListResponseDTO on success or an appropriate error if the query is invalid.
+func (h *Handler) ListChats(c *gin.Context) {
+
+	createdAfter, err := parseTime(c, createdAfterKey)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	createdBefore, err := parseTime(c, createdBeforeKey)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	filter := models.ChatFilter{
+		Title:         c.Query(titleKey),
+		CreatedAfter:  createdAfter,
+		CreatedBefore: createdBefore,
+		Sort:          models.ChatSort(c.Query(sortKey)),
+	}
+
+	chats, cursor, err := h.service.ListChats(c.Request.Context(), currentUserID(c), filter, c.Query(limitKey), c.Query(cursorKey))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, ChatListResponseDTO{
+		Chats:      mapChatSummariesToDTO(chats),
+		NextCursor: cursor})
+
+}
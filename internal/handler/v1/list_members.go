@@ -0,0 +1,25 @@
+package v1
+
+import "github.com/gin-gonic/gin"
+
+// ListMembers handles GET /chats/:id/members requests.
+//
+// Returns all members of the chat in the order they joined as MemberListResponseDTO.
+// Responds with ErrForbidden if the caller is not a member of the chat.
+func (h *Handler) ListMembers(c *gin.Context) {
+
+	chatID, err := parseChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	members, err := h.service.ListMembers(c.Request.Context(), currentUserID(c), chatID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, MemberListResponseDTO{Members: mapMembersToDTO(members)})
+
+}
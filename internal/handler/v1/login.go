@@ -0,0 +1,31 @@
+package v1
+
+import (
+	"chatX/internal/errs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Login handles POST /auth/login requests.
+//
+// Expects JSON body with CredentialsRequestDTO. Returns a bearer token as TokenResponseDTO.
+// Responds with ErrInvalidJSON if JSON parsing fails or ErrInvalidCredentials if the
+// username or password is wrong.
+func (h *Handler) Login(c *gin.Context) {
+
+	var dto CredentialsRequestDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		respondError(c, errs.ErrInvalidJSON)
+		return
+	}
+
+	token, err := h.service.Login(c.Request.Context(), dto.Username, dto.Password)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, TokenResponseDTO{AccessToken: token.Value, TokenType: tokenType, ExpiresAt: token.ExpiresAt})
+
+}
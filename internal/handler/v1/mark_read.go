@@ -0,0 +1,48 @@
+package v1
+
+import (
+	"chatX/internal/errs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MarkRead handles POST /chats/:id/read requests.
+//
+// Expects JSON body with ReadRequestDTO naming the last message the user has read. The read
+// marker only moves forward, so marking an older message keeps the current one. Returns the
+// resulting marker with the number of unread messages as ReadReceiptResponseDTO. Responds with
+// ErrInvalidJSON if JSON parsing fails, ErrInvalidMessageID if the message ID is not positive,
+// or ErrMessageNotFound if the chat has no such message.
+func (h *Handler) MarkRead(c *gin.Context) {
+
+	var dto ReadRequestDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		respondError(c, errs.ErrInvalidJSON)
+		return
+	}
+
+	chatID, err := parseChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if dto.MessageID <= 0 {
+		respondError(c, errs.ErrInvalidMessageID)
+		return
+	}
+
+	receipt, err := h.service.MarkRead(c.Request.Context(), currentUserID(c), chatID, dto.MessageID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, ReadReceiptResponseDTO{
+		ChatID:            receipt.ChatID,
+		LastReadMessageID: receipt.LastReadMessageID,
+		UnreadCount:       receipt.UnreadCount,
+		UpdatedAt:         receipt.UpdatedAt})
+
+}
@@ -0,0 +1,60 @@
+package v1
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/ratelimit"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit returns a middleware that limits the request rate of each client.
+//
+// Reads (GET, HEAD and OPTIONS requests) and writes take tokens from separate limiters.
+// Authenticated requests are keyed by the user, so the middleware must run after
+// Authenticate to see it; anonymous requests are keyed by client IP.
+//
+// Every response carries the X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset headers. Requests over the budget are rejected with
+// 429 Too Many Requests and a Retry-After header.
+func RateLimit(reads, writes ratelimit.Limiter) gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+
+		limiter := writes
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			limiter = reads
+		}
+
+		key := "ip:" + c.ClientIP()
+		if userID := currentUserID(c); userID != 0 {
+			key = "user:" + strconv.Itoa(userID)
+		}
+
+		status := limiter.Allow(key)
+
+		c.Header(rateLimitLimitHeader, strconv.Itoa(status.Limit))
+		c.Header(rateLimitRemainingHeader, strconv.Itoa(status.Remaining))
+		c.Header(rateLimitResetHeader, strconv.Itoa(ceilSeconds(status.Reset)))
+
+		if !status.Allowed {
+			c.Header(retryAfterHeader, strconv.Itoa(ceilSeconds(status.RetryAfter)))
+			respondError(c, errs.ErrRateLimited)
+			return
+		}
+
+		c.Next()
+
+	}
+
+}
+
+// ceilSeconds rounds a duration up to whole seconds.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,31 @@
+package v1
+
+import (
+	"chatX/internal/errs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Register handles POST /auth/register requests.
+//
+// Expects JSON body with CredentialsRequestDTO. Returns the created user as UserResponseDTO.
+// Responds with ErrInvalidJSON if JSON parsing fails, a validation error if the username
+// or password is invalid, or ErrUsernameTaken if the username is already registered.
+func (h *Handler) Register(c *gin.Context) {
+
+	var dto CredentialsRequestDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		respondError(c, errs.ErrInvalidJSON)
+		return
+	}
+
+	user, err := h.service.Register(c.Request.Context(), dto.Username, dto.Password)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, UserResponseDTO{ID: user.ID, Username: user.Username, CreatedAt: user.CreatedAt})
+
+}
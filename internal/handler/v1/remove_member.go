@@ -0,0 +1,30 @@
+package v1
+
+import "github.com/gin-gonic/gin"
+
+// RemoveMember handles DELETE /chats/:id/members/:userId requests.
+//
+// Removes the member identified by the path parameters; members leave a chat by removing themselves.
+// Responds with statusDeleted on success or an error if removal fails.
+func (h *Handler) RemoveMember(c *gin.Context) {
+
+	chatID, err := parseChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	memberID, err := parseMemberID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := h.service.RemoveMember(c.Request.Context(), currentUserID(c), chatID, memberID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, statusDeleted)
+
+}
@@ -0,0 +1,30 @@
+package v1
+
+import "github.com/gin-gonic/gin"
+
+// RemoveReaction handles DELETE /chats/:id/messages/:msgId/reactions/:emoji requests.
+//
+// Removes the reaction of the current user with the URL-encoded emoji from the message.
+// Responds with statusDeleted on success or ErrReactionNotFound if there is no such reaction.
+func (h *Handler) RemoveReaction(c *gin.Context) {
+
+	chatID, err := parseChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	messageID, err := parseMessageID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := h.service.RemoveReaction(c.Request.Context(), currentUserID(c), chatID, messageID, c.Param(emojiKey)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, statusDeleted)
+
+}
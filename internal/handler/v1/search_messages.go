@@ -0,0 +1,36 @@
+package v1
+
+import (
+	"chatX/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchMessages handles GET /search requests.
+//
+// Searches the messages of the user's chats for the "q" terms, in web search syntax, optionally
+// restricted to the chat given by "chat_id" and limited by "limit". Hits are ordered by relevance
+// and carry a snippet of the text with the matches wrapped in <mark> tags. The next page is
+// requested with the "cursor" query parameter set to the next_cursor of a previous response.
+// Responds with SearchResponseDTO on success or an appropriate error if the query is invalid.
+func (h *Handler) SearchMessages(c *gin.Context) {
+
+	chatID, err := parseSearchChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	query := models.SearchQuery{Text: c.Query(queryKey), ChatID: chatID}
+
+	hits, cursor, err := h.service.SearchMessages(c.Request.Context(), currentUserID(c), query, c.Query(limitKey), c.Query(cursorKey))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, SearchResponseDTO{
+		Hits:       mapSearchHitsToDTO(hits),
+		NextCursor: cursor})
+
+}
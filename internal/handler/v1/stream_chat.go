@@ -0,0 +1,241 @@
+package v1
+
+import (
+	"chatX/internal/models"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const lastEventIDHeader = "Last-Event-ID" // Header sent by SSE clients to resume a stream
+const sseKeepAlive = 15 * time.Second     // Interval of comment lines that keep idle proxies from dropping the stream
+const sseDeliveredWindow = 1024           // Number of recently delivered message IDs a stream remembers to skip duplicates
+const sseSeenLimit = 128                  // Most message IDs below the highest delivered one an event ID carries
+
+// StreamChat handles GET /chats/:id/events requests.
+//
+// Streams chat events as Server-Sent Events: message.created events carry the
+// message as an EventResponseDTO, and a final chat.deleted event ends the stream.
+// Message IDs are taken before their transactions commit, so a message may arrive
+// after one with a higher ID. Every event ID is therefore the highest message ID
+// delivered so far, followed by a colon and the lower IDs delivered within the
+// replay grace before it, if any (e.g. "9" or "9:7,8"). A client that reconnects
+// with the Last-Event-ID header first receives the messages it missed, replayed
+// from storage, and then the live events; the messages named in Last-Event-ID are
+// not sent again. A bare message ID is accepted as Last-Event-ID as well. Responds
+// with an error before the stream starts if the chat ID or Last-Event-ID is invalid
+// or the chat is not found.
+func (h *Handler) StreamChat(c *gin.Context) {
+
+	chatID, err := parseChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	position, resume, err := parseLastEventID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	userID := currentUserID(c)
+
+	// subscribe before replaying so that no message falls between the two
+	events, unsubscribe, err := h.service.SubscribeChat(c.Request.Context(), userID, chatID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer unsubscribe()
+
+	var missed []models.Message
+	if resume {
+		missed, err = h.service.ReplayMessages(c.Request.Context(), userID, chatID, position.AfterID)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+
+	// the stream outlives the server write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	stream := newEventStream(c, position, h.replayGrace)
+
+	for len(missed) > 0 {
+		for _, message := range missed {
+			stream.send(models.Event{Type: models.EventMessageCreated, ChatID: chatID, Message: message})
+		}
+		if missed, err = h.service.GetMessagesAfter(c.Request.Context(), userID, chatID, stream.lastID); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(sseKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			stream.send(event)
+			c.Writer.Flush()
+			if event.Type == models.EventChatDeleted {
+				return
+			}
+
+		case <-ticker.C:
+			if _, err := c.Writer.WriteString(": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+
+		case <-c.Request.Context().Done():
+			return
+
+		}
+	}
+
+}
+
+// eventStream writes chat events to an SSE response and tracks the delivered messages.
+type eventStream struct {
+	c         *gin.Context
+	grace     time.Duration    // How long before the highest delivered message a resumed stream looks for late commits
+	lastID    int              // highest message ID delivered
+	lastAt    time.Time        // creation time of message lastID
+	seen      []delivery       // lower message IDs delivered within grace before lastAt, carried in event IDs
+	delivered map[int]struct{} // IDs of the most recently delivered messages
+	recent    []int            // the same IDs in delivery order, used as a ring
+	next      int              // index in recent of the next ID to remember
+}
+
+// delivery is a message delivered to the client of an eventStream.
+type delivery struct {
+	id        int
+	createdAt time.Time
+}
+
+// newEventStream creates an eventStream resuming at the given position.
+//
+// The creation times of the messages the client already has are unknown, so they
+// are taken as now: a message is kept in event IDs for longer than needed rather
+// than sent again on the next resume.
+func newEventStream(c *gin.Context, position models.PollCursor, grace time.Duration) *eventStream {
+
+	now := time.Now()
+
+	stream := &eventStream{
+		c:         c,
+		grace:     grace,
+		lastID:    position.AfterID,
+		lastAt:    now,
+		delivered: make(map[int]struct{}, sseDeliveredWindow),
+		recent:    make([]int, 0, sseDeliveredWindow),
+	}
+
+	if position.AfterID > 0 {
+		stream.remember(position.AfterID)
+	}
+	for _, id := range position.Seen {
+		stream.remember(id)
+		stream.seen = append(stream.seen, delivery{id: id, createdAt: now})
+	}
+
+	return stream
+
+}
+
+// send writes a single event to the stream.
+//
+// Message events already delivered by this stream, e.g. both replayed and published
+// live, or named in Last-Event-ID, are skipped. Messages may be delivered out of ID
+// order, so the event ID starts with the highest message ID delivered so far, which
+// never decreases.
+func (s *eventStream) send(event models.Event) {
+
+	if event.Type == models.EventMessageCreated {
+		if _, ok := s.delivered[event.Message.ID]; ok {
+			return
+		}
+		s.remember(event.Message.ID)
+		s.track(event.Message)
+	}
+
+	s.c.Render(-1, sse.Event{
+		Id:    s.eventID(),
+		Event: string(event.Type),
+		Data:  mapEventToDTO(event),
+	})
+
+}
+
+// track records a delivered message in the position of the stream.
+//
+// Lower IDs are kept while they are within grace before the highest delivered
+// message, since a resumed stream replays those again, and at most sseSeenLimit
+// of them; older ones are dropped first.
+func (s *eventStream) track(message models.Message) {
+
+	if message.ID > s.lastID {
+		if s.lastID > 0 {
+			s.seen = append(s.seen, delivery{id: s.lastID, createdAt: s.lastAt})
+		}
+		s.lastID, s.lastAt = message.ID, message.CreatedAt
+		since := s.lastAt.Add(-s.grace)
+		s.seen = slices.DeleteFunc(s.seen, func(d delivery) bool { return d.createdAt.Before(since) })
+	} else {
+		s.seen = append(s.seen, delivery{id: message.ID, createdAt: message.CreatedAt})
+	}
+
+	if len(s.seen) > sseSeenLimit {
+		s.seen = slices.Delete(s.seen, 0, len(s.seen)-sseSeenLimit)
+	}
+
+}
+
+// eventID formats the position of the stream as SSE event ID, as parsed by parseLastEventID.
+func (s *eventStream) eventID() string {
+
+	if len(s.seen) == 0 {
+		return strconv.Itoa(s.lastID)
+	}
+
+	ids := make([]string, len(s.seen))
+	for i, d := range s.seen {
+		ids[i] = strconv.Itoa(d.id)
+	}
+
+	return strconv.Itoa(s.lastID) + ":" + strings.Join(ids, ",")
+
+}
+
+// remember records a delivered message ID, forgetting the oldest one once the window is full.
+func (s *eventStream) remember(id int) {
+
+	if len(s.recent) < sseDeliveredWindow {
+		s.recent = append(s.recent, id)
+	} else {
+		delete(s.delivered, s.recent[s.next])
+		s.recent[s.next] = id
+	}
+
+	s.delivered[id] = struct{}{}
+	s.next = (s.next + 1) % sseDeliveredWindow
+
+}
@@ -0,0 +1,112 @@
+package v1
+
+import (
+	"chatX/internal/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const wsWriteWait = 10 * time.Second     // Time allowed to write a single frame to the peer
+const wsPongWait = 60 * time.Second      // Time allowed to read the next pong from the peer
+const wsPingPeriod = wsPongWait * 9 / 10 // Interval of pings, must be shorter than wsPongWait
+const wsMaxMessageSize = 512             // Maximum size of an incoming frame; clients only send control frames
+
+// upgrader upgrades HTTP connections to the WebSocket protocol.
+// A client authenticating with a subprotocol token gets "bearer" back, as browsers require.
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024, Subprotocols: []string{bearerProtocol}}
+
+// SubscribeChat handles GET /chats/:id/ws requests.
+//
+// Upgrades the connection to WebSocket and streams every new message of the chat
+// as an EventResponseDTO JSON frame. Responds with an error before the upgrade if
+// the chat ID is invalid or the chat is not found. The connection is closed when the
+// client goes away, stops answering pings, falls behind the event stream, the chat
+// is deleted (after a final chat.deleted frame), or the server shuts down.
+func (h *Handler) SubscribeChat(c *gin.Context) {
+
+	chatID, err := parseChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	events, unsubscribe, err := h.service.SubscribeChat(c.Request.Context(), currentUserID(c), chatID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	defer unsubscribe()
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // the upgrader has already replied with an HTTP error
+	}
+	defer func() { _ = conn.Close() }()
+
+	done := make(chan struct{})
+	go readPump(conn, done)
+
+	writePump(conn, events, done)
+
+}
+
+// readPump consumes incoming frames to process pongs and detect a closed connection.
+//
+// Every pong extends the read deadline; done is closed once reading fails.
+func readPump(conn *websocket.Conn, done chan<- struct{}) {
+
+	defer close(done)
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error { return conn.SetReadDeadline(time.Now().Add(wsPongWait)) })
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+
+}
+
+// writePump writes chat events and periodic pings to the connection.
+//
+// It returns when the peer is gone, a write fails, the chat is deleted, or the event
+// channel is closed by the broker; in the last two cases a close frame is sent first.
+func writePump(conn *websocket.Conn, events <-chan models.Event, done <-chan struct{}) {
+
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+
+		case event, ok := <-events:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			if err := conn.WriteJSON(mapEventToDTO(event)); err != nil {
+				return
+			}
+			if event.Type == models.EventChatDeleted {
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "chat deleted"))
+				return
+			}
+
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+
+		case <-done:
+			return
+
+		}
+	}
+
+}
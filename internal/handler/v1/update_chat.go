@@ -0,0 +1,37 @@
+package v1
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateChat handles PATCH /chats/:id requests.
+//
+// Expects JSON body with ChatRequestDTO. Returns the renamed chat as ChatResponseDTO.
+// Responds with ErrInvalidJSON if JSON parsing fails or error if chat ID is invalid.
+func (h *Handler) UpdateChat(c *gin.Context) {
+
+	var dto ChatRequestDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		respondError(c, errs.ErrInvalidJSON)
+		return
+	}
+
+	chatID, err := parseChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	chat, err := h.service.UpdateChat(c.Request.Context(), currentUserID(c), models.Chat{ID: chatID, Title: dto.Title})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, mapChatToDTO(chat))
+
+}
@@ -0,0 +1,43 @@
+package v1
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateMessage handles PATCH /chats/:id/messages/:msgId requests.
+//
+// Expects JSON body with MessageRequestDTO. Returns the edited message as MessageResponseDTO.
+// Responds with ErrInvalidJSON if JSON parsing fails or error if chat or message ID is invalid.
+func (h *Handler) UpdateMessage(c *gin.Context) {
+
+	var dto MessageRequestDTO
+
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		respondError(c, errs.ErrInvalidJSON)
+		return
+	}
+
+	chatID, err := parseChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	messageID, err := parseMessageID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	msg, err := h.service.UpdateMessage(c.Request.Context(), currentUserID(c), models.Message{ID: messageID, ChatID: chatID, Text: dto.Text})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, mapMessageToDTO(msg))
+
+}
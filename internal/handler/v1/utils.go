@@ -4,8 +4,11 @@ import (
 	"chatX/internal/errs"
 	"chatX/internal/models"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,6 +24,147 @@ func parseChatID(c *gin.Context) (int, error) {
 	return chatID, nil
 }
 
+// parseMessageID extracts and validates the message ID from the URL path parameter.
+//
+// Returns the message ID as an integer, or ErrInvalidMessageID if the ID is invalid or non-positive.
+func parseMessageID(c *gin.Context) (int, error) {
+	messageID, err := strconv.Atoi(c.Param(msgIDKey))
+	if err != nil || messageID <= 0 {
+		return 0, errs.ErrInvalidMessageID
+	}
+	return messageID, nil
+}
+
+// parseMemberID extracts and validates the member user ID from the URL path parameter.
+//
+// Returns the user ID as an integer, or ErrInvalidUserID if the ID is invalid or non-positive.
+func parseMemberID(c *gin.Context) (int, error) {
+	userID, err := strconv.Atoi(c.Param(memberIDKey))
+	if err != nil || userID <= 0 {
+		return 0, errs.ErrInvalidUserID
+	}
+	return userID, nil
+}
+
+// parseAttachmentID extracts and validates the attachment ID from the URL path parameter.
+//
+// Returns the attachment ID as an integer, or ErrInvalidAttachmentID if the ID is invalid or non-positive.
+func parseAttachmentID(c *gin.Context) (int, error) {
+	attachmentID, err := strconv.Atoi(c.Param(attachmentIDKey))
+	if err != nil || attachmentID <= 0 {
+		return 0, errs.ErrInvalidAttachmentID
+	}
+	return attachmentID, nil
+}
+
+// parseTime extracts and parses an optional RFC 3339 timestamp from the query parameter.
+//
+// Returns the zero time if the parameter is absent, or ErrInvalidTimestamp if it cannot be parsed.
+func parseTime(c *gin.Context, key string) (time.Time, error) {
+
+	value := c.Query(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errs.ErrInvalidTimestamp
+	}
+
+	return t.UTC(), nil
+
+}
+
+// parseAfterID extracts and validates the optional after_id query parameter.
+//
+// Returns 0 if the parameter is absent, or ErrInvalidAfterID if it is not a non-negative integer.
+func parseAfterID(c *gin.Context) (int, error) {
+
+	value := c.Query(afterIDKey)
+	if value == "" {
+		return 0, nil
+	}
+
+	afterID, err := strconv.Atoi(value)
+	if err != nil || afterID < 0 {
+		return 0, errs.ErrInvalidAfterID
+	}
+
+	return afterID, nil
+
+}
+
+// parseSearchChatID extracts and validates the optional chat_id query parameter of a search.
+//
+// Returns 0 if the parameter is absent, or ErrInvalidChatID if it is not a positive integer.
+func parseSearchChatID(c *gin.Context) (int, error) {
+
+	value := c.Query(chatIDKey)
+	if value == "" {
+		return 0, nil
+	}
+
+	chatID, err := strconv.Atoi(value)
+	if err != nil || chatID <= 0 {
+		return 0, errs.ErrInvalidChatID
+	}
+
+	return chatID, nil
+
+}
+
+// parseLastEventID extracts and validates the Last-Event-ID header of a resumed SSE stream.
+//
+// The header is an event ID of the stream: the highest message ID delivered, optionally
+// followed by a colon and the lower IDs delivered shortly before it. Returns false if
+// the header is absent, or ErrInvalidEventID if it is malformed.
+func parseLastEventID(c *gin.Context) (models.PollCursor, bool, error) {
+
+	value := c.GetHeader(lastEventIDHeader)
+	if value == "" {
+		return models.PollCursor{}, false, nil
+	}
+
+	last, list, found := strings.Cut(value, ":")
+
+	lastID, err := strconv.Atoi(last)
+	if err != nil || lastID < 0 {
+		return models.PollCursor{}, false, errs.ErrInvalidEventID
+	}
+
+	position := models.PollCursor{AfterID: lastID}
+	if !found {
+		return position, true, nil
+	}
+
+	ids := strings.Split(list, ",")
+	if len(ids) > sseSeenLimit {
+		return models.PollCursor{}, false, errs.ErrInvalidEventID
+	}
+
+	for _, value := range ids {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 || id >= lastID {
+			return models.PollCursor{}, false, errs.ErrInvalidEventID
+		}
+		position.Seen = append(position.Seen, id)
+	}
+
+	return position, true, nil
+
+}
+
+// mapChatToDTO converts a models.Chat without its messages to a ChatResponseDTO.
+func mapChatToDTO(chat models.Chat) ChatResponseDTO {
+	return ChatResponseDTO{
+		ID:        chat.ID,
+		Title:     chat.Title,
+		CreatedAt: chat.CreatedAt,
+		UpdatedAt: chat.UpdatedAt,
+	}
+}
+
 // mapMessagesToDTO converts a slice of models.Message to a slice of MessageResponseDTO.
 //
 // Used to format messages for API responses.
@@ -29,18 +173,131 @@ func mapMessagesToDTO(messages []models.Message) []MessageResponseDTO {
 	msgs := make([]MessageResponseDTO, len(messages))
 
 	for i, m := range messages {
-		msgs[i] = MessageResponseDTO{
-			ID:        m.ID,
-			ChatID:    m.ChatID,
-			Text:      m.Text,
-			CreatedAt: m.CreatedAt,
-		}
+		msgs[i] = mapMessageToDTO(m)
 	}
 
 	return msgs
 
 }
 
+// mapSearchHitsToDTO converts a slice of models.SearchHit to a slice of SearchHitDTO.
+func mapSearchHitsToDTO(hits []models.SearchHit) []SearchHitDTO {
+
+	dtos := make([]SearchHitDTO, len(hits))
+
+	for i, h := range hits {
+		dtos[i] = SearchHitDTO{Message: mapMessageToDTO(h.Message), Rank: h.Rank, Snippet: h.Snippet}
+	}
+
+	return dtos
+
+}
+
+// mapMessageToDTO converts a single models.Message to a MessageResponseDTO.
+func mapMessageToDTO(message models.Message) MessageResponseDTO {
+	return MessageResponseDTO{
+		ID:          message.ID,
+		ChatID:      message.ChatID,
+		Text:        message.Text,
+		CreatedAt:   message.CreatedAt,
+		EditedAt:    message.EditedAt,
+		AuthorID:    message.AuthorID,
+		ParentID:    message.ParentID,
+		ReplyCount:  message.ReplyCount,
+		Reactions:   mapReactionsToDTO(message.Reactions),
+		Attachments: mapAttachmentsToDTO(message.ChatID, message.Attachments),
+	}
+}
+
+// mapAttachmentsToDTO converts attachments of a message of the chat to a slice of AttachmentDTO,
+// each carrying the path of its download endpoint.
+func mapAttachmentsToDTO(chatID int, attachments models.Attachments) []AttachmentDTO {
+	if len(attachments) == 0 {
+		return nil
+	}
+	dtos := make([]AttachmentDTO, len(attachments))
+	for i, attachment := range attachments {
+		dtos[i] = AttachmentDTO{
+			ID:          attachment.ID,
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+			URL:         fmt.Sprintf("/api/v1/chats/%d/attachments/%d", chatID, attachment.ID),
+		}
+	}
+	return dtos
+}
+
+// mapReactionsToDTO converts reaction counts to a slice of ReactionCountDTO, empty if there are none.
+func mapReactionsToDTO(reactions models.Reactions) []ReactionCountDTO {
+	dtos := make([]ReactionCountDTO, len(reactions))
+	for i, reaction := range reactions {
+		dtos[i] = ReactionCountDTO{Emoji: reaction.Emoji, Count: reaction.Count}
+	}
+	return dtos
+}
+
+// mapMemberToDTO converts a models.ChatMember to a MemberResponseDTO.
+func mapMemberToDTO(member models.ChatMember) MemberResponseDTO {
+	return MemberResponseDTO{
+		UserID:   member.UserID,
+		Username: member.Username,
+		Role:     string(member.Role),
+		JoinedAt: member.CreatedAt,
+	}
+}
+
+// mapMembersToDTO converts a slice of models.ChatMember to a slice of MemberResponseDTO.
+func mapMembersToDTO(members []models.ChatMember) []MemberResponseDTO {
+
+	dtos := make([]MemberResponseDTO, len(members))
+
+	for i, m := range members {
+		dtos[i] = mapMemberToDTO(m)
+	}
+
+	return dtos
+
+}
+
+// mapChatSummariesToDTO converts a slice of models.ChatSummary to a slice of ChatSummaryResponseDTO.
+//
+// Used to format chat listings for API responses.
+func mapChatSummariesToDTO(chats []models.ChatSummary) []ChatSummaryResponseDTO {
+
+	summaries := make([]ChatSummaryResponseDTO, len(chats))
+
+	for i, c := range chats {
+		summaries[i] = ChatSummaryResponseDTO{
+			ID:            c.ID,
+			Title:         c.Title,
+			CreatedAt:     c.CreatedAt,
+			MessageCount:  c.MessageCount,
+			LastMessageAt: c.LastMessageAt,
+			UnreadCount:   c.UnreadCount,
+		}
+	}
+
+	return summaries
+
+}
+
+// mapEventToDTO converts a models.Event to an EventResponseDTO.
+//
+// The message is included only for events that carry one.
+func mapEventToDTO(event models.Event) EventResponseDTO {
+
+	dto := EventResponseDTO{Type: string(event.Type), ChatID: event.ChatID}
+
+	if event.Message.ID != 0 {
+		message := mapMessageToDTO(event.Message)
+		dto.Message = &message
+	}
+
+	return dto
+
+}
+
 // respondOK sends a successful HTTP 200 response with a JSON payload.
 //
 // Wraps the response in a "result" field to maintain consistent API response format.
@@ -62,7 +319,10 @@ func respondError(c *gin.Context, err error) {
 //
 // Returns a tuple of (status code, message) based on the error type.
 //   - 400 Bad Request: validation or input errors
-//   - 404 Not Found: chat not found
+//   - 401 Unauthorized: missing or invalid token, wrong credentials
+//   - 403 Forbidden: chat role does not permit the request
+//   - 404 Not Found: chat, message, user or member not found
+//   - 409 Conflict: username already taken or user already a member
 //   - 500 Internal Server Error: all other errors
 func mapErrorToStatus(err error) (int, string) {
 
@@ -73,15 +333,66 @@ func mapErrorToStatus(err error) (int, string) {
 		errors.Is(err, errs.ErrTitleTooLong),
 		errors.Is(err, errs.ErrMessageEmpty),
 		errors.Is(err, errs.ErrMessageTooLong),
+		errors.Is(err, errs.ErrSearchQueryEmpty),
+		errors.Is(err, errs.ErrSearchQueryTooLong),
+		errors.Is(err, errs.ErrInvalidIdempotencyKey),
 		errors.Is(err, errs.ErrLimitTooSmall),
 		errors.Is(err, errs.ErrLimitTooLarge),
 		errors.Is(err, errs.ErrInvalidChatID),
-		errors.Is(err, errs.ErrInvalidLimit):
+		errors.Is(err, errs.ErrInvalidMessageID),
+		errors.Is(err, errs.ErrInvalidParent),
+		errors.Is(err, errs.ErrInvalidLimit),
+		errors.Is(err, errs.ErrInvalidCursor),
+		errors.Is(err, errs.ErrInvalidEventID),
+		errors.Is(err, errs.ErrInvalidAfterID),
+		errors.Is(err, errs.ErrInvalidWait),
+		errors.Is(err, errs.ErrWaitTooLong),
+		errors.Is(err, errs.ErrCursorConflict),
+		errors.Is(err, errs.ErrInvalidSort),
+		errors.Is(err, errs.ErrInvalidTimestamp),
+		errors.Is(err, errs.ErrInvalidTimeRange),
+		errors.Is(err, errs.ErrUsernameInvalid),
+		errors.Is(err, errs.ErrPasswordTooShort),
+		errors.Is(err, errs.ErrPasswordTooLong),
+		errors.Is(err, errs.ErrInvalidUserID),
+		errors.Is(err, errs.ErrInvalidRole),
+		errors.Is(err, errs.ErrInvalidEmoji),
+		errors.Is(err, errs.ErrInvalidForm),
+		errors.Is(err, errs.ErrInvalidAttachmentID),
+		errors.Is(err, errs.ErrTooManyAttachments):
 		return http.StatusBadRequest, err.Error()
 
-	case errors.Is(err, errs.ErrChatNotFound):
+	case errors.Is(err, errs.ErrUnauthorized),
+		errors.Is(err, errs.ErrInvalidCredentials):
+		return http.StatusUnauthorized, err.Error()
+
+	case errors.Is(err, errs.ErrForbidden):
+		return http.StatusForbidden, err.Error()
+
+	case errors.Is(err, errs.ErrChatNotFound),
+		errors.Is(err, errs.ErrMessageNotFound),
+		errors.Is(err, errs.ErrUserNotFound),
+		errors.Is(err, errs.ErrMemberNotFound),
+		errors.Is(err, errs.ErrReactionNotFound),
+		errors.Is(err, errs.ErrAttachmentNotFound):
 		return http.StatusNotFound, err.Error()
 
+	case errors.Is(err, errs.ErrAttachmentTooLarge),
+		errors.Is(err, errs.ErrBodyTooLarge):
+		return http.StatusRequestEntityTooLarge, err.Error()
+
+	case errors.Is(err, errs.ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType, err.Error()
+
+	case errors.Is(err, errs.ErrUsernameTaken),
+		errors.Is(err, errs.ErrMemberExists),
+		errors.Is(err, errs.ErrIdempotencyKeyReused),
+		errors.Is(err, errs.ErrIdempotencyKeyInProgress):
+		return http.StatusConflict, err.Error()
+
+	case errors.Is(err, errs.ErrRateLimited):
+		return http.StatusTooManyRequests, err.Error()
+
 	default:
 		return http.StatusInternalServerError, errs.ErrInternal.Error()
 	}
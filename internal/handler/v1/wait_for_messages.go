@@ -0,0 +1,45 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WaitForMessages handles GET /chats/:id/messages requests.
+//
+// Returns the messages newer than the "after_id" query parameter, by ID, or those not received
+// yet by the poll that returned the "cursor" query parameter, which takes precedence. If there
+// are none and the "wait" query parameter is set (e.g. "30s"), the request blocks until a
+// new message arrives or the wait elapses, in which case an empty list is returned.
+// Responds with MessageListResponseDTO on success or an appropriate error if the chat is
+// not found, the chat ID, after_id, cursor or wait is invalid, or other service errors occur.
+func (h *Handler) WaitForMessages(c *gin.Context) {
+
+	chatID, err := parseChatID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	afterID, err := parseAfterID(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// the wait is bounded by the service, not by the server write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	messages, cursor, err := h.service.WaitForMessages(c.Request.Context(), currentUserID(c), chatID, afterID, c.Query(cursorKey), c.Query(waitKey))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, MessageListResponseDTO{
+		Messages:   mapMessagesToDTO(messages),
+		NextCursor: cursor})
+
+}
@@ -0,0 +1,23 @@
+// Package health provides an interface and factory function
+// for checking whether the application is ready to serve traffic.
+package health
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/health/impl"
+	"chatX/internal/logger"
+	"chatX/internal/models"
+	"chatX/internal/repository"
+	"context"
+)
+
+// Checker defines the interface for the readiness checks of the application.
+type Checker interface {
+	Ready(ctx context.Context) models.Readiness // Ready runs all readiness checks and reports their outcome.
+}
+
+// NewChecker creates a new Checker that reports not ready once shutdown is closed,
+// while the database is unreachable, or while its schema is older than schemaVersion.
+func NewChecker(logger logger.Logger, config config.Server, storage repository.Storage, schemaVersion int64, shutdown <-chan struct{}) Checker {
+	return impl.NewChecker(logger, config, storage, schemaVersion, shutdown)
+}
@@ -0,0 +1,90 @@
+// Package impl provides the readiness checks of the application.
+package impl
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/errs"
+	"chatX/internal/logger"
+	"chatX/internal/models"
+	"chatX/internal/repository"
+	"context"
+	"fmt"
+)
+
+// Names of the readiness checks.
+const (
+	checkShutdown   = "shutdown"   // Shutdown has not begun
+	checkDatabase   = "database"   // Database is reachable
+	checkMigrations = "migrations" // Database schema is up to date
+)
+
+// Checker implements the health.Checker interface.
+type Checker struct {
+	storage       repository.Storage // Storage whose database is checked
+	schemaVersion int64              // Oldest acceptable schema version
+	shutdown      <-chan struct{}    // Closed once the application begins to shut down
+	config        config.Server      // Server configuration
+	logger        logger.Logger      // Logger instance
+}
+
+// NewChecker creates a new Checker instance.
+func NewChecker(logger logger.Logger, config config.Server, storage repository.Storage, schemaVersion int64, shutdown <-chan struct{}) *Checker {
+	return &Checker{storage: storage, schemaVersion: schemaVersion, shutdown: shutdown, config: config, logger: logger}
+}
+
+// Ready runs all readiness checks and reports their outcome.
+//
+// The application is not ready once shutdown has begun, so that load balancers stop
+// routing requests to it before the server stops accepting them. Otherwise it is ready
+// if the database answers within the configured timeout and its schema is at least at
+// the version this build migrated it to; a newer schema, applied by a newer instance
+// during a rolling deployment, is accepted.
+func (c *Checker) Ready(ctx context.Context) models.Readiness {
+
+	readiness := models.Readiness{Ready: true, Checks: make(map[string]string, 3)}
+
+	report := func(name string, err error) {
+		if err == nil {
+			readiness.Checks[name] = "ok"
+			return
+		}
+		readiness.Ready = false
+		readiness.Checks[name] = err.Error()
+	}
+
+	select {
+	case <-c.shutdown:
+		report(checkShutdown, errs.ErrShuttingDown)
+	default:
+		report(checkShutdown, nil)
+	}
+
+	if c.config.ReadyTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.ReadyTimeout)
+		defer cancel()
+	}
+
+	if err := c.storage.Ping(ctx); err != nil {
+		c.logger.LogWarnContext(ctx, "health — database is unreachable", "err", err.Error(), "layer", "health.impl")
+		report(checkDatabase, err)
+		report(checkMigrations, err)
+		return readiness
+	}
+
+	report(checkDatabase, nil)
+
+	version, err := c.storage.SchemaVersion(ctx)
+	switch {
+	case err != nil:
+		c.logger.LogWarnContext(ctx, "health — failed to get schema version", "err", err.Error(), "layer", "health.impl")
+		report(checkMigrations, err)
+	case version < c.schemaVersion:
+		report(checkMigrations, fmt.Errorf("%w: at version %d, expected %d", errs.ErrSchemaOutdated, version, c.schemaVersion))
+	default:
+		report(checkMigrations, nil)
+	}
+
+	return readiness
+
+}
@@ -0,0 +1,108 @@
+package impl
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/errs"
+	mockLogger "chatX/internal/logger/mocks"
+	mockStorage "chatX/internal/repository/mocks"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testSchemaVersion = 11
+
+func setupChecker(t *testing.T) (*Checker, *mockStorage.MockStorage, chan struct{}) {
+
+	controller := gomock.NewController(t)
+	logger := mockLogger.NewMockLogger(controller)
+	storage := mockStorage.NewMockStorage(controller)
+
+	logger.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogWarnContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	shutdown := make(chan struct{})
+
+	return NewChecker(logger, config.Server{ReadyTimeout: time.Second}, storage, testSchemaVersion, shutdown), storage, shutdown
+
+}
+
+func TestChecker_Ready(t *testing.T) {
+
+	checker, storage, _ := setupChecker(t)
+
+	storage.EXPECT().Ping(gomock.Any()).Return(nil)
+	storage.EXPECT().SchemaVersion(gomock.Any()).Return(int64(testSchemaVersion), nil)
+
+	readiness := checker.Ready(context.Background())
+
+	require.True(t, readiness.Ready)
+	require.Equal(t, map[string]string{checkShutdown: "ok", checkDatabase: "ok", checkMigrations: "ok"}, readiness.Checks)
+
+}
+
+func TestChecker_Ready_NewerSchemaIsAccepted(t *testing.T) {
+
+	checker, storage, _ := setupChecker(t)
+
+	storage.EXPECT().Ping(gomock.Any()).Return(nil)
+	storage.EXPECT().SchemaVersion(gomock.Any()).Return(int64(testSchemaVersion+1), nil)
+
+	require.True(t, checker.Ready(context.Background()).Ready)
+
+}
+
+func TestChecker_Ready_ShuttingDown(t *testing.T) {
+
+	checker, storage, shutdown := setupChecker(t)
+
+	storage.EXPECT().Ping(gomock.Any()).Return(nil)
+	storage.EXPECT().SchemaVersion(gomock.Any()).Return(int64(testSchemaVersion), nil)
+
+	close(shutdown)
+
+	readiness := checker.Ready(context.Background())
+
+	require.False(t, readiness.Ready)
+	require.Equal(t, errs.ErrShuttingDown.Error(), readiness.Checks[checkShutdown])
+	require.Equal(t, "ok", readiness.Checks[checkDatabase])
+
+}
+
+func TestChecker_Ready_DatabaseUnreachable(t *testing.T) {
+
+	checker, storage, _ := setupChecker(t)
+
+	storage.EXPECT().Ping(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		require.True(t, ok, "ping must be bounded by the ready timeout")
+		return errors.New("connection refused")
+	})
+	storage.EXPECT().SchemaVersion(gomock.Any()).Times(0)
+
+	readiness := checker.Ready(context.Background())
+
+	require.False(t, readiness.Ready)
+	require.Equal(t, "connection refused", readiness.Checks[checkDatabase])
+	require.Equal(t, "connection refused", readiness.Checks[checkMigrations])
+
+}
+
+func TestChecker_Ready_SchemaOutdated(t *testing.T) {
+
+	checker, storage, _ := setupChecker(t)
+
+	storage.EXPECT().Ping(gomock.Any()).Return(nil)
+	storage.EXPECT().SchemaVersion(gomock.Any()).Return(int64(testSchemaVersion-1), nil)
+
+	readiness := checker.Ready(context.Background())
+
+	require.False(t, readiness.Ready)
+	require.Equal(t, "ok", readiness.Checks[checkDatabase])
+	require.Equal(t, "database schema is older than expected: at version 10, expected 11", readiness.Checks[checkMigrations])
+
+}
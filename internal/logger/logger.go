@@ -6,6 +6,7 @@ package logger
 import (
 	"chatX/internal/config"
 	"chatX/internal/logger/slog"
+	"context"
 	"io"
 	"log"
 	"os"
@@ -14,12 +15,18 @@ import (
 )
 
 // Logger defines the interface for structured logging with different severity levels.
+//
+// The Context variants also add the trace and span IDs of the span carried by ctx to the record.
 type Logger interface {
-	LogFatal(msg string, err error, args ...any) // LogFatal logs a fatal message with an error and optional key-value arguments.
-	LogError(string, error, ...any)              // LogError logs an error message with an error and optional key-value arguments.
-	LogWarn(msg string, args ...any)             // LogWarn logs a warning message with an error and optional key-value arguments.
-	LogInfo(msg string, args ...any)             // LogInfo logs an informational message with optional key-value arguments.
-	Debug(msg string, args ...any)               // Debug logs a debug message with optional key-value arguments.
+	LogFatal(msg string, err error, args ...any)                             // LogFatal logs a fatal message with an error and optional key-value arguments.
+	LogError(string, error, ...any)                                          // LogError logs an error message with an error and optional key-value arguments.
+	LogWarn(msg string, args ...any)                                         // LogWarn logs a warning message with an error and optional key-value arguments.
+	LogInfo(msg string, args ...any)                                         // LogInfo logs an informational message with optional key-value arguments.
+	Debug(msg string, args ...any)                                           // Debug logs a debug message with optional key-value arguments.
+	LogErrorContext(ctx context.Context, msg string, err error, args ...any) // LogErrorContext logs an error message of the operation of ctx.
+	LogWarnContext(ctx context.Context, msg string, args ...any)             // LogWarnContext logs a warning message of the operation of ctx.
+	LogInfoContext(ctx context.Context, msg string, args ...any)             // LogInfoContext logs an informational message of the operation of ctx.
+	DebugContext(ctx context.Context, msg string, args ...any)               // DebugContext logs a debug message of the operation of ctx.
 }
 
 // NewLogger creates a new structured Logger instance based on the provided configuration.
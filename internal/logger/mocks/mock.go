@@ -10,6 +10,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -56,6 +57,23 @@ func (mr *MockLoggerMockRecorder) Debug(msg any, args ...any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debug", reflect.TypeOf((*MockLogger)(nil).Debug), varargs...)
 }
 
+// DebugContext mocks base method.
+func (m *MockLogger) DebugContext(ctx context.Context, msg string, args ...any) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, msg}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "DebugContext", varargs...)
+}
+
+// DebugContext indicates an expected call of DebugContext.
+func (mr *MockLoggerMockRecorder) DebugContext(ctx, msg any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, msg}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugContext", reflect.TypeOf((*MockLogger)(nil).DebugContext), varargs...)
+}
+
 // LogError mocks base method.
 func (m *MockLogger) LogError(arg0 string, arg1 error, arg2 ...any) {
 	m.ctrl.T.Helper()
@@ -73,6 +91,23 @@ func (mr *MockLoggerMockRecorder) LogError(arg0, arg1 any, arg2 ...any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogError", reflect.TypeOf((*MockLogger)(nil).LogError), varargs...)
 }
 
+// LogErrorContext mocks base method.
+func (m *MockLogger) LogErrorContext(ctx context.Context, msg string, err error, args ...any) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, msg, err}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "LogErrorContext", varargs...)
+}
+
+// LogErrorContext indicates an expected call of LogErrorContext.
+func (mr *MockLoggerMockRecorder) LogErrorContext(ctx, msg, err any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, msg, err}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogErrorContext", reflect.TypeOf((*MockLogger)(nil).LogErrorContext), varargs...)
+}
+
 // LogFatal mocks base method.
 func (m *MockLogger) LogFatal(msg string, err error, args ...any) {
 	m.ctrl.T.Helper()
@@ -107,6 +142,23 @@ func (mr *MockLoggerMockRecorder) LogInfo(msg any, args ...any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogInfo", reflect.TypeOf((*MockLogger)(nil).LogInfo), varargs...)
 }
 
+// LogInfoContext mocks base method.
+func (m *MockLogger) LogInfoContext(ctx context.Context, msg string, args ...any) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, msg}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "LogInfoContext", varargs...)
+}
+
+// LogInfoContext indicates an expected call of LogInfoContext.
+func (mr *MockLoggerMockRecorder) LogInfoContext(ctx, msg any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, msg}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogInfoContext", reflect.TypeOf((*MockLogger)(nil).LogInfoContext), varargs...)
+}
+
 // LogWarn mocks base method.
 func (m *MockLogger) LogWarn(msg string, args ...any) {
 	m.ctrl.T.Helper()
@@ -123,3 +175,20 @@ func (mr *MockLoggerMockRecorder) LogWarn(msg any, args ...any) *gomock.Call {
 	varargs := append([]any{msg}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogWarn", reflect.TypeOf((*MockLogger)(nil).LogWarn), varargs...)
 }
+
+// LogWarnContext mocks base method.
+func (m *MockLogger) LogWarnContext(ctx context.Context, msg string, args ...any) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, msg}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "LogWarnContext", varargs...)
+}
+
+// LogWarnContext indicates an expected call of LogWarnContext.
+func (mr *MockLoggerMockRecorder) LogWarnContext(ctx, msg any, args ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, msg}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogWarnContext", reflect.TypeOf((*MockLogger)(nil).LogWarnContext), varargs...)
+}
@@ -4,10 +4,13 @@ package slog
 
 import (
 	"chatX/internal/config"
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Logger wraps a slog.Logger and implements the Logger interface.
@@ -33,7 +36,7 @@ func NewLogger(config config.Logger) (*Logger, *os.File) {
 	} else {
 		level = slog.LevelInfo
 	}
-	handler := slog.NewJSONHandler(logDest, &slog.HandlerOptions{Level: level})
+	handler := traceHandler{slog.NewJSONHandler(logDest, &slog.HandlerOptions{Level: level})}
 	logger := &Logger{logger: slog.New(handler)}
 	slog.SetDefault(logger.logger)
 	return logger, logDest
@@ -86,3 +89,53 @@ func (l *Logger) LogInfo(msg string, args ...any) {
 func (l *Logger) Debug(msg string, args ...any) {
 	l.logger.Debug(msg, args...)
 }
+
+// LogErrorContext logs an error message with an optional error and the trace of ctx.
+func (l *Logger) LogErrorContext(ctx context.Context, msg string, err error, args ...any) {
+	if err != nil {
+		args = append(args, "err", err.Error())
+	}
+	slog.ErrorContext(ctx, msg, args...)
+}
+
+// LogWarnContext writes a warning-level log message with the provided fields and the trace of ctx.
+func (l *Logger) LogWarnContext(ctx context.Context, msg string, args ...any) {
+	slog.WarnContext(ctx, msg, args...)
+}
+
+// LogInfoContext logs an informational message with the trace of ctx.
+func (l *Logger) LogInfoContext(ctx context.Context, msg string, args ...any) {
+	slog.InfoContext(ctx, msg, args...)
+}
+
+// DebugContext logs a debug message with the trace of ctx.
+func (l *Logger) DebugContext(ctx context.Context, msg string, args ...any) {
+	l.logger.DebugContext(ctx, msg, args...)
+}
+
+// traceHandler is a slog.Handler that adds the trace and span IDs
+// of the span in the record context to every record.
+type traceHandler struct {
+	slog.Handler
+}
+
+// Handle adds the trace and span IDs to the record and passes it to the wrapped handler.
+func (h traceHandler) Handle(ctx context.Context, record slog.Record) error {
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", span.TraceID().String()),
+			slog.String("span_id", span.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs returns a traceHandler wrapping the handler with the given attributes.
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a traceHandler wrapping the handler with the given group.
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,25 @@
+// Package metrics provides an interface and factory function
+// for collecting application metrics and exposing them for scraping.
+package metrics
+
+import (
+	"chatX/internal/cache"
+	"chatX/internal/metrics/prometheus"
+	"chatX/internal/repository"
+	"chatX/internal/service"
+	"net/http"
+	"time"
+)
+
+// Metrics defines the interface for collecting and exposing application metrics.
+type Metrics interface {
+	ObserveRequest(method, route string, status int, latency time.Duration) // ObserveRequest records a served HTTP request by route and status.
+	ObserveStream(method, route string, status int)                         // ObserveStream records a served event stream by route and status, without its latency.
+	Handler() http.Handler                                                  // Handler serves all metrics in Prometheus text format.
+}
+
+// NewMetrics creates a new Metrics implementation that also reports
+// the usage of the cache, the connection pool of the storage and the counters of the service.
+func NewMetrics(cache cache.Cache, storage repository.Storage, service service.Service) Metrics {
+	return prometheus.NewMetrics(cache.Stats, storage.Stats, service.Stats)
+}
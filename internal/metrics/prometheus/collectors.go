@@ -0,0 +1,136 @@
+package prometheus
+
+import (
+	"chatX/internal/models"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newDesc creates the description of an application metric without variable labels.
+func newDesc(subsystem, name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, nil, nil)
+}
+
+// cacheCollector reports the usage of the chat cache.
+type cacheCollector struct {
+	stats     func() models.CacheStats // Source of the cache counters
+	hits      *prometheus.Desc         // Lookups that found a chat
+	misses    *prometheus.Desc         // Lookups that did not find a chat
+	evictions *prometheus.Desc         // Chats evicted at capacity
+	size      *prometheus.Desc         // Chats currently cached
+}
+
+// newCacheCollector creates a collector reading the given cache stats on every scrape.
+func newCacheCollector(stats func() models.CacheStats) *cacheCollector {
+	return &cacheCollector{
+		stats:     stats,
+		hits:      newDesc("cache", "hits_total", "Number of cache lookups that found a chat."),
+		misses:    newDesc("cache", "misses_total", "Number of cache lookups that did not find a chat."),
+		evictions: newDesc("cache", "evictions_total", "Number of chats evicted from the cache to make room for others."),
+		size:      newDesc("cache", "size", "Number of chats currently cached."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.size
+}
+
+// Collect implements prometheus.Collector.
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(stats.Size))
+}
+
+// dbCollector reports the connection pool statistics of the database.
+type dbCollector struct {
+	stats             func() sql.DBStats // Source of the pool statistics
+	maxOpen           *prometheus.Desc   // Maximum number of open connections
+	open              *prometheus.Desc   // Established connections
+	inUse             *prometheus.Desc   // Connections in use
+	idle              *prometheus.Desc   // Idle connections
+	waitCount         *prometheus.Desc   // Connections waited for
+	waitDuration      *prometheus.Desc   // Time spent waiting for connections
+	maxIdleClosed     *prometheus.Desc   // Connections closed due to the idle limit
+	maxIdleTimeClosed *prometheus.Desc   // Connections closed due to the idle time limit
+	maxLifetimeClosed *prometheus.Desc   // Connections closed due to the lifetime limit
+}
+
+// newDBCollector creates a collector reading the given pool statistics on every scrape.
+func newDBCollector(stats func() sql.DBStats) *dbCollector {
+	return &dbCollector{
+		stats:             stats,
+		maxOpen:           newDesc("db", "max_open_connections", "Maximum number of open connections to the database."),
+		open:              newDesc("db", "open_connections", "Number of established connections, both in use and idle."),
+		inUse:             newDesc("db", "in_use_connections", "Number of connections currently in use."),
+		idle:              newDesc("db", "idle_connections", "Number of idle connections."),
+		waitCount:         newDesc("db", "wait_count_total", "Number of connections waited for."),
+		waitDuration:      newDesc("db", "wait_duration_seconds_total", "Total time blocked waiting for a new connection."),
+		maxIdleClosed:     newDesc("db", "max_idle_closed_total", "Number of connections closed due to the maximum number of idle connections."),
+		maxIdleTimeClosed: newDesc("db", "max_idle_time_closed_total", "Number of connections closed due to the maximum idle time."),
+		maxLifetimeClosed: newDesc("db", "max_lifetime_closed_total", "Number of connections closed due to the maximum connection lifetime."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *dbCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxIdleClosed
+	ch <- c.maxIdleTimeClosed
+	ch <- c.maxLifetimeClosed
+}
+
+// Collect implements prometheus.Collector.
+func (c *dbCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(stats.MaxIdleClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxIdleTimeClosed, prometheus.CounterValue, float64(stats.MaxIdleTimeClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(stats.MaxLifetimeClosed))
+}
+
+// serviceCollector reports the business-level counters of the service.
+type serviceCollector struct {
+	stats    func() models.ServiceStats // Source of the service counters
+	chats    *prometheus.Desc           // Chats created
+	messages *prometheus.Desc           // Messages created
+}
+
+// newServiceCollector creates a collector reading the given service counters on every scrape.
+func newServiceCollector(stats func() models.ServiceStats) *serviceCollector {
+	return &serviceCollector{
+		stats:    stats,
+		chats:    newDesc("", "chats_created_total", "Number of chats created."),
+		messages: newDesc("", "messages_created_total", "Number of messages created."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *serviceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.chats
+	ch <- c.messages
+}
+
+// Collect implements prometheus.Collector.
+func (c *serviceCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.chats, prometheus.CounterValue, float64(stats.ChatsCreated))
+	ch <- prometheus.MustNewConstMetric(c.messages, prometheus.CounterValue, float64(stats.MessagesCreated))
+}
@@ -0,0 +1,79 @@
+// Package prometheus provides a Prometheus implementation of the Metrics interface.
+package prometheus
+
+import (
+	"chatX/internal/models"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the names of all application metrics.
+const namespace = "chatx"
+
+// Metrics implements the metrics.Metrics interface on a private Prometheus registry.
+//
+// HTTP requests are recorded as they are served. Cache, connection pool and service
+// counters are kept by their owners and read through the stats functions on every scrape.
+type Metrics struct {
+	registry *prometheus.Registry     // Registry of all collectors
+	requests *prometheus.CounterVec   // HTTP requests by method, route and status
+	latency  *prometheus.HistogramVec // HTTP request latency by method, route and status
+}
+
+// NewMetrics creates a new Metrics instance reading the given stats functions on every scrape.
+func NewMetrics(cache func() models.CacheStats, db func() sql.DBStats, service func() models.ServiceStats) *Metrics {
+
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests served, by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of HTTP requests, by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.latency,
+		newCacheCollector(cache),
+		newDBCollector(db),
+		newServiceCollector(service),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+
+}
+
+// ObserveRequest records a served HTTP request by route and status.
+func (m *Metrics) ObserveRequest(method, route string, status int, latency time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.latency.WithLabelValues(method, route, code).Observe(latency.Seconds())
+}
+
+// ObserveStream records a served event stream by route and status. Its latency is
+// the lifetime of the stream and would distort the latency of the other requests.
+func (m *Metrics) ObserveStream(method, route string, status int) {
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+}
+
+// Handler serves all metrics in Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,96 @@
+package prometheus
+
+import (
+	"chatX/internal/models"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setupMetrics() *Metrics {
+
+	cache := func() models.CacheStats {
+		return models.CacheStats{Hits: 7, Misses: 3, Evictions: 2, Size: 5}
+	}
+	db := func() sql.DBStats {
+		return sql.DBStats{MaxOpenConnections: 10, OpenConnections: 4, InUse: 1, Idle: 3, WaitDuration: 1500 * time.Millisecond}
+	}
+	service := func() models.ServiceStats {
+		return models.ServiceStats{ChatsCreated: 2, MessagesCreated: 11}
+	}
+
+	return NewMetrics(cache, db, service)
+
+}
+
+func scrape(t *testing.T, m *Metrics) string {
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+
+	return string(body)
+
+}
+
+func TestMetrics_ObserveRequest(t *testing.T) {
+
+	m := setupMetrics()
+
+	m.ObserveRequest(http.MethodGet, "/api/v1/chats/:id", http.StatusOK, 20*time.Millisecond)
+	m.ObserveRequest(http.MethodGet, "/api/v1/chats/:id", http.StatusOK, 30*time.Millisecond)
+	m.ObserveRequest(http.MethodPost, "/api/v1/chats/:id/messages/", http.StatusTooManyRequests, time.Millisecond)
+
+	body := scrape(t, m)
+
+	require.Contains(t, body, `chatx_http_requests_total{method="GET",route="/api/v1/chats/:id",status="200"} 2`)
+	require.Contains(t, body, `chatx_http_requests_total{method="POST",route="/api/v1/chats/:id/messages/",status="429"} 1`)
+	require.Contains(t, body, `chatx_http_request_duration_seconds_count{method="GET",route="/api/v1/chats/:id",status="200"} 2`)
+	require.Contains(t, body, `chatx_http_request_duration_seconds_bucket{method="GET",route="/api/v1/chats/:id",status="200",le="0.025"} 1`)
+
+}
+
+func TestMetrics_ObserveStream(t *testing.T) {
+
+	m := setupMetrics()
+
+	m.ObserveStream(http.MethodGet, "/api/v1/chats/:id/events", http.StatusOK)
+
+	body := scrape(t, m)
+
+	require.Contains(t, body, `chatx_http_requests_total{method="GET",route="/api/v1/chats/:id/events",status="200"} 1`)
+	require.NotContains(t, body, `chatx_http_request_duration_seconds_count{method="GET",route="/api/v1/chats/:id/events"`)
+
+}
+
+func TestMetrics_ReportsStats(t *testing.T) {
+
+	body := scrape(t, setupMetrics())
+
+	for _, line := range []string{
+		"chatx_cache_hits_total 7",
+		"chatx_cache_misses_total 3",
+		"chatx_cache_evictions_total 2",
+		"chatx_cache_size 5",
+		"chatx_db_max_open_connections 10",
+		"chatx_db_open_connections 4",
+		"chatx_db_in_use_connections 1",
+		"chatx_db_idle_connections 3",
+		"chatx_db_wait_duration_seconds_total 1.5",
+		"chatx_chats_created_total 2",
+		"chatx_messages_created_total 11",
+		"# TYPE chatx_cache_hits_total counter",
+		"# TYPE chatx_cache_size gauge",
+	} {
+		require.Contains(t, body, line)
+	}
+
+}
@@ -2,20 +2,337 @@
 // the domain entities for the chatX application.
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
 
 // Chat represents a chat conversation.
 type Chat struct {
-	ID        int       `db:"id"`         // Chat ID
-	Title     string    `db:"title"`      // Chat title
-	CreatedAt time.Time `db:"created_at"` // Chat creation timestamp
-	Messages  []Message `db:"messages"`   // Messages in this chat
+	ID          int       `db:"id"`         // Chat ID
+	Title       string    `db:"title"`      // Chat title
+	CreatedAt   time.Time `db:"created_at"` // Chat creation timestamp
+	UpdatedAt   time.Time `db:"updated_at"` // Chat last update timestamp
+	Messages    []Message `db:"messages"`   // Messages in this chat
+	UnreadCount int       `gorm:"-"`        // Number of messages the requesting user has not read yet, set by the service
+}
+
+// WithNewMessage returns a copy of the chat with a new message added to its messages,
+// which are ordered newest first, keeping at most limit of them.
+//
+// A reply also increments the reply count of its parent if the parent is among the messages.
+// A message older than all the messages of a chat already holding limit of them is left out,
+// since older messages may be missing. The messages are copied, as they may be shared.
+func (c Chat) WithNewMessage(message Message, limit int) Chat {
+
+	i := slices.IndexFunc(c.Messages, message.newerThan)
+	if i < 0 {
+		i = len(c.Messages)
+	}
+
+	messages := make([]Message, 0, len(c.Messages)+1)
+	messages = append(messages, c.Messages[:i]...)
+	if i < len(c.Messages) || len(c.Messages) < limit {
+		messages = append(messages, message)
+	}
+	messages = append(messages, c.Messages[i:]...)
+
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	if message.ParentID != nil {
+		for j := range messages {
+			if messages[j].ID == *message.ParentID {
+				messages[j].ReplyCount++
+			}
+		}
+	}
+
+	c.Messages = messages
+
+	return c
+
+}
+
+// WithEditedMessage returns a copy of the chat with the message of the same ID replaced,
+// and whether the chat holds such a message. The messages are copied, as they may be shared.
+func (c Chat) WithEditedMessage(message Message) (Chat, bool) {
+
+	i := slices.IndexFunc(c.Messages, func(m Message) bool { return m.ID == message.ID })
+	if i < 0 {
+		return c, false
+	}
+
+	c.Messages = slices.Clone(c.Messages)
+	c.Messages[i] = message
+
+	return c, true
+
 }
 
 // Message represents a single message in a chat.
 type Message struct {
-	ID        int       `db:"id"`         // Message ID
-	ChatID    int       `db:"chat_id"`    // Parent chat ID
-	Text      string    `db:"text"`       // Message text
-	CreatedAt time.Time `db:"created_at"` // Message creation timestamp
+	ID          int         `db:"id"`                              // Message ID
+	ChatID      int         `db:"chat_id"`                         // Parent chat ID
+	Text        string      `db:"text"`                            // Message text
+	CreatedAt   time.Time   `db:"created_at"`                      // Message creation timestamp
+	EditedAt    *time.Time  `db:"edited_at"`                       // Last edit timestamp, nil if the message was never edited
+	AuthorID    *int        `db:"author_id"`                       // ID of the user who wrote the message, nil for messages written before accounts existed
+	ParentID    *int        `db:"parent_id"`                       // ID of the message this one replies to, nil for messages outside threads
+	ReplyCount  int         `db:"reply_count" gorm:"->"`           // Number of replies in the thread of this message, computed on read
+	Reactions   Reactions   `db:"reactions" gorm:"->;type:json"`   // Reaction counts of this message, computed on read
+	Attachments Attachments `db:"attachments" gorm:"->;type:json"` // Files attached to this message, loaded on read and inserted along with the message
+}
+
+// newerThan reports whether the message comes before other in the newest first order of messages.
+func (m Message) newerThan(other Message) bool {
+	return m.CreatedAt.After(other.CreatedAt) || (m.CreatedAt.Equal(other.CreatedAt) && m.ID > other.ID)
+}
+
+// Attachment describes a file attached to a message; its content is kept in a blob store.
+type Attachment struct {
+	ID          int       `db:"id" json:"id"`                     // Attachment ID
+	MessageID   int       `db:"message_id" json:"message_id"`     // ID of the message the file is attached to
+	BlobKey     string    `db:"blob_key" json:"-"`                // Key of the content in the blob store
+	Filename    string    `db:"filename" json:"filename"`         // Original file name
+	ContentType string    `db:"content_type" json:"content_type"` // Media type sniffed from the content
+	Size        int64     `db:"size" json:"size"`                 // Content size in bytes
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`     // Upload timestamp
+}
+
+// Attachments lists the files attached to a message in upload order.
+type Attachments []Attachment
+
+// Scan decodes attachments aggregated by the database as a JSON array.
+func (a *Attachments) Scan(src any) error {
+	return scanJSON(src, a)
+}
+
+// Upload is a file received with a new message, not yet stored.
+type Upload struct {
+	Filename string    // File name given by the client
+	Size     int64     // Content size in bytes
+	Content  io.Reader // File content
+}
+
+// MessageReaction represents an emoji reaction of a user to a message.
+type MessageReaction struct {
+	MessageID int       `db:"message_id"` // Message ID
+	UserID    int       `db:"user_id"`    // ID of the user who reacted
+	Emoji     string    `db:"emoji"`      // Reaction emoji
+	CreatedAt time.Time `db:"created_at"` // Reaction timestamp
+}
+
+// ReactionCount is the number of users who reacted to a message with the same emoji.
+type ReactionCount struct {
+	Emoji string `json:"emoji"` // Reaction emoji
+	Count int    `json:"count"` // Number of users who reacted with the emoji
+}
+
+// Reactions lists the reaction counts of a message, most frequent first.
+type Reactions []ReactionCount
+
+// Scan decodes reaction counts aggregated by the database as a JSON array.
+func (r *Reactions) Scan(src any) error {
+	return scanJSON(src, r)
+}
+
+// scanJSON decodes a JSON database value into dst; a NULL value leaves dst untouched.
+func scanJSON(src any, dst any) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dst)
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	default:
+		return fmt.Errorf("models: cannot scan %T into %T", src, dst)
+	}
+}
+
+// Thread represents a message together with a page of its replies.
+type Thread struct {
+	Message Message   // Message that started the thread
+	Replies []Message // Replies to the message, oldest first
+}
+
+// User represents a registered account.
+type User struct {
+	ID           int       `db:"id"`            // User ID
+	Username     string    `db:"username"`      // Unique, case-insensitive login name
+	PasswordHash string    `db:"password_hash"` // bcrypt hash of the password
+	CreatedAt    time.Time `db:"created_at"`    // Registration timestamp
+}
+
+// Role is the access level of a user in a chat.
+type Role string
+
+const (
+	RoleOwner    Role = "owner"     // RoleOwner has full control of the chat, including deleting it
+	RoleAdmin    Role = "admin"     // RoleAdmin manages members and the chat title
+	RoleMember   Role = "member"    // RoleMember reads and writes messages
+	RoleReadOnly Role = "read-only" // RoleReadOnly only reads messages
+)
+
+// ChatMember represents the membership of a user in a chat.
+type ChatMember struct {
+	ChatID    int       `db:"chat_id"`    // Chat ID
+	UserID    int       `db:"user_id"`    // Member user ID
+	Username  string    `db:"username"`   // Member username, populated only in member listings
+	Role      Role      `db:"role"`       // Access level of the member
+	CreatedAt time.Time `db:"created_at"` // Timestamp when the user joined the chat
+}
+
+// ReadReceipt marks the last message of a chat a member has read.
+//
+// Messages created after the marked one and written by other users count as unread.
+type ReadReceipt struct {
+	ChatID            int       `db:"chat_id"`              // Chat ID
+	UserID            int       `db:"user_id"`              // Member user ID
+	LastReadMessageID int       `db:"last_read_message_id"` // ID of the last read message
+	LastReadAt        time.Time `db:"last_read_at"`         // Creation timestamp of the last read message
+	UpdatedAt         time.Time `db:"updated_at"`           // Timestamp when the marker last advanced
+	UnreadCount       int       `gorm:"-"`                  // Number of messages the member has not read yet, set by the service
+}
+
+// IdempotencyKey records a request made with an Idempotency-Key header and its response.
+//
+// A key is reserved before the request is processed and completed with the response
+// afterwards, so that retries of the request replay the response instead of repeating it.
+type IdempotencyKey struct {
+	UserID       int       `db:"user_id"`       // ID of the user who sent the request
+	Key          string    `db:"key"`           // Client-chosen key, unique per user
+	RequestHash  string    `db:"request_hash"`  // Hex SHA-256 of the request method, path and body
+	StatusCode   int       `db:"status_code"`   // HTTP status of the stored response, 0 while the request is in progress
+	ResponseBody []byte    `db:"response_body"` // Stored response body
+	CreatedAt    time.Time `db:"created_at"`    // Timestamp when the key was reserved
+	ExpiresAt    time.Time `db:"expires_at"`    // Timestamp after which the key may be reused and is garbage-collected
+}
+
+// Readiness reports whether the application is ready to serve traffic.
+type Readiness struct {
+	Ready  bool              // Whether all checks passed
+	Checks map[string]string // Outcome of each check by name: "ok" or the reason it failed
+}
+
+// CacheStats is a snapshot of cache usage counters.
+type CacheStats struct {
+	Hits      uint64 // Number of lookups that found a chat
+	Misses    uint64 // Number of lookups that did not find a chat
+	Evictions uint64 // Number of chats evicted to make room for others
+	Size      int    // Number of chats currently cached
+}
+
+// ServiceStats is a snapshot of business-level counters.
+type ServiceStats struct {
+	ChatsCreated    uint64 // Number of chats created since startup
+	MessagesCreated uint64 // Number of messages created since startup
+}
+
+// RateLimit reports the outcome of a rate-limited request and the state of the client's budget.
+type RateLimit struct {
+	Allowed    bool          // Whether the request may proceed
+	Limit      int           // Maximum number of requests in a burst
+	Remaining  int           // Number of requests the client may still make right away
+	RetryAfter time.Duration // Time until the next request is allowed, zero if this one was
+	Reset      time.Duration // Time until the budget is fully restored
+}
+
+// Token is a signed bearer token issued on login.
+type Token struct {
+	Value     string    // Encoded token sent in the Authorization header
+	ExpiresAt time.Time // Expiry timestamp, after which the token is rejected
+}
+
+// Direction specifies which way a page of messages extends from its cursor.
+type Direction int
+
+const (
+	Before Direction = iota // Before selects messages older than the cursor
+	After                   // After selects messages newer than the cursor
+)
+
+// Cursor identifies a position in chat history for keyset pagination.
+type Cursor struct {
+	CreatedAt time.Time // Creation timestamp of the boundary message
+	ID        int       // ID of the boundary message
+	Direction Direction // Direction in which the page extends from the boundary
+}
+
+// ChatSort specifies the order in which chats are listed.
+type ChatSort string
+
+const (
+	SortByCreatedAt    ChatSort = "created_at"    // SortByCreatedAt lists the newest chats first
+	SortByLastActivity ChatSort = "last_activity" // SortByLastActivity lists the most recently active chats first
+)
+
+// ChatFilter describes the criteria for listing chats.
+type ChatFilter struct {
+	Title         string    // Case-insensitive substring of the chat title
+	CreatedAfter  time.Time // Lower bound of the creation timestamp, ignored if zero
+	CreatedBefore time.Time // Upper bound of the creation timestamp, ignored if zero
+	Sort          ChatSort  // Listing order
+	MemberID      int       // ID of the user whose chats are listed
+}
+
+// ChatSummary represents a chat overview without its messages.
+type ChatSummary struct {
+	ID            int        `db:"id"`              // Chat ID
+	Title         string     `db:"title"`           // Chat title
+	CreatedAt     time.Time  `db:"created_at"`      // Chat creation timestamp
+	MessageCount  int        `db:"message_count"`   // Number of messages in the chat
+	LastMessageAt *time.Time `db:"last_message_at"` // Timestamp of the newest message, nil if the chat is empty
+	UnreadCount   int        `db:"unread_count"`    // Number of messages the member has not read yet
+}
+
+// SearchQuery describes a full-text search over chat messages.
+type SearchQuery struct {
+	Text     string // Search terms in web search syntax: quoted phrases, "or" and "-" to exclude
+	ChatID   int    // Chat to search in, 0 to search all chats of the member
+	MemberID int    // ID of the user whose chats are searched
+}
+
+// SearchCursor identifies a position in ranked search results for keyset pagination.
+type SearchCursor struct {
+	Rank float32 // Rank of the boundary hit
+	ID   int     // Message ID of the boundary hit
+}
+
+// PollCursor identifies the position of a long-polling reader in a chat.
+//
+// Message IDs are taken before their transactions commit, so a message may become visible
+// after one with a higher ID. Besides the highest ID delivered, the cursor therefore keeps
+// the lower IDs delivered within the replay grace before it, so that such a late message
+// is delivered once it is visible and the others are not delivered again.
+type PollCursor struct {
+	AfterID int   // Highest message ID delivered
+	Seen    []int // IDs below AfterID delivered within the replay grace before it
+}
+
+// SearchHit is a message matching a search query.
+type SearchHit struct {
+	Message Message // Matching message
+	Rank    float32 // Relevance of the message to the query; higher is better
+	Snippet string  // HTML-escaped excerpt of the text with matches wrapped in <mark> tags
+}
+
+// EventType identifies the kind of change that happened in a chat.
+type EventType string
+
+const (
+	EventMessageCreated EventType = "message.created" // EventMessageCreated is emitted after a message is persisted
+	EventChatDeleted    EventType = "chat.deleted"    // EventChatDeleted is emitted after a chat is deleted; it is the last event of the chat
+)
+
+// Event represents a change in a chat delivered to its subscribers.
+type Event struct {
+	Type    EventType // Kind of change
+	ChatID  int       // Chat in which the change happened
+	Message Message   // Affected message, if any
 }
@@ -0,0 +1,114 @@
+// Package memory provides an in-process notifier that wakes goroutines
+// waiting for new messages in a chat.
+package memory
+
+import (
+	"chatX/internal/logger"
+	"sync"
+)
+
+// waiters holds the wake-up channel shared by all waiters of a chat.
+type waiters struct {
+	ready chan struct{} // Channel closed to wake the waiters
+	count int           // Number of waiters that have not stopped waiting yet
+}
+
+// Notifier is a thread-safe in-process notifier.
+//
+// All waiters of a chat share one channel that is closed by the next Notify, so a
+// notification costs the same regardless of the number of waiters. The channel is
+// dropped once the last waiter stops waiting, keeping memory bounded by the number
+// of chats that are being waited on.
+type Notifier struct {
+	mu     sync.Mutex       // Mutex for concurrent access
+	chats  map[int]*waiters // Waiters grouped by chat ID
+	closed bool             // Whether the notifier has been closed
+	logger logger.Logger    // Logger instance
+}
+
+// NewNotifier creates a new Notifier instance with the given logger.
+func NewNotifier(logger logger.Logger) *Notifier {
+	return &Notifier{
+		chats:  make(map[int]*waiters),
+		logger: logger,
+	}
+}
+
+// Wait registers a waiter for the chat.
+//
+// It returns a channel that is closed on the next Notify for the chat or on Close,
+// and a function that must be called once the caller stops waiting. If the notifier
+// is already closed, the returned channel is closed immediately.
+func (n *Notifier) Wait(chatID int) (<-chan struct{}, func()) {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		ready := make(chan struct{})
+		close(ready)
+		return ready, func() {}
+	}
+
+	w := n.chats[chatID]
+	if w == nil {
+		w = &waiters{ready: make(chan struct{})}
+		n.chats[chatID] = w
+	}
+	w.count++
+
+	return w.ready, sync.OnceFunc(func() { n.release(chatID, w) })
+
+}
+
+// release removes a waiter from the chat and drops the chat's channel once no waiters remain.
+// It is a no-op if the channel has already been closed by Notify or Close.
+func (n *Notifier) release(chatID int, w *waiters) {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.chats[chatID] != w {
+		return
+	}
+
+	w.count--
+	if w.count == 0 {
+		delete(n.chats, chatID)
+	}
+
+}
+
+// Notify wakes all current waiters of the chat. Later waiters wait for the next notification.
+func (n *Notifier) Notify(chatID int) {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if w, ok := n.chats[chatID]; ok {
+		close(w.ready)
+		delete(n.chats, chatID)
+	}
+
+}
+
+// Close wakes all waiters and makes further waits return immediately. It is safe to call more than once.
+func (n *Notifier) Close() {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return
+	}
+
+	n.closed = true
+
+	for chatID, w := range n.chats {
+		close(w.ready)
+		delete(n.chats, chatID)
+	}
+
+	n.logger.LogInfo("notifier — all waiters released", "layer", "notifier.memory")
+
+}
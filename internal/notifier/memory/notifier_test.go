@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"chatX/internal/logger/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func setupNotifier(t *testing.T) *Notifier {
+
+	controller := gomock.NewController(t)
+	logger := mocks.NewMockLogger(controller)
+
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+
+	return NewNotifier(logger)
+
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestNotifier_Notify_WakesChatWaiters(t *testing.T) {
+
+	notifier := setupNotifier(t)
+
+	first, releaseFirst := notifier.Wait(1)
+	defer releaseFirst()
+	second, releaseSecond := notifier.Wait(1)
+	defer releaseSecond()
+	other, releaseOther := notifier.Wait(2)
+	defer releaseOther()
+
+	notifier.Notify(1)
+
+	require.True(t, isClosed(first))
+	require.True(t, isClosed(second))
+	require.False(t, isClosed(other))
+
+	next, releaseNext := notifier.Wait(1)
+	defer releaseNext()
+
+	require.False(t, isClosed(next))
+
+}
+
+func TestNotifier_Release_DropsIdleChats(t *testing.T) {
+
+	notifier := setupNotifier(t)
+
+	_, releaseFirst := notifier.Wait(1)
+	_, releaseSecond := notifier.Wait(1)
+
+	releaseFirst()
+	releaseFirst()
+	require.Len(t, notifier.chats, 1)
+
+	releaseSecond()
+	require.Empty(t, notifier.chats)
+
+	notifier.Notify(1)
+
+}
+
+func TestNotifier_Close(t *testing.T) {
+
+	notifier := setupNotifier(t)
+
+	ready, release := notifier.Wait(1)
+	defer release()
+
+	notifier.Close()
+	notifier.Close()
+
+	require.True(t, isClosed(ready))
+
+	after, releaseAfter := notifier.Wait(1)
+	defer releaseAfter()
+
+	require.True(t, isClosed(after))
+
+}
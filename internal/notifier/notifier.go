@@ -0,0 +1,20 @@
+// Package notifier provides an interface and factory function
+// for waking in-process waiters when new messages arrive in a chat.
+package notifier
+
+import (
+	"chatX/internal/logger"
+	"chatX/internal/notifier/memory"
+)
+
+// Notifier defines the interface for waiting on new messages of a chat.
+type Notifier interface {
+	Wait(chatID int) (<-chan struct{}, func()) // Wait returns a channel closed on the next Notify for the chat or on Close, and a function to stop waiting.
+	Notify(chatID int)                         // Notify wakes all current waiters of the chat.
+	Close()                                    // Close wakes all waiters and makes further waits return immediately.
+}
+
+// NewNotifier creates a new Notifier implementation.
+func NewNotifier(logger logger.Logger) Notifier {
+	return memory.NewNotifier(logger)
+}
@@ -0,0 +1,114 @@
+// Package memory provides an in-process token bucket rate limiter.
+package memory
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/logger"
+	"chatX/internal/models"
+	"math"
+	"sync"
+	"time"
+)
+
+// bucket holds the tokens left to a client.
+type bucket struct {
+	tokens  float64   // Tokens left as of updated
+	updated time.Time // Timestamp of the last refill
+}
+
+// Limiter is a thread-safe token bucket rate limiter.
+//
+// Every client has a bucket of Burst tokens that refills at Rate tokens per second, and
+// each request takes one token. A bucket left idle long enough to refill completely is
+// indistinguishable from a new one, so such buckets are evicted by a sweep that runs at
+// most once per refill period, keeping memory bounded by the number of recently active clients.
+type Limiter struct {
+	mu      sync.Mutex         // Mutex for concurrent access
+	buckets map[string]*bucket // Buckets by client key
+	rate    float64            // Tokens added per second
+	burst   float64            // Bucket capacity
+	refill  time.Duration      // Time an empty bucket takes to refill completely
+	swept   time.Time          // Timestamp of the last sweep of idle buckets
+	now     func() time.Time   // Clock, replaced in tests
+	logger  logger.Logger      // Logger instance
+}
+
+// NewLimiter creates a new Limiter with the given budget.
+//
+// The rate and burst of the budget must be positive.
+func NewLimiter(logger logger.Logger, config config.RateBudget) *Limiter {
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+		rate:    config.Rate,
+		burst:   float64(config.Burst),
+		refill:  time.Duration(float64(config.Burst) / config.Rate * float64(time.Second)),
+		swept:   time.Now(),
+		now:     time.Now,
+		logger:  logger,
+	}
+}
+
+// Allow takes a token from the bucket of the client identified by key.
+//
+// The request may proceed if a token was available. Otherwise the returned status tells
+// how long the client has to wait for the next token.
+func (l *Limiter) Allow(key string) models.RateLimit {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	if now.Sub(l.swept) >= l.refill {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	status := models.RateLimit{Limit: int(l.burst)}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		status.Allowed = true
+	} else {
+		status.RetryAfter = l.timeFor(1 - b.tokens)
+	}
+
+	status.Remaining = int(b.tokens)
+	status.Reset = l.timeFor(l.burst - b.tokens)
+
+	return status
+
+}
+
+// sweep evicts the buckets that have been idle long enough to refill completely.
+func (l *Limiter) sweep(now time.Time) {
+
+	evicted := 0
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= l.refill {
+			delete(l.buckets, key)
+			evicted++
+		}
+	}
+
+	l.swept = now
+
+	if evicted > 0 {
+		l.logger.Debug("rate limiter — evicted idle buckets", "count", evicted, "remaining", len(l.buckets), "layer", "ratelimit.memory")
+	}
+
+}
+
+// timeFor returns how long the bucket takes to gain the given number of tokens.
+func (l *Limiter) timeFor(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/logger/mocks"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// clock is a manually advanced time source.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func setupLimiter(t *testing.T, rate float64, burst int) (*Limiter, *clock) {
+
+	controller := gomock.NewController(t)
+	logger := mocks.NewMockLogger(controller)
+
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+
+	clock := &clock{now: time.Unix(1_700_000_000, 0)}
+
+	limiter := NewLimiter(logger, config.RateBudget{Rate: rate, Burst: burst})
+	limiter.now = clock.Now
+	limiter.swept = clock.now
+
+	return limiter, clock
+
+}
+
+func TestLimiter_Allow_SpendsBurstThenRejects(t *testing.T) {
+
+	limiter, _ := setupLimiter(t, 1, 3)
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		status := limiter.Allow("a")
+		require.True(t, status.Allowed)
+		require.Equal(t, 3, status.Limit)
+		require.Equal(t, remaining, status.Remaining)
+		require.Zero(t, status.RetryAfter)
+	}
+
+	status := limiter.Allow("a")
+	require.False(t, status.Allowed)
+	require.Equal(t, 0, status.Remaining)
+	require.Equal(t, time.Second, status.RetryAfter)
+	require.Equal(t, 3*time.Second, status.Reset)
+
+}
+
+func TestLimiter_Allow_RefillsOverTime(t *testing.T) {
+
+	limiter, clock := setupLimiter(t, 2, 2)
+
+	require.True(t, limiter.Allow("a").Allowed)
+	require.True(t, limiter.Allow("a").Allowed)
+	require.False(t, limiter.Allow("a").Allowed)
+
+	clock.Advance(250 * time.Millisecond)
+	status := limiter.Allow("a")
+	require.False(t, status.Allowed)
+	require.Equal(t, 250*time.Millisecond, status.RetryAfter)
+
+	clock.Advance(250 * time.Millisecond)
+	require.True(t, limiter.Allow("a").Allowed)
+
+	clock.Advance(time.Hour)
+	status = limiter.Allow("a")
+	require.True(t, status.Allowed)
+	require.Equal(t, 1, status.Remaining, "refill must be capped at burst")
+
+}
+
+func TestLimiter_Allow_KeysAreIndependent(t *testing.T) {
+
+	limiter, _ := setupLimiter(t, 1, 1)
+
+	require.True(t, limiter.Allow("a").Allowed)
+	require.False(t, limiter.Allow("a").Allowed)
+	require.True(t, limiter.Allow("b").Allowed)
+
+}
+
+func TestLimiter_Allow_EvictsIdleBuckets(t *testing.T) {
+
+	limiter, clock := setupLimiter(t, 1, 2)
+
+	limiter.Allow("idle")
+	clock.Advance(time.Second)
+	limiter.Allow("active")
+	require.Len(t, limiter.buckets, 2)
+
+	// The idle bucket is full again after two seconds, the active one is not.
+	clock.Advance(time.Second)
+	limiter.Allow("active")
+	require.Len(t, limiter.buckets, 1)
+	require.Contains(t, limiter.buckets, "active")
+
+	// An evicted client starts over with a full bucket.
+	status := limiter.Allow("idle")
+	require.True(t, status.Allowed)
+	require.Equal(t, 1, status.Remaining)
+
+}
+
+func TestLimiter_Allow_Concurrent(t *testing.T) {
+
+	limiter, _ := setupLimiter(t, 1, 100)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limiter.Allow("a").Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	require.Equal(t, 100, allowed)
+
+}
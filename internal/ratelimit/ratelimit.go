@@ -0,0 +1,20 @@
+// Package ratelimit provides an interface and factory function
+// for limiting the request rate of individual clients.
+package ratelimit
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/logger"
+	"chatX/internal/models"
+	"chatX/internal/ratelimit/memory"
+)
+
+// Limiter defines the interface for a per-client request rate limiter.
+type Limiter interface {
+	Allow(key string) models.RateLimit // Allow takes a token from the budget of the client identified by key and reports whether the request may proceed.
+}
+
+// NewLimiter creates a new Limiter implementation with the given budget.
+func NewLimiter(logger logger.Logger, config config.RateBudget) Limiter {
+	return memory.NewLimiter(logger, config)
+}
@@ -12,7 +12,9 @@ package mocks
 import (
 	models "chatX/internal/models"
 	context "context"
+	sql "database/sql"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,6 +43,34 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// AddMember mocks base method.
+func (m *MockStorage) AddMember(ctx context.Context, member *models.ChatMember) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMember", ctx, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddMember indicates an expected call of AddMember.
+func (mr *MockStorageMockRecorder) AddMember(ctx, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMember", reflect.TypeOf((*MockStorage)(nil).AddMember), ctx, member)
+}
+
+// AddReaction mocks base method.
+func (m *MockStorage) AddReaction(ctx context.Context, reaction *models.MessageReaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReaction", ctx, reaction)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddReaction indicates an expected call of AddReaction.
+func (mr *MockStorageMockRecorder) AddReaction(ctx, reaction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReaction", reflect.TypeOf((*MockStorage)(nil).AddReaction), ctx, reaction)
+}
+
 // Close mocks base method.
 func (m *MockStorage) Close() {
 	m.ctrl.T.Helper()
@@ -53,18 +83,47 @@ func (mr *MockStorageMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStorage)(nil).Close))
 }
 
+// CompleteIdempotencyKey mocks base method.
+func (m *MockStorage) CompleteIdempotencyKey(ctx context.Context, key models.IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteIdempotencyKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteIdempotencyKey indicates an expected call of CompleteIdempotencyKey.
+func (mr *MockStorageMockRecorder) CompleteIdempotencyKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotencyKey", reflect.TypeOf((*MockStorage)(nil).CompleteIdempotencyKey), ctx, key)
+}
+
+// CountUnread mocks base method.
+func (m *MockStorage) CountUnread(ctx context.Context, chatID, userID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnread", ctx, chatID, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnread indicates an expected call of CountUnread.
+func (mr *MockStorageMockRecorder) CountUnread(ctx, chatID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnread", reflect.TypeOf((*MockStorage)(nil).CountUnread), ctx, chatID, userID)
+}
+
 // CreateChat mocks base method.
-func (m *MockStorage) CreateChat(ctx context.Context, chat *models.Chat) error {
+func (m *MockStorage) CreateChat(ctx context.Context, chat *models.Chat, ownerID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateChat", ctx, chat)
+	ret := m.ctrl.Call(m, "CreateChat", ctx, chat, ownerID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateChat indicates an expected call of CreateChat.
-func (mr *MockStorageMockRecorder) CreateChat(ctx, chat any) *gomock.Call {
+func (mr *MockStorageMockRecorder) CreateChat(ctx, chat, ownerID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChat", reflect.TypeOf((*MockStorage)(nil).CreateChat), ctx, chat)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChat", reflect.TypeOf((*MockStorage)(nil).CreateChat), ctx, chat, ownerID)
 }
 
 // CreateMessage mocks base method.
@@ -81,31 +140,368 @@ func (mr *MockStorageMockRecorder) CreateMessage(ctx, message any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockStorage)(nil).CreateMessage), ctx, message)
 }
 
-// DeleteChat mocks base method.
-func (m *MockStorage) DeleteChat(ctx context.Context, chatID int) error {
+// CreateUser mocks base method.
+func (m *MockStorage) CreateUser(ctx context.Context, user *models.User) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteChat", ctx, chatID)
+	ret := m.ctrl.Call(m, "CreateUser", ctx, user)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStorageMockRecorder) CreateUser(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStorage)(nil).CreateUser), ctx, user)
+}
+
+// DeleteChat mocks base method.
+func (m *MockStorage) DeleteChat(ctx context.Context, chatID int) (models.Attachments, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteChat", ctx, chatID)
+	ret0, _ := ret[0].(models.Attachments)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // DeleteChat indicates an expected call of DeleteChat.
 func (mr *MockStorageMockRecorder) DeleteChat(ctx, chatID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChat", reflect.TypeOf((*MockStorage)(nil).DeleteChat), ctx, chatID)
 }
 
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockStorage) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys", ctx, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockStorageMockRecorder) DeleteExpiredIdempotencyKeys(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockStorage)(nil).DeleteExpiredIdempotencyKeys), ctx, now)
+}
+
+// DeleteMessage mocks base method.
+func (m *MockStorage) DeleteMessage(ctx context.Context, chatID, messageID int) (models.Attachments, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMessage", ctx, chatID, messageID)
+	ret0, _ := ret[0].(models.Attachments)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMessage indicates an expected call of DeleteMessage.
+func (mr *MockStorageMockRecorder) DeleteMessage(ctx, chatID, messageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMessage", reflect.TypeOf((*MockStorage)(nil).DeleteMessage), ctx, chatID, messageID)
+}
+
+// GetAttachment mocks base method.
+func (m *MockStorage) GetAttachment(ctx context.Context, chatID, attachmentID int) (models.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttachment", ctx, chatID, attachmentID)
+	ret0, _ := ret[0].(models.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttachment indicates an expected call of GetAttachment.
+func (mr *MockStorageMockRecorder) GetAttachment(ctx, chatID, attachmentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttachment", reflect.TypeOf((*MockStorage)(nil).GetAttachment), ctx, chatID, attachmentID)
+}
+
 // GetChat mocks base method.
-func (m *MockStorage) GetChat(ctx context.Context, chatID, limit int) (models.Chat, error) {
+func (m *MockStorage) GetChat(ctx context.Context, chatID, limit int, cursor *models.Cursor) (models.Chat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetChat", ctx, chatID, limit)
+	ret := m.ctrl.Call(m, "GetChat", ctx, chatID, limit, cursor)
 	ret0, _ := ret[0].(models.Chat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetChat indicates an expected call of GetChat.
-func (mr *MockStorageMockRecorder) GetChat(ctx, chatID, limit any) *gomock.Call {
+func (mr *MockStorageMockRecorder) GetChat(ctx, chatID, limit, cursor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChat", reflect.TypeOf((*MockStorage)(nil).GetChat), ctx, chatID, limit, cursor)
+}
+
+// GetMember mocks base method.
+func (m *MockStorage) GetMember(ctx context.Context, chatID, userID int) (models.ChatMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMember", ctx, chatID, userID)
+	ret0, _ := ret[0].(models.ChatMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMember indicates an expected call of GetMember.
+func (mr *MockStorageMockRecorder) GetMember(ctx, chatID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMember", reflect.TypeOf((*MockStorage)(nil).GetMember), ctx, chatID, userID)
+}
+
+// GetMessage mocks base method.
+func (m *MockStorage) GetMessage(ctx context.Context, chatID, messageID int) (models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessage", ctx, chatID, messageID)
+	ret0, _ := ret[0].(models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessage indicates an expected call of GetMessage.
+func (mr *MockStorageMockRecorder) GetMessage(ctx, chatID, messageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessage", reflect.TypeOf((*MockStorage)(nil).GetMessage), ctx, chatID, messageID)
+}
+
+// GetMessagesAfter mocks base method.
+func (m *MockStorage) GetMessagesAfter(ctx context.Context, chatID, afterID, limit int) ([]models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessagesAfter", ctx, chatID, afterID, limit)
+	ret0, _ := ret[0].([]models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessagesAfter indicates an expected call of GetMessagesAfter.
+func (mr *MockStorageMockRecorder) GetMessagesAfter(ctx, chatID, afterID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessagesAfter", reflect.TypeOf((*MockStorage)(nil).GetMessagesAfter), ctx, chatID, afterID, limit)
+}
+
+// GetMessagesSince mocks base method.
+func (m *MockStorage) GetMessagesSince(ctx context.Context, chatID, afterID int, grace time.Duration, limit int) ([]models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessagesSince", ctx, chatID, afterID, grace, limit)
+	ret0, _ := ret[0].([]models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessagesSince indicates an expected call of GetMessagesSince.
+func (mr *MockStorageMockRecorder) GetMessagesSince(ctx, chatID, afterID, grace, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessagesSince", reflect.TypeOf((*MockStorage)(nil).GetMessagesSince), ctx, chatID, afterID, grace, limit)
+}
+
+// GetReplies mocks base method.
+func (m *MockStorage) GetReplies(ctx context.Context, chatID, parentID, limit int, cursor *models.Cursor) ([]models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReplies", ctx, chatID, parentID, limit, cursor)
+	ret0, _ := ret[0].([]models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReplies indicates an expected call of GetReplies.
+func (mr *MockStorageMockRecorder) GetReplies(ctx, chatID, parentID, limit, cursor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReplies", reflect.TypeOf((*MockStorage)(nil).GetReplies), ctx, chatID, parentID, limit, cursor)
+}
+
+// GetUserByUsername mocks base method.
+func (m *MockStorage) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUsername", ctx, username)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUsername indicates an expected call of GetUserByUsername.
+func (mr *MockStorageMockRecorder) GetUserByUsername(ctx, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockStorage)(nil).GetUserByUsername), ctx, username)
+}
+
+// ListChats mocks base method.
+func (m *MockStorage) ListChats(ctx context.Context, filter models.ChatFilter, limit int, cursor *models.Cursor) ([]models.ChatSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChats", ctx, filter, limit, cursor)
+	ret0, _ := ret[0].([]models.ChatSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListChats indicates an expected call of ListChats.
+func (mr *MockStorageMockRecorder) ListChats(ctx, filter, limit, cursor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChats", reflect.TypeOf((*MockStorage)(nil).ListChats), ctx, filter, limit, cursor)
+}
+
+// ListMembers mocks base method.
+func (m *MockStorage) ListMembers(ctx context.Context, chatID int) ([]models.ChatMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMembers", ctx, chatID)
+	ret0, _ := ret[0].([]models.ChatMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMembers indicates an expected call of ListMembers.
+func (mr *MockStorageMockRecorder) ListMembers(ctx, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMembers", reflect.TypeOf((*MockStorage)(nil).ListMembers), ctx, chatID)
+}
+
+// MarkRead mocks base method.
+func (m *MockStorage) MarkRead(ctx context.Context, receipt *models.ReadReceipt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", ctx, receipt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockStorageMockRecorder) MarkRead(ctx, receipt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockStorage)(nil).MarkRead), ctx, receipt)
+}
+
+// Ping mocks base method.
+func (m *MockStorage) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockStorageMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStorage)(nil).Ping), ctx)
+}
+
+// ReleaseIdempotencyKey mocks base method.
+func (m *MockStorage) ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseIdempotencyKey", ctx, userID, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseIdempotencyKey indicates an expected call of ReleaseIdempotencyKey.
+func (mr *MockStorageMockRecorder) ReleaseIdempotencyKey(ctx, userID, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdempotencyKey", reflect.TypeOf((*MockStorage)(nil).ReleaseIdempotencyKey), ctx, userID, key)
+}
+
+// RemoveMember mocks base method.
+func (m *MockStorage) RemoveMember(ctx context.Context, chatID, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", ctx, chatID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockStorageMockRecorder) RemoveMember(ctx, chatID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*MockStorage)(nil).RemoveMember), ctx, chatID, userID)
+}
+
+// RemoveReaction mocks base method.
+func (m *MockStorage) RemoveReaction(ctx context.Context, chatID int, reaction models.MessageReaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveReaction", ctx, chatID, reaction)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveReaction indicates an expected call of RemoveReaction.
+func (mr *MockStorageMockRecorder) RemoveReaction(ctx, chatID, reaction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveReaction", reflect.TypeOf((*MockStorage)(nil).RemoveReaction), ctx, chatID, reaction)
+}
+
+// ReserveIdempotencyKey mocks base method.
+func (m *MockStorage) ReserveIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveIdempotencyKey", ctx, key)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveIdempotencyKey indicates an expected call of ReserveIdempotencyKey.
+func (mr *MockStorageMockRecorder) ReserveIdempotencyKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveIdempotencyKey", reflect.TypeOf((*MockStorage)(nil).ReserveIdempotencyKey), ctx, key)
+}
+
+// SchemaVersion mocks base method.
+func (m *MockStorage) SchemaVersion(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SchemaVersion", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SchemaVersion indicates an expected call of SchemaVersion.
+func (mr *MockStorageMockRecorder) SchemaVersion(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchemaVersion", reflect.TypeOf((*MockStorage)(nil).SchemaVersion), ctx)
+}
+
+// SearchMessages mocks base method.
+func (m *MockStorage) SearchMessages(ctx context.Context, query models.SearchQuery, limit int, cursor *models.SearchCursor) ([]models.SearchHit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchMessages", ctx, query, limit, cursor)
+	ret0, _ := ret[0].([]models.SearchHit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchMessages indicates an expected call of SearchMessages.
+func (mr *MockStorageMockRecorder) SearchMessages(ctx, query, limit, cursor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchMessages", reflect.TypeOf((*MockStorage)(nil).SearchMessages), ctx, query, limit, cursor)
+}
+
+// Stats mocks base method.
+func (m *MockStorage) Stats() sql.DBStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(sql.DBStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockStorageMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockStorage)(nil).Stats))
+}
+
+// UpdateChat mocks base method.
+func (m *MockStorage) UpdateChat(ctx context.Context, chat *models.Chat) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateChat", ctx, chat)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateChat indicates an expected call of UpdateChat.
+func (mr *MockStorageMockRecorder) UpdateChat(ctx, chat any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateChat", reflect.TypeOf((*MockStorage)(nil).UpdateChat), ctx, chat)
+}
+
+// UpdateMessage mocks base method.
+func (m *MockStorage) UpdateMessage(ctx context.Context, message *models.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMessage", ctx, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMessage indicates an expected call of UpdateMessage.
+func (mr *MockStorageMockRecorder) UpdateMessage(ctx, message any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChat", reflect.TypeOf((*MockStorage)(nil).GetChat), ctx, chatID, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMessage", reflect.TypeOf((*MockStorage)(nil).UpdateMessage), ctx, message)
 }
@@ -0,0 +1,11 @@
+package postgres
+
+import (
+	"chatX/internal/models"
+	"context"
+)
+
+// AddMember inserts a new chat membership record into the database.
+func (s *Storage) AddMember(ctx context.Context, member *models.ChatMember) error {
+	return s.db.WithContext(ctx).Omit("Username").Create(member).Error
+}
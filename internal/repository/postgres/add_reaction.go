@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"chatX/internal/models"
+	"context"
+
+	"gorm.io/gorm/clause"
+)
+
+// AddReaction inserts a reaction to a message into the database.
+//
+// Adding a reaction the user has already made is a no-op.
+func (s *Storage) AddReaction(ctx context.Context, reaction *models.MessageReaction) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(reaction).Error
+}
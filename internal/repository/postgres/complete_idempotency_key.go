@@ -0,0 +1,13 @@
+package postgres
+
+import (
+	"chatX/internal/models"
+	"context"
+)
+
+// CompleteIdempotencyKey stores the response to the request of a reserved idempotency key.
+func (s *Storage) CompleteIdempotencyKey(ctx context.Context, key models.IdempotencyKey) error {
+	return s.db.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND key = ?", key.UserID, key.Key).
+		Updates(map[string]any{"status_code": key.StatusCode, "response_body": key.ResponseBody}).Error
+}
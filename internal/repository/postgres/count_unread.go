@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"context"
+)
+
+// unreadCondition matches the messages created after the read marker joined as read_receipts
+// and written by someone other than the member given as its parameter. Without a marker every
+// message counts. The created_at range lets Postgres scan the (chat_id, created_at) index, while
+// the id comparison breaks ties between messages sharing the marker's timestamp.
+const unreadCondition = "messages.created_at >= COALESCE(read_receipts.last_read_at, '-infinity') " +
+	"AND (messages.created_at > COALESCE(read_receipts.last_read_at, '-infinity') OR messages.id > read_receipts.last_read_message_id) " +
+	"AND messages.author_id IS DISTINCT FROM ?"
+
+// readReceiptJoin joins the read marker of the member given as its parameter to the chats
+// of the outer query; the marker columns are NULL if the member has not read anything yet.
+const readReceiptJoin = "LEFT JOIN read_receipts ON read_receipts.chat_id = chats.id AND read_receipts.user_id = ?"
+
+// CountUnread counts the messages of a chat the member has not read yet.
+func (s *Storage) CountUnread(ctx context.Context, chatID int, userID int) (int, error) {
+
+	var count int64
+
+	err := s.db.WithContext(ctx).Table("chats").
+		Joins(readReceiptJoin, userID).
+		Joins("JOIN messages ON messages.chat_id = chats.id").
+		Where("chats.id = ?", chatID).
+		Where(unreadCondition, userID).
+		Count(&count).Error
+
+	return int(count), err
+
+}
@@ -3,9 +3,23 @@ package postgres
 import (
 	"chatX/internal/models"
 	"context"
+
+	"gorm.io/gorm"
 )
 
 // CreateChat inserts a new chat record into the database.
-func (s *Storage) CreateChat(ctx context.Context, chat *models.Chat) error {
-	return s.db.WithContext(ctx).Create(chat).Error
+//
+// The chat and the membership of its owner are inserted in one transaction,
+// so a chat never exists without an owner.
+func (s *Storage) CreateChat(ctx context.Context, chat *models.Chat, ownerID int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		if err := tx.Create(chat).Error; err != nil {
+			return err
+		}
+
+		owner := models.ChatMember{ChatID: chat.ID, UserID: ownerID, Role: models.RoleOwner, CreatedAt: chat.CreatedAt}
+		return tx.Omit("Username").Create(&owner).Error
+
+	})
 }
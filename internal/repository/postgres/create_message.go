@@ -3,9 +3,33 @@ package postgres
 import (
 	"chatX/internal/models"
 	"context"
+
+	"gorm.io/gorm"
 )
 
 // CreateMessage inserts a new message record into the database.
+//
+// Attachments of the message are inserted in the same transaction and
+// receive their IDs and the ID of the message.
 func (s *Storage) CreateMessage(ctx context.Context, message *models.Message) error {
-	return s.db.WithContext(ctx).Create(message).Error
+
+	if len(message.Attachments) == 0 {
+		return s.db.WithContext(ctx).Create(message).Error
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+
+		attachments := []models.Attachment(message.Attachments)
+		for i := range attachments {
+			attachments[i].MessageID = message.ID
+		}
+
+		return tx.Create(&attachments).Error
+
+	})
+
 }
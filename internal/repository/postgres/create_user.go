@@ -0,0 +1,11 @@
+package postgres
+
+import (
+	"chatX/internal/models"
+	"context"
+)
+
+// CreateUser inserts a new user record into the database.
+func (s *Storage) CreateUser(ctx context.Context, user *models.User) error {
+	return s.db.WithContext(ctx).Create(user).Error
+}
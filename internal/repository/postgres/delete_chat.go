@@ -4,20 +4,54 @@ import (
 	"chatX/internal/errs"
 	"chatX/internal/models"
 	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // DeleteChat deletes a chat and its associated messages from the database by chat ID.
-func (s *Storage) DeleteChat(ctx context.Context, chatID int) error {
+//
+// The attachments of the messages are deleted along with them and returned, so
+// that the caller removes their blobs once the deletion has committed.
+func (s *Storage) DeleteChat(ctx context.Context, chatID int) (models.Attachments, error) {
 
-	result := s.db.WithContext(ctx).Delete(&models.Chat{}, chatID)
-	if result.Error != nil {
-		return result.Error
-	}
+	var attachments models.Attachments
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		if err := lockChat(tx, chatID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errs.ErrChatNotFound
+			}
+			return err
+		}
 
-	if result.RowsAffected == 0 {
-		return errs.ErrChatNotFound
+		if err := tx.Select("attachments.*").
+			Joins("JOIN messages ON messages.id = attachments.message_id").
+			Where("messages.chat_id = ?", chatID).
+			Order("attachments.id").
+			Find(&attachments).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&models.Chat{}, chatID).Error
+
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return attachments, nil
+
+}
 
+// lockChat locks the row of a chat until the end of the transaction.
+//
+// The message triggers update the same row, so no message is written to the
+// chat between reading the attachments of messages and deleting them. Returns
+// gorm.ErrRecordNotFound if there is no such chat.
+func lockChat(tx *gorm.DB, chatID int) error {
+	var chat models.Chat
+	return tx.Clauses(clause.Locking{Strength: "NO KEY UPDATE"}).Select("id").Take(&chat, chatID).Error
 }
@@ -0,0 +1,14 @@
+package postgres
+
+import (
+	"chatX/internal/models"
+	"context"
+	"time"
+)
+
+// DeleteExpiredIdempotencyKeys deletes the idempotency keys that expired before now,
+// using the expires_at index, and returns how many were deleted.
+func (s *Storage) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
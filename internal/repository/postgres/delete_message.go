@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// DeleteMessage deletes a message from the database by chat ID and message ID.
+//
+// Replies to the message and the attachments of both are deleted along with it;
+// the attachments are returned so that the caller removes their blobs once the
+// deletion has committed.
+func (s *Storage) DeleteMessage(ctx context.Context, chatID int, messageID int) (models.Attachments, error) {
+
+	var attachments models.Attachments
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		if err := lockChat(tx, chatID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errs.ErrMessageNotFound
+			}
+			return err
+		}
+
+		// threads are one level deep, so the replies are the messages with this parent
+		if err := tx.Select("attachments.*").
+			Joins("JOIN messages ON messages.id = attachments.message_id").
+			Where("messages.chat_id = ? AND (messages.id = ? OR messages.parent_id = ?)", chatID, messageID, messageID).
+			Order("attachments.id").
+			Find(&attachments).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("chat_id = ?", chatID).Delete(&models.Message{}, messageID)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return errs.ErrMessageNotFound
+		}
+
+		return nil
+
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+
+}
@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// GetAttachment retrieves an attachment of a message of the given chat.
+//
+// Returns ErrAttachmentNotFound if no message of the chat has such an attachment.
+func (s *Storage) GetAttachment(ctx context.Context, chatID int, attachmentID int) (models.Attachment, error) {
+
+	var attachment models.Attachment
+
+	if err := s.db.WithContext(ctx).Select("attachments.*").
+		Joins("JOIN messages ON messages.id = attachments.message_id").
+		Where("messages.chat_id = ?", chatID).
+		Take(&attachment, "attachments.id = ?", attachmentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Attachment{}, errs.ErrAttachmentNotFound
+		}
+		return models.Attachment{}, err
+	}
+
+	return attachment, nil
+
+}
@@ -5,30 +5,67 @@ import (
 	"chatX/internal/models"
 	"context"
 	"errors"
+	"slices"
 
 	"gorm.io/gorm"
 )
 
-const order = "created_at DESC" // order defines the default sorting order for messages: newest first.
+const order = "created_at DESC, id DESC" // order defines the default sorting order for messages: newest first.
+const reverseOrder = "created_at, id"    // reverseOrder defines the sorting order for pages after a cursor: oldest first.
+
+// messageColumns selects message fields together with the number of replies in the thread of each message,
+// its reaction counts aggregated into a JSON array ordered by count, and its attachments in upload order.
+const messageColumns = "messages.*, " +
+	"(SELECT COUNT(*) FROM messages AS replies WHERE replies.parent_id = messages.id) AS reply_count, " +
+	"(SELECT COALESCE(json_agg(json_build_object('emoji', counts.emoji, 'count', counts.count) ORDER BY counts.count DESC, counts.emoji), '[]') " +
+	"FROM (SELECT emoji, COUNT(*) AS count FROM message_reactions WHERE message_reactions.message_id = messages.id GROUP BY emoji) AS counts) AS reactions, " +
+	"(SELECT COALESCE(json_agg(json_build_object('id', attachments.id, 'message_id', attachments.message_id, " +
+	"'filename', attachments.filename, 'content_type', attachments.content_type, 'size', attachments.size, " +
+	"'created_at', attachments.created_at) ORDER BY attachments.id), '[]') " +
+	"FROM attachments WHERE attachments.message_id = messages.id) AS attachments"
 
 // GetChat retrieves a chat and its messages from the database.
-func (s *Storage) GetChat(ctx context.Context, chatID int, limit int) (models.Chat, error) {
+//
+// Without a cursor the newest messages are returned. With a cursor the messages
+// are selected by keyset pagination relative to the cursor position, using the
+// (chat_id, created_at) index. Messages are always returned newest first.
+func (s *Storage) GetChat(ctx context.Context, chatID int, limit int, cursor *models.Cursor) (models.Chat, error) {
 
 	var chat models.Chat
 
-	if err := s.db.WithContext(ctx).Preload("Messages", preload(limit)).First(&chat, chatID).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Messages", preload(limit, cursor)).First(&chat, chatID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return models.Chat{}, errs.ErrChatNotFound
 		}
 		return models.Chat{}, err
 	}
 
+	if cursor != nil && cursor.Direction == models.After {
+		slices.Reverse(chat.Messages)
+	}
+
 	return chat, nil
 
 }
 
-// preload returns a GORM query modifier to preload messages with a given limit
-// and ordered by created_at descending.
-func preload(limit int) func(*gorm.DB) *gorm.DB {
-	return func(db *gorm.DB) *gorm.DB { return db.Order(order).Limit(limit) }
+// preload returns a GORM query modifier to preload messages with a given limit.
+//
+// Messages older than a Before cursor are ordered by created_at descending; messages
+// newer than an After cursor are ordered ascending so that the limit keeps the ones
+// closest to the cursor. The created_at range condition lets Postgres scan the index,
+// while the id comparison breaks ties between messages sharing a timestamp.
+func preload(limit int, cursor *models.Cursor) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Select(messageColumns)
+		switch {
+		case cursor == nil:
+			return db.Order(order).Limit(limit)
+		case cursor.Direction == models.After:
+			return db.Where("created_at >= ? AND (created_at > ? OR id > ?)", cursor.CreatedAt, cursor.CreatedAt, cursor.ID).
+				Order(reverseOrder).Limit(limit)
+		default:
+			return db.Where("created_at <= ? AND (created_at < ? OR id < ?)", cursor.CreatedAt, cursor.CreatedAt, cursor.ID).
+				Order(order).Limit(limit)
+		}
+	}
 }
@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// GetMember retrieves the membership of a user in a chat.
+//
+// Returns ErrMemberNotFound if the user is not a member of the chat.
+func (s *Storage) GetMember(ctx context.Context, chatID int, userID int) (models.ChatMember, error) {
+
+	var member models.ChatMember
+
+	if err := s.db.WithContext(ctx).Where("chat_id = ? AND user_id = ?", chatID, userID).Take(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ChatMember{}, errs.ErrMemberNotFound
+		}
+		return models.ChatMember{}, err
+	}
+
+	return member, nil
+
+}
@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// GetMessage retrieves a single message by chat ID and message ID.
+//
+// Returns ErrMessageNotFound if the chat has no such message.
+func (s *Storage) GetMessage(ctx context.Context, chatID int, messageID int) (models.Message, error) {
+
+	var message models.Message
+
+	if err := s.db.WithContext(ctx).Select(messageColumns).Where("chat_id = ?", chatID).Take(&message, messageID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Message{}, errs.ErrMessageNotFound
+		}
+		return models.Message{}, err
+	}
+
+	return message, nil
+
+}
@@ -0,0 +1,23 @@
+package postgres
+
+import (
+	"chatX/internal/models"
+	"context"
+)
+
+// GetMessagesAfter retrieves up to limit messages of a chat whose IDs are greater than afterID.
+//
+// Message IDs grow with every insert, so the result contains the messages created after
+// the given one, ordered oldest first.
+func (s *Storage) GetMessagesAfter(ctx context.Context, chatID int, afterID int, limit int) ([]models.Message, error) {
+
+	messages := make([]models.Message, 0, limit)
+
+	if err := s.db.WithContext(ctx).Select(messageColumns).Where("chat_id = ? AND id > ?", chatID, afterID).
+		Order("id").Limit(limit).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+
+}
@@ -0,0 +1,27 @@
+package postgres
+
+import (
+	"chatX/internal/models"
+	"context"
+	"time"
+)
+
+// GetMessagesSince retrieves up to limit messages of a chat a reader that has seen afterID may have missed.
+//
+// These are the messages with IDs greater than afterID and, since IDs are taken before their
+// transactions commit, those with lower IDs created at most grace before message afterID,
+// which may have committed after it. The result is ordered by ID.
+func (s *Storage) GetMessagesSince(ctx context.Context, chatID int, afterID int, grace time.Duration, limit int) ([]models.Message, error) {
+
+	messages := make([]models.Message, 0, limit)
+
+	if err := s.db.WithContext(ctx).Select(messageColumns).
+		Where("chat_id = ? AND (id > ? OR (id < ? AND created_at >= (SELECT created_at FROM messages WHERE id = ?) - make_interval(secs => ?)))",
+			chatID, afterID, afterID, afterID, grace.Seconds()).
+		Order("id").Limit(limit).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+
+}
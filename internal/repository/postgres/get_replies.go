@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"chatX/internal/models"
+	"context"
+)
+
+// GetReplies retrieves up to limit replies to a message, oldest first.
+//
+// If a cursor is provided, only the replies following it are returned. The
+// (parent_id, created_at, id) index serves both the filter and the order.
+func (s *Storage) GetReplies(ctx context.Context, chatID int, parentID int, limit int, cursor *models.Cursor) ([]models.Message, error) {
+
+	query := s.db.WithContext(ctx).Select(messageColumns).Where("chat_id = ? AND parent_id = ?", chatID, parentID)
+
+	if cursor != nil {
+		query = query.Where("created_at >= ? AND (created_at > ? OR id > ?)", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	replies := make([]models.Message, 0, limit)
+
+	if err := query.Order(reverseOrder).Limit(limit).Find(&replies).Error; err != nil {
+		return nil, err
+	}
+
+	return replies, nil
+
+}
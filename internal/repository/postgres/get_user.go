@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// GetUserByUsername retrieves a user by username, ignoring case.
+//
+// The lookup matches the unique index on lower(username).
+func (s *Storage) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+
+	var user models.User
+
+	if err := s.db.WithContext(ctx).Where("lower(username) = lower(?)", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.User{}, errs.ErrUserNotFound
+		}
+		return models.User{}, err
+	}
+
+	return user, nil
+
+}
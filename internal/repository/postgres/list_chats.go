@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"chatX/internal/models"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// summaryColumns selects chat fields together with the message statistics kept on the chat.
+const summaryColumns = "chats.id, chats.title, chats.created_at, chats.message_count, chats.last_message_at"
+
+// unreadColumn counts the unread messages of the chat for the member whose read marker is joined.
+const unreadColumn = "(SELECT COUNT(*) FROM messages WHERE messages.chat_id = chats.id AND " + unreadCondition + ") AS unread_count"
+
+// likeEscaper escapes LIKE wildcards so that the title filter matches a literal substring.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListChats retrieves chat summaries matching the filter.
+//
+// Chats are ordered by the sort key descending with the chat ID as a tie-breaker,
+// and the cursor, if provided, selects the chats following it in that order.
+// A chat without messages is considered last active at its creation time. The message
+// statistics and the last activity are kept on the chat, so pages are read in index order
+// without counting the messages of the chats that are not listed.
+// If the filter names a member, only the chats that user belongs to are listed, along
+// with the number of messages the member has not read yet.
+func (s *Storage) ListChats(ctx context.Context, filter models.ChatFilter, limit int, cursor *models.Cursor) ([]models.ChatSummary, error) {
+
+	query := s.db.WithContext(ctx).Table("chats").Select(summaryColumns + ", 0 AS unread_count")
+
+	if filter.MemberID != 0 {
+		query = query.Select(summaryColumns+", "+unreadColumn, filter.MemberID).Joins(readReceiptJoin, filter.MemberID)
+		query = query.Where("EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = chats.id AND chat_members.user_id = ?)", filter.MemberID)
+	}
+
+	if filter.Title != "" {
+		query = query.Where("chats.title ILIKE ?", "%"+likeEscaper.Replace(filter.Title)+"%")
+	}
+
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("chats.created_at >= ?", filter.CreatedAfter)
+	}
+
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("chats.created_at < ?", filter.CreatedBefore)
+	}
+
+	// both keys are indexed together with the ID, so a page is read from the index
+	key := "chats.created_at"
+	if filter.Sort == models.SortByLastActivity {
+		key = "chats.last_activity"
+	}
+
+	if cursor != nil {
+		query = query.Where(fmt.Sprintf("(%s, chats.id) < (?, ?)", key), cursor.CreatedAt, cursor.ID)
+	}
+
+	summaries := make([]models.ChatSummary, 0, limit)
+
+	if err := query.Order(key + " DESC, chats.id DESC").Limit(limit).Scan(&summaries).Error; err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+
+}
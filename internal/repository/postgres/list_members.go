@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"chatX/internal/models"
+	"context"
+)
+
+// ListMembers retrieves all members of a chat with their usernames, in the order they joined.
+func (s *Storage) ListMembers(ctx context.Context, chatID int) ([]models.ChatMember, error) {
+
+	var members []models.ChatMember
+
+	err := s.db.WithContext(ctx).Table("chat_members").
+		Select("chat_members.chat_id, chat_members.user_id, users.username, chat_members.role, chat_members.created_at").
+		Joins("JOIN users ON users.id = chat_members.user_id").
+		Where("chat_members.chat_id = ?", chatID).
+		Order("chat_members.created_at, chat_members.user_id").
+		Scan(&members).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return members, nil
+
+}
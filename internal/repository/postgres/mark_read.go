@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MarkRead advances the read marker of a member to a message of the chat.
+//
+// The marker only moves forward: marking a message older than the current marker
+// leaves it unchanged. The receipt is filled with the resulting marker. Returns
+// ErrMessageNotFound if the chat has no such message.
+func (s *Storage) MarkRead(ctx context.Context, receipt *models.ReadReceipt) error {
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		var message models.Message
+
+		if err := tx.Select("id, created_at").Where("chat_id = ?", receipt.ChatID).Take(&message, receipt.LastReadMessageID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errs.ErrMessageNotFound
+			}
+			return err
+		}
+
+		receipt.LastReadAt = message.CreatedAt
+
+		advance := clause.OnConflict{
+			Columns:   []clause.Column{{Name: "chat_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"last_read_message_id", "last_read_at", "updated_at"}),
+			Where: clause.Where{Exprs: []clause.Expression{clause.Expr{
+				SQL: "(read_receipts.last_read_at, read_receipts.last_read_message_id) < (excluded.last_read_at, excluded.last_read_message_id)",
+			}}},
+		}
+
+		if err := tx.Clauses(advance).Create(receipt).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("chat_id = ? AND user_id = ?", receipt.ChatID, receipt.UserID).Take(receipt).Error
+
+	})
+
+}
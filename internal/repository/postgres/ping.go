@@ -0,0 +1,18 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// Ping verifies that the database is reachable.
+func (s *Storage) Ping(ctx context.Context) error {
+
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+
+	return sqlDB.PingContext(ctx)
+
+}
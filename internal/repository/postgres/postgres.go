@@ -4,6 +4,7 @@ package postgres
 import (
 	"chatX/internal/config"
 	"chatX/internal/logger"
+	"database/sql"
 
 	"gorm.io/gorm"
 )
@@ -20,6 +21,16 @@ func NewStorage(logger logger.Logger, config config.Storage, db *gorm.DB) *Stora
 	return &Storage{db: db, logger: logger, config: config}
 }
 
+// Stats returns the connection pool statistics of the underlying SQL database.
+// Returns zero statistics if the connection is unavailable.
+func (s *Storage) Stats() sql.DBStats {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+	return sqlDB.Stats()
+}
+
 // Close closes the underlying SQL database connection.
 // Logs errors if the connection cannot be closed properly.
 func (s *Storage) Close() {
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,11 +18,16 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/pressly/goose/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	gormpostgres "gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var testStorage *postgres.Storage
+var testOwner *models.User
 
 func TestMain(m *testing.M) {
 
@@ -48,6 +55,10 @@ func TestMain(m *testing.M) {
 		logger.LogFatal("failed to connect to test DB: %v", err)
 	}
 
+	if err := db.Use(postgres.TracingPlugin{}); err != nil {
+		logger.LogFatal("failed to register tracing plugin: %v", err)
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		logger.LogFatal("failed to get sql.DB from gorm: %v", err)
@@ -63,6 +74,11 @@ func TestMain(m *testing.M) {
 
 	testStorage = postgres.NewStorage(logger, cfg, db)
 
+	testOwner = &models.User{Username: fmt.Sprintf("owner_%d", time.Now().UnixNano()), PasswordHash: "hash", CreatedAt: time.Now().UTC()}
+	if err := testStorage.CreateUser(context.Background(), testOwner); err != nil {
+		logger.LogFatal("failed to create test chat owner: %v", err)
+	}
+
 	exitCode := m.Run()
 
 	testStorage.Close()
@@ -76,7 +92,7 @@ func TestChatLifecycle(t *testing.T) {
 
 	chat := &models.Chat{Title: "Integration Chat", CreatedAt: time.Now().UTC()}
 
-	err := testStorage.CreateChat(ctx, chat)
+	err := testStorage.CreateChat(ctx, chat, testOwner.ID)
 	if err != nil {
 		t.Fatalf("CreateChat failed: %v", err)
 	}
@@ -100,7 +116,7 @@ func TestChatLifecycle(t *testing.T) {
 		t.Fatal("message ID not set after creation")
 	}
 
-	gotChat, err := testStorage.GetChat(ctx, chat.ID, 10)
+	gotChat, err := testStorage.GetChat(ctx, chat.ID, 10, nil)
 	if err != nil {
 		t.Fatalf("GetChat failed: %v", err)
 	}
@@ -113,21 +129,116 @@ func TestChatLifecycle(t *testing.T) {
 		t.Fatal("GetChat returned wrong messages")
 	}
 
-	err = testStorage.DeleteChat(ctx, chat.ID)
+	_, err = testStorage.DeleteChat(ctx, chat.ID)
 	if err != nil {
 		t.Fatalf("DeleteChat failed: %v", err)
 	}
 
-	_, err = testStorage.GetChat(ctx, chat.ID, 10)
+	_, err = testStorage.GetChat(ctx, chat.ID, 10, nil)
 	if err == nil || !errors.Is(err, errs.ErrChatNotFound) {
 		t.Fatal("expected ErrChatNotFound after delete")
 	}
 
 }
 
+func TestMessageEditAndDelete(t *testing.T) {
+
+	ctx := context.Background()
+
+	chat := &models.Chat{Title: "Edit Chat", CreatedAt: time.Now().UTC()}
+
+	err := testStorage.CreateChat(ctx, chat, testOwner.ID)
+	if err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	msg := &models.Message{ChatID: chat.ID, Text: "original", CreatedAt: time.Now().UTC()}
+
+	err = testStorage.CreateMessage(ctx, msg)
+	if err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	editedAt := time.Now().UTC()
+	edit := &models.Message{ID: msg.ID, ChatID: chat.ID, Text: "edited", EditedAt: &editedAt}
+
+	err = testStorage.UpdateMessage(ctx, edit)
+	if err != nil {
+		t.Fatalf("UpdateMessage failed: %v", err)
+	}
+
+	if edit.CreatedAt.IsZero() || edit.Text != "edited" {
+		t.Fatalf("UpdateMessage did not return the stored row: %+v", edit)
+	}
+
+	err = testStorage.UpdateMessage(ctx, &models.Message{ID: msg.ID, ChatID: chat.ID + 1, Text: "wrong chat"})
+	if !errors.Is(err, errs.ErrMessageNotFound) {
+		t.Fatalf("expected ErrMessageNotFound for wrong chat, got %v", err)
+	}
+
+	gotChat, err := testStorage.GetChat(ctx, chat.ID, 10, nil)
+	if err != nil {
+		t.Fatalf("GetChat failed: %v", err)
+	}
+
+	if len(gotChat.Messages) != 1 || gotChat.Messages[0].Text != "edited" || gotChat.Messages[0].EditedAt == nil {
+		t.Fatalf("GetChat returned stale message: %+v", gotChat.Messages)
+	}
+
+	_, err = testStorage.DeleteMessage(ctx, chat.ID, msg.ID)
+	if err != nil {
+		t.Fatalf("DeleteMessage failed: %v", err)
+	}
+
+	_, err = testStorage.DeleteMessage(ctx, chat.ID, msg.ID)
+	if !errors.Is(err, errs.ErrMessageNotFound) {
+		t.Fatalf("expected ErrMessageNotFound after delete, got %v", err)
+	}
+
+}
+
+func TestUpdateChat(t *testing.T) {
+
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	chat := &models.Chat{Title: "Old Title", CreatedAt: now, UpdatedAt: now}
+
+	err := testStorage.CreateChat(ctx, chat, testOwner.ID)
+	if err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	rename := &models.Chat{ID: chat.ID, Title: "New Title", UpdatedAt: now.Add(time.Minute)}
+
+	err = testStorage.UpdateChat(ctx, rename)
+	if err != nil {
+		t.Fatalf("UpdateChat failed: %v", err)
+	}
+
+	if rename.CreatedAt.IsZero() || !rename.UpdatedAt.After(rename.CreatedAt) {
+		t.Fatalf("UpdateChat did not return the stored row: %+v", rename)
+	}
+
+	gotChat, err := testStorage.GetChat(ctx, chat.ID, 10, nil)
+	if err != nil {
+		t.Fatalf("GetChat failed: %v", err)
+	}
+
+	if gotChat.Title != "New Title" {
+		t.Fatalf("expected renamed chat, got %q", gotChat.Title)
+	}
+
+	err = testStorage.UpdateChat(ctx, &models.Chat{ID: 9999, Title: "Ghost", UpdatedAt: now})
+	if !errors.Is(err, errs.ErrChatNotFound) {
+		t.Fatalf("expected ErrChatNotFound, got %v", err)
+	}
+
+}
+
 func TestDeleteNonExistingChat(t *testing.T) {
 	ctx := context.Background()
-	err := testStorage.DeleteChat(ctx, 9999)
+	_, err := testStorage.DeleteChat(ctx, 9999)
 	if err == nil || !errors.Is(err, errs.ErrChatNotFound) {
 		t.Fatalf("expected ErrChatNotFound, got %v", err)
 	}
@@ -139,7 +250,7 @@ func TestGetChatWithLimit(t *testing.T) {
 
 	chat := &models.Chat{Title: "Limit Chat", CreatedAt: time.Now().UTC()}
 
-	err := testStorage.CreateChat(ctx, chat)
+	err := testStorage.CreateChat(ctx, chat, testOwner.ID)
 	if err != nil {
 		t.Fatalf("CreateChat failed: %v", err)
 	}
@@ -159,7 +270,7 @@ func TestGetChatWithLimit(t *testing.T) {
 
 	}
 
-	gotChat, err := testStorage.GetChat(ctx, chat.ID, 3)
+	gotChat, err := testStorage.GetChat(ctx, chat.ID, 3, nil)
 	if err != nil {
 		t.Fatalf("GetChat failed: %v", err)
 	}
@@ -178,6 +289,779 @@ func TestGetChatWithLimit(t *testing.T) {
 
 }
 
+func TestGetChatWithCursor(t *testing.T) {
+
+	ctx := context.Background()
+
+	chat := &models.Chat{Title: "Cursor Chat", CreatedAt: time.Now().UTC()}
+
+	err := testStorage.CreateChat(ctx, chat, testOwner.ID)
+	if err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	base := time.Now().UTC().Truncate(time.Microsecond)
+	ids := make([]int, 0, 5)
+
+	for i := 1; i <= 5; i++ {
+
+		msg := &models.Message{
+			ChatID:    chat.ID,
+			Text:      fmt.Sprintf("Message %d", i),
+			CreatedAt: base.Add(time.Duration(i/2) * time.Second), // pairs share a timestamp
+		}
+
+		err := testStorage.CreateMessage(ctx, msg)
+		if err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+
+		ids = append(ids, msg.ID)
+
+	}
+
+	before := &models.Cursor{CreatedAt: base.Add(time.Second), ID: ids[2], Direction: models.Before}
+
+	gotChat, err := testStorage.GetChat(ctx, chat.ID, 10, before)
+	if err != nil {
+		t.Fatalf("GetChat failed: %v", err)
+	}
+
+	if len(gotChat.Messages) != 2 || gotChat.Messages[0].ID != ids[1] || gotChat.Messages[1].ID != ids[0] {
+		t.Fatalf("unexpected messages before cursor: %+v", gotChat.Messages)
+	}
+
+	after := &models.Cursor{CreatedAt: base.Add(time.Second), ID: ids[1], Direction: models.After}
+
+	gotChat, err = testStorage.GetChat(ctx, chat.ID, 2, after)
+	if err != nil {
+		t.Fatalf("GetChat failed: %v", err)
+	}
+
+	if len(gotChat.Messages) != 2 || gotChat.Messages[0].ID != ids[3] || gotChat.Messages[1].ID != ids[2] {
+		t.Fatalf("unexpected messages after cursor: %+v", gotChat.Messages)
+	}
+
+}
+
+func TestListChats(t *testing.T) {
+
+	ctx := context.Background()
+
+	base := time.Now().UTC().Truncate(time.Microsecond)
+	chats := make([]*models.Chat, 0, 3)
+
+	for i := 1; i <= 3; i++ {
+
+		chat := &models.Chat{Title: fmt.Sprintf("List_%d 100%%", i), CreatedAt: base.Add(time.Duration(i) * time.Second)}
+
+		err := testStorage.CreateChat(ctx, chat, testOwner.ID)
+		if err != nil {
+			t.Fatalf("CreateChat failed: %v", err)
+		}
+
+		chats = append(chats, chat)
+
+	}
+
+	msg := &models.Message{ChatID: chats[0].ID, Text: "revive", CreatedAt: base.Add(time.Minute)}
+
+	err := testStorage.CreateMessage(ctx, msg)
+	if err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	filter := models.ChatFilter{Title: "list_", CreatedAfter: base, Sort: models.SortByCreatedAt}
+
+	got, err := testStorage.ListChats(ctx, filter, 2, nil)
+	if err != nil {
+		t.Fatalf("ListChats failed: %v", err)
+	}
+
+	if len(got) != 2 || got[0].ID != chats[2].ID || got[1].ID != chats[1].ID {
+		t.Fatalf("unexpected chats sorted by creation: %+v", got)
+	}
+
+	cursor := &models.Cursor{CreatedAt: got[1].CreatedAt, ID: got[1].ID}
+
+	got, err = testStorage.ListChats(ctx, filter, 2, cursor)
+	if err != nil {
+		t.Fatalf("ListChats failed: %v", err)
+	}
+
+	if len(got) != 1 || got[0].ID != chats[0].ID || got[0].MessageCount != 1 || got[0].LastMessageAt == nil {
+		t.Fatalf("unexpected chats on second page: %+v", got)
+	}
+
+	filter.Sort = models.SortByLastActivity
+
+	got, err = testStorage.ListChats(ctx, filter, 1, nil)
+	if err != nil {
+		t.Fatalf("ListChats failed: %v", err)
+	}
+
+	if len(got) != 1 || got[0].ID != chats[0].ID {
+		t.Fatalf("unexpected chats sorted by activity: %+v", got)
+	}
+
+}
+
+func TestGetMessagesAfter(t *testing.T) {
+
+	ctx := context.Background()
+
+	chat := &models.Chat{Title: "Replay Chat", CreatedAt: time.Now().UTC()}
+
+	err := testStorage.CreateChat(ctx, chat, testOwner.ID)
+	if err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	var ids []int
+	for i := 0; i < 4; i++ {
+		msg := &models.Message{ChatID: chat.ID, Text: fmt.Sprintf("msg %d", i), CreatedAt: time.Now().UTC()}
+		if err := testStorage.CreateMessage(ctx, msg); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		ids = append(ids, msg.ID)
+	}
+
+	messages, err := testStorage.GetMessagesAfter(ctx, chat.ID, ids[0], 2)
+	if err != nil {
+		t.Fatalf("GetMessagesAfter failed: %v", err)
+	}
+
+	if len(messages) != 2 || messages[0].ID != ids[1] || messages[1].ID != ids[2] {
+		t.Fatalf("expected messages %v oldest first, got %+v", ids[1:3], messages)
+	}
+
+	messages, err = testStorage.GetMessagesAfter(ctx, chat.ID, ids[3], 10)
+	if err != nil {
+		t.Fatalf("GetMessagesAfter failed: %v", err)
+	}
+
+	if len(messages) != 0 {
+		t.Fatalf("expected no messages after the newest one, got %+v", messages)
+	}
+
+}
+
+func TestGetMessagesSince(t *testing.T) {
+
+	ctx := context.Background()
+
+	chat := &models.Chat{Title: "Late Commit Chat", CreatedAt: time.Now().UTC()}
+
+	err := testStorage.CreateChat(ctx, chat, testOwner.ID)
+	if err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	now := time.Now().UTC()
+
+	// the second message stands in for one committed after the third despite its lower ID
+	var ids []int
+	for _, createdAt := range []time.Time{now.Add(-time.Hour), now.Add(-time.Second), now, now} {
+		msg := &models.Message{ChatID: chat.ID, Text: "msg", CreatedAt: createdAt}
+		if err := testStorage.CreateMessage(ctx, msg); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		ids = append(ids, msg.ID)
+	}
+
+	messages, err := testStorage.GetMessagesSince(ctx, chat.ID, ids[2], 5*time.Second, 10)
+	if err != nil {
+		t.Fatalf("GetMessagesSince failed: %v", err)
+	}
+
+	if len(messages) != 2 || messages[0].ID != ids[1] || messages[1].ID != ids[3] {
+		t.Fatalf("expected messages %v, got %+v", []int{ids[1], ids[3]}, messages)
+	}
+
+	messages, err = testStorage.GetMessagesSince(ctx, chat.ID, ids[2], 0, 10)
+	if err != nil {
+		t.Fatalf("GetMessagesSince failed: %v", err)
+	}
+
+	if len(messages) != 1 || messages[0].ID != ids[3] {
+		t.Fatalf("expected only message %d without a grace, got %+v", ids[3], messages)
+	}
+
+}
+
+func TestUsers(t *testing.T) {
+
+	ctx := context.Background()
+
+	username := fmt.Sprintf("Neo_%d", time.Now().UnixNano())
+	user := &models.User{Username: username, PasswordHash: "hash", CreatedAt: time.Now().UTC()}
+
+	err := testStorage.CreateUser(ctx, user)
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	if user.ID == 0 {
+		t.Fatal("expected user ID to be set")
+	}
+
+	got, err := testStorage.GetUserByUsername(ctx, strings.ToLower(username))
+	if err != nil {
+		t.Fatalf("GetUserByUsername failed: %v", err)
+	}
+
+	if got.ID != user.ID || got.PasswordHash != "hash" {
+		t.Fatalf("GetUserByUsername returned %+v, expected %+v", got, user)
+	}
+
+	err = testStorage.CreateUser(ctx, &models.User{Username: strings.ToUpper(username), PasswordHash: "hash", CreatedAt: time.Now().UTC()})
+	if err == nil {
+		t.Fatal("expected unique violation for a username differing only in case")
+	}
+
+	_, err = testStorage.GetUserByUsername(ctx, username+"_missing")
+	if !errors.Is(err, errs.ErrUserNotFound) {
+		t.Fatalf("expected ErrUserNotFound, got %v", err)
+	}
+
+	chat := &models.Chat{Title: "Authored Chat", CreatedAt: time.Now().UTC()}
+	if err := testStorage.CreateChat(ctx, chat, testOwner.ID); err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	msg := &models.Message{ChatID: chat.ID, Text: "signed", CreatedAt: time.Now().UTC(), AuthorID: &user.ID}
+	if err := testStorage.CreateMessage(ctx, msg); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	gotChat, err := testStorage.GetChat(ctx, chat.ID, 10, nil)
+	if err != nil {
+		t.Fatalf("GetChat failed: %v", err)
+	}
+
+	if len(gotChat.Messages) != 1 || gotChat.Messages[0].AuthorID == nil || *gotChat.Messages[0].AuthorID != user.ID {
+		t.Fatalf("expected message authored by user %d, got %+v", user.ID, gotChat.Messages)
+	}
+
+}
+
+func TestChatMembers(t *testing.T) {
+
+	ctx := context.Background()
+
+	chat := &models.Chat{Title: "Members Chat", CreatedAt: time.Now().UTC()}
+	if err := testStorage.CreateChat(ctx, chat, testOwner.ID); err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	owner, err := testStorage.GetMember(ctx, chat.ID, testOwner.ID)
+	if err != nil || owner.Role != models.RoleOwner {
+		t.Fatalf("expected creator to own the chat, got %+v, %v", owner, err)
+	}
+
+	user := &models.User{Username: fmt.Sprintf("Trinity_%d", time.Now().UnixNano()), PasswordHash: "hash", CreatedAt: time.Now().UTC()}
+	if err := testStorage.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	_, err = testStorage.GetMember(ctx, chat.ID, user.ID)
+	if !errors.Is(err, errs.ErrMemberNotFound) {
+		t.Fatalf("expected ErrMemberNotFound, got %v", err)
+	}
+
+	member := &models.ChatMember{ChatID: chat.ID, UserID: user.ID, Role: models.RoleReadOnly, CreatedAt: time.Now().UTC()}
+	if err := testStorage.AddMember(ctx, member); err != nil {
+		t.Fatalf("AddMember failed: %v", err)
+	}
+
+	if err := testStorage.AddMember(ctx, member); err == nil {
+		t.Fatal("expected unique violation for a duplicate member")
+	}
+
+	members, err := testStorage.ListMembers(ctx, chat.ID)
+	if err != nil {
+		t.Fatalf("ListMembers failed: %v", err)
+	}
+
+	if len(members) != 2 || members[0].UserID != testOwner.ID || members[1].Username != user.Username || members[1].Role != models.RoleReadOnly {
+		t.Fatalf("unexpected members: %+v", members)
+	}
+
+	summaries, err := testStorage.ListChats(ctx, models.ChatFilter{MemberID: user.ID}, 10, nil)
+	if err != nil {
+		t.Fatalf("ListChats failed: %v", err)
+	}
+
+	if len(summaries) != 1 || summaries[0].ID != chat.ID {
+		t.Fatalf("expected only the chat of the member, got %+v", summaries)
+	}
+
+	if err := testStorage.RemoveMember(ctx, chat.ID, user.ID); err != nil {
+		t.Fatalf("RemoveMember failed: %v", err)
+	}
+
+	if err := testStorage.RemoveMember(ctx, chat.ID, user.ID); !errors.Is(err, errs.ErrMemberNotFound) {
+		t.Fatalf("expected ErrMemberNotFound, got %v", err)
+	}
+
+	msg := &models.Message{ChatID: chat.ID, Text: "find me", CreatedAt: time.Now().UTC()}
+	if err := testStorage.CreateMessage(ctx, msg); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	got, err := testStorage.GetMessage(ctx, chat.ID, msg.ID)
+	if err != nil || got.Text != "find me" {
+		t.Fatalf("GetMessage returned %+v, %v", got, err)
+	}
+
+	if _, err := testStorage.GetMessage(ctx, chat.ID+1, msg.ID); !errors.Is(err, errs.ErrMessageNotFound) {
+		t.Fatalf("expected ErrMessageNotFound for another chat, got %v", err)
+	}
+
+}
+
+func TestThreads(t *testing.T) {
+
+	ctx := context.Background()
+
+	chat := &models.Chat{Title: "Thread Chat", CreatedAt: time.Now().UTC()}
+	if err := testStorage.CreateChat(ctx, chat, testOwner.ID); err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	root := &models.Message{ChatID: chat.ID, Text: "question", CreatedAt: time.Now().UTC()}
+	if err := testStorage.CreateMessage(ctx, root); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	var replies []int
+	for i := 0; i < 3; i++ {
+		reply := &models.Message{ChatID: chat.ID, Text: fmt.Sprintf("answer %d", i), CreatedAt: time.Now().UTC(), ParentID: &root.ID}
+		if err := testStorage.CreateMessage(ctx, reply); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+		replies = append(replies, reply.ID)
+	}
+
+	got, err := testStorage.GetMessage(ctx, chat.ID, root.ID)
+	if err != nil || got.ReplyCount != 3 {
+		t.Fatalf("expected 3 replies to the root message, got %+v, %v", got, err)
+	}
+
+	fetched, err := testStorage.GetChat(ctx, chat.ID, 10, nil)
+	if err != nil {
+		t.Fatalf("GetChat failed: %v", err)
+	}
+
+	if last := fetched.Messages[len(fetched.Messages)-1]; last.ID != root.ID || last.ReplyCount != 3 {
+		t.Fatalf("expected the oldest message to carry the reply count, got %+v", last)
+	}
+
+	page, err := testStorage.GetReplies(ctx, chat.ID, root.ID, 2, nil)
+	if err != nil {
+		t.Fatalf("GetReplies failed: %v", err)
+	}
+
+	if len(page) != 2 || page[0].ID != replies[0] || page[1].ID != replies[1] || *page[0].ParentID != root.ID {
+		t.Fatalf("expected replies %v oldest first, got %+v", replies[:2], page)
+	}
+
+	page, err = testStorage.GetReplies(ctx, chat.ID, root.ID, 2, &models.Cursor{CreatedAt: page[1].CreatedAt, ID: page[1].ID})
+	if err != nil {
+		t.Fatalf("GetReplies failed: %v", err)
+	}
+
+	if len(page) != 1 || page[0].ID != replies[2] {
+		t.Fatalf("expected the last reply after the cursor, got %+v", page)
+	}
+
+	if _, err := testStorage.DeleteMessage(ctx, chat.ID, root.ID); err != nil {
+		t.Fatalf("DeleteMessage failed: %v", err)
+	}
+
+	if _, err := testStorage.GetMessage(ctx, chat.ID, replies[0]); !errors.Is(err, errs.ErrMessageNotFound) {
+		t.Fatalf("expected replies to be deleted with their parent, got %v", err)
+	}
+
+}
+
+func TestAttachments(t *testing.T) {
+
+	ctx := context.Background()
+
+	chat := &models.Chat{Title: "Attachment Chat", CreatedAt: time.Now().UTC()}
+	if err := testStorage.CreateChat(ctx, chat, testOwner.ID); err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	key := fmt.Sprintf("attachments/%d", time.Now().UnixNano())
+	message := &models.Message{ChatID: chat.ID, CreatedAt: time.Now().UTC(), Attachments: models.Attachments{
+		{BlobKey: key + "a", Filename: "photo.png", ContentType: "image/png", Size: 48213, CreatedAt: time.Now().UTC()},
+		{BlobKey: key + "b", Filename: "notes.txt", ContentType: "text/plain; charset=utf-8", Size: 5, CreatedAt: time.Now().UTC()},
+	}}
+	if err := testStorage.CreateMessage(ctx, message); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	if message.Attachments[0].ID == 0 || message.Attachments[1].MessageID != message.ID {
+		t.Fatalf("expected attachments to receive their IDs, got %+v", message.Attachments)
+	}
+
+	fetched, err := testStorage.GetChat(ctx, chat.ID, 10, nil)
+	if err != nil {
+		t.Fatalf("GetChat failed: %v", err)
+	}
+
+	got := fetched.Messages[0].Attachments
+	if len(got) != 2 || got[0].ID != message.Attachments[0].ID || got[0].Filename != "photo.png" || got[1].Size != 5 {
+		t.Fatalf("expected attachments in upload order, got %+v", got)
+	}
+
+	attachment, err := testStorage.GetAttachment(ctx, chat.ID, message.Attachments[1].ID)
+	if err != nil || attachment.BlobKey != key+"b" || attachment.ContentType != "text/plain; charset=utf-8" {
+		t.Fatalf("expected the second attachment with its blob key, got %+v, %v", attachment, err)
+	}
+
+	if _, err := testStorage.GetAttachment(ctx, chat.ID+1, attachment.ID); !errors.Is(err, errs.ErrAttachmentNotFound) {
+		t.Fatalf("expected ErrAttachmentNotFound for another chat, got %v", err)
+	}
+
+	reply := &models.Message{ChatID: chat.ID, ParentID: &message.ID, CreatedAt: time.Now().UTC(), Attachments: models.Attachments{
+		{BlobKey: key + "c", Filename: "reply.png", ContentType: "image/png", Size: 7, CreatedAt: time.Now().UTC()},
+	}}
+	if err := testStorage.CreateMessage(ctx, reply); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	deleted, err := testStorage.DeleteMessage(ctx, chat.ID, message.ID)
+	if err != nil {
+		t.Fatalf("DeleteMessage failed: %v", err)
+	}
+
+	if len(deleted) != 3 || deleted[0].BlobKey != key+"a" || deleted[1].BlobKey != key+"b" || deleted[2].BlobKey != key+"c" {
+		t.Fatalf("expected the attachments of the message and its reply, got %+v", deleted)
+	}
+
+	if _, err := testStorage.GetAttachment(ctx, chat.ID, attachment.ID); !errors.Is(err, errs.ErrAttachmentNotFound) {
+		t.Fatalf("expected attachments to be deleted with their message, got %v", err)
+	}
+
+	other := &models.Message{ChatID: chat.ID, CreatedAt: time.Now().UTC(), Attachments: models.Attachments{
+		{BlobKey: key + "d", Filename: "other.png", ContentType: "image/png", Size: 9, CreatedAt: time.Now().UTC()},
+	}}
+	if err := testStorage.CreateMessage(ctx, other); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	deleted, err = testStorage.DeleteChat(ctx, chat.ID)
+	if err != nil {
+		t.Fatalf("DeleteChat failed: %v", err)
+	}
+
+	if len(deleted) != 1 || deleted[0].BlobKey != key+"d" {
+		t.Fatalf("expected the attachments of the remaining messages, got %+v", deleted)
+	}
+
+}
+
+func TestReactions(t *testing.T) {
+
+	ctx := context.Background()
+
+	chat := &models.Chat{Title: "Reaction Chat", CreatedAt: time.Now().UTC()}
+	if err := testStorage.CreateChat(ctx, chat, testOwner.ID); err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	message := &models.Message{ChatID: chat.ID, Text: "react to me", CreatedAt: time.Now().UTC()}
+	if err := testStorage.CreateMessage(ctx, message); err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+
+	user := &models.User{Username: fmt.Sprintf("Morpheus_%d", time.Now().UnixNano()), PasswordHash: "hash", CreatedAt: time.Now().UTC()}
+	if err := testStorage.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	for _, reaction := range []models.MessageReaction{
+		{MessageID: message.ID, UserID: testOwner.ID, Emoji: "👍"},
+		{MessageID: message.ID, UserID: user.ID, Emoji: "👍"},
+		{MessageID: message.ID, UserID: user.ID, Emoji: "🔥"},
+		{MessageID: message.ID, UserID: user.ID, Emoji: "🔥"},
+	} {
+		reaction.CreatedAt = time.Now().UTC()
+		if err := testStorage.AddReaction(ctx, &reaction); err != nil {
+			t.Fatalf("AddReaction failed: %v", err)
+		}
+	}
+
+	want := models.Reactions{{Emoji: "👍", Count: 2}, {Emoji: "🔥", Count: 1}}
+
+	fetched, err := testStorage.GetChat(ctx, chat.ID, 10, nil)
+	if err != nil {
+		t.Fatalf("GetChat failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(fetched.Messages[0].Reactions, want) {
+		t.Fatalf("expected reactions %+v, got %+v", want, fetched.Messages[0].Reactions)
+	}
+
+	if err := testStorage.RemoveReaction(ctx, chat.ID+1, models.MessageReaction{MessageID: message.ID, UserID: user.ID, Emoji: "🔥"}); !errors.Is(err, errs.ErrReactionNotFound) {
+		t.Fatalf("expected ErrReactionNotFound for a message of another chat, got %v", err)
+	}
+
+	if err := testStorage.RemoveReaction(ctx, chat.ID, models.MessageReaction{MessageID: message.ID, UserID: user.ID, Emoji: "🔥"}); err != nil {
+		t.Fatalf("RemoveReaction failed: %v", err)
+	}
+
+	got, err := testStorage.GetMessage(ctx, chat.ID, message.ID)
+	if err != nil || !reflect.DeepEqual(got.Reactions, want[:1]) {
+		t.Fatalf("expected reactions %+v, got %+v, %v", want[:1], got.Reactions, err)
+	}
+
+	if err := testStorage.RemoveReaction(ctx, chat.ID, models.MessageReaction{MessageID: message.ID, UserID: user.ID, Emoji: "🔥"}); !errors.Is(err, errs.ErrReactionNotFound) {
+		t.Fatalf("expected ErrReactionNotFound, got %v", err)
+	}
+
+}
+
+func TestSearchMessages(t *testing.T) {
+
+	ctx := context.Background()
+
+	chats := make([]*models.Chat, 2)
+	for i := range chats {
+		chats[i] = &models.Chat{Title: fmt.Sprintf("Search Chat %d", i), CreatedAt: time.Now().UTC()}
+		if err := testStorage.CreateChat(ctx, chats[i], testOwner.ID); err != nil {
+			t.Fatalf("CreateChat failed: %v", err)
+		}
+	}
+
+	word := fmt.Sprintf("zion%d", time.Now().UnixNano())
+
+	texts := []struct {
+		chat *models.Chat
+		text string
+	}{
+		{chats[0], word + " " + word + " <b>bold</b> " + word},
+		{chats[0], "nothing to see here"},
+		{chats[1], "a longer message that mentions " + word + " once among many other words"},
+		{chats[1], "the same " + word + " mentioned once among other words"},
+	}
+
+	for _, tt := range texts {
+		message := &models.Message{ChatID: tt.chat.ID, Text: tt.text, CreatedAt: time.Now().UTC()}
+		if err := testStorage.CreateMessage(ctx, message); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	query := models.SearchQuery{Text: strings.ToUpper(word), MemberID: testOwner.ID}
+
+	hits, err := testStorage.SearchMessages(ctx, query, 10, nil)
+	if err != nil {
+		t.Fatalf("SearchMessages failed: %v", err)
+	}
+
+	if len(hits) != 3 {
+		t.Fatalf("expected 3 hits, got %d", len(hits))
+	}
+
+	if hits[0].Message.ChatID != chats[0].ID || hits[0].Rank <= hits[1].Rank {
+		t.Fatalf("expected the message with most matches first, got %+v", hits)
+	}
+
+	if !strings.Contains(hits[0].Snippet, "<mark>"+word+"</mark>") || !strings.Contains(hits[0].Snippet, "&lt;b&gt;bold&lt;/b&gt;") {
+		t.Fatalf("expected an escaped snippet with highlighted matches, got %q", hits[0].Snippet)
+	}
+
+	page, err := testStorage.SearchMessages(ctx, query, 10, &models.SearchCursor{Rank: hits[0].Rank, ID: hits[0].Message.ID})
+	if err != nil || len(page) != 2 || page[0].Message.ID != hits[1].Message.ID {
+		t.Fatalf("expected the hits after the cursor, got %+v, %v", page, err)
+	}
+
+	query.ChatID = chats[1].ID
+	if hits, err := testStorage.SearchMessages(ctx, query, 10, nil); err != nil || len(hits) != 2 {
+		t.Fatalf("expected 2 hits in the second chat, got %d, %v", len(hits), err)
+	}
+
+	query.MemberID = testOwner.ID + 1000000
+	if hits, err := testStorage.SearchMessages(ctx, query, 10, nil); err != nil || len(hits) != 0 {
+		t.Fatalf("expected no hits for a non-member, got %d, %v", len(hits), err)
+	}
+
+}
+
+func TestReadReceipts(t *testing.T) {
+
+	ctx := context.Background()
+
+	chat := &models.Chat{Title: "Unread Chat", CreatedAt: time.Now().UTC()}
+	if err := testStorage.CreateChat(ctx, chat, testOwner.ID); err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	user := &models.User{Username: fmt.Sprintf("Tank_%d", time.Now().UnixNano()), PasswordHash: "hash", CreatedAt: time.Now().UTC()}
+	if err := testStorage.CreateUser(ctx, user); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	// a message of the owner and three of the user, all sharing one timestamp
+	created := time.Now().UTC()
+	messages := make([]*models.Message, 4)
+	for i := range messages {
+		author := &user.ID
+		if i == 0 {
+			author = &testOwner.ID
+		}
+		messages[i] = &models.Message{ChatID: chat.ID, Text: fmt.Sprintf("unread %d", i), CreatedAt: created, AuthorID: author}
+		if err := testStorage.CreateMessage(ctx, messages[i]); err != nil {
+			t.Fatalf("CreateMessage failed: %v", err)
+		}
+	}
+
+	unread, err := testStorage.CountUnread(ctx, chat.ID, testOwner.ID)
+	if err != nil || unread != 3 {
+		t.Fatalf("expected 3 unread messages of other users, got %d, %v", unread, err)
+	}
+
+	receipt := &models.ReadReceipt{ChatID: chat.ID, UserID: testOwner.ID, LastReadMessageID: messages[2].ID, UpdatedAt: time.Now().UTC()}
+	if err := testStorage.MarkRead(ctx, receipt); err != nil {
+		t.Fatalf("MarkRead failed: %v", err)
+	}
+
+	if unread, err := testStorage.CountUnread(ctx, chat.ID, testOwner.ID); err != nil || unread != 1 {
+		t.Fatalf("expected 1 unread message after the marker, got %d, %v", unread, err)
+	}
+
+	// marking an older message keeps the marker
+	receipt = &models.ReadReceipt{ChatID: chat.ID, UserID: testOwner.ID, LastReadMessageID: messages[1].ID, UpdatedAt: time.Now().UTC()}
+	if err := testStorage.MarkRead(ctx, receipt); err != nil || receipt.LastReadMessageID != messages[2].ID {
+		t.Fatalf("expected the marker to stay at %d, got %+v, %v", messages[2].ID, receipt, err)
+	}
+
+	summaries, err := testStorage.ListChats(ctx, models.ChatFilter{Title: "Unread Chat", MemberID: testOwner.ID}, 10, nil)
+	if err != nil || len(summaries) == 0 || summaries[0].ID != chat.ID || summaries[0].UnreadCount != 1 {
+		t.Fatalf("expected the listing to report 1 unread message, got %+v, %v", summaries, err)
+	}
+
+	receipt = &models.ReadReceipt{ChatID: chat.ID + 1, UserID: testOwner.ID, LastReadMessageID: messages[3].ID, UpdatedAt: time.Now().UTC()}
+	if err := testStorage.MarkRead(ctx, receipt); !errors.Is(err, errs.ErrMessageNotFound) {
+		t.Fatalf("expected ErrMessageNotFound for a message of another chat, got %v", err)
+	}
+
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	key := &models.IdempotencyKey{UserID: testOwner.ID, Key: fmt.Sprintf("key-%d", now.UnixNano()), RequestHash: "hash", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+
+	if reserved, err := testStorage.ReserveIdempotencyKey(ctx, key); err != nil || !reserved {
+		t.Fatalf("expected the key to be reserved, got %v, %v", reserved, err)
+	}
+
+	retry := &models.IdempotencyKey{UserID: testOwner.ID, Key: key.Key, RequestHash: "other", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	if reserved, err := testStorage.ReserveIdempotencyKey(ctx, retry); err != nil || reserved || retry.RequestHash != "hash" || retry.StatusCode != 0 {
+		t.Fatalf("expected the key in progress to be loaded, got %+v, %v, %v", retry, reserved, err)
+	}
+
+	if err := testStorage.ReleaseIdempotencyKey(ctx, testOwner.ID, key.Key); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey failed: %v", err)
+	}
+
+	if reserved, err := testStorage.ReserveIdempotencyKey(ctx, key); err != nil || !reserved {
+		t.Fatalf("expected the released key to be reserved again, got %v, %v", reserved, err)
+	}
+
+	key.StatusCode, key.ResponseBody = 200, []byte(`{"result":{}}`)
+	if err := testStorage.CompleteIdempotencyKey(ctx, *key); err != nil {
+		t.Fatalf("CompleteIdempotencyKey failed: %v", err)
+	}
+
+	// completed keys are kept on release
+	if err := testStorage.ReleaseIdempotencyKey(ctx, testOwner.ID, key.Key); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey failed: %v", err)
+	}
+
+	retry = &models.IdempotencyKey{UserID: testOwner.ID, Key: key.Key, RequestHash: "hash", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	if reserved, err := testStorage.ReserveIdempotencyKey(ctx, retry); err != nil || reserved || retry.StatusCode != 200 || string(retry.ResponseBody) != `{"result":{}}` {
+		t.Fatalf("expected the stored response, got %+v, %v, %v", retry, reserved, err)
+	}
+
+	later := now.Add(2 * time.Hour)
+
+	takeover := &models.IdempotencyKey{UserID: testOwner.ID, Key: key.Key, RequestHash: "other", CreatedAt: later, ExpiresAt: later.Add(time.Hour)}
+	if reserved, err := testStorage.ReserveIdempotencyKey(ctx, takeover); err != nil || !reserved {
+		t.Fatalf("expected the expired key to be reserved again, got %v, %v", reserved, err)
+	}
+
+	deleted, err := testStorage.DeleteExpiredIdempotencyKeys(ctx, later.Add(2*time.Hour))
+	if err != nil || deleted < 1 {
+		t.Fatalf("expected expired keys to be deleted, got %d, %v", deleted, err)
+	}
+
+}
+
+func TestPingAndSchemaVersion(t *testing.T) {
+
+	ctx := context.Background()
+
+	if err := testStorage.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+
+	migrations, err := goose.CollectMigrations("../../../migrations", 0, goose.MaxVersion)
+	if err != nil {
+		t.Fatalf("failed to collect migrations: %v", err)
+	}
+	latest, err := migrations.Last()
+	if err != nil {
+		t.Fatalf("failed to find latest migration: %v", err)
+	}
+
+	version, err := testStorage.SchemaVersion(ctx)
+	if err != nil || version != latest.Version {
+		t.Fatalf("expected schema version %d, got %d, %v", latest.Version, version, err)
+	}
+
+}
+
+func TestTracingPlugin(t *testing.T) {
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	_, err := testStorage.GetChat(ctx, -1, 10, nil)
+	parent.End()
+
+	if !errors.Is(err, errs.ErrChatNotFound) {
+		t.Fatalf("expected ErrChatNotFound, got %v", err)
+	}
+
+	var queries int
+	for _, span := range recorder.Ended() {
+		if span.Name() != "gorm.query" {
+			continue
+		}
+		queries++
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("expected query span to be a child of the request span")
+		}
+		if span.Status().Code == codes.Error {
+			t.Errorf("a missing record must not mark the span as failed")
+		}
+	}
+
+	if queries == 0 {
+		t.Fatal("expected at least one query span")
+	}
+
+}
+
 func TestStorageClose(t *testing.T) {
 	if testStorage == nil {
 		t.Fatal("testStorage is nil")
@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"chatX/internal/models"
+	"context"
+)
+
+// ReleaseIdempotencyKey deletes an idempotency key that is in progress so that the request can be retried.
+//
+// Keys that are already completed are kept.
+func (s *Storage) ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error {
+	return s.db.WithContext(ctx).
+		Where("user_id = ? AND key = ? AND status_code = 0", userID, key).
+		Delete(&models.IdempotencyKey{}).Error
+}
@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+)
+
+// RemoveMember deletes the membership of a user in a chat.
+//
+// Returns ErrMemberNotFound if the user is not a member of the chat.
+func (s *Storage) RemoveMember(ctx context.Context, chatID int, userID int) error {
+
+	result := s.db.WithContext(ctx).Where("chat_id = ? AND user_id = ?", chatID, userID).Delete(&models.ChatMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrMemberNotFound
+	}
+
+	return nil
+
+}
@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+)
+
+// RemoveReaction deletes a reaction of a user to a message of a chat.
+//
+// Returns ErrReactionNotFound if the user has not reacted to the message with the emoji.
+func (s *Storage) RemoveReaction(ctx context.Context, chatID int, reaction models.MessageReaction) error {
+
+	result := s.db.WithContext(ctx).
+		Where("message_id = ? AND user_id = ? AND emoji = ?", reaction.MessageID, reaction.UserID, reaction.Emoji).
+		Where("EXISTS (SELECT 1 FROM messages WHERE messages.id = message_reactions.message_id AND messages.chat_id = ?)", chatID).
+		Delete(&models.MessageReaction{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrReactionNotFound
+	}
+
+	return nil
+
+}
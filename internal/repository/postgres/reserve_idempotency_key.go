@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReserveIdempotencyKey inserts an idempotency key that is in progress.
+//
+// Returns true if the key was reserved, either because it was unused or because its previous
+// use has expired. Otherwise the key is filled with the stored record and false is returned.
+// Returns ErrIdempotencyKeyInProgress if the stored record disappears in between, since the
+// request holding it has just been released.
+func (s *Storage) ReserveIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) (bool, error) {
+
+	takeover := clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"request_hash", "status_code", "response_body", "created_at", "expires_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{clause.Expr{
+			SQL: "idempotency_keys.expires_at <= excluded.created_at",
+		}}},
+	}
+
+	result := s.db.WithContext(ctx).Clauses(takeover).Create(key)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+
+	if err := s.db.WithContext(ctx).Where("user_id = ? AND key = ?", key.UserID, key.Key).Take(key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, errs.ErrIdempotencyKeyInProgress
+		}
+		return false, err
+	}
+
+	return false, nil
+
+}
@@ -0,0 +1,20 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+)
+
+// SchemaVersion returns the version of the latest migration applied by goose.
+func (s *Storage) SchemaVersion(ctx context.Context) (int64, error) {
+
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+
+	return goose.GetDBVersionContext(ctx, sqlDB)
+
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"chatX/internal/models"
+	"context"
+	"html"
+	"strings"
+)
+
+// searchQuery parses the search terms in web search syntax with the text search configuration of the search_vector column.
+const searchQuery = "websearch_to_tsquery('simple', ?)"
+
+// Snippet highlighting markers. Control characters are used so that the text can be
+// HTML-escaped after ts_headline has marked the matches.
+const (
+	startSel = "\x02"
+	stopSel  = "\x03"
+)
+
+// headlineOptions configures the snippets of search hits: up to two short fragments around the matches.
+const headlineOptions = "StartSel=" + startSel + ", StopSel=" + stopSel + ", MaxWords=20, MinWords=5, MaxFragments=2, FragmentDelimiter=\" … \""
+
+// highlighter turns the snippet markers into <mark> tags once the snippet is HTML-escaped.
+var highlighter = strings.NewReplacer(startSel, "<mark>", stopSel, "</mark>")
+
+// searchRow is a message search result as scanned from the database.
+type searchRow struct {
+	models.Message
+	Rank    float32
+	Snippet string
+}
+
+// SearchMessages retrieves messages matching a full-text search query, most relevant first.
+//
+// Matches are found with the GIN index on search_vector and ranked with ts_rank; the message
+// ID breaks ties between equally ranked hits. The cursor, if provided, selects the hits
+// following it in that order. Only chats the member belongs to are searched.
+func (s *Storage) SearchMessages(ctx context.Context, query models.SearchQuery, limit int, cursor *models.SearchCursor) ([]models.SearchHit, error) {
+
+	inner := s.db.Table("messages").
+		Select("messages.*, ts_rank(messages.search_vector, "+searchQuery+") AS rank", query.Text).
+		Where("messages.search_vector @@ "+searchQuery, query.Text).
+		Where("EXISTS (SELECT 1 FROM chat_members WHERE chat_members.chat_id = messages.chat_id AND chat_members.user_id = ?)", query.MemberID)
+
+	if query.ChatID != 0 {
+		inner = inner.Where("messages.chat_id = ?", query.ChatID)
+	}
+
+	search := s.db.WithContext(ctx).Table("(?) AS messages", inner).
+		Select(messageColumns+", ts_headline('simple', messages.text, "+searchQuery+", ?) AS snippet", query.Text, headlineOptions)
+
+	if cursor != nil {
+		search = search.Where("(messages.rank, messages.id) < (?, ?)", cursor.Rank, cursor.ID)
+	}
+
+	rows := make([]searchRow, 0, limit)
+
+	if err := search.Order("messages.rank DESC, messages.id DESC").Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]models.SearchHit, len(rows))
+	for i, row := range rows {
+		hits[i] = models.SearchHit{Message: row.Message, Rank: row.Rank, Snippet: highlighter.Replace(html.EscapeString(row.Snippet))}
+	}
+
+	return hits, nil
+
+}
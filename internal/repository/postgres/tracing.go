@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracer creates the spans of database queries.
+var tracer = otel.Tracer("chatX/internal/repository/postgres")
+
+// TracingPlugin is a GORM plugin that records a client span for every
+// statement executed with a context, as a child of the span in that context.
+type TracingPlugin struct{}
+
+// Name returns the name the plugin is registered under.
+func (TracingPlugin) Name() string {
+	return "chatx:tracing"
+}
+
+// Initialize registers span callbacks around every GORM statement processor.
+func (TracingPlugin) Initialize(db *gorm.DB) error {
+
+	callbacks := db.Callback()
+
+	register := []struct {
+		operation string
+		before    func(string, func(*gorm.DB)) error
+		after     func(string, func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+
+	for _, r := range register {
+		if err := r.before("tracing:before_"+r.operation, startSpan("gorm."+r.operation)); err != nil {
+			return err
+		}
+		if err := r.after("tracing:after_"+r.operation, endSpan); err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+// startSpan returns a callback starting the span of a statement and storing it in the statement context.
+func startSpan(name string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+		db.Statement.Context, _ = tracer.Start(db.Statement.Context, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("db.system", "postgresql")),
+		)
+	}
+}
+
+// endSpan records the executed SQL, the affected rows and any error other
+// than a missing record on the span of a statement, then ends it.
+func endSpan(db *gorm.DB) {
+
+	if db.Statement.Context == nil {
+		return
+	}
+
+	span := trace.SpanFromContext(db.Statement.Context)
+	if !span.IsRecording() {
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.String("db.sql.table", db.Statement.Table),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+
+	span.End()
+
+}
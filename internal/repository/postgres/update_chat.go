@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+
+	"gorm.io/gorm/clause"
+)
+
+// UpdateChat updates the title and update timestamp of a chat in the database.
+//
+// The stored row is written back into the chat. Returns ErrChatNotFound if no such chat exists.
+func (s *Storage) UpdateChat(ctx context.Context, chat *models.Chat) error {
+
+	result := s.db.WithContext(ctx).Model(chat).Clauses(clause.Returning{}).
+		Updates(map[string]any{"title": chat.Title, "updated_at": chat.UpdatedAt})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrChatNotFound
+	}
+
+	return nil
+
+}
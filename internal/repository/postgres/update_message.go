@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+
+	"gorm.io/gorm/clause"
+)
+
+// returningMessage returns the updated row along with the reply and reaction counts of the message.
+var returningMessage = clause.Returning{Columns: []clause.Column{{Name: messageColumns, Raw: true}}}
+
+// UpdateMessage updates the text and edit timestamp of a message in the database.
+//
+// The message is matched by both its ID and chat ID, and the stored row is
+// written back into the message together with its reply and reaction counts. Returns ErrMessageNotFound if no such message exists.
+func (s *Storage) UpdateMessage(ctx context.Context, message *models.Message) error {
+
+	result := s.db.WithContext(ctx).Model(message).Clauses(returningMessage).
+		Where("chat_id = ?", message.ChatID).
+		Updates(map[string]any{"text": message.Text, "edited_at": message.EditedAt})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrMessageNotFound
+	}
+
+	return nil
+
+}
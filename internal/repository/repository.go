@@ -8,7 +8,9 @@ import (
 	"chatX/internal/models"
 	"chatX/internal/repository/postgres"
 	"context"
+	"database/sql"
 	"fmt"
+	"time"
 
 	pg "gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -17,11 +19,38 @@ import (
 
 // Storage defines the interface for interacting with chat and message data.
 type Storage interface {
-	CreateChat(ctx context.Context, chat *models.Chat) error                 // CreateChat inserts a new chat into the database.
-	CreateMessage(ctx context.Context, message *models.Message) error        // CreateMessage inserts a new message into the database.
-	GetChat(ctx context.Context, chatID int, limit int) (models.Chat, error) // GetChat retrieves a chat by ID, optionally limiting the number of messages returned.
-	DeleteChat(ctx context.Context, chatID int) error                        // DeleteChat deletes a chat and its messages by chat ID.
-	Close()                                                                  // Close closes any resources used by the storage backend (e.g., database connections).
+	CreateChat(ctx context.Context, chat *models.Chat, ownerID int) error                                                             // CreateChat inserts a new chat together with the membership of its owner.
+	CreateMessage(ctx context.Context, message *models.Message) error                                                                 // CreateMessage inserts a new message together with its attachments.
+	GetChat(ctx context.Context, chatID int, limit int, cursor *models.Cursor) (models.Chat, error)                                   // GetChat retrieves a chat by ID with a page of messages, starting from the newest or from the cursor.
+	ListChats(ctx context.Context, filter models.ChatFilter, limit int, cursor *models.Cursor) ([]models.ChatSummary, error)          // ListChats retrieves chat summaries matching the filter, starting after the cursor if provided.
+	GetMessage(ctx context.Context, chatID int, messageID int) (models.Message, error)                                                // GetMessage retrieves a single message of a chat.
+	GetReplies(ctx context.Context, chatID int, parentID int, limit int, cursor *models.Cursor) ([]models.Message, error)             // GetReplies retrieves replies to a message, oldest first, starting after the cursor if provided.
+	GetMessagesAfter(ctx context.Context, chatID int, afterID int, limit int) ([]models.Message, error)                               // GetMessagesAfter retrieves messages of a chat with IDs greater than afterID, oldest first.
+	GetMessagesSince(ctx context.Context, chatID int, afterID int, grace time.Duration, limit int) ([]models.Message, error)          // GetMessagesSince retrieves messages of a chat newer than afterID or committed late within grace before it, by ID.
+	UpdateMessage(ctx context.Context, message *models.Message) error                                                                 // UpdateMessage updates the text and edit timestamp of a message in its chat.
+	DeleteMessage(ctx context.Context, chatID int, messageID int) (models.Attachments, error)                                         // DeleteMessage deletes a single message and its replies from its chat, returning their attachments.
+	UpdateChat(ctx context.Context, chat *models.Chat) error                                                                          // UpdateChat updates the title and update timestamp of a chat.
+	DeleteChat(ctx context.Context, chatID int) (models.Attachments, error)                                                           // DeleteChat deletes a chat and its messages by chat ID, returning their attachments.
+	MarkRead(ctx context.Context, receipt *models.ReadReceipt) error                                                                  // MarkRead advances the read marker of a chat member to a message, never moving it backwards.
+	CountUnread(ctx context.Context, chatID int, userID int) (int, error)                                                             // CountUnread counts the messages of a chat the member has not read yet.
+	ReserveIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) (bool, error)                                              // ReserveIdempotencyKey reserves an unused or expired idempotency key, or loads the stored record.
+	CompleteIdempotencyKey(ctx context.Context, key models.IdempotencyKey) error                                                      // CompleteIdempotencyKey stores the response to the request of a reserved idempotency key.
+	ReleaseIdempotencyKey(ctx context.Context, userID int, key string) error                                                          // ReleaseIdempotencyKey deletes an idempotency key that is in progress.
+	DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error)                                                   // DeleteExpiredIdempotencyKeys deletes the idempotency keys that expired before now.
+	SearchMessages(ctx context.Context, query models.SearchQuery, limit int, cursor *models.SearchCursor) ([]models.SearchHit, error) // SearchMessages retrieves messages matching a full-text query, most relevant first, starting after the cursor if provided.
+	GetAttachment(ctx context.Context, chatID int, attachmentID int) (models.Attachment, error)                                       // GetAttachment retrieves an attachment of a message of a chat.
+	AddReaction(ctx context.Context, reaction *models.MessageReaction) error                                                          // AddReaction inserts a reaction to a message unless the user has already made it.
+	RemoveReaction(ctx context.Context, chatID int, reaction models.MessageReaction) error                                            // RemoveReaction deletes a reaction of a user to a message of a chat.
+	AddMember(ctx context.Context, member *models.ChatMember) error                                                                   // AddMember inserts a new chat membership.
+	GetMember(ctx context.Context, chatID int, userID int) (models.ChatMember, error)                                                 // GetMember retrieves the membership of a user in a chat.
+	ListMembers(ctx context.Context, chatID int) ([]models.ChatMember, error)                                                         // ListMembers retrieves all members of a chat with their usernames.
+	RemoveMember(ctx context.Context, chatID int, userID int) error                                                                   // RemoveMember deletes the membership of a user in a chat.
+	CreateUser(ctx context.Context, user *models.User) error                                                                          // CreateUser inserts a new user into the database.
+	GetUserByUsername(ctx context.Context, username string) (models.User, error)                                                      // GetUserByUsername retrieves a user by case-insensitive username.
+	Ping(ctx context.Context) error                                                                                                   // Ping verifies that the database is reachable.
+	SchemaVersion(ctx context.Context) (int64, error)                                                                                 // SchemaVersion returns the version of the latest applied migration.
+	Stats() sql.DBStats                                                                                                               // Stats returns the connection pool statistics of the database.
+	Close()                                                                                                                           // Close closes any resources used by the storage backend (e.g., database connections).
 }
 
 // NewStorage creates a new Storage instance using the Postgres backend.
@@ -29,6 +58,12 @@ func NewStorage(logger logger.Logger, config config.Storage, db *gorm.DB) Storag
 	return postgres.NewStorage(logger, config, db)
 }
 
+// DSN builds the PostgreSQL connection string from the given configuration.
+func DSN(config config.Storage) string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		config.Host, config.Port, config.Username, config.Password, config.DBName, config.SSLMode)
+}
+
 // ConnectDB establishes a GORM connection to a PostgreSQL database based on the given configuration.
 // Configures connection pool limits and verifies connectivity via Ping.
 //
@@ -37,14 +72,15 @@ func NewStorage(logger logger.Logger, config config.Storage, db *gorm.DB) Storag
 //   - error: any connection or configuration error
 func ConnectDB(config config.Storage) (*gorm.DB, error) {
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		config.Host, config.Port, config.Username, config.Password, config.DBName, config.SSLMode)
-
-	db, err := gorm.Open(pg.Open(dsn), &gorm.Config{Logger: gormLogger.Discard})
+	db, err := gorm.Open(pg.Open(DSN(config)), &gorm.Config{Logger: gormLogger.Discard})
 	if err != nil {
 		return nil, fmt.Errorf("failed to open gorm connection: %w", err)
 	}
 
+	if err := db.Use(postgres.TracingPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register gorm tracing plugin: %w", err)
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get underlying sql.DB from gorm: %w", err)
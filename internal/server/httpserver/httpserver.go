@@ -47,6 +47,14 @@ func (s *HttpServer) Run() error {
 	return nil
 }
 
+// RegisterOnShutdown registers a function to call when Shutdown begins.
+//
+// Used to release hijacked and streaming connections, which are not closed
+// by the HTTP server itself and would otherwise delay the graceful shutdown.
+func (s *HttpServer) RegisterOnShutdown(f func()) {
+	s.srv.RegisterOnShutdown(f)
+}
+
 // Shutdown gracefully stops the HTTP server, waiting up to shutdownTimeout
 func (s *HttpServer) Shutdown() {
 	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
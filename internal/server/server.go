@@ -10,8 +10,9 @@ import (
 
 // Server defines the interface for running and gracefully shutting down an HTTP server.
 type Server interface {
-	Run() error // Run starts the server and begins listening for HTTP requests.
-	Shutdown()  // Shutdown gracefully stops the server, allowing in-flight requests to complete.
+	Run() error                  // Run starts the server and begins listening for HTTP requests.
+	Shutdown()                   // Shutdown gracefully stops the server, allowing in-flight requests to complete.
+	RegisterOnShutdown(f func()) // RegisterOnShutdown registers a function to call when Shutdown begins, e.g. to close long-lived connections.
 }
 
 // NewServer creates a new Server instance using the internal HTTP server implementation.
@@ -0,0 +1,55 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// AddMember adds a user to a chat with the given role.
+//
+// Only admins and the owner manage members, and only with a role below their own:
+// admins add members and read-only members, the owner also adds admins.
+func (s *Service) AddMember(ctx context.Context, userID int, member models.ChatMember) (models.ChatMember, error) {
+
+	ctx, span := startSpan(ctx, "AddMember", attribute.Int("user.id", userID), attribute.Int("chat.id", member.ChatID))
+	defer span.End()
+
+	if err := validateRole(&member.Role); err != nil {
+		return models.ChatMember{}, err
+	}
+
+	actor, err := s.authorize(ctx, userID, member.ChatID, models.RoleAdmin)
+	if err != nil {
+		return models.ChatMember{}, err
+	}
+
+	if roleRanks[actor.Role] <= roleRanks[member.Role] {
+		return models.ChatMember{}, errs.ErrForbidden
+	}
+
+	member.CreatedAt = time.Now().UTC()
+
+	if err := s.storage.AddMember(ctx, &member); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case pgerrcode.UniqueViolation:
+				return models.ChatMember{}, errs.ErrMemberExists
+			case pgerrcode.ForeignKeyViolation:
+				return models.ChatMember{}, errs.ErrUserNotFound
+			}
+		}
+		s.logger.LogErrorContext(ctx, "service — failed to add chat member", err, "chatID", member.ChatID, "userID", member.UserID, "layer", "service.impl")
+		return models.ChatMember{}, err
+	}
+
+	return member, nil
+
+}
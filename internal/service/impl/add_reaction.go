@@ -0,0 +1,66 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// AddReaction adds an emoji reaction of the user to a message and invalidates the chat's cache entry.
+//
+// Reacting requires permission to write in the chat; repeating a reaction is a no-op.
+// Returns the message with its updated reaction counts, or ErrMessageNotFound if the
+// chat has no such message.
+func (s *Service) AddReaction(ctx context.Context, userID int, chatID int, messageID int, emoji string) (models.Message, error) {
+
+	ctx, span := startSpan(ctx, "AddReaction", attribute.Int("user.id", userID), attribute.Int("chat.id", chatID), attribute.Int("message.id", messageID))
+	defer span.End()
+
+	if err := validateEmoji(&emoji); err != nil {
+		return models.Message{}, err
+	}
+
+	if _, err := s.authorize(ctx, userID, chatID, models.RoleMember); err != nil {
+		return models.Message{}, err
+	}
+
+	if _, err := s.getMessage(ctx, chatID, messageID); err != nil {
+		return models.Message{}, err
+	}
+
+	reaction := models.MessageReaction{MessageID: messageID, UserID: userID, Emoji: emoji, CreatedAt: time.Now().UTC()}
+
+	if err := s.storage.AddReaction(ctx, &reaction); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return models.Message{}, errs.ErrMessageNotFound
+		}
+		s.logger.LogErrorContext(ctx, "service — failed to add reaction", err, "messageID", messageID, "layer", "service.impl")
+		return models.Message{}, err
+	}
+
+	s.invalidate(ctx, chatID)
+	return s.getMessage(ctx, chatID, messageID)
+
+}
+
+// getMessage retrieves a message of a chat, logging unexpected storage errors.
+func (s *Service) getMessage(ctx context.Context, chatID int, messageID int) (models.Message, error) {
+
+	message, err := s.storage.GetMessage(ctx, chatID, messageID)
+	if err != nil {
+		if !errors.Is(err, errs.ErrMessageNotFound) {
+			s.logger.LogErrorContext(ctx, "service — failed to get message", err, "messageID", messageID, "layer", "service.impl")
+		}
+		return models.Message{}, err
+	}
+
+	return message, nil
+
+}
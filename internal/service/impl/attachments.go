@@ -0,0 +1,171 @@
+package impl
+
+import (
+	"bytes"
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const sniffLength = 512              // sniffLength is the number of leading bytes http.DetectContentType considers
+const maxFilenameLength = 255        // maxFilenameLength is the maximum length of a stored file name in runes
+const defaultFilename = "attachment" // defaultFilename replaces file names that are empty after sanitizing
+const blobKeyPrefix = "attachments/" // blobKeyPrefix groups attachment contents in the blob store
+
+// validateUploads checks the number and sizes of the files uploaded with a message.
+func (s *Service) validateUploads(uploads []models.Upload) error {
+
+	if len(uploads) > s.config.MaxAttachments {
+		return errs.ErrTooManyAttachments
+	}
+
+	for _, upload := range uploads {
+		if upload.Size < 0 || upload.Size > s.config.MaxAttachmentSize {
+			return errs.ErrAttachmentTooLarge
+		}
+	}
+
+	return nil
+
+}
+
+// storeUploads sniffs the media type of every upload and writes its content to the blob store.
+//
+// The type declared by the client is never trusted. If a file is rejected or cannot be
+// stored, the blobs already written are deleted again.
+func (s *Service) storeUploads(ctx context.Context, uploads []models.Upload) (models.Attachments, error) {
+
+	attachments := make(models.Attachments, 0, len(uploads))
+
+	for _, upload := range uploads {
+		attachment, err := s.storeUpload(ctx, upload)
+		if err != nil {
+			s.deleteBlobs(ctx, attachments)
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+
+}
+
+// storeUpload sniffs the media type of a single upload and writes it to the blob store.
+func (s *Service) storeUpload(ctx context.Context, upload models.Upload) (models.Attachment, error) {
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(upload.Content, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		s.logger.LogErrorContext(ctx, "service — failed to read upload", err, "layer", "service.impl")
+		return models.Attachment{}, err
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	if !s.allowedMediaType(contentType) {
+		return models.Attachment{}, errs.ErrUnsupportedMediaType
+	}
+
+	key, err := newBlobKey()
+	if err != nil {
+		s.logger.LogErrorContext(ctx, "service — failed to generate blob key", err, "layer", "service.impl")
+		return models.Attachment{}, err
+	}
+
+	content := io.MultiReader(bytes.NewReader(head), upload.Content)
+	if err := s.blobs.Put(ctx, key, content, upload.Size, contentType); err != nil {
+		s.logger.LogErrorContext(ctx, "service — failed to store attachment", err, "key", key, "layer", "service.impl")
+		return models.Attachment{}, err
+	}
+
+	return models.Attachment{
+		BlobKey:     key,
+		Filename:    sanitizeFilename(upload.Filename),
+		ContentType: contentType,
+		Size:        upload.Size,
+		CreatedAt:   time.Now().UTC(),
+	}, nil
+
+}
+
+// deleteBlobs removes the contents of attachments that were never persisted or have been deleted.
+//
+// Failures are only logged: the blobs are unreachable either way. The cleanup
+// runs even if the caller has gone away in the meantime.
+func (s *Service) deleteBlobs(ctx context.Context, attachments models.Attachments) {
+	for _, attachment := range attachments {
+		if err := s.blobs.Delete(context.WithoutCancel(ctx), attachment.BlobKey); err != nil {
+			s.logger.LogErrorContext(ctx, "service — failed to delete orphaned attachment", err, "key", attachment.BlobKey, "layer", "service.impl")
+		}
+	}
+}
+
+// allowedMediaType reports whether the sniffed content type matches one of the configured media types.
+//
+// Parameters such as the charset are ignored, and an entry of the form "type/*"
+// matches every subtype of the type.
+func (s *Service) allowedMediaType(contentType string) bool {
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range s.config.AllowedMediaTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == allowed {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// newBlobKey returns a random, unguessable blob store key for a new attachment.
+func newBlobKey() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	return blobKeyPrefix + hex.EncodeToString(id[:]), nil
+}
+
+// sanitizeFilename strips directories and control characters from a client file name
+// and truncates it to maxFilenameLength runes.
+func sanitizeFilename(name string) string {
+
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = path.Base(strings.ToValidUTF8(name, ""))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	if utf8.RuneCountInString(name) > maxFilenameLength {
+		name = string([]rune(name)[:maxFilenameLength])
+	}
+
+	if name == "" || name == "." || name == "/" {
+		return defaultFilename
+	}
+
+	return name
+
+}
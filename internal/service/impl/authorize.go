@@ -0,0 +1,62 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+)
+
+// roleRanks orders chat roles by the permissions they grant; unknown roles rank lowest.
+var roleRanks = map[models.Role]int{
+	models.RoleReadOnly: 1,
+	models.RoleMember:   2,
+	models.RoleAdmin:    3,
+	models.RoleOwner:    4,
+}
+
+// authorize checks that the user is a member of the chat with at least the given role.
+//
+// Returns the membership of the user, or ErrForbidden if the user is not a member or
+// its role is too low. Non-members get ErrForbidden whether or not the chat exists,
+// so chat IDs cannot be probed; a membership in turn guarantees that the chat exists.
+func (s *Service) authorize(ctx context.Context, userID int, chatID int, role models.Role) (models.ChatMember, error) {
+
+	member, err := s.storage.GetMember(ctx, chatID, userID)
+	if err != nil {
+		if errors.Is(err, errs.ErrMemberNotFound) {
+			return models.ChatMember{}, errs.ErrForbidden
+		}
+		s.logger.LogErrorContext(ctx, "service — failed to get chat member", err, "chatID", chatID, "userID", userID, "layer", "service.impl")
+		return models.ChatMember{}, err
+	}
+
+	if roleRanks[member.Role] < roleRanks[role] {
+		return models.ChatMember{}, errs.ErrForbidden
+	}
+
+	return member, nil
+
+}
+
+// authorizeAuthor checks that the member wrote the message.
+//
+// Returns ErrMessageNotFound if the chat has no such message and ErrForbidden if the
+// message belongs to someone else.
+func (s *Service) authorizeAuthor(ctx context.Context, member models.ChatMember, messageID int) error {
+
+	message, err := s.storage.GetMessage(ctx, member.ChatID, messageID)
+	if err != nil {
+		if !errors.Is(err, errs.ErrMessageNotFound) {
+			s.logger.LogErrorContext(ctx, "service — failed to get message", err, "messageID", messageID, "layer", "service.impl")
+		}
+		return err
+	}
+
+	if message.AuthorID == nil || *message.AuthorID != member.UserID {
+		return errs.ErrForbidden
+	}
+
+	return nil
+
+}
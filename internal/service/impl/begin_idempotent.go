@@ -0,0 +1,58 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// BeginIdempotent reserves an idempotency key of the user for a request.
+//
+// It returns true if the request should be processed; its response must then be passed
+// to CompleteIdempotent. It returns false with the stored record if a previous request
+// with the same key has completed, so that its response can be replayed. A key reused
+// for a request with a different hash fails with ErrIdempotencyKeyReused, and a key
+// whose request is still being processed with ErrIdempotencyKeyInProgress.
+func (s *Service) BeginIdempotent(ctx context.Context, userID int, key, requestHash string) (models.IdempotencyKey, bool, error) {
+
+	ctx, span := startSpan(ctx, "BeginIdempotent", attribute.Int("user.id", userID))
+	defer span.End()
+
+	if err := validateIdempotencyKey(key); err != nil {
+		return models.IdempotencyKey{}, false, err
+	}
+
+	now := time.Now().UTC()
+
+	record := models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.config.IdempotencyTTL),
+	}
+
+	reserved, err := s.storage.ReserveIdempotencyKey(ctx, &record)
+	if err != nil {
+		if !errors.Is(err, errs.ErrIdempotencyKeyInProgress) {
+			s.logger.LogErrorContext(ctx, "service — failed to reserve idempotency key", err, "userID", userID, "layer", "service.impl")
+		}
+		return models.IdempotencyKey{}, false, err
+	}
+
+	switch {
+	case reserved:
+		return record, true, nil
+	case record.RequestHash != requestHash:
+		return models.IdempotencyKey{}, false, errs.ErrIdempotencyKeyReused
+	case record.StatusCode == 0:
+		return models.IdempotencyKey{}, false, errs.ErrIdempotencyKeyInProgress
+	default:
+		return record, false, nil
+	}
+
+}
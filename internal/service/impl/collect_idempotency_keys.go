@@ -0,0 +1,36 @@
+package impl
+
+import (
+	"context"
+	"time"
+)
+
+// CollectIdempotencyKeys periodically deletes expired idempotency keys until the context is cancelled.
+//
+// Keys are deleted every IdempotencyGC interval; a non-positive interval disables the collection.
+func (s *Service) CollectIdempotencyKeys(ctx context.Context) {
+
+	if s.config.IdempotencyGC <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.config.IdempotencyGC)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			deleted, err := s.storage.DeleteExpiredIdempotencyKeys(ctx, now.UTC())
+			if err != nil {
+				if ctx.Err() == nil {
+					s.logger.LogErrorContext(ctx, "service — failed to delete expired idempotency keys", err, "layer", "service.impl")
+				}
+				continue
+			}
+			s.logger.Debug("service — deleted expired idempotency keys", "count", deleted, "layer", "service.impl")
+		}
+	}
+
+}
@@ -0,0 +1,31 @@
+package impl
+
+import (
+	"chatX/internal/models"
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// CompleteIdempotent stores the response to a request whose idempotency key was reserved by BeginIdempotent.
+//
+// Server errors are not stored: the key is released instead, so that a retry processes
+// the request again. Failures are only logged, since the response has already been sent.
+func (s *Service) CompleteIdempotent(ctx context.Context, record models.IdempotencyKey) {
+
+	ctx, span := startSpan(ctx, "CompleteIdempotent", attribute.Int("user.id", record.UserID))
+	defer span.End()
+
+	if record.StatusCode >= http.StatusInternalServerError {
+		if err := s.storage.ReleaseIdempotencyKey(ctx, record.UserID, record.Key); err != nil {
+			s.logger.LogErrorContext(ctx, "service — failed to release idempotency key", err, "userID", record.UserID, "layer", "service.impl")
+		}
+		return
+	}
+
+	if err := s.storage.CompleteIdempotencyKey(ctx, record); err != nil {
+		s.logger.LogErrorContext(ctx, "service — failed to complete idempotency key", err, "userID", record.UserID, "layer", "service.impl")
+	}
+
+}
@@ -4,10 +4,15 @@ import (
 	"chatX/internal/models"
 	"context"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// CreateChat creates a new chat in the system.
-func (s *Service) CreateChat(ctx context.Context, chat models.Chat) (models.Chat, error) {
+// CreateChat creates a new chat in the system, owned by the user who created it.
+func (s *Service) CreateChat(ctx context.Context, userID int, chat models.Chat) (models.Chat, error) {
+
+	ctx, span := startSpan(ctx, "CreateChat", attribute.Int("user.id", userID))
+	defer span.End()
 
 	if err := s.validateChat(&chat); err != nil {
 		return models.Chat{}, err
@@ -15,11 +20,13 @@ func (s *Service) CreateChat(ctx context.Context, chat models.Chat) (models.Chat
 
 	initChat(&chat)
 
-	if err := s.storage.CreateChat(ctx, &chat); err != nil {
-		s.logger.LogError("service — failed to create chat", err, "layer", "service.impl")
+	if err := s.storage.CreateChat(ctx, &chat, userID); err != nil {
+		s.logger.LogErrorContext(ctx, "service — failed to create chat", err, "layer", "service.impl")
 		return models.Chat{}, err
 	}
 
+	s.chatsCreated.Add(1)
+
 	return chat, nil
 
 }
@@ -27,4 +34,5 @@ func (s *Service) CreateChat(ctx context.Context, chat models.Chat) (models.Chat
 // initChat initializes fields for a new chat.
 func initChat(chat *models.Chat) {
 	chat.CreatedAt = time.Now().UTC()
+	chat.UpdatedAt = chat.CreatedAt
 }
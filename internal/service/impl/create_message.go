@@ -9,31 +9,106 @@ import (
 
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // CreateMessage creates a new message associated with a chat.
-func (s *Service) CreateMessage(ctx context.Context, message models.Message) (models.Message, error) {
+//
+// The user must be a chat member allowed to write and becomes the author of the message.
+// A message with a parent ID is a reply and must answer a message of the same chat.
+// Uploaded files are stored in the blob store and attached to the message; a message
+// with attachments may have an empty text. The new message is added to the cached chat
+// instead of evicting it, so an active chat keeps being served from the cache.
+func (s *Service) CreateMessage(ctx context.Context, userID int, message models.Message, uploads ...models.Upload) (models.Message, error) {
 
-	if err := s.validateMessage(&message); err != nil {
+	ctx, span := startSpan(ctx, "CreateMessage", attribute.Int("user.id", userID), attribute.Int("chat.id", message.ChatID))
+	defer span.End()
+
+	if err := s.validateMessage(&message); err != nil && !(errors.Is(err, errs.ErrMessageEmpty) && len(uploads) > 0) {
+		return models.Message{}, err
+	}
+
+	if err := s.validateUploads(uploads); err != nil {
+		return models.Message{}, err
+	}
+
+	if _, err := s.authorize(ctx, userID, message.ChatID, models.RoleMember); err != nil {
+		return models.Message{}, err
+	}
+
+	if err := s.resolveParent(ctx, &message); err != nil {
+		return models.Message{}, err
+	}
+
+	attachments, err := s.storeUploads(ctx, uploads)
+	if err != nil {
 		return models.Message{}, err
 	}
 
 	initMessage(&message)
+	message.AuthorID = &userID
+	message.Attachments = attachments
 
 	if err := s.storage.CreateMessage(ctx, &message); err != nil {
+		s.deleteBlobs(ctx, attachments)
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			// the parent may have been deleted since it was resolved
+			if pgErr.ConstraintName == parentConstraint {
+				return models.Message{}, errs.ErrInvalidParent
+			}
 			return models.Message{}, errs.ErrChatNotFound
 		}
-		s.logger.LogError("service — failed to create message", err, "layer", "service.impl")
+		s.logger.LogErrorContext(ctx, "service — failed to create message", err, "layer", "service.impl")
 		return models.Message{}, err
 	}
 
-	s.cache.Delete(message.ChatID)
+	s.messagesCreated.Add(1)
+
+	// the cache takes the message in, while other instances with caches of their own evict the chat
+	s.cache.Append(ctx, message.ChatID, cachedMessage(message), s.config.GetLimitMax)
+	s.invalidateOthers(ctx, message.ChatID)
+	s.broker.Publish(models.Event{Type: models.EventMessageCreated, ChatID: message.ChatID, Message: message})
+	s.notifier.Notify(message.ChatID)
+
 	return message, nil
 
 }
 
+// parentConstraint is the name of the foreign key from a reply to its parent message.
+const parentConstraint = "fk_messages_parent"
+
+// resolveParent checks that a reply answers an existing message of the same chat.
+//
+// Threads are one level deep: a reply to a reply joins the thread of its parent.
+// Messages without a parent ID are left untouched.
+func (s *Service) resolveParent(ctx context.Context, message *models.Message) error {
+
+	if message.ParentID == nil {
+		return nil
+	}
+
+	if *message.ParentID <= 0 {
+		return errs.ErrInvalidParent
+	}
+
+	parent, err := s.storage.GetMessage(ctx, message.ChatID, *message.ParentID)
+	if err != nil {
+		if errors.Is(err, errs.ErrMessageNotFound) {
+			return errs.ErrInvalidParent
+		}
+		s.logger.LogErrorContext(ctx, "service — failed to get parent message", err, "messageID", *message.ParentID, "layer", "service.impl")
+		return err
+	}
+
+	if parent.ParentID != nil {
+		message.ParentID = parent.ParentID
+	}
+
+	return nil
+
+}
+
 // initMessage initializes fields for a new message.
 func initMessage(message *models.Message) {
 	message.CreatedAt = time.Now().UTC()
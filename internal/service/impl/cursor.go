@@ -0,0 +1,160 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// encodeCursor converts a message position into an opaque cursor string.
+//
+// The cursor carries the creation timestamp in microseconds, matching Postgres
+// timestamp precision, and the message ID used to break ties.
+func encodeCursor(createdAt time.Time, id int) string {
+	raw := fmt.Sprintf("%d:%d", createdAt.UnixMicro(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor parses an opaque cursor string produced by encodeCursor.
+//
+// Returns ErrInvalidCursor if the string is not a well-formed cursor.
+func decodeCursor(cursorStr string, direction models.Direction) (*models.Cursor, error) {
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursorStr)
+	if err != nil {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	var micros int64
+	var id int
+
+	if n, err := fmt.Sscanf(string(raw), "%d:%d", &micros, &id); err != nil || n != 2 || id <= 0 {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	return &models.Cursor{CreatedAt: time.UnixMicro(micros).UTC(), ID: id, Direction: direction}, nil
+
+}
+
+// encodeSearchCursor converts a search hit position into an opaque cursor string.
+//
+// The cursor carries the rank of the hit in its shortest exact float32 form and
+// the message ID used to break ties.
+func encodeSearchCursor(rank float32, id int) string {
+	raw := strconv.FormatFloat(float64(rank), 'g', -1, 32) + ":" + strconv.Itoa(id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeSearchCursor parses an opaque cursor string produced by encodeSearchCursor.
+//
+// An empty string yields a nil cursor, which means the most relevant hits are
+// requested. Returns ErrInvalidCursor if the string is not a well-formed cursor.
+func decodeSearchCursor(cursorStr string) (*models.SearchCursor, error) {
+
+	if cursorStr == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursorStr)
+	if err != nil {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	rankStr, idStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	rank, err := strconv.ParseFloat(rankStr, 32)
+	if err != nil {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	return &models.SearchCursor{Rank: float32(rank), ID: id}, nil
+
+}
+
+// encodePollCursor converts the position of a long-polling reader into an opaque cursor string.
+//
+// The cursor carries the highest delivered message ID followed by the lower IDs
+// delivered within the replay grace before it.
+func encodePollCursor(cursor models.PollCursor) string {
+
+	raw := strconv.AppendInt(nil, int64(cursor.AfterID), 10)
+	raw = append(raw, ':')
+	for i, id := range cursor.Seen {
+		if i > 0 {
+			raw = append(raw, ',')
+		}
+		raw = strconv.AppendInt(raw, int64(id), 10)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+
+}
+
+// decodePollCursor parses an opaque cursor string produced by encodePollCursor.
+//
+// Returns ErrInvalidCursor if the string is not a well-formed cursor.
+func decodePollCursor(cursorStr string) (models.PollCursor, error) {
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursorStr)
+	if err != nil {
+		return models.PollCursor{}, errs.ErrInvalidCursor
+	}
+
+	afterStr, seenStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return models.PollCursor{}, errs.ErrInvalidCursor
+	}
+
+	afterID, err := strconv.Atoi(afterStr)
+	if err != nil || afterID < 0 {
+		return models.PollCursor{}, errs.ErrInvalidCursor
+	}
+
+	cursor := models.PollCursor{AfterID: afterID}
+	if seenStr == "" {
+		return cursor, nil
+	}
+
+	for idStr := range strings.SplitSeq(seenStr, ",") {
+		id, err := strconv.Atoi(idStr)
+		if err != nil || id <= 0 || id >= afterID {
+			return models.PollCursor{}, errs.ErrInvalidCursor
+		}
+		cursor.Seen = append(cursor.Seen, id)
+	}
+
+	return cursor, nil
+
+}
+
+// nextCursor returns the cursor of the page following the given messages.
+//
+// Messages are expected newest first. When paging backwards the cursor points at
+// the oldest message of the page, when paging forwards at the newest one.
+// Returns an empty string if there are no more messages in that direction.
+func nextCursor(messages []models.Message, direction models.Direction, hasMore bool) string {
+
+	if !hasMore || len(messages) == 0 {
+		return ""
+	}
+
+	boundary := messages[len(messages)-1]
+	if direction == models.After {
+		boundary = messages[0]
+	}
+
+	return encodeCursor(boundary.CreatedAt, boundary.ID)
+
+}
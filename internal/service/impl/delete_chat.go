@@ -2,18 +2,34 @@ package impl
 
 import (
 	"chatX/internal/errs"
+	"chatX/internal/models"
 	"context"
 	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // DeleteChat deletes a chat and invalidates its cache entry.
-func (s *Service) DeleteChat(ctx context.Context, chatID int) error {
-	if err := s.storage.DeleteChat(ctx, chatID); err != nil {
+//
+// Only the owner of the chat may delete it. The blobs of the attachments of its
+// messages are deleted once the deletion has committed.
+func (s *Service) DeleteChat(ctx context.Context, userID int, chatID int) error {
+	ctx, span := startSpan(ctx, "DeleteChat", attribute.Int("user.id", userID), attribute.Int("chat.id", chatID))
+	defer span.End()
+
+	if _, err := s.authorize(ctx, userID, chatID, models.RoleOwner); err != nil {
+		return err
+	}
+	attachments, err := s.storage.DeleteChat(ctx, chatID)
+	if err != nil {
 		if !errors.Is(err, errs.ErrChatNotFound) {
-			s.logger.LogError("service — failed to delete chat", err, "chatID", chatID, "layer", "service.impl")
+			s.logger.LogErrorContext(ctx, "service — failed to delete chat", err, "chatID", chatID, "layer", "service.impl")
 		}
 		return err
 	}
-	s.cache.Delete(chatID)
+	s.invalidate(ctx, chatID)
+	s.broker.Publish(models.Event{Type: models.EventChatDeleted, ChatID: chatID})
+	s.notifier.Notify(chatID)
+	s.deleteBlobs(ctx, attachments)
 	return nil
 }
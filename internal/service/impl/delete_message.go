@@ -0,0 +1,47 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DeleteMessage deletes a single message and invalidates the chat's cache entry.
+//
+// Replies to the message are deleted along with it, and the blobs of the
+// attachments of both once the deletion has committed.
+//
+// Members allowed to write may delete their own messages; admins and the owner
+// may delete any message of the chat.
+func (s *Service) DeleteMessage(ctx context.Context, userID int, chatID int, messageID int) error {
+
+	ctx, span := startSpan(ctx, "DeleteMessage", attribute.Int("user.id", userID), attribute.Int("chat.id", chatID), attribute.Int("message.id", messageID))
+	defer span.End()
+
+	member, err := s.authorize(ctx, userID, chatID, models.RoleMember)
+	if err != nil {
+		return err
+	}
+
+	if roleRanks[member.Role] < roleRanks[models.RoleAdmin] {
+		if err := s.authorizeAuthor(ctx, member, messageID); err != nil {
+			return err
+		}
+	}
+
+	attachments, err := s.storage.DeleteMessage(ctx, chatID, messageID)
+	if err != nil {
+		if !errors.Is(err, errs.ErrMessageNotFound) {
+			s.logger.LogErrorContext(ctx, "service — failed to delete message", err, "messageID", messageID, "layer", "service.impl")
+		}
+		return err
+	}
+
+	s.invalidate(ctx, chatID)
+	s.deleteBlobs(ctx, attachments)
+	return nil
+
+}
@@ -0,0 +1,46 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GetAttachment retrieves an attachment of a chat message and opens its content in the blob store.
+//
+// The caller must close the returned content. Returns ErrAttachmentNotFound if no message
+// of the chat has such an attachment or its content is missing from the blob store.
+func (s *Service) GetAttachment(ctx context.Context, userID int, chatID int, attachmentID int) (models.Attachment, io.ReadCloser, error) {
+
+	ctx, span := startSpan(ctx, "GetAttachment", attribute.Int("user.id", userID), attribute.Int("chat.id", chatID), attribute.Int("attachment.id", attachmentID))
+	defer span.End()
+
+	if _, err := s.authorize(ctx, userID, chatID, models.RoleReadOnly); err != nil {
+		return models.Attachment{}, nil, err
+	}
+
+	attachment, err := s.storage.GetAttachment(ctx, chatID, attachmentID)
+	if err != nil {
+		if !errors.Is(err, errs.ErrAttachmentNotFound) {
+			s.logger.LogErrorContext(ctx, "service — failed to get attachment", err, "attachmentID", attachmentID, "layer", "service.impl")
+		}
+		return models.Attachment{}, nil, err
+	}
+
+	content, err := s.blobs.Get(ctx, attachment.BlobKey)
+	if err != nil {
+		if errors.Is(err, errs.ErrBlobNotFound) {
+			s.logger.LogWarnContext(ctx, "service — attachment content is missing", "attachmentID", attachmentID, "key", attachment.BlobKey, "layer", "service.impl")
+			return models.Attachment{}, nil, errs.ErrAttachmentNotFound
+		}
+		s.logger.LogErrorContext(ctx, "service — failed to open attachment", err, "attachmentID", attachmentID, "layer", "service.impl")
+		return models.Attachment{}, nil, err
+	}
+
+	return attachment, content, nil
+
+}
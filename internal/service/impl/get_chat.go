@@ -5,37 +5,115 @@ import (
 	"chatX/internal/models"
 	"context"
 	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// GetChat retrieves a chat along with its messages, applying a messages limit.
+// GetChat retrieves a chat along with a page of its messages, applying a messages limit.
+//
+// This method first validates the provided limit and cursor strings and checks that
+// the user is a member of the chat. Requests for the
+// first page attempt to fetch the chat from the cache. If the chat is not found in cache,
+// it loads the chat from storage with the maximum allowed messages, sharing the load with
+// concurrent requests for the same chat, caches it, and then
+// applies the requested limit to the messages slice. Requests with a before or after
+// cursor bypass the cache and are served from storage via keyset pagination. The
+// number of messages the user has not read yet is counted on every request.
 //
-// This method first validates the provided limit string. Then it attempts to fetch
-// the chat from the cache. If the chat is not found in cache, it loads the chat
-// from storage with the maximum allowed messages, caches it, and then applies
-// the requested limit to the messages slice.
-func (s *Service) GetChat(ctx context.Context, chatID int, limitStr string) (models.Chat, error) {
+// Along with the chat it returns the cursor of the next page in the same direction,
+// or an empty string if there are no more messages.
+func (s *Service) GetChat(ctx context.Context, userID int, chatID int, limitStr, before, after string) (models.Chat, string, error) {
+
+	ctx, span := startSpan(ctx, "GetChat", attribute.Int("user.id", userID), attribute.Int("chat.id", chatID))
+	defer span.End()
 
 	limit, err := s.validateLimit(limitStr)
 	if err != nil {
-		return models.Chat{}, err
+		return models.Chat{}, "", err
+	}
+
+	cursor, err := validateCursor(before, after)
+	if err != nil {
+		return models.Chat{}, "", err
+	}
+
+	if _, err := s.authorize(ctx, userID, chatID, models.RoleReadOnly); err != nil {
+		return models.Chat{}, "", err
+	}
+
+	var chat models.Chat
+	var next string
+
+	if cursor != nil {
+		chat, next, err = s.getChatPage(ctx, chatID, limit, cursor)
+	} else {
+		chat, next, err = s.getLatestPage(ctx, chatID, limit)
+	}
+	if err != nil {
+		return models.Chat{}, "", err
 	}
 
-	chat, err := s.cache.Get(chatID)
+	// the unread count is per user, so it is never part of the cached chat
+	chat.UnreadCount, err = s.countUnread(ctx, userID, chatID)
 	if err != nil {
-		chat, err = s.storage.GetChat(ctx, chatID, s.config.GetLimitMax)
+		return models.Chat{}, "", err
+	}
+
+	return chat, next, nil
+
+}
+
+// getLatestPage loads the page of the newest messages, serving it from the cache if possible.
+//
+// On a cache miss the chat is loaded from storage with the maximum allowed messages
+// and cached before the requested limit is applied. Concurrent misses of the same chat
+// share a single storage load.
+func (s *Service) getLatestPage(ctx context.Context, chatID int, limit int) (models.Chat, string, error) {
+
+	chat, err := s.cache.Get(ctx, chatID)
+	if err != nil {
+		chat, err = s.loads.do(ctx, chatID, func(ctx context.Context) (models.Chat, error) {
+			return s.loadChat(ctx, chatID)
+		})
 		if err != nil {
-			if !errors.Is(err, errs.ErrChatNotFound) {
-				s.logger.LogError("service — failed to get chat", err, "chatID", chatID, "layer", "service.impl")
-			}
-			return models.Chat{}, err
+			return models.Chat{}, "", err
 		}
-		s.cache.Put(chatID, chat)
 	}
 
+	// a full GetLimitMax load may hide older messages that were never fetched
+	hasMore := len(chat.Messages) > limit || len(chat.Messages) == s.config.GetLimitMax
+
 	if len(chat.Messages) > limit {
 		chat.Messages = chat.Messages[:limit]
 	}
 
-	return chat, nil
+	return chat, nextCursor(chat.Messages, models.Before, hasMore), nil
+
+}
+
+// getChatPage loads a page of messages relative to the cursor directly from storage.
+//
+// One extra message is requested to find out whether another page follows.
+func (s *Service) getChatPage(ctx context.Context, chatID int, limit int, cursor *models.Cursor) (models.Chat, string, error) {
+
+	chat, err := s.storage.GetChat(ctx, chatID, limit+1, cursor)
+	if err != nil {
+		if !errors.Is(err, errs.ErrChatNotFound) {
+			s.logger.LogErrorContext(ctx, "service — failed to get chat page", err, "chatID", chatID, "layer", "service.impl")
+		}
+		return models.Chat{}, "", err
+	}
+
+	hasMore := len(chat.Messages) > limit
+
+	if hasMore {
+		if cursor.Direction == models.After {
+			chat.Messages = chat.Messages[len(chat.Messages)-limit:]
+		} else {
+			chat.Messages = chat.Messages[:limit]
+		}
+	}
+
+	return chat, nextCursor(chat.Messages, cursor.Direction, hasMore), nil
 
 }
@@ -0,0 +1,64 @@
+package impl
+
+import (
+	"chatX/internal/models"
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GetMessagesAfter retrieves messages of a chat newer than the given message ID.
+//
+// At most GetLimitMax messages are returned, oldest first, so callers catching up
+// on a long backlog request the next batch after the last returned ID.
+func (s *Service) GetMessagesAfter(ctx context.Context, userID int, chatID int, afterID int) ([]models.Message, error) {
+
+	ctx, span := startSpan(ctx, "GetMessagesAfter", attribute.Int("user.id", userID), attribute.Int("chat.id", chatID))
+	defer span.End()
+
+	if _, err := s.authorize(ctx, userID, chatID, models.RoleReadOnly); err != nil {
+		return nil, err
+	}
+
+	return s.getMessagesAfter(ctx, chatID, afterID)
+
+}
+
+// getMessagesAfter loads messages newer than afterID from storage without access checks.
+func (s *Service) getMessagesAfter(ctx context.Context, chatID int, afterID int) ([]models.Message, error) {
+
+	messages, err := s.storage.GetMessagesAfter(ctx, chatID, afterID, s.config.GetLimitMax)
+	if err != nil {
+		s.logger.LogErrorContext(ctx, "service — failed to get messages", err, "chatID", chatID, "layer", "service.impl")
+		return nil, err
+	}
+
+	return messages, nil
+
+}
+
+// ReplayMessages retrieves the messages of a chat a stream resumed after the given message ID may have missed.
+//
+// Besides the messages newer than lastID, these include older ones created at most the replay
+// grace before it: message IDs are taken before their transactions commit, so such a message may
+// have been committed, and published, after lastID was delivered. The caller skips those it has
+// already delivered. At most GetLimitMax messages are returned, ordered by ID, so callers
+// catching up on a long backlog request the next batch with GetMessagesAfter.
+func (s *Service) ReplayMessages(ctx context.Context, userID int, chatID int, lastID int) ([]models.Message, error) {
+
+	ctx, span := startSpan(ctx, "ReplayMessages", attribute.Int("user.id", userID), attribute.Int("chat.id", chatID))
+	defer span.End()
+
+	if _, err := s.authorize(ctx, userID, chatID, models.RoleReadOnly); err != nil {
+		return nil, err
+	}
+
+	messages, err := s.storage.GetMessagesSince(ctx, chatID, lastID, s.config.ReplayGrace, s.config.GetLimitMax)
+	if err != nil {
+		s.logger.LogErrorContext(ctx, "service — failed to replay messages", err, "chatID", chatID, "layer", "service.impl")
+		return nil, err
+	}
+
+	return messages, nil
+
+}
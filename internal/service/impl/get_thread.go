@@ -0,0 +1,60 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GetThread retrieves a message along with a page of its replies, oldest first.
+//
+// The limit and cursor strings are validated like those of GetChat; the cursor
+// selects the replies following it. One extra reply is loaded to find out whether
+// another page follows. Along with the thread it returns the cursor of the next
+// page, or an empty string if there are no more replies.
+func (s *Service) GetThread(ctx context.Context, userID int, chatID int, messageID int, limitStr, cursorStr string) (models.Thread, string, error) {
+
+	ctx, span := startSpan(ctx, "GetThread", attribute.Int("user.id", userID), attribute.Int("chat.id", chatID), attribute.Int("message.id", messageID))
+	defer span.End()
+
+	limit, err := s.validateLimit(limitStr)
+	if err != nil {
+		return models.Thread{}, "", err
+	}
+
+	cursor, err := validateCursor(cursorStr, "")
+	if err != nil {
+		return models.Thread{}, "", err
+	}
+
+	if _, err := s.authorize(ctx, userID, chatID, models.RoleReadOnly); err != nil {
+		return models.Thread{}, "", err
+	}
+
+	message, err := s.storage.GetMessage(ctx, chatID, messageID)
+	if err != nil {
+		if !errors.Is(err, errs.ErrMessageNotFound) {
+			s.logger.LogErrorContext(ctx, "service — failed to get message", err, "messageID", messageID, "layer", "service.impl")
+		}
+		return models.Thread{}, "", err
+	}
+
+	replies, err := s.storage.GetReplies(ctx, chatID, messageID, limit+1, cursor)
+	if err != nil {
+		s.logger.LogErrorContext(ctx, "service — failed to get replies", err, "messageID", messageID, "layer", "service.impl")
+		return models.Thread{}, "", err
+	}
+
+	if len(replies) <= limit {
+		return models.Thread{Message: message, Replies: replies}, "", nil
+	}
+
+	replies = replies[:limit]
+	last := replies[limit-1]
+
+	return models.Thread{Message: message, Replies: replies}, encodeCursor(last.CreatedAt, last.ID), nil
+
+}
@@ -3,21 +3,34 @@
 package impl
 
 import (
+	"chatX/internal/blob"
+	"chatX/internal/broker"
 	"chatX/internal/cache"
 	"chatX/internal/config"
+	"chatX/internal/eventbus"
 	"chatX/internal/logger"
+	"chatX/internal/notifier"
 	"chatX/internal/repository"
+	"sync/atomic"
 )
 
 // Service implements the business logic for managing chats and messages.
 type Service struct {
-	logger  logger.Logger      // structured logger
-	config  config.Service     // service-specific configuration
-	cache   cache.Cache        // cache layer for fast access
-	storage repository.Storage // persistent storage layer
+	logger   logger.Logger      // structured logger
+	config   config.Service     // service-specific configuration
+	cache    cache.Cache        // cache layer for fast access
+	storage  repository.Storage // persistent storage layer
+	broker   broker.Broker      // event broker for real-time delivery
+	notifier notifier.Notifier  // notifier waking long-polling requests
+	bus      eventbus.EventBus  // event bus invalidating caches of other instances
+	blobs    blob.BlobStore     // blob store keeping attachment contents
+	loads    chatLoads          // storage loads of chats shared by concurrent cache misses
+
+	chatsCreated    atomic.Uint64 // number of chats created since startup
+	messagesCreated atomic.Uint64 // number of messages created since startup
 }
 
 // NewService creates a new Service instance with the provided dependencies.
-func NewService(logger logger.Logger, config config.Service, cache cache.Cache, storage repository.Storage) *Service {
-	return &Service{logger: logger, cache: cache, config: config, storage: storage}
+func NewService(logger logger.Logger, config config.Service, cache cache.Cache, storage repository.Storage, broker broker.Broker, notifier notifier.Notifier, bus eventbus.EventBus, blobs blob.BlobStore) *Service {
+	return &Service{logger: logger, cache: cache, config: config, storage: storage, broker: broker, notifier: notifier, bus: bus, blobs: blobs}
 }
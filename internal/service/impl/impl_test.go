@@ -1,45 +1,85 @@
 package impl
 
 import (
+	mockBlob "chatX/internal/blob/mocks"
+	mockBroker "chatX/internal/broker/mocks"
+	"chatX/internal/cache/memory"
 	mockCache "chatX/internal/cache/mocks"
 	"chatX/internal/config"
 	"chatX/internal/errs"
+	mockBus "chatX/internal/eventbus/mocks"
 	mockLogger "chatX/internal/logger/mocks"
 	"chatX/internal/models"
+	mockNotifier "chatX/internal/notifier/mocks"
 	mockStorage "chatX/internal/repository/mocks"
 	"context"
 	"errors"
+	"io"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
 )
 
-func newTestService(controller *gomock.Controller) (*Service, *mockLogger.MockLogger, *mockCache.MockCache, *mockStorage.MockStorage) {
+const testUserID = 3
+
+// expectRole makes the storage mock report the role of the test user in the chat.
+func expectRole(storageMock *mockStorage.MockStorage, chatID int, role models.Role) {
+	storageMock.EXPECT().GetMember(gomock.Any(), chatID, testUserID).
+		Return(models.ChatMember{ChatID: chatID, UserID: testUserID, Role: role}, nil)
+}
+
+func newTestService(controller *gomock.Controller) (*Service, *mockLogger.MockLogger, *mockCache.MockCache, *mockStorage.MockStorage, *mockBroker.MockBroker, *mockNotifier.MockNotifier, *mockBus.MockEventBus) {
 
 	loggerMock := mockLogger.NewMockLogger(controller)
 	cacheMock := mockCache.NewMockCache(controller)
 	storageMock := mockStorage.NewMockStorage(controller)
+	brokerMock := mockBroker.NewMockBroker(controller)
+	notifierMock := mockNotifier.NewMockNotifier(controller)
+	busMock := mockBus.NewMockEventBus(controller)
 
 	loggerMock.EXPECT().LogError(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogErrorContext(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 	loggerMock.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogInfoContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 	loggerMock.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogWarnContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 	loggerMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().DebugContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 	loggerMock.EXPECT().LogFatal(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	cacheMock.EXPECT().Shared().Return(false).AnyTimes()
 
 	cfg := config.Service{
-		MaxTitleLength:   200,
-		MaxMessageLength: 1000,
-		GetLimitDefault:  10,
-		GetLimitMax:      100,
+		MaxTitleLength:    200,
+		MaxMessageLength:  1000,
+		GetLimitDefault:   10,
+		GetLimitMax:       100,
+		MaxWait:           time.Minute,
+		ReplayGrace:       5 * time.Second,
+		MinPasswordLength: 8,
+		TokenTTL:          time.Hour,
+		StreamTokenTTL:    time.Minute,
+		TokenSecret:       "test-secret",
+		MaxAttachments:    2,
+		MaxAttachmentSize: 1024,
+		AllowedMediaTypes: []string{"image/*", "text/plain"},
+		IdempotencyTTL:    time.Hour,
 	}
 
-	svc := NewService(loggerMock, cfg, cacheMock, storageMock)
-	return svc, loggerMock, cacheMock, storageMock
+	svc := NewService(loggerMock, cfg, cacheMock, storageMock, brokerMock, notifierMock, busMock, mockBlob.NewMockBlobStore(controller))
+	return svc, loggerMock, cacheMock, storageMock, brokerMock, notifierMock, busMock
 
 }
 
@@ -48,15 +88,16 @@ func TestCreateChat_Success(t *testing.T) {
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc, _, _, storageMock := newTestService(controller)
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
 
 	chat := models.Chat{Title: "  test aboba  "}
 
-	storageMock.EXPECT().CreateChat(gomock.Any(), gomock.AssignableToTypeOf(&models.Chat{})).Return(nil)
+	storageMock.EXPECT().CreateChat(gomock.Any(), gomock.AssignableToTypeOf(&models.Chat{}), testUserID).Return(nil)
 
-	res, err := svc.CreateChat(context.Background(), chat)
+	res, err := svc.CreateChat(context.Background(), testUserID, chat)
 	assert.NoError(t, err)
 	assert.Equal(t, "test aboba", res.Title)
+	assert.Equal(t, models.ServiceStats{ChatsCreated: 1}, svc.Stats())
 
 }
 
@@ -65,32 +106,154 @@ func TestCreateChat_ValidateFail_TitleEmpty(t *testing.T) {
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc, _, _, _ := newTestService(controller)
+	svc, _, _, _, _, _, _ := newTestService(controller)
 
 	chat := models.Chat{Title: "   "}
 
-	_, err := svc.CreateChat(context.Background(), chat)
+	_, err := svc.CreateChat(context.Background(), testUserID, chat)
 	assert.Error(t, err)
 	assert.True(t, errors.Is(err, errs.ErrTitleEmpty))
 
 }
 
+func TestUpdateChat_Success(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, busMock := newTestService(controller)
+
+	expectRole(storageMock, 7, models.RoleAdmin)
+	storageMock.EXPECT().UpdateChat(gomock.Any(), gomock.AssignableToTypeOf(&models.Chat{})).Return(nil)
+	cacheMock.EXPECT().Delete(7).Times(1)
+	busMock.EXPECT().Publish(gomock.Any(), 7).Return(nil)
+
+	res, err := svc.UpdateChat(context.Background(), testUserID, models.Chat{ID: 7, Title: "  renamed  "})
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed", res.Title)
+	assert.False(t, res.UpdatedAt.IsZero())
+
+}
+
+func TestUpdateChat_ValidateFail_TitleTooLong(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	storageMock.EXPECT().UpdateChat(gomock.Any(), gomock.Any()).Times(0)
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+
+	_, err := svc.UpdateChat(context.Background(), testUserID, models.Chat{ID: 7, Title: strings.Repeat("a", svc.config.MaxTitleLength+1)})
+	assert.True(t, errors.Is(err, errs.ErrTitleTooLong))
+
+}
+
+func TestUpdateChat_NotFound_ReturnsErrChatNotFound(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	expectRole(storageMock, 7, models.RoleOwner)
+	storageMock.EXPECT().UpdateChat(gomock.Any(), gomock.Any()).Return(errs.ErrChatNotFound)
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+
+	_, err := svc.UpdateChat(context.Background(), testUserID, models.Chat{ID: 7, Title: "renamed"})
+	assert.True(t, errors.Is(err, errs.ErrChatNotFound))
+
+}
+
 func TestCreateMessage_Success(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc, _, cacheMock, storageMock := newTestService(controller)
+	svc, _, cacheMock, storageMock, brokerMock, notifierMock, busMock := newTestService(controller)
 
 	msg := models.Message{ChatID: 1, Text: "  qwe  "}
 
+	expectRole(storageMock, msg.ChatID, models.RoleMember)
 	storageMock.EXPECT().CreateMessage(gomock.Any(), gomock.AssignableToTypeOf(&models.Message{})).Return(nil)
-	cacheMock.EXPECT().Delete(msg.ChatID).Times(1)
-
-	res, err := svc.CreateMessage(context.Background(), msg)
+	cacheMock.EXPECT().Append(gomock.Any(), msg.ChatID, gomock.Any(), svc.config.GetLimitMax).Do(func(_ context.Context, _ int, message models.Message, _ int) {
+		assert.Equal(t, "qwe", message.Text)
+		assert.Equal(t, models.Reactions{}, message.Reactions)
+	})
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+	busMock.EXPECT().Publish(gomock.Any(), msg.ChatID).Return(nil)
+	brokerMock.EXPECT().Publish(gomock.Any()).Do(func(event models.Event) {
+		assert.Equal(t, models.EventMessageCreated, event.Type)
+		assert.Equal(t, msg.ChatID, event.ChatID)
+		assert.Equal(t, "qwe", event.Message.Text)
+	})
+	notifierMock.EXPECT().Notify(msg.ChatID)
+
+	res, err := svc.CreateMessage(context.Background(), testUserID, msg)
 	assert.NoError(t, err)
 	assert.Equal(t, "qwe", res.Text)
 	assert.Equal(t, 1, res.ChatID)
+	assert.Equal(t, testUserID, *res.AuthorID)
+	assert.Equal(t, models.ServiceStats{MessagesCreated: 1}, svc.Stats())
+
+}
+
+// sharedCache is an in-memory cache standing in for a cache shared by all instances.
+type sharedCache struct {
+	*memory.ShardedCache
+}
+
+// Shared reports true.
+func (sharedCache) Shared() bool {
+	return true
+}
+
+// peerBus is an event bus delivering published changes straight to the listener of a peer instance.
+type peerBus struct {
+	invalidate func(chatID int)
+}
+
+// Publish invalidates the chat in the peer instance.
+func (b peerBus) Publish(_ context.Context, chatID int) error {
+	b.invalidate(chatID)
+	return nil
+}
+
+// Listen does nothing; changes are delivered by Publish.
+func (peerBus) Listen(context.Context, func(chatID int), func()) {}
+
+func TestCreateMessage_SharedCache_SurvivesPeerInvalidation(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	base, loggerMock, _, storageMock, brokerMock, notifierMock, _ := newTestService(controller)
+
+	cache := sharedCache{memory.NewShardedCache(loggerMock, config.Cache{Capacity: 8, Shards: 1, MaxMessages: 100})}
+	defer cache.Close()
+
+	// the peer's cache is the same shared cache, so its listener would evict the shared chat
+	svc := NewService(loggerMock, base.config, cache, storageMock, brokerMock, notifierMock, peerBus{invalidate: cache.Delete}, mockBlob.NewMockBlobStore(controller))
+
+	chatID := 1
+	cache.Put(context.Background(), chatID, models.Chat{ID: chatID, Title: "chat", Messages: []models.Message{{ID: 1, ChatID: chatID, Text: "first", CreatedAt: time.Now().Add(-time.Minute)}}})
+
+	expectRole(storageMock, chatID, models.RoleMember)
+	storageMock.EXPECT().CreateMessage(gomock.Any(), gomock.AssignableToTypeOf(&models.Message{})).DoAndReturn(func(_ context.Context, message *models.Message) error {
+		message.ID = 2
+		return nil
+	})
+	brokerMock.EXPECT().Publish(gomock.Any())
+	notifierMock.EXPECT().Notify(chatID)
+
+	_, err := svc.CreateMessage(context.Background(), testUserID, models.Message{ChatID: chatID, Text: "second"})
+	require.NoError(t, err)
+
+	chat, err := cache.Get(context.Background(), chatID)
+	require.NoError(t, err)
+	require.Len(t, chat.Messages, 2)
+	assert.Equal(t, "second", chat.Messages[0].Text)
 
 }
 
@@ -99,349 +262,2316 @@ func TestCreateMessage_ForeignKeyViolation_ReturnsChatNotFound(t *testing.T) {
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc, _, cacheMock, storageMock := newTestService(controller)
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
 
 	msg := models.Message{ChatID: 999, Text: "qweqweqwe"}
 
 	pgErr := &pgconn.PgError{Code: pgerrcode.ForeignKeyViolation}
 
+	expectRole(storageMock, msg.ChatID, models.RoleMember)
 	storageMock.EXPECT().CreateMessage(gomock.Any(), gomock.AssignableToTypeOf(&models.Message{})).Return(pgErr)
 	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
 
-	_, err := svc.CreateMessage(context.Background(), msg)
+	_, err := svc.CreateMessage(context.Background(), testUserID, msg)
 	assert.Error(t, err)
 	assert.True(t, errors.Is(err, errs.ErrChatNotFound))
 
 }
 
-func TestDeleteChat_Success(t *testing.T) {
+func TestCreateMessage_ReplyToReply_JoinsParentThread(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc, _, cacheMock, storageMock := newTestService(controller)
-
-	chatID := 7
+	svc, _, cacheMock, storageMock, brokerMock, notifierMock, busMock := newTestService(controller)
 
-	storageMock.EXPECT().DeleteChat(gomock.Any(), chatID).Return(nil)
-	cacheMock.EXPECT().Delete(chatID).Times(1)
+	root, reply := 5, 6
 
-	err := svc.DeleteChat(context.Background(), chatID)
+	expectRole(storageMock, 1, models.RoleMember)
+	storageMock.EXPECT().GetMessage(gomock.Any(), 1, reply).Return(models.Message{ID: reply, ChatID: 1, ParentID: &root}, nil)
+	storageMock.EXPECT().CreateMessage(gomock.Any(), gomock.AssignableToTypeOf(&models.Message{})).Return(nil)
+	cacheMock.EXPECT().Append(gomock.Any(), 1, gomock.Any(), gomock.Any()).Do(func(_ context.Context, _ int, message models.Message, _ int) {
+		assert.Equal(t, root, *message.ParentID)
+	})
+	busMock.EXPECT().Publish(gomock.Any(), 1).Return(nil)
+	brokerMock.EXPECT().Publish(gomock.Any())
+	notifierMock.EXPECT().Notify(1)
+
+	res, err := svc.CreateMessage(context.Background(), testUserID, models.Message{ChatID: 1, Text: "me too", ParentID: &reply})
 	assert.NoError(t, err)
+	assert.Equal(t, root, *res.ParentID)
 
 }
 
-func TestDeleteChat_NotFound_ReturnsErrChatNotFound(t *testing.T) {
+func TestCreateMessage_InvalidParent(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc, _, cacheMock, storageMock := newTestService(controller)
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
 
-	chatID := 42
+	zero, other, gone := 0, 7, 8
 
-	storageMock.EXPECT().DeleteChat(gomock.Any(), chatID).Return(errs.ErrChatNotFound)
-	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+	storageMock.EXPECT().GetMember(gomock.Any(), 1, testUserID).
+		Return(models.ChatMember{ChatID: 1, UserID: testUserID, Role: models.RoleMember}, nil).Times(3)
+	storageMock.EXPECT().GetMessage(gomock.Any(), 1, other).Return(models.Message{}, errs.ErrMessageNotFound)
+	storageMock.EXPECT().GetMessage(gomock.Any(), 1, gone).Return(models.Message{ID: gone, ChatID: 1}, nil)
+	storageMock.EXPECT().CreateMessage(gomock.Any(), gomock.Any()).
+		Return(&pgconn.PgError{Code: pgerrcode.ForeignKeyViolation, ConstraintName: parentConstraint})
 
-	err := svc.DeleteChat(context.Background(), chatID)
-	assert.Error(t, err)
-	assert.True(t, errors.Is(err, errs.ErrChatNotFound))
+	for _, parentID := range []*int{&zero, &other, &gone} {
+		_, err := svc.CreateMessage(context.Background(), testUserID, models.Message{ChatID: 1, Text: "qwe", ParentID: parentID})
+		assert.True(t, errors.Is(err, errs.ErrInvalidParent), "parent %d", *parentID)
+	}
 
 }
 
-func TestGetChat_CacheHit_NoStorageCall(t *testing.T) {
+// pngHeader is the signature http.DetectContentType recognizes as image/png.
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+func TestCreateMessage_WithAttachments_StoresBlobs(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc, _, cacheMock, storageMock := newTestService(controller)
+	svc, _, cacheMock, storageMock, brokerMock, notifierMock, busMock := newTestService(controller)
+	blobMock := mockBlob.NewMockBlobStore(controller)
+	svc.blobs = blobMock
 
-	chatID := 1
-	chat := models.Chat{
-		ID:    chatID,
-		Title: "cached",
-		Messages: []models.Message{
-			{Text: "m1"},
-			{Text: "m2"},
-		},
+	image := pngHeader + "pixels"
+	uploads := []models.Upload{
+		{Filename: "../../photo.png", Size: int64(len(image)), Content: strings.NewReader(image)},
+		{Filename: "notes.txt", Size: 5, Content: strings.NewReader("hello")},
 	}
 
-	cacheMock.EXPECT().Get(chatID).Return(chat, nil)
-	storageMock.EXPECT().GetChat(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
-
-	res, err := svc.GetChat(context.Background(), chatID, "")
+	var stored []string
+	blobMock.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2).
+		DoAndReturn(func(_ context.Context, key string, content io.Reader, size int64, _ string) error {
+			data, err := io.ReadAll(content)
+			assert.NoError(t, err)
+			assert.EqualValues(t, size, len(data))
+			assert.True(t, strings.HasPrefix(key, blobKeyPrefix))
+			stored = append(stored, string(data))
+			return nil
+		})
+
+	expectRole(storageMock, 1, models.RoleMember)
+	storageMock.EXPECT().CreateMessage(gomock.Any(), gomock.Any()).Return(nil)
+	cacheMock.EXPECT().Append(gomock.Any(), 1, gomock.Any(), gomock.Any()).Do(func(_ context.Context, _ int, message models.Message, _ int) {
+		require.Len(t, message.Attachments, 2)
+		for _, attachment := range message.Attachments {
+			assert.Empty(t, attachment.BlobKey, "blob keys are not cached")
+		}
+	})
+	busMock.EXPECT().Publish(gomock.Any(), 1).Return(nil)
+	brokerMock.EXPECT().Publish(gomock.Any())
+	notifierMock.EXPECT().Notify(1)
+
+	msg, err := svc.CreateMessage(context.Background(), testUserID, models.Message{ChatID: 1, Text: "  "}, uploads...)
 	assert.NoError(t, err)
-	assert.Equal(t, "cached", res.Title)
+	assert.Equal(t, []string{image, "hello"}, stored)
+	assert.Len(t, msg.Attachments, 2)
+	assert.Equal(t, "photo.png", msg.Attachments[0].Filename)
+	assert.Equal(t, "image/png", msg.Attachments[0].ContentType)
+	assert.Equal(t, "text/plain; charset=utf-8", msg.Attachments[1].ContentType)
+	assert.NotEmpty(t, msg.Attachments[0].BlobKey)
 
 }
 
-func TestGetChat_CacheMiss_StorageSuccess_TrimsMessagesAndPutsToCache(t *testing.T) {
+func TestCreateMessage_UnsupportedMediaType_DeletesStoredBlobs(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	loggerMock := mockLogger.NewMockLogger(controller)
-	cacheMock := mockCache.NewMockCache(controller)
-	storageMock := mockStorage.NewMockStorage(controller)
-	loggerMock.EXPECT().LogError(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().LogFatal(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
-
-	cfg := config.Service{
-		MaxTitleLength:   200,
-		MaxMessageLength: 1000,
-		GetLimitDefault:  10,
-		GetLimitMax:      100,
-	}
-
-	svc := NewService(loggerMock, cfg, cacheMock, storageMock)
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+	blobMock := mockBlob.NewMockBlobStore(controller)
+	svc.blobs = blobMock
 
-	chatID := 5
-	chatFromDB := models.Chat{
-		ID:    chatID,
-		Title: "fromdb",
-		Messages: []models.Message{
-			{Text: "m1"},
-			{Text: "m2"},
-			{Text: "m3"},
-		},
+	pdf := "%PDF-1.7"
+	uploads := []models.Upload{
+		{Filename: "notes.txt", Size: 5, Content: strings.NewReader("hello")},
+		{Filename: "photo.png", Size: int64(len(pdf)), Content: strings.NewReader(pdf)},
 	}
 
-	cacheMock.EXPECT().Get(chatID).Return(models.Chat{}, errors.New("cache miss"))
-	storageMock.EXPECT().GetChat(gomock.Any(), chatID, cfg.GetLimitMax).Return(chatFromDB, nil)
-	cacheMock.EXPECT().Put(chatID, chatFromDB).Times(1)
+	var key string
+	blobMock.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), int64(5), "text/plain; charset=utf-8").
+		DoAndReturn(func(_ context.Context, k string, _ io.Reader, _ int64, _ string) error {
+			key = k
+			return nil
+		})
+	blobMock.EXPECT().Delete(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, k string) error {
+		assert.Equal(t, key, k)
+		return nil
+	})
+
+	expectRole(storageMock, 1, models.RoleMember)
+	storageMock.EXPECT().CreateMessage(gomock.Any(), gomock.Any()).Times(0)
 
-	res, err := svc.GetChat(context.Background(), chatID, "2")
-	assert.NoError(t, err)
-	assert.Equal(t, 2, len(res.Messages))
-	assert.Equal(t, "m1", res.Messages[0].Text)
-	assert.Equal(t, "m2", res.Messages[1].Text)
+	_, err := svc.CreateMessage(context.Background(), testUserID, models.Message{ChatID: 1, Text: "files"}, uploads...)
+	assert.True(t, errors.Is(err, errs.ErrUnsupportedMediaType))
 
 }
 
-func TestGetChat_InvalidLimitString_ReturnsErrInvalidLimit(t *testing.T) {
+func TestCreateMessage_AttachmentLimits(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc, _, _, _ := newTestService(controller)
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
 
-	_, err := svc.GetChat(context.Background(), 1, "not int")
-	assert.Error(t, err)
-	assert.True(t, errors.Is(err, errs.ErrInvalidLimit))
+	storageMock.EXPECT().GetMember(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	upload := models.Upload{Filename: "a.txt", Size: 1, Content: strings.NewReader("a")}
+
+	_, err := svc.CreateMessage(context.Background(), testUserID, models.Message{ChatID: 1, Text: "x"}, upload, upload, upload)
+	assert.True(t, errors.Is(err, errs.ErrTooManyAttachments))
+
+	_, err = svc.CreateMessage(context.Background(), testUserID, models.Message{ChatID: 1, Text: "x"}, models.Upload{Filename: "big.txt", Size: 1025})
+	assert.True(t, errors.Is(err, errs.ErrAttachmentTooLarge))
 
 }
 
-func TestValidateLimit_Boundaries(t *testing.T) {
+func TestCreateMessage_StorageFails_DeletesStoredBlobs(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc, _, _, _ := newTestService(controller)
-
-	lim, err := svc.validateLimit("")
-	assert.NoError(t, err)
-	assert.Equal(t, svc.config.GetLimitDefault, lim)
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+	blobMock := mockBlob.NewMockBlobStore(controller)
+	svc.blobs = blobMock
 
-	lim, err = svc.validateLimit("0")
-	assert.NoError(t, err)
-	assert.Equal(t, svc.config.GetLimitDefault, lim)
+	blobMock.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	blobMock.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
 
-	_, err = svc.validateLimit("-1")
-	assert.Error(t, err)
-	assert.True(t, errors.Is(err, errs.ErrLimitTooSmall))
+	expectRole(storageMock, 1, models.RoleMember)
+	storageMock.EXPECT().CreateMessage(gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
 
-	tooLarge := strconv.Itoa(svc.config.GetLimitMax + 1)
-	_, err = svc.validateLimit(tooLarge)
+	_, err := svc.CreateMessage(context.Background(), testUserID, models.Message{ChatID: 1}, models.Upload{Filename: "a.txt", Size: 1, Content: strings.NewReader("a")})
 	assert.Error(t, err)
-	assert.True(t, errors.Is(err, errs.ErrLimitTooLarge))
 
 }
 
-func TestCreateChat_StorageError_LogsAndReturnsError(t *testing.T) {
+func TestGetAttachment_OpensContent(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	loggerMock := mockLogger.NewMockLogger(controller)
-	cacheMock := mockCache.NewMockCache(controller)
-	storageMock := mockStorage.NewMockStorage(controller)
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+	blobMock := mockBlob.NewMockBlobStore(controller)
+	svc.blobs = blobMock
 
-	loggerMock.EXPECT().LogError(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
-	loggerMock.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().LogFatal(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	attachment := models.Attachment{ID: 4, MessageID: 3, BlobKey: "attachments/abc", Filename: "a.txt", ContentType: "text/plain", Size: 5}
 
-	cfg := config.Service{
-		MaxTitleLength:   200,
-		MaxMessageLength: 1000,
-		GetLimitDefault:  10,
-		GetLimitMax:      100,
-	}
-	svc := NewService(loggerMock, cfg, cacheMock, storageMock)
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().GetAttachment(gomock.Any(), 1, 4).Return(attachment, nil)
+	blobMock.EXPECT().Get(gomock.Any(), "attachments/abc").Return(io.NopCloser(strings.NewReader("hello")), nil)
 
-	chat := models.Chat{Title: "  asdadqwd  "}
+	got, content, err := svc.GetAttachment(context.Background(), testUserID, 1, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, attachment, got)
+	data, _ := io.ReadAll(content)
+	assert.Equal(t, "hello", string(data))
 
-	storageErr := errors.New("db down")
-	storageMock.EXPECT().CreateChat(gomock.Any(), gomock.AssignableToTypeOf(&models.Chat{})).Return(storageErr)
+}
 
-	res, err := svc.CreateChat(context.Background(), chat)
+func TestGetAttachment_MissingBlob_ReturnsNotFound(t *testing.T) {
 
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "db down")
-	assert.Equal(t, models.Chat{}, res)
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+	blobMock := mockBlob.NewMockBlobStore(controller)
+	svc.blobs = blobMock
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().GetAttachment(gomock.Any(), 1, 4).Return(models.Attachment{ID: 4, BlobKey: "k"}, nil)
+	blobMock.EXPECT().Get(gomock.Any(), "k").Return(nil, errs.ErrBlobNotFound)
+
+	_, _, err := svc.GetAttachment(context.Background(), testUserID, 1, 4)
+	assert.True(t, errors.Is(err, errs.ErrAttachmentNotFound))
 
 }
 
-func TestCreateMessage_ValidationFails_ReturnsError(t *testing.T) {
+func TestGetThread_ReturnsRepliesAndNextCursor(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc, _, cacheMock, storageMock := newTestService(controller)
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
 
-	msg := models.Message{ChatID: 1, Text: "   "}
+	created := time.Date(2025, 1, 16, 12, 0, 0, 0, time.UTC)
+	parent := models.Message{ID: 5, ChatID: 1, ReplyCount: 3}
+	replies := []models.Message{
+		{ID: 6, ChatID: 1, CreatedAt: created},
+		{ID: 7, ChatID: 1, CreatedAt: created.Add(time.Second)},
+		{ID: 8, ChatID: 1, CreatedAt: created.Add(2 * time.Second)},
+	}
 
-	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
-	storageMock.EXPECT().CreateMessage(gomock.Any(), gomock.Any()).Times(0)
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().GetMessage(gomock.Any(), 1, 5).Return(parent, nil)
+	storageMock.EXPECT().GetReplies(gomock.Any(), 1, 5, 3, nil).Return(replies, nil)
 
-	res, err := svc.CreateMessage(context.Background(), msg)
+	thread, cursor, err := svc.GetThread(context.Background(), testUserID, 1, 5, "2", "")
+	assert.NoError(t, err)
+	assert.Equal(t, parent, thread.Message)
+	assert.Equal(t, replies[:2], thread.Replies)
+	assert.Equal(t, encodeCursor(replies[1].CreatedAt, 7), cursor)
 
-	assert.Error(t, err)
-	assert.True(t, errors.Is(err, errs.ErrMessageEmpty))
-	assert.Equal(t, models.Message{}, res)
+	cursorModel := &models.Cursor{CreatedAt: created.Add(time.Second), ID: 7}
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().GetMessage(gomock.Any(), 1, 5).Return(parent, nil)
+	storageMock.EXPECT().GetReplies(gomock.Any(), 1, 5, 3, cursorModel).Return(replies[2:], nil)
+
+	thread, cursor, err = svc.GetThread(context.Background(), testUserID, 1, 5, "2", cursor)
+	assert.NoError(t, err)
+	assert.Len(t, thread.Replies, 1)
+	assert.Empty(t, cursor)
 
 }
 
-func TestCreateMessage_StorageError_LogsAndReturnsError(t *testing.T) {
+func TestGetThread_ZeroLimit_UsesDefault(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	loggerMock := mockLogger.NewMockLogger(controller)
-	cacheMock := mockCache.NewMockCache(controller)
-	storageMock := mockStorage.NewMockStorage(controller)
-
-	loggerMock.EXPECT().LogError(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
-	loggerMock.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().LogFatal(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
 
-	cfg := config.Service{
-		MaxTitleLength:   200,
-		MaxMessageLength: 1000,
-		GetLimitDefault:  10,
-		GetLimitMax:      100,
+	created := time.Date(2025, 1, 16, 12, 0, 0, 0, time.UTC)
+	replies := make([]models.Message, svc.config.GetLimitDefault+1)
+	for i := range replies {
+		replies[i] = models.Message{ID: 6 + i, ChatID: 1, CreatedAt: created.Add(time.Duration(i) * time.Second)}
 	}
-	svc := NewService(loggerMock, cfg, cacheMock, storageMock)
 
-	message := models.Message{ChatID: 1, Text: "qwe"}
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().GetMessage(gomock.Any(), 1, 5).Return(models.Message{ID: 5, ChatID: 1}, nil)
+	storageMock.EXPECT().GetReplies(gomock.Any(), 1, 5, svc.config.GetLimitDefault+1, nil).Return(replies, nil)
 
-	storageErr := errors.New("db unavailable")
-	storageMock.EXPECT().CreateMessage(gomock.Any(), gomock.AssignableToTypeOf(&models.Message{})).Return(storageErr)
-	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+	thread, cursor, err := svc.GetThread(context.Background(), testUserID, 1, 5, "00", "")
+	require.NoError(t, err)
+	assert.Len(t, thread.Replies, svc.config.GetLimitDefault)
+	assert.NotEmpty(t, cursor)
 
-	res, err := svc.CreateMessage(context.Background(), message)
+}
 
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "db unavailable")
-	assert.Equal(t, models.Message{}, res)
+func TestGetThread_MessageNotFound(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().GetMessage(gomock.Any(), 1, 5).Return(models.Message{}, errs.ErrMessageNotFound)
+	storageMock.EXPECT().GetReplies(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, _, err := svc.GetThread(context.Background(), testUserID, 1, 5, "", "")
+	assert.True(t, errors.Is(err, errs.ErrMessageNotFound))
 
 }
 
-func TestDeleteChat_StorageError_LogsAndReturnsError(t *testing.T) {
+func TestUpdateMessage_Success(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	loggerMock := mockLogger.NewMockLogger(controller)
-	cacheMock := mockCache.NewMockCache(controller)
-	storageMock := mockStorage.NewMockStorage(controller)
+	svc, _, cacheMock, storageMock, _, _, busMock := newTestService(controller)
 
-	storageErr := errors.New("db unavailable")
-	loggerMock.EXPECT().LogError("service — failed to delete chat", storageErr, "chatID", 1, "layer", "service.impl").Times(1)
-	loggerMock.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().LogFatal(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	msg := models.Message{ID: 3, ChatID: 1, Text: "  edited  "}
 
-	svc := NewService(loggerMock, config.Service{}, cacheMock, storageMock)
-	chatID := 1
+	author := testUserID
 
-	storageMock.EXPECT().DeleteChat(gomock.Any(), chatID).Return(storageErr)
+	expectRole(storageMock, msg.ChatID, models.RoleMember)
+	storageMock.EXPECT().GetMessage(gomock.Any(), msg.ChatID, msg.ID).Return(models.Message{ID: 3, ChatID: 1, AuthorID: &author}, nil)
+	storageMock.EXPECT().UpdateMessage(gomock.Any(), gomock.AssignableToTypeOf(&models.Message{})).Return(nil)
+	cacheMock.EXPECT().Update(gomock.Any(), msg.ChatID, gomock.Any()).Do(func(_ context.Context, _ int, message models.Message) {
+		assert.Equal(t, "edited", message.Text)
+		assert.NotNil(t, message.EditedAt)
+	})
 	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+	busMock.EXPECT().Publish(gomock.Any(), msg.ChatID).Return(nil)
 
-	err := svc.DeleteChat(context.Background(), chatID)
-
-	assert.Error(t, err)
-	assert.Equal(t, storageErr, err)
+	res, err := svc.UpdateMessage(context.Background(), testUserID, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, "edited", res.Text)
+	assert.NotNil(t, res.EditedAt)
 
 }
 
-func TestGetChat_StorageError_LogsAndReturnsError(t *testing.T) {
+func TestUpdateMessage_ValidationFails_ReturnsError(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	loggerMock := mockLogger.NewMockLogger(controller)
-	cacheMock := mockCache.NewMockCache(controller)
-	storageMock := mockStorage.NewMockStorage(controller)
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
 
-	chatID := 1
-	storageErr := errors.New("db unavailable")
+	storageMock.EXPECT().UpdateMessage(gomock.Any(), gomock.Any()).Times(0)
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
 
-	loggerMock.EXPECT().LogError("service — failed to get chat", storageErr, "chatID", chatID, "layer", "service.impl").Times(1)
-	loggerMock.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
-	loggerMock.EXPECT().LogFatal(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	_, err := svc.UpdateMessage(context.Background(), testUserID, models.Message{ID: 3, ChatID: 1, Text: " "})
+	assert.True(t, errors.Is(err, errs.ErrMessageEmpty))
 
-	cfg := config.Service{GetLimitMax: 100}
-	svc := NewService(loggerMock, cfg, cacheMock, storageMock)
+}
 
-	cacheMock.EXPECT().Get(chatID).Return(models.Chat{}, errors.New("cache miss"))
-	storageMock.EXPECT().GetChat(gomock.Any(), chatID, cfg.GetLimitMax).Return(models.Chat{}, storageErr)
-	cacheMock.EXPECT().Put(gomock.Any(), gomock.Any()).Times(0)
+func TestUpdateMessage_NotFound_ReturnsErrMessageNotFound(t *testing.T) {
 
-	res, err := svc.GetChat(context.Background(), chatID, "")
+	controller := gomock.NewController(t)
+	defer controller.Finish()
 
-	assert.Error(t, err)
-	assert.Equal(t, storageErr, err)
-	assert.Equal(t, models.Chat{}, res)
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	expectRole(storageMock, 1, models.RoleMember)
+	storageMock.EXPECT().GetMessage(gomock.Any(), 1, 3).Return(models.Message{}, errs.ErrMessageNotFound)
+	storageMock.EXPECT().UpdateMessage(gomock.Any(), gomock.Any()).Times(0)
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+
+	_, err := svc.UpdateMessage(context.Background(), testUserID, models.Message{ID: 3, ChatID: 1, Text: "qwe"})
+	assert.True(t, errors.Is(err, errs.ErrMessageNotFound))
 
 }
 
-func TestValidateChat_TitleTooLong_ReturnsError(t *testing.T) {
+func TestDeleteMessage_Success(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc, _, _, _ := newTestService(controller)
+	svc, _, cacheMock, storageMock, _, _, busMock := newTestService(controller)
 
-	longTitle := strings.Repeat("a", svc.config.MaxTitleLength+1)
-	chat := &models.Chat{Title: longTitle}
+	expectRole(storageMock, 1, models.RoleAdmin)
+	storageMock.EXPECT().DeleteMessage(gomock.Any(), 1, 3).Return(nil, nil)
+	cacheMock.EXPECT().Delete(1).Times(1)
+	busMock.EXPECT().Publish(gomock.Any(), 1).Return(nil)
 
-	err := svc.validateChat(chat)
-	assert.Error(t, err)
-	assert.True(t, errors.Is(err, errs.ErrTitleTooLong))
+	err := svc.DeleteMessage(context.Background(), testUserID, 1, 3)
+	assert.NoError(t, err)
 
 }
 
-func TestValidateMessage_TextTooLong_ReturnsError(t *testing.T) {
+func TestDeleteMessage_WithAttachments_DeletesBlobs(t *testing.T) {
 
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
-	svc, _, _, _ := newTestService(controller)
+	svc, _, cacheMock, storageMock, _, _, busMock := newTestService(controller)
+	blobMock := mockBlob.NewMockBlobStore(controller)
+	svc.blobs = blobMock
 
-	longText := strings.Repeat("x", svc.config.MaxMessageLength+1)
-	err := svc.validateMessage(&models.Message{Text: longText})
+	// the second attachment belongs to a reply deleted along with the message
+	attachments := models.Attachments{{ID: 4, MessageID: 3, BlobKey: "attachments/a"}, {ID: 5, MessageID: 6, BlobKey: "attachments/b"}}
 
-	assert.Error(t, err)
-	assert.True(t, errors.Is(err, errs.ErrMessageTooLong))
+	expectRole(storageMock, 1, models.RoleAdmin)
+	storageMock.EXPECT().DeleteMessage(gomock.Any(), 1, 3).Return(attachments, nil)
+	cacheMock.EXPECT().Delete(1).Times(1)
+	busMock.EXPECT().Publish(gomock.Any(), 1).Return(nil)
+	blobMock.EXPECT().Delete(gomock.Any(), "attachments/a").Return(nil)
+	blobMock.EXPECT().Delete(gomock.Any(), "attachments/b").Return(nil)
+
+	err := svc.DeleteMessage(context.Background(), testUserID, 1, 3)
+	assert.NoError(t, err)
+
+}
+
+func TestDeleteMessage_NotFound_ReturnsErrMessageNotFound(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	expectRole(storageMock, 1, models.RoleAdmin)
+	storageMock.EXPECT().DeleteMessage(gomock.Any(), 1, 3).Return(nil, errs.ErrMessageNotFound)
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+
+	err := svc.DeleteMessage(context.Background(), testUserID, 1, 3)
+	assert.True(t, errors.Is(err, errs.ErrMessageNotFound))
+
+}
+
+func TestAddReaction_Success_InvalidatesCache(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, busMock := newTestService(controller)
+
+	reacted := models.Message{ID: 3, ChatID: 1, Reactions: models.Reactions{{Emoji: "👍", Count: 1}}}
+
+	expectRole(storageMock, 1, models.RoleMember)
+	gomock.InOrder(
+		storageMock.EXPECT().GetMessage(gomock.Any(), 1, 3).Return(models.Message{ID: 3, ChatID: 1}, nil),
+		storageMock.EXPECT().AddReaction(gomock.Any(), gomock.AssignableToTypeOf(&models.MessageReaction{})).
+			DoAndReturn(func(_ context.Context, reaction *models.MessageReaction) error {
+				assert.Equal(t, models.MessageReaction{MessageID: 3, UserID: testUserID, Emoji: "👍", CreatedAt: reaction.CreatedAt}, *reaction)
+				return nil
+			}),
+		storageMock.EXPECT().GetMessage(gomock.Any(), 1, 3).Return(reacted, nil),
+	)
+	cacheMock.EXPECT().Delete(1).Times(1)
+	busMock.EXPECT().Publish(gomock.Any(), 1).Return(nil)
+
+	res, err := svc.AddReaction(context.Background(), testUserID, 1, 3, " 👍 ")
+	assert.NoError(t, err)
+	assert.Equal(t, reacted, res)
+
+}
+
+func TestAddReaction_InvalidEmoji(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	storageMock.EXPECT().AddReaction(gomock.Any(), gomock.Any()).Times(0)
+
+	for _, emoji := range []string{"", "  ", "thumbs up", "\x00", strings.Repeat("👍", 9)} {
+		_, err := svc.AddReaction(context.Background(), testUserID, 1, 3, emoji)
+		assert.True(t, errors.Is(err, errs.ErrInvalidEmoji), emoji)
+	}
+
+}
+
+func TestAddReaction_ReadOnly_ReturnsForbidden(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().AddReaction(gomock.Any(), gomock.Any()).Times(0)
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+
+	_, err := svc.AddReaction(context.Background(), testUserID, 1, 3, "👍")
+	assert.True(t, errors.Is(err, errs.ErrForbidden))
+
+}
+
+func TestAddReaction_MessageNotFound(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	expectRole(storageMock, 1, models.RoleMember)
+	storageMock.EXPECT().GetMessage(gomock.Any(), 1, 3).Return(models.Message{}, errs.ErrMessageNotFound)
+	storageMock.EXPECT().AddReaction(gomock.Any(), gomock.Any()).Times(0)
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+
+	_, err := svc.AddReaction(context.Background(), testUserID, 1, 3, "👍")
+	assert.True(t, errors.Is(err, errs.ErrMessageNotFound))
+
+}
+
+func TestRemoveReaction_Success_InvalidatesCache(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, busMock := newTestService(controller)
+
+	expectRole(storageMock, 1, models.RoleMember)
+	storageMock.EXPECT().RemoveReaction(gomock.Any(), 1, models.MessageReaction{MessageID: 3, UserID: testUserID, Emoji: "👍"}).Return(nil)
+	cacheMock.EXPECT().Delete(1).Times(1)
+	busMock.EXPECT().Publish(gomock.Any(), 1).Return(nil)
+
+	err := svc.RemoveReaction(context.Background(), testUserID, 1, 3, "👍")
+	assert.NoError(t, err)
+
+}
+
+func TestRemoveReaction_NotFound(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	expectRole(storageMock, 1, models.RoleMember)
+	storageMock.EXPECT().RemoveReaction(gomock.Any(), 1, gomock.Any()).Return(errs.ErrReactionNotFound)
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+
+	err := svc.RemoveReaction(context.Background(), testUserID, 1, 3, "👍")
+	assert.True(t, errors.Is(err, errs.ErrReactionNotFound))
+
+}
+
+func TestSubscribeChat_Success(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, brokerMock, _, _ := newTestService(controller)
+
+	events := make(chan models.Event)
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	brokerMock.EXPECT().Subscribe(1).Return(events, func() {})
+
+	res, unsubscribe, err := svc.SubscribeChat(context.Background(), testUserID, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, unsubscribe)
+	assert.Equal(t, (<-chan models.Event)(events), res)
+
+}
+
+func TestSubscribeChat_NotMember_DoesNotSubscribe(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, brokerMock, _, _ := newTestService(controller)
+
+	storageMock.EXPECT().GetMember(gomock.Any(), 1, testUserID).Return(models.ChatMember{}, errs.ErrMemberNotFound)
+	brokerMock.EXPECT().Subscribe(gomock.Any()).Times(0)
+
+	_, _, err := svc.SubscribeChat(context.Background(), testUserID, 1)
+	assert.True(t, errors.Is(err, errs.ErrForbidden))
+
+}
+
+func TestGetMessagesAfter_UsesMaxLimit(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	messages := []models.Message{{ID: 6, ChatID: 1}, {ID: 7, ChatID: 1}}
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().GetMessagesAfter(gomock.Any(), 1, 5, 100).Return(messages, nil)
+
+	res, err := svc.GetMessagesAfter(context.Background(), testUserID, 1, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, messages, res)
+
+}
+
+func TestReplayMessages_IncludesLateCommits(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	// message 4 was committed after message 5 and has not been delivered yet
+	messages := []models.Message{{ID: 4, ChatID: 1}, {ID: 6, ChatID: 1}}
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().GetMessagesSince(gomock.Any(), 1, 5, 5*time.Second, 100).Return(messages, nil)
+
+	res, err := svc.ReplayMessages(context.Background(), testUserID, 1, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, messages, res)
+
+}
+
+func TestWaitForMessages_ReturnsAvailableMessagesImmediately(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, notifierMock, _ := newTestService(controller)
+
+	messages := []models.Message{{ID: 6, ChatID: 1}}
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	notifierMock.EXPECT().Wait(1).Return(make(chan struct{}), func() {})
+	storageMock.EXPECT().GetMessagesSince(gomock.Any(), 1, 5, 5*time.Second, 100).Return(messages, nil)
+
+	res, _, err := svc.WaitForMessages(context.Background(), testUserID, 1, 5, "", "30s")
+	assert.NoError(t, err)
+	assert.Equal(t, messages, res)
+
+}
+
+func TestWaitForMessages_WakesUpOnNotify(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, notifierMock, _ := newTestService(controller)
+
+	ready := make(chan struct{})
+	released := false
+	messages := []models.Message{{ID: 6, ChatID: 1}}
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	notifierMock.EXPECT().Wait(1).Return(ready, func() { released = true })
+	gomock.InOrder(
+		storageMock.EXPECT().GetMessagesSince(gomock.Any(), 1, 5, 5*time.Second, 100).DoAndReturn(func(context.Context, int, int, time.Duration, int) ([]models.Message, error) {
+			close(ready)
+			return []models.Message{}, nil
+		}),
+		storageMock.EXPECT().GetMessagesSince(gomock.Any(), 1, 5, 5*time.Second, 100).Return(messages, nil),
+	)
+
+	res, _, err := svc.WaitForMessages(context.Background(), testUserID, 1, 5, "", "30s")
+	assert.NoError(t, err)
+	assert.Equal(t, messages, res)
+	assert.True(t, released)
+
+}
+
+func TestWaitForMessages_Timeout_ReturnsEmpty(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, notifierMock, _ := newTestService(controller)
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	notifierMock.EXPECT().Wait(1).Return(make(chan struct{}), func() {})
+	storageMock.EXPECT().GetMessagesSince(gomock.Any(), 1, 5, 5*time.Second, 100).Return([]models.Message{}, nil)
+
+	res, _, err := svc.WaitForMessages(context.Background(), testUserID, 1, 5, "", "10ms")
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+}
+
+func TestWaitForMessages_ContextCancelled(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, notifierMock, _ := newTestService(controller)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	notifierMock.EXPECT().Wait(1).Return(make(chan struct{}), func() {})
+	storageMock.EXPECT().GetMessagesSince(gomock.Any(), 1, 5, 5*time.Second, 100).DoAndReturn(func(context.Context, int, int, time.Duration, int) ([]models.Message, error) {
+		cancel()
+		return []models.Message{}, nil
+	})
+
+	_, _, err := svc.WaitForMessages(ctx, testUserID, 1, 5, "", "30s")
+	assert.True(t, errors.Is(err, context.Canceled))
+
+}
+
+func TestWaitForMessages_NotMember_DoesNotWait(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, notifierMock, _ := newTestService(controller)
+
+	storageMock.EXPECT().GetMember(gomock.Any(), 1, testUserID).Return(models.ChatMember{}, errs.ErrMemberNotFound)
+	notifierMock.EXPECT().Wait(gomock.Any()).Times(0)
+
+	_, _, err := svc.WaitForMessages(context.Background(), testUserID, 1, 0, "", "30s")
+	assert.True(t, errors.Is(err, errs.ErrForbidden))
+
+}
+
+func TestWaitForMessages_InvalidWait(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, _, _, _, _ := newTestService(controller)
+
+	_, _, err := svc.WaitForMessages(context.Background(), testUserID, 1, 0, "", "soon")
+	assert.True(t, errors.Is(err, errs.ErrInvalidWait))
+
+	_, _, err = svc.WaitForMessages(context.Background(), testUserID, 1, 0, "", "2m")
+	assert.True(t, errors.Is(err, errs.ErrWaitTooLong))
+
+}
+
+func TestWaitForMessages_AfterID_SkipsEarlierMessages(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, notifierMock, _ := newTestService(controller)
+
+	now := time.Now()
+	window := []models.Message{
+		{ID: 3, ChatID: 1, CreatedAt: now.Add(-2 * time.Second)},
+		{ID: 6, ChatID: 1, CreatedAt: now},
+	}
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	notifierMock.EXPECT().Wait(1).Return(make(chan struct{}), func() {})
+	storageMock.EXPECT().GetMessagesSince(gomock.Any(), 1, 5, 5*time.Second, 100).Return(window, nil)
+
+	res, cursor, err := svc.WaitForMessages(context.Background(), testUserID, 1, 5, "", "30s")
+	require.NoError(t, err)
+	assert.Equal(t, window[1:], res)
+
+	position, err := decodePollCursor(cursor)
+	require.NoError(t, err)
+	assert.Equal(t, models.PollCursor{AfterID: 6, Seen: []int{3}}, position)
+
+}
+
+func TestWaitForMessages_Cursor_ReturnsLateMessagesOnce(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, notifierMock, _ := newTestService(controller)
+
+	now := time.Now()
+	window := []models.Message{
+		{ID: 3, ChatID: 1, CreatedAt: now.Add(-time.Second)},
+		{ID: 4, ChatID: 1, CreatedAt: now.Add(-time.Second)},
+	}
+
+	// message 4 committed after message 6 was received, message 3 before
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	notifierMock.EXPECT().Wait(1).Return(make(chan struct{}), func() {})
+	storageMock.EXPECT().GetMessagesSince(gomock.Any(), 1, 6, 5*time.Second, 100).Return(window, nil)
+
+	cursor := encodePollCursor(models.PollCursor{AfterID: 6, Seen: []int{3}})
+	res, next, err := svc.WaitForMessages(context.Background(), testUserID, 1, 0, cursor, "30s")
+	require.NoError(t, err)
+	assert.Equal(t, window[1:], res)
+
+	position, err := decodePollCursor(next)
+	require.NoError(t, err)
+	assert.Equal(t, models.PollCursor{AfterID: 6, Seen: []int{3, 4}}, position)
+
+}
+
+func TestWaitForMessages_Cursor_WaitsForUnseenMessages(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, notifierMock, _ := newTestService(controller)
+
+	window := []models.Message{{ID: 3, ChatID: 1, CreatedAt: time.Now()}}
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	notifierMock.EXPECT().Wait(1).Return(make(chan struct{}), func() {})
+	storageMock.EXPECT().GetMessagesSince(gomock.Any(), 1, 6, 5*time.Second, 100).Return(window, nil)
+
+	cursor := encodePollCursor(models.PollCursor{AfterID: 6, Seen: []int{3}})
+	res, next, err := svc.WaitForMessages(context.Background(), testUserID, 1, 0, cursor, "10ms")
+	require.NoError(t, err)
+	assert.Empty(t, res)
+	assert.Equal(t, cursor, next)
+
+}
+
+func TestWaitForMessages_InvalidCursor(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, _, _, _, _ := newTestService(controller)
+
+	for _, cursor := range []string{"???", encodePollCursor(models.PollCursor{AfterID: 3, Seen: []int{5}}), "Ng"} {
+		_, _, err := svc.WaitForMessages(context.Background(), testUserID, 1, 0, cursor, "")
+		assert.ErrorIs(t, err, errs.ErrInvalidCursor, cursor)
+	}
+
+}
+
+func TestDeleteChat_Success(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, brokerMock, notifierMock, busMock := newTestService(controller)
+
+	chatID := 7
+
+	expectRole(storageMock, chatID, models.RoleOwner)
+	storageMock.EXPECT().DeleteChat(gomock.Any(), chatID).Return(nil, nil)
+	cacheMock.EXPECT().Delete(chatID).Times(1)
+	busMock.EXPECT().Publish(gomock.Any(), chatID).Return(nil)
+	brokerMock.EXPECT().Publish(models.Event{Type: models.EventChatDeleted, ChatID: chatID})
+	notifierMock.EXPECT().Notify(chatID)
+
+	err := svc.DeleteChat(context.Background(), testUserID, chatID)
+	assert.NoError(t, err)
+
+}
+
+func TestDeleteChat_WithAttachments_DeletesBlobs(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, brokerMock, notifierMock, busMock := newTestService(controller)
+	blobMock := mockBlob.NewMockBlobStore(controller)
+	svc.blobs = blobMock
+
+	chatID := 7
+	attachments := models.Attachments{{ID: 4, MessageID: 3, BlobKey: "attachments/a"}, {ID: 5, MessageID: 6, BlobKey: "attachments/b"}}
+
+	expectRole(storageMock, chatID, models.RoleOwner)
+	storageMock.EXPECT().DeleteChat(gomock.Any(), chatID).Return(attachments, nil)
+	cacheMock.EXPECT().Delete(chatID).Times(1)
+	busMock.EXPECT().Publish(gomock.Any(), chatID).Return(nil)
+	brokerMock.EXPECT().Publish(models.Event{Type: models.EventChatDeleted, ChatID: chatID})
+	notifierMock.EXPECT().Notify(chatID)
+
+	// a failed blob deletion is logged and does not stop the others
+	blobMock.EXPECT().Delete(gomock.Any(), "attachments/a").Return(errors.New("blob store down"))
+	blobMock.EXPECT().Delete(gomock.Any(), "attachments/b").Return(nil)
+
+	err := svc.DeleteChat(context.Background(), testUserID, chatID)
+	assert.NoError(t, err)
+
+}
+
+func TestDeleteChat_PublishFails_StillSucceeds(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, brokerMock, notifierMock, busMock := newTestService(controller)
+
+	chatID := 7
+
+	expectRole(storageMock, chatID, models.RoleOwner)
+	storageMock.EXPECT().DeleteChat(gomock.Any(), chatID).Return(nil, nil)
+	cacheMock.EXPECT().Delete(chatID).Times(1)
+	busMock.EXPECT().Publish(gomock.Any(), chatID).Return(errors.New("connection refused"))
+	brokerMock.EXPECT().Publish(gomock.Any())
+	notifierMock.EXPECT().Notify(chatID)
+
+	err := svc.DeleteChat(context.Background(), testUserID, chatID)
+	assert.NoError(t, err)
+
+}
+
+func TestDeleteChat_NotFound_ReturnsErrChatNotFound(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	chatID := 42
+
+	expectRole(storageMock, chatID, models.RoleOwner)
+	storageMock.EXPECT().DeleteChat(gomock.Any(), chatID).Return(nil, errs.ErrChatNotFound)
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+
+	err := svc.DeleteChat(context.Background(), testUserID, chatID)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrChatNotFound))
+
+}
+
+func TestDeleteChat_NotOwner_ReturnsForbidden(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	expectRole(storageMock, 7, models.RoleAdmin)
+	storageMock.EXPECT().DeleteChat(gomock.Any(), gomock.Any()).Times(0)
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+
+	err := svc.DeleteChat(context.Background(), testUserID, 7)
+	assert.True(t, errors.Is(err, errs.ErrForbidden))
+
+}
+
+func TestCreateMessage_ReadOnly_ReturnsForbidden(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().CreateMessage(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := svc.CreateMessage(context.Background(), testUserID, models.Message{ChatID: 1, Text: "qwe"})
+	assert.True(t, errors.Is(err, errs.ErrForbidden))
+
+}
+
+func TestUpdateMessage_NotAuthor_ReturnsForbidden(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	other := testUserID + 1
+
+	expectRole(storageMock, 1, models.RoleOwner)
+	storageMock.EXPECT().GetMessage(gomock.Any(), 1, 3).Return(models.Message{ID: 3, ChatID: 1, AuthorID: &other}, nil)
+	storageMock.EXPECT().UpdateMessage(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := svc.UpdateMessage(context.Background(), testUserID, models.Message{ID: 3, ChatID: 1, Text: "qwe"})
+	assert.True(t, errors.Is(err, errs.ErrForbidden))
+
+}
+
+func TestDeleteMessage_Member_OnlyOwnMessages(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, busMock := newTestService(controller)
+
+	author := testUserID
+	other := testUserID + 1
+
+	storageMock.EXPECT().GetMember(gomock.Any(), 1, testUserID).
+		Return(models.ChatMember{ChatID: 1, UserID: testUserID, Role: models.RoleMember}, nil).Times(2)
+	storageMock.EXPECT().GetMessage(gomock.Any(), 1, 3).Return(models.Message{ID: 3, ChatID: 1, AuthorID: &author}, nil)
+	storageMock.EXPECT().GetMessage(gomock.Any(), 1, 4).Return(models.Message{ID: 4, ChatID: 1, AuthorID: &other}, nil)
+	storageMock.EXPECT().DeleteMessage(gomock.Any(), 1, 3).Return(nil, nil)
+	cacheMock.EXPECT().Delete(1).Times(1)
+	busMock.EXPECT().Publish(gomock.Any(), 1).Return(nil)
+
+	assert.NoError(t, svc.DeleteMessage(context.Background(), testUserID, 1, 3))
+	assert.True(t, errors.Is(svc.DeleteMessage(context.Background(), testUserID, 1, 4), errs.ErrForbidden))
+
+}
+
+func TestGetChat_NotMember_ReturnsForbidden(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	storageMock.EXPECT().GetMember(gomock.Any(), 1, testUserID).Return(models.ChatMember{}, errs.ErrMemberNotFound)
+	cacheMock.EXPECT().Get(gomock.Any(), gomock.Any()).Times(0)
+
+	_, _, err := svc.GetChat(context.Background(), testUserID, 1, "", "", "")
+	assert.True(t, errors.Is(err, errs.ErrForbidden))
+
+}
+
+func TestAddMember_DefaultsToMember(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	expectRole(storageMock, 1, models.RoleAdmin)
+	storageMock.EXPECT().AddMember(gomock.Any(), gomock.AssignableToTypeOf(&models.ChatMember{})).Return(nil)
+
+	member, err := svc.AddMember(context.Background(), testUserID, models.ChatMember{ChatID: 1, UserID: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleMember, member.Role)
+	assert.False(t, member.CreatedAt.IsZero())
+
+}
+
+func TestAddMember_RoleChecks(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	_, err := svc.AddMember(context.Background(), testUserID, models.ChatMember{ChatID: 1, UserID: 4, Role: models.RoleOwner})
+	assert.True(t, errors.Is(err, errs.ErrInvalidRole))
+
+	expectRole(storageMock, 1, models.RoleAdmin)
+	_, err = svc.AddMember(context.Background(), testUserID, models.ChatMember{ChatID: 1, UserID: 4, Role: models.RoleAdmin})
+	assert.True(t, errors.Is(err, errs.ErrForbidden))
+
+	expectRole(storageMock, 1, models.RoleMember)
+	_, err = svc.AddMember(context.Background(), testUserID, models.ChatMember{ChatID: 1, UserID: 4, Role: models.RoleReadOnly})
+	assert.True(t, errors.Is(err, errs.ErrForbidden))
+
+}
+
+func TestAddMember_StorageViolations(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	storageMock.EXPECT().GetMember(gomock.Any(), 1, testUserID).
+		Return(models.ChatMember{ChatID: 1, UserID: testUserID, Role: models.RoleOwner}, nil).Times(2)
+	gomock.InOrder(
+		storageMock.EXPECT().AddMember(gomock.Any(), gomock.Any()).Return(&pgconn.PgError{Code: pgerrcode.UniqueViolation}),
+		storageMock.EXPECT().AddMember(gomock.Any(), gomock.Any()).Return(&pgconn.PgError{Code: pgerrcode.ForeignKeyViolation}),
+	)
+
+	_, err := svc.AddMember(context.Background(), testUserID, models.ChatMember{ChatID: 1, UserID: 4, Role: models.RoleAdmin})
+	assert.True(t, errors.Is(err, errs.ErrMemberExists))
+
+	_, err = svc.AddMember(context.Background(), testUserID, models.ChatMember{ChatID: 1, UserID: 999})
+	assert.True(t, errors.Is(err, errs.ErrUserNotFound))
+
+}
+
+func TestRemoveMember_Leave(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().RemoveMember(gomock.Any(), 1, testUserID).Return(nil)
+
+	assert.NoError(t, svc.RemoveMember(context.Background(), testUserID, 1, testUserID))
+
+	expectRole(storageMock, 2, models.RoleOwner)
+
+	err := svc.RemoveMember(context.Background(), testUserID, 2, testUserID)
+	assert.True(t, errors.Is(err, errs.ErrForbidden))
+
+}
+
+func TestRemoveMember_RequiresHigherRole(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	expectRole(storageMock, 1, models.RoleAdmin)
+	storageMock.EXPECT().GetMember(gomock.Any(), 1, 4).Return(models.ChatMember{ChatID: 1, UserID: 4, Role: models.RoleAdmin}, nil)
+	storageMock.EXPECT().RemoveMember(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	err := svc.RemoveMember(context.Background(), testUserID, 1, 4)
+	assert.True(t, errors.Is(err, errs.ErrForbidden))
+
+	expectRole(storageMock, 1, models.RoleAdmin)
+	storageMock.EXPECT().GetMember(gomock.Any(), 1, 5).Return(models.ChatMember{}, errs.ErrMemberNotFound)
+
+	err = svc.RemoveMember(context.Background(), testUserID, 1, 5)
+	assert.True(t, errors.Is(err, errs.ErrMemberNotFound))
+
+}
+
+func TestListMembers_Success(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	members := []models.ChatMember{{ChatID: 1, UserID: testUserID, Username: "neo", Role: models.RoleOwner}}
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().ListMembers(gomock.Any(), 1).Return(members, nil)
+
+	res, err := svc.ListMembers(context.Background(), testUserID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, members, res)
+
+}
+
+func TestGetChat_CacheHit_NoStorageCall(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	chatID := 1
+	chat := models.Chat{
+		ID:    chatID,
+		Title: "cached",
+		Messages: []models.Message{
+			{Text: "m1"},
+			{Text: "m2"},
+		},
+	}
+
+	expectRole(storageMock, chatID, models.RoleReadOnly)
+	cacheMock.EXPECT().Get(gomock.Any(), chatID).Return(chat, nil)
+	storageMock.EXPECT().GetChat(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	storageMock.EXPECT().CountUnread(gomock.Any(), chatID, testUserID).Return(3, nil)
+
+	res, _, err := svc.GetChat(context.Background(), testUserID, chatID, "", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "cached", res.Title)
+	assert.Equal(t, 3, res.UnreadCount)
+
+}
+
+func TestGetChat_RecordsSpanAroundCache(t *testing.T) {
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	chatID := 1
+	var cacheSpan trace.SpanContext
+
+	expectRole(storageMock, chatID, models.RoleMember)
+	cacheMock.EXPECT().Get(gomock.Any(), chatID).DoAndReturn(func(ctx context.Context, _ int) (models.Chat, error) {
+		cacheSpan = trace.SpanContextFromContext(ctx)
+		return models.Chat{ID: chatID}, nil
+	})
+	storageMock.EXPECT().CountUnread(gomock.Any(), chatID, testUserID).Return(0, nil)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	_, _, err := svc.GetChat(ctx, testUserID, chatID, "", "", "")
+	parent.End()
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	service := spans[0]
+	assert.Equal(t, "service.GetChat", service.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), service.Parent().SpanID())
+	assert.Equal(t, service.SpanContext().SpanID(), cacheSpan.SpanID(), "cache must be called within the service span")
+
+}
+
+func TestGetChat_CacheMiss_StorageSuccess_TrimsMessagesAndPutsToCache(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	loggerMock := mockLogger.NewMockLogger(controller)
+	cacheMock := mockCache.NewMockCache(controller)
+	storageMock := mockStorage.NewMockStorage(controller)
+	loggerMock.EXPECT().LogError(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogErrorContext(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogInfoContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogWarnContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().DebugContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogFatal(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := config.Service{
+		MaxTitleLength:   200,
+		MaxMessageLength: 1000,
+		GetLimitDefault:  10,
+		GetLimitMax:      100,
+	}
+
+	svc := NewService(loggerMock, cfg, cacheMock, storageMock, mockBroker.NewMockBroker(controller), mockNotifier.NewMockNotifier(controller), mockBus.NewMockEventBus(controller), mockBlob.NewMockBlobStore(controller))
+
+	chatID := 5
+	chatFromDB := models.Chat{
+		ID:    chatID,
+		Title: "fromdb",
+		Messages: []models.Message{
+			{Text: "m1"},
+			{Text: "m2"},
+			{Text: "m3"},
+		},
+	}
+
+	expectRole(storageMock, chatID, models.RoleReadOnly)
+	cacheMock.EXPECT().Get(gomock.Any(), chatID).Return(models.Chat{}, errors.New("cache miss"))
+	storageMock.EXPECT().GetChat(gomock.Any(), chatID, cfg.GetLimitMax, nil).Return(chatFromDB, nil)
+	cacheMock.EXPECT().Put(gomock.Any(), chatID, chatFromDB).Times(1)
+	storageMock.EXPECT().CountUnread(gomock.Any(), chatID, testUserID).Return(0, nil)
+
+	res, cursor, err := svc.GetChat(context.Background(), testUserID, chatID, "2", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(res.Messages))
+	assert.Equal(t, "m1", res.Messages[0].Text)
+	assert.Equal(t, "m2", res.Messages[1].Text)
+	assert.Equal(t, encodeCursor(res.Messages[1].CreatedAt, res.Messages[1].ID), cursor)
+
+}
+
+func TestGetChat_LastPage_ReturnsEmptyCursor(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	chat := models.Chat{ID: 1, Messages: []models.Message{{ID: 2}, {ID: 1}}}
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	cacheMock.EXPECT().Get(gomock.Any(), 1).Return(chat, nil)
+	storageMock.EXPECT().CountUnread(gomock.Any(), 1, testUserID).Return(0, nil)
+
+	res, cursor, err := svc.GetChat(context.Background(), testUserID, 1, "5", "", "")
+	assert.NoError(t, err)
+	assert.Len(t, res.Messages, 2)
+	assert.Empty(t, cursor)
+
+}
+
+func TestGetChat_BeforeCursor_BypassesCacheAndTrims(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	boundary := time.Date(2025, 1, 16, 12, 0, 0, 0, time.UTC)
+	before := encodeCursor(boundary, 10)
+
+	page := models.Chat{ID: 1, Messages: []models.Message{
+		{ID: 9, CreatedAt: boundary.Add(-time.Second)},
+		{ID: 8, CreatedAt: boundary.Add(-2 * time.Second)},
+		{ID: 7, CreatedAt: boundary.Add(-3 * time.Second)},
+	}}
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	cacheMock.EXPECT().Get(gomock.Any(), gomock.Any()).Times(0)
+	cacheMock.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	storageMock.EXPECT().GetChat(gomock.Any(), 1, 3, &models.Cursor{CreatedAt: boundary, ID: 10, Direction: models.Before}).Return(page, nil)
+	storageMock.EXPECT().CountUnread(gomock.Any(), 1, testUserID).Return(0, nil)
+
+	res, cursor, err := svc.GetChat(context.Background(), testUserID, 1, "2", before, "")
+	assert.NoError(t, err)
+	assert.Len(t, res.Messages, 2)
+	assert.Equal(t, 9, res.Messages[0].ID)
+	assert.Equal(t, 8, res.Messages[1].ID)
+	assert.Equal(t, encodeCursor(res.Messages[1].CreatedAt, 8), cursor)
+
+}
+
+func TestGetChat_AfterCursor_KeepsMessagesClosestToCursor(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	boundary := time.Date(2025, 1, 16, 12, 0, 0, 0, time.UTC)
+	after := encodeCursor(boundary, 10)
+
+	page := models.Chat{ID: 1, Messages: []models.Message{
+		{ID: 13, CreatedAt: boundary.Add(3 * time.Second)},
+		{ID: 12, CreatedAt: boundary.Add(2 * time.Second)},
+		{ID: 11, CreatedAt: boundary.Add(time.Second)},
+	}}
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().GetChat(gomock.Any(), 1, 3, &models.Cursor{CreatedAt: boundary, ID: 10, Direction: models.After}).Return(page, nil)
+	storageMock.EXPECT().CountUnread(gomock.Any(), 1, testUserID).Return(0, nil)
+
+	res, cursor, err := svc.GetChat(context.Background(), testUserID, 1, "2", "", after)
+	assert.NoError(t, err)
+	assert.Len(t, res.Messages, 2)
+	assert.Equal(t, 12, res.Messages[0].ID)
+	assert.Equal(t, 11, res.Messages[1].ID)
+	assert.Equal(t, encodeCursor(res.Messages[0].CreatedAt, 12), cursor)
+
+}
+
+func TestGetChat_InvalidCursor_ReturnsError(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, _, _, _, _ := newTestService(controller)
+
+	_, _, err := svc.GetChat(context.Background(), testUserID, 1, "", "not a cursor", "")
+	assert.True(t, errors.Is(err, errs.ErrInvalidCursor))
+
+	_, _, err = svc.GetChat(context.Background(), testUserID, 1, "", encodeCursor(time.Now(), 1), encodeCursor(time.Now(), 2))
+	assert.True(t, errors.Is(err, errs.ErrCursorConflict))
+
+}
+
+func TestListChats_LastActivity_ReturnsNextCursor(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	created := time.Date(2025, 1, 16, 12, 0, 0, 0, time.UTC)
+	active := created.Add(time.Hour)
+
+	chats := []models.ChatSummary{
+		{ID: 3, CreatedAt: created},
+		{ID: 2, CreatedAt: created, LastMessageAt: &active},
+		{ID: 1, CreatedAt: created},
+	}
+
+	filter := models.ChatFilter{Title: " best ", Sort: models.SortByLastActivity}
+	expected := models.ChatFilter{Title: "best", Sort: models.SortByLastActivity, MemberID: testUserID}
+
+	storageMock.EXPECT().ListChats(gomock.Any(), expected, 3, nil).Return(chats, nil)
+
+	res, cursor, err := svc.ListChats(context.Background(), testUserID, filter, "2", "")
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, encodeCursor(active, 2), cursor)
+
+}
+
+func TestListChats_LastPage_DefaultSort(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	before := encodeCursor(time.Date(2025, 1, 16, 12, 0, 0, 0, time.UTC), 5)
+	cursor := &models.Cursor{CreatedAt: time.Date(2025, 1, 16, 12, 0, 0, 0, time.UTC), ID: 5}
+
+	storageMock.EXPECT().ListChats(gomock.Any(), models.ChatFilter{Sort: models.SortByCreatedAt, MemberID: testUserID}, svc.config.GetLimitDefault+1, cursor).
+		Return([]models.ChatSummary{{ID: 4}}, nil)
+
+	res, next, err := svc.ListChats(context.Background(), testUserID, models.ChatFilter{}, "", before)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Empty(t, next)
+
+}
+
+func TestListChats_ZeroLimit_UsesDefault(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	chats := make([]models.ChatSummary, svc.config.GetLimitDefault+1)
+	for i := range chats {
+		chats[i] = models.ChatSummary{ID: len(chats) - i}
+	}
+
+	storageMock.EXPECT().ListChats(gomock.Any(), gomock.Any(), svc.config.GetLimitDefault+1, nil).Return(chats, nil)
+
+	res, next, err := svc.ListChats(context.Background(), testUserID, models.ChatFilter{}, "00", "")
+	require.NoError(t, err)
+	assert.Len(t, res, svc.config.GetLimitDefault)
+	assert.NotEmpty(t, next)
+
+}
+
+func TestListChats_InvalidFilter_ReturnsError(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, _, _, _, _ := newTestService(controller)
+
+	_, _, err := svc.ListChats(context.Background(), testUserID, models.ChatFilter{Sort: "title"}, "", "")
+	assert.True(t, errors.Is(err, errs.ErrInvalidSort))
+
+	now := time.Now()
+	_, _, err = svc.ListChats(context.Background(), testUserID, models.ChatFilter{CreatedAfter: now, CreatedBefore: now.Add(-time.Hour)}, "", "")
+	assert.True(t, errors.Is(err, errs.ErrInvalidTimeRange))
+
+}
+
+func TestGetChat_InvalidLimitString_ReturnsErrInvalidLimit(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, _, _, _, _ := newTestService(controller)
+
+	_, _, err := svc.GetChat(context.Background(), testUserID, 1, "not int", "", "")
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrInvalidLimit))
+
+}
+
+func TestValidateLimit_Boundaries(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, _, _, _, _ := newTestService(controller)
+
+	lim, err := svc.validateLimit("")
+	assert.NoError(t, err)
+	assert.Equal(t, svc.config.GetLimitDefault, lim)
+
+	for _, zero := range []string{"0", "00", "-0", "+0"} {
+		lim, err = svc.validateLimit(zero)
+		assert.NoError(t, err)
+		assert.Equal(t, svc.config.GetLimitDefault, lim, zero)
+	}
+
+	_, err = svc.validateLimit("-1")
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrLimitTooSmall))
+
+	tooLarge := strconv.Itoa(svc.config.GetLimitMax + 1)
+	_, err = svc.validateLimit(tooLarge)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrLimitTooLarge))
+
+}
+
+func TestCreateChat_StorageError_LogsAndReturnsError(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	loggerMock := mockLogger.NewMockLogger(controller)
+	cacheMock := mockCache.NewMockCache(controller)
+	storageMock := mockStorage.NewMockStorage(controller)
+
+	loggerMock.EXPECT().LogErrorContext(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+	loggerMock.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogInfoContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogWarnContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().DebugContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogFatal(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := config.Service{
+		MaxTitleLength:   200,
+		MaxMessageLength: 1000,
+		GetLimitDefault:  10,
+		GetLimitMax:      100,
+	}
+	svc := NewService(loggerMock, cfg, cacheMock, storageMock, mockBroker.NewMockBroker(controller), mockNotifier.NewMockNotifier(controller), mockBus.NewMockEventBus(controller), mockBlob.NewMockBlobStore(controller))
+
+	chat := models.Chat{Title: "  asdadqwd  "}
+
+	storageErr := errors.New("db down")
+	storageMock.EXPECT().CreateChat(gomock.Any(), gomock.AssignableToTypeOf(&models.Chat{}), testUserID).Return(storageErr)
+
+	res, err := svc.CreateChat(context.Background(), testUserID, chat)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "db down")
+	assert.Equal(t, models.Chat{}, res)
+	assert.Zero(t, svc.Stats().ChatsCreated)
+
+}
+
+func TestCreateMessage_ValidationFails_ReturnsError(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	msg := models.Message{ChatID: 1, Text: "   "}
+
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+	storageMock.EXPECT().CreateMessage(gomock.Any(), gomock.Any()).Times(0)
+
+	res, err := svc.CreateMessage(context.Background(), testUserID, msg)
+
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrMessageEmpty))
+	assert.Equal(t, models.Message{}, res)
+
+}
+
+func TestCreateMessage_StorageError_LogsAndReturnsError(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	loggerMock := mockLogger.NewMockLogger(controller)
+	cacheMock := mockCache.NewMockCache(controller)
+	storageMock := mockStorage.NewMockStorage(controller)
+
+	loggerMock.EXPECT().LogErrorContext(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+	loggerMock.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogInfoContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogWarnContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().DebugContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogFatal(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := config.Service{
+		MaxTitleLength:   200,
+		MaxMessageLength: 1000,
+		GetLimitDefault:  10,
+		GetLimitMax:      100,
+	}
+	svc := NewService(loggerMock, cfg, cacheMock, storageMock, mockBroker.NewMockBroker(controller), mockNotifier.NewMockNotifier(controller), mockBus.NewMockEventBus(controller), mockBlob.NewMockBlobStore(controller))
+
+	message := models.Message{ChatID: 1, Text: "qwe"}
+
+	storageErr := errors.New("db unavailable")
+	expectRole(storageMock, message.ChatID, models.RoleMember)
+	storageMock.EXPECT().CreateMessage(gomock.Any(), gomock.AssignableToTypeOf(&models.Message{})).Return(storageErr)
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+
+	res, err := svc.CreateMessage(context.Background(), testUserID, message)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "db unavailable")
+	assert.Equal(t, models.Message{}, res)
+
+}
+
+func TestDeleteChat_StorageError_LogsAndReturnsError(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	loggerMock := mockLogger.NewMockLogger(controller)
+	cacheMock := mockCache.NewMockCache(controller)
+	storageMock := mockStorage.NewMockStorage(controller)
+
+	storageErr := errors.New("db unavailable")
+	loggerMock.EXPECT().LogErrorContext(gomock.Any(), "service — failed to delete chat", storageErr, "chatID", 1, "layer", "service.impl").Times(1)
+	loggerMock.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogInfoContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogWarnContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().DebugContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogFatal(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	svc := NewService(loggerMock, config.Service{}, cacheMock, storageMock, mockBroker.NewMockBroker(controller), mockNotifier.NewMockNotifier(controller), mockBus.NewMockEventBus(controller), mockBlob.NewMockBlobStore(controller))
+	chatID := 1
+
+	expectRole(storageMock, chatID, models.RoleOwner)
+	storageMock.EXPECT().DeleteChat(gomock.Any(), chatID).Return(nil, storageErr)
+	cacheMock.EXPECT().Delete(gomock.Any()).Times(0)
+
+	err := svc.DeleteChat(context.Background(), testUserID, chatID)
+
+	assert.Error(t, err)
+	assert.Equal(t, storageErr, err)
+
+}
+
+func TestGetChat_StorageError_LogsAndReturnsError(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	loggerMock := mockLogger.NewMockLogger(controller)
+	cacheMock := mockCache.NewMockCache(controller)
+	storageMock := mockStorage.NewMockStorage(controller)
+
+	chatID := 1
+	storageErr := errors.New("db unavailable")
+
+	loggerMock.EXPECT().LogErrorContext(gomock.Any(), "service — failed to get chat", storageErr, "chatID", chatID, "layer", "service.impl").Times(1)
+	loggerMock.EXPECT().LogInfo(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogInfoContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogWarn(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogWarnContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().DebugContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().LogFatal(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := config.Service{GetLimitMax: 100}
+	svc := NewService(loggerMock, cfg, cacheMock, storageMock, mockBroker.NewMockBroker(controller), mockNotifier.NewMockNotifier(controller), mockBus.NewMockEventBus(controller), mockBlob.NewMockBlobStore(controller))
+
+	expectRole(storageMock, chatID, models.RoleReadOnly)
+	cacheMock.EXPECT().Get(gomock.Any(), chatID).Return(models.Chat{}, errors.New("cache miss"))
+	storageMock.EXPECT().GetChat(gomock.Any(), chatID, cfg.GetLimitMax, nil).Return(models.Chat{}, storageErr)
+	cacheMock.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	res, _, err := svc.GetChat(context.Background(), testUserID, chatID, "", "", "")
+
+	assert.Error(t, err)
+	assert.Equal(t, storageErr, err)
+	assert.Equal(t, models.Chat{}, res)
+
+}
+
+// waitForLoad blocks until the given number of callers wait for the load of the chat.
+func waitForLoad(t *testing.T, svc *Service, chatID int, waiters int) {
+	require.Eventually(t, func() bool {
+		svc.loads.mu.Lock()
+		defer svc.loads.mu.Unlock()
+		f, ok := svc.loads.flights[chatID]
+		return ok && f.waiters == waiters
+	}, time.Second, time.Millisecond)
+}
+
+func TestGetChat_ConcurrentCacheMisses_ShareStorageLoad(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	const callers = 8
+	chatID := 1
+	chatFromDB := models.Chat{ID: chatID, Title: "fromdb", Messages: []models.Message{{Text: "m1"}}}
+	release := make(chan struct{})
+
+	storageMock.EXPECT().GetMember(gomock.Any(), chatID, testUserID).
+		Return(models.ChatMember{ChatID: chatID, UserID: testUserID, Role: models.RoleReadOnly}, nil).Times(callers)
+	cacheMock.EXPECT().Get(gomock.Any(), chatID).Return(models.Chat{}, errs.ErrCacheMiss).Times(callers)
+	storageMock.EXPECT().GetChat(gomock.Any(), chatID, svc.config.GetLimitMax, nil).DoAndReturn(
+		func(context.Context, int, int, *models.Cursor) (models.Chat, error) {
+			<-release
+			return chatFromDB, nil
+		}).Times(1)
+	cacheMock.EXPECT().Put(gomock.Any(), chatID, chatFromDB).Times(1)
+	storageMock.EXPECT().CountUnread(gomock.Any(), chatID, testUserID).Return(0, nil).Times(callers)
+
+	var wg sync.WaitGroup
+	results := make([]models.Chat, callers)
+	failures := make([]error, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _, failures[i] = svc.GetChat(context.Background(), testUserID, chatID, "", "", "")
+		}()
+	}
+
+	waitForLoad(t, svc, chatID, callers)
+	close(release)
+	wg.Wait()
+
+	for i := range callers {
+		require.NoError(t, failures[i])
+		assert.Equal(t, "fromdb", results[i].Title)
+	}
+	assert.Empty(t, svc.loads.flights)
+
+}
+
+func TestGetChat_ConcurrentCacheMisses_ShareStorageError(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	loggerMock := mockLogger.NewMockLogger(controller)
+	cacheMock := mockCache.NewMockCache(controller)
+	storageMock := mockStorage.NewMockStorage(controller)
+
+	const callers = 4
+	chatID := 1
+	storageErr := errors.New("db unavailable")
+	release := make(chan struct{})
+
+	loggerMock.EXPECT().LogErrorContext(gomock.Any(), "service — failed to get chat", storageErr, "chatID", chatID, "layer", "service.impl").Times(1)
+	loggerMock.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().DebugContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	cfg := config.Service{GetLimitMax: 100}
+	svc := NewService(loggerMock, cfg, cacheMock, storageMock, mockBroker.NewMockBroker(controller), mockNotifier.NewMockNotifier(controller), mockBus.NewMockEventBus(controller), mockBlob.NewMockBlobStore(controller))
+
+	storageMock.EXPECT().GetMember(gomock.Any(), chatID, testUserID).
+		Return(models.ChatMember{ChatID: chatID, UserID: testUserID, Role: models.RoleReadOnly}, nil).Times(callers)
+	cacheMock.EXPECT().Get(gomock.Any(), chatID).Return(models.Chat{}, errs.ErrCacheMiss).Times(callers)
+	storageMock.EXPECT().GetChat(gomock.Any(), chatID, cfg.GetLimitMax, nil).DoAndReturn(
+		func(context.Context, int, int, *models.Cursor) (models.Chat, error) {
+			<-release
+			return models.Chat{}, storageErr
+		}).Times(1)
+
+	var wg sync.WaitGroup
+	failures := make([]error, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, failures[i] = svc.GetChat(context.Background(), testUserID, chatID, "", "", "")
+		}()
+	}
+
+	waitForLoad(t, svc, chatID, callers)
+	close(release)
+	wg.Wait()
+
+	for _, err := range failures {
+		assert.Equal(t, storageErr, err)
+	}
+
+}
+
+func TestGetChat_CallerCancelled_OthersKeepWaiting(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	chatID := 1
+	chatFromDB := models.Chat{ID: chatID, Title: "fromdb"}
+	release := make(chan struct{})
+	var loadErr error
+
+	storageMock.EXPECT().GetMember(gomock.Any(), chatID, testUserID).
+		Return(models.ChatMember{ChatID: chatID, UserID: testUserID, Role: models.RoleReadOnly}, nil).Times(2)
+	cacheMock.EXPECT().Get(gomock.Any(), chatID).Return(models.Chat{}, errs.ErrCacheMiss).Times(2)
+	storageMock.EXPECT().GetChat(gomock.Any(), chatID, svc.config.GetLimitMax, nil).DoAndReturn(
+		func(ctx context.Context, _ int, _ int, _ *models.Cursor) (models.Chat, error) {
+			<-release
+			loadErr = ctx.Err()
+			return chatFromDB, nil
+		}).Times(1)
+	cacheMock.EXPECT().Put(gomock.Any(), chatID, chatFromDB).Times(1)
+	storageMock.EXPECT().CountUnread(gomock.Any(), chatID, testUserID).Return(0, nil).Times(1)
+
+	// the first caller starts the load and goes away while it is in progress
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		_, _, err := svc.GetChat(ctx, testUserID, chatID, "", "", "")
+		cancelled <- err
+	}()
+	waitForLoad(t, svc, chatID, 1)
+
+	done := make(chan error)
+	var res models.Chat
+	go func() {
+		var err error
+		res, _, err = svc.GetChat(context.Background(), testUserID, chatID, "", "", "")
+		done <- err
+	}()
+	waitForLoad(t, svc, chatID, 2)
+
+	cancel()
+	require.ErrorIs(t, <-cancelled, context.Canceled)
+
+	close(release)
+	require.NoError(t, <-done)
+	assert.Equal(t, "fromdb", res.Title)
+	assert.NoError(t, loadErr, "the load must outlive the caller that started it")
+
+}
+
+func TestGetChat_AllCallersCancelled_CancelsStorageLoad(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, cacheMock, storageMock, _, _, _ := newTestService(controller)
+
+	chatID := 1
+	loadDone := make(chan error)
+
+	expectRole(storageMock, chatID, models.RoleReadOnly)
+	cacheMock.EXPECT().Get(gomock.Any(), chatID).Return(models.Chat{}, errs.ErrCacheMiss)
+	storageMock.EXPECT().GetChat(gomock.Any(), chatID, svc.config.GetLimitMax, nil).DoAndReturn(
+		func(ctx context.Context, _ int, _ int, _ *models.Cursor) (models.Chat, error) {
+			<-ctx.Done()
+			loadDone <- ctx.Err()
+			return models.Chat{}, ctx.Err()
+		}).Times(1)
+	cacheMock.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error)
+	go func() {
+		_, _, err := svc.GetChat(ctx, testUserID, chatID, "", "", "")
+		result <- err
+	}()
+	waitForLoad(t, svc, chatID, 1)
+
+	cancel()
+	require.ErrorIs(t, <-result, context.Canceled)
+	require.ErrorIs(t, <-loadDone, context.Canceled)
+
+	// a later miss starts a new load instead of joining the cancelled one
+	svc.loads.mu.Lock()
+	assert.Empty(t, svc.loads.flights)
+	svc.loads.mu.Unlock()
+
+}
+
+func TestGetChat_MessageCreatedDuringLoad_NotCachedStale(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	base, loggerMock, _, storageMock, brokerMock, notifierMock, busMock := newTestService(controller)
+
+	cache := memory.NewShardedCache(loggerMock, config.Cache{Capacity: 8, Shards: 1, MaxMessages: 100, TombstoneTTL: time.Minute})
+	defer cache.Close()
+
+	svc := NewService(loggerMock, base.config, cache, storageMock, brokerMock, notifierMock, busMock, mockBlob.NewMockBlobStore(controller))
+
+	chatID := 1
+	stale := models.Chat{ID: chatID, Title: "chat", Messages: []models.Message{{ID: 1, ChatID: chatID, Text: "first"}}}
+	loaded := make(chan struct{})
+	release := make(chan struct{})
+
+	storageMock.EXPECT().GetMember(gomock.Any(), chatID, testUserID).
+		Return(models.ChatMember{ChatID: chatID, UserID: testUserID, Role: models.RoleMember}, nil).Times(2)
+	storageMock.EXPECT().GetChat(gomock.Any(), chatID, svc.config.GetLimitMax, nil).DoAndReturn(
+		func(context.Context, int, int, *models.Cursor) (models.Chat, error) {
+			close(loaded)
+			<-release
+			return stale, nil
+		})
+	storageMock.EXPECT().CountUnread(gomock.Any(), chatID, testUserID).Return(0, nil)
+	storageMock.EXPECT().CreateMessage(gomock.Any(), gomock.AssignableToTypeOf(&models.Message{})).DoAndReturn(func(_ context.Context, message *models.Message) error {
+		message.ID = 2
+		return nil
+	})
+	busMock.EXPECT().Publish(gomock.Any(), chatID).Return(nil)
+	brokerMock.EXPECT().Publish(gomock.Any())
+	notifierMock.EXPECT().Notify(chatID)
+
+	result := make(chan error)
+	go func() {
+		_, _, err := svc.GetChat(context.Background(), testUserID, chatID, "", "", "")
+		result <- err
+	}()
+
+	// the message is created after the load has read the chat and before it stores it
+	<-loaded
+	_, err := svc.CreateMessage(context.Background(), testUserID, models.Message{ChatID: chatID, Text: "second"})
+	require.NoError(t, err)
+	close(release)
+	require.NoError(t, <-result)
+
+	_, err = cache.Get(context.Background(), chatID)
+	assert.ErrorIs(t, err, errs.ErrCacheMiss, "the chat read before the message was created must not be cached")
+
+}
+
+func TestValidateChat_TitleTooLong_ReturnsError(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, _, _, _, _ := newTestService(controller)
+
+	longTitle := strings.Repeat("a", svc.config.MaxTitleLength+1)
+	chat := &models.Chat{Title: longTitle}
+
+	err := svc.validateChat(chat)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrTitleTooLong))
+
+}
+
+func TestValidateMessage_TextTooLong_ReturnsError(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, _, _, _, _ := newTestService(controller)
+
+	longText := strings.Repeat("x", svc.config.MaxMessageLength+1)
+	err := svc.validateMessage(&models.Message{Text: longText})
+
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, errs.ErrMessageTooLong))
+
+}
+
+func TestRegister_Success_HashesPassword(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	storageMock.EXPECT().CreateUser(gomock.Any(), gomock.AssignableToTypeOf(&models.User{})).DoAndReturn(func(_ context.Context, user *models.User) error {
+		user.ID = 3
+		return nil
+	})
+
+	user, err := svc.Register(context.Background(), "neo", "follow-the-rabbit")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, user.ID)
+	assert.NotEqual(t, "follow-the-rabbit", user.PasswordHash)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("follow-the-rabbit")))
+
+}
+
+func TestRegister_Validation(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, _, _, _, _ := newTestService(controller)
+
+	tests := []struct {
+		username string
+		password string
+		err      error
+	}{
+		{"ne", "long-enough", errs.ErrUsernameInvalid},
+		{"neo smith", "long-enough", errs.ErrUsernameInvalid},
+		{"neo", "short", errs.ErrPasswordTooShort},
+		{"neo", strings.Repeat("p", 73), errs.ErrPasswordTooLong},
+	}
+
+	for _, tt := range tests {
+		_, err := svc.Register(context.Background(), tt.username, tt.password)
+		assert.True(t, errors.Is(err, tt.err), "%s/%s: %v", tt.username, tt.password, err)
+	}
+
+}
+
+func TestRegister_UniqueViolation_ReturnsUsernameTaken(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	storageMock.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(&pgconn.PgError{Code: pgerrcode.UniqueViolation})
+
+	_, err := svc.Register(context.Background(), "neo", "follow-the-rabbit")
+	assert.True(t, errors.Is(err, errs.ErrUsernameTaken))
+
+}
+
+func TestLogin_IssuesVerifiableToken(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("follow-the-rabbit"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	storageMock.EXPECT().GetUserByUsername(gomock.Any(), "Neo").Return(models.User{ID: 3, Username: "neo", PasswordHash: string(hash)}, nil)
+
+	token, err := svc.Login(context.Background(), "Neo", "follow-the-rabbit")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresAt, 2*time.Second)
+
+	userID, err := svc.Authenticate(context.Background(), token.Value)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, userID)
+
+}
+
+func TestLogin_InvalidCredentials(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("follow-the-rabbit"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	storageMock.EXPECT().GetUserByUsername(gomock.Any(), "neo").Return(models.User{ID: 3, PasswordHash: string(hash)}, nil)
+	storageMock.EXPECT().GetUserByUsername(gomock.Any(), "smith").Return(models.User{}, errs.ErrUserNotFound)
+
+	_, err = svc.Login(context.Background(), "neo", "wrong-password")
+	assert.True(t, errors.Is(err, errs.ErrInvalidCredentials))
+
+	_, err = svc.Login(context.Background(), "smith", "follow-the-rabbit")
+	assert.True(t, errors.Is(err, errs.ErrInvalidCredentials))
+
+}
+
+func TestAuthenticate_RejectsInvalidTokens(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, _, _, _, _ := newTestService(controller)
+
+	valid := svc.issueToken(3, time.Now().Add(time.Hour))
+	expired := svc.issueToken(3, time.Now().Add(-time.Second))
+	tampered := "4" + valid[1:]
+
+	for _, token := range []string{"", "garbage", expired, tampered, valid + "x"} {
+		_, err := svc.Authenticate(context.Background(), token)
+		assert.True(t, errors.Is(err, errs.ErrUnauthorized), "token %q", token)
+	}
+
+}
+
+func TestIssueStreamToken_AcceptedOnlyForStreams(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, _, _, _, _ := newTestService(controller)
+
+	token, err := svc.IssueStreamToken(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), token.ExpiresAt, 2*time.Second)
+
+	userID, err := svc.AuthenticateStream(context.Background(), token.Value)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, userID)
+
+	_, err = svc.Authenticate(context.Background(), token.Value)
+	assert.True(t, errors.Is(err, errs.ErrUnauthorized))
+
+	// a bearer token does not pass as a stream token either
+	_, err = svc.AuthenticateStream(context.Background(), svc.issueToken(3, time.Now().Add(time.Hour)))
+	assert.True(t, errors.Is(err, errs.ErrUnauthorized))
+
+}
+
+func TestSearchMessages_AllChats_ReturnsNextCursor(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	hits := []models.SearchHit{
+		{Message: models.Message{ID: 7}, Rank: 0.75},
+		{Message: models.Message{ID: 5}, Rank: 0.0607927},
+		{Message: models.Message{ID: 3}, Rank: 0.0607927},
+	}
+
+	storageMock.EXPECT().GetMember(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	storageMock.EXPECT().SearchMessages(gomock.Any(), models.SearchQuery{Text: "release party", MemberID: testUserID}, 3, nil).Return(hits, nil)
+
+	res, cursor, err := svc.SearchMessages(context.Background(), testUserID, models.SearchQuery{Text: "  release party "}, "2", "")
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+
+	decoded, err := decodeSearchCursor(cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.SearchCursor{Rank: 0.0607927, ID: 5}, decoded)
+
+}
+
+func TestSearchMessages_ZeroLimit_UsesDefault(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	hits := make([]models.SearchHit, svc.config.GetLimitDefault+1)
+	for i := range hits {
+		hits[i] = models.SearchHit{Message: models.Message{ID: len(hits) - i}, Rank: 0.5}
+	}
+
+	storageMock.EXPECT().SearchMessages(gomock.Any(), gomock.Any(), svc.config.GetLimitDefault+1, nil).Return(hits, nil)
+
+	res, cursor, err := svc.SearchMessages(context.Background(), testUserID, models.SearchQuery{Text: "x"}, "00", "")
+	require.NoError(t, err)
+	assert.Len(t, res, svc.config.GetLimitDefault)
+	assert.NotEmpty(t, cursor)
+
+}
+
+func TestSearchMessages_InChat_RequiresReadAccess(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	cursor := encodeSearchCursor(0.5, 9)
+	query := models.SearchQuery{Text: "release", ChatID: 1, MemberID: testUserID}
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().SearchMessages(gomock.Any(), query, svc.config.GetLimitDefault+1, &models.SearchCursor{Rank: 0.5, ID: 9}).Return(nil, nil)
+
+	res, next, err := svc.SearchMessages(context.Background(), testUserID, models.SearchQuery{Text: "release", ChatID: 1}, "", cursor)
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+	assert.Empty(t, next)
+
+	storageMock.EXPECT().GetMember(gomock.Any(), 2, testUserID).Return(models.ChatMember{}, errs.ErrMemberNotFound)
+
+	_, _, err = svc.SearchMessages(context.Background(), testUserID, models.SearchQuery{Text: "release", ChatID: 2}, "", "")
+	assert.True(t, errors.Is(err, errs.ErrForbidden))
+
+}
+
+func TestSearchMessages_Validation(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, _, _, _, _ := newTestService(controller)
+
+	tests := []struct {
+		query  models.SearchQuery
+		cursor string
+		err    error
+	}{
+		{models.SearchQuery{Text: "   "}, "", errs.ErrSearchQueryEmpty},
+		{models.SearchQuery{Text: strings.Repeat("я", maxSearchQueryLength+1)}, "", errs.ErrSearchQueryTooLong},
+		{models.SearchQuery{Text: "hi", ChatID: -1}, "", errs.ErrInvalidChatID},
+		{models.SearchQuery{Text: "hi"}, "not a cursor", errs.ErrInvalidCursor},
+		{models.SearchQuery{Text: "hi"}, "MC41OjA", errs.ErrInvalidCursor}, // "0.5:0"
+	}
+
+	for _, tt := range tests {
+		_, _, err := svc.SearchMessages(context.Background(), testUserID, tt.query, "", tt.cursor)
+		assert.True(t, errors.Is(err, tt.err), "query %q: got %v", tt.query.Text, err)
+	}
+
+}
+
+func TestMarkRead_ReturnsMarkerAndUnreadCount(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	readAt := time.Date(2025, 1, 16, 12, 0, 0, 0, time.UTC)
+
+	expectRole(storageMock, 1, models.RoleReadOnly)
+	storageMock.EXPECT().MarkRead(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, receipt *models.ReadReceipt) error {
+		assert.Equal(t, 1, receipt.ChatID)
+		assert.Equal(t, testUserID, receipt.UserID)
+		assert.Equal(t, 7, receipt.LastReadMessageID)
+		assert.False(t, receipt.UpdatedAt.IsZero())
+		// a newer marker is kept
+		receipt.LastReadMessageID, receipt.LastReadAt = 9, readAt
+		return nil
+	})
+	storageMock.EXPECT().CountUnread(gomock.Any(), 1, testUserID).Return(2, nil)
+
+	receipt, err := svc.MarkRead(context.Background(), testUserID, 1, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, receipt.LastReadMessageID)
+	assert.Equal(t, readAt, receipt.LastReadAt)
+	assert.Equal(t, 2, receipt.UnreadCount)
+
+}
+
+func TestMarkRead_Errors(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	storageMock.EXPECT().GetMember(gomock.Any(), 2, testUserID).Return(models.ChatMember{}, errs.ErrMemberNotFound)
+
+	_, err := svc.MarkRead(context.Background(), testUserID, 2, 7)
+	assert.True(t, errors.Is(err, errs.ErrForbidden))
+
+	expectRole(storageMock, 1, models.RoleMember)
+	storageMock.EXPECT().MarkRead(gomock.Any(), gomock.Any()).Return(errs.ErrMessageNotFound)
+	storageMock.EXPECT().CountUnread(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err = svc.MarkRead(context.Background(), testUserID, 1, 404)
+	assert.True(t, errors.Is(err, errs.ErrMessageNotFound))
+
+}
+
+func TestBeginIdempotent_ReservesKey(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	storageMock.EXPECT().ReserveIdempotencyKey(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key *models.IdempotencyKey) (bool, error) {
+		assert.Equal(t, testUserID, key.UserID)
+		assert.Equal(t, "retry-1", key.Key)
+		assert.Equal(t, "hash", key.RequestHash)
+		assert.Equal(t, time.Hour, key.ExpiresAt.Sub(key.CreatedAt))
+		return true, nil
+	})
+
+	record, fresh, err := svc.BeginIdempotent(context.Background(), testUserID, "retry-1", "hash")
+	assert.NoError(t, err)
+	assert.True(t, fresh)
+	assert.Equal(t, "retry-1", record.Key)
+
+}
+
+func TestBeginIdempotent_StoredKeys(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	tests := []struct {
+		stored models.IdempotencyKey
+		fresh  bool
+		err    error
+	}{
+		{models.IdempotencyKey{RequestHash: "hash", StatusCode: 200, ResponseBody: []byte(`{}`)}, false, nil},
+		{models.IdempotencyKey{RequestHash: "other", StatusCode: 200}, false, errs.ErrIdempotencyKeyReused},
+		{models.IdempotencyKey{RequestHash: "hash"}, false, errs.ErrIdempotencyKeyInProgress},
+	}
+
+	for _, tt := range tests {
+
+		storageMock.EXPECT().ReserveIdempotencyKey(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key *models.IdempotencyKey) (bool, error) {
+			*key = tt.stored
+			return false, nil
+		})
+
+		record, fresh, err := svc.BeginIdempotent(context.Background(), testUserID, "retry-1", "hash")
+		assert.Equal(t, tt.fresh, fresh)
+		if tt.err != nil {
+			assert.True(t, errors.Is(err, tt.err), "got %v", err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tt.stored, record)
+
+	}
+
+}
+
+func TestBeginIdempotent_InvalidKey(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	storageMock.EXPECT().ReserveIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+
+	for _, key := range []string{"", strings.Repeat("k", maxIdempotencyKeyLength+1), "tab\tkey", "ключ"} {
+		_, _, err := svc.BeginIdempotent(context.Background(), testUserID, key, "hash")
+		assert.True(t, errors.Is(err, errs.ErrInvalidIdempotencyKey), "key %q: got %v", key, err)
+	}
+
+}
+
+func TestCompleteIdempotent_StoresOrReleases(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+
+	created := models.IdempotencyKey{UserID: testUserID, Key: "retry-1", StatusCode: 200, ResponseBody: []byte(`{}`)}
+	storageMock.EXPECT().CompleteIdempotencyKey(gomock.Any(), created).Return(nil)
+	svc.CompleteIdempotent(context.Background(), created)
+
+	invalid := models.IdempotencyKey{UserID: testUserID, Key: "retry-2", StatusCode: 400}
+	storageMock.EXPECT().CompleteIdempotencyKey(gomock.Any(), invalid).Return(nil)
+	svc.CompleteIdempotent(context.Background(), invalid)
+
+	storageMock.EXPECT().ReleaseIdempotencyKey(gomock.Any(), testUserID, "retry-3").Return(nil)
+	svc.CompleteIdempotent(context.Background(), models.IdempotencyKey{UserID: testUserID, Key: "retry-3", StatusCode: 500})
+
+}
+
+func TestCollectIdempotencyKeys_DeletesUntilCancelled(t *testing.T) {
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	svc, _, _, storageMock, _, _, _ := newTestService(controller)
+	svc.config.IdempotencyGC = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the ticker may fire once more before the cancellation is noticed
+	storageMock.EXPECT().DeleteExpiredIdempotencyKeys(gomock.Any(), gomock.Any()).MinTimes(1).DoAndReturn(func(context.Context, time.Time) (int64, error) {
+		cancel()
+		return 1, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		svc.CollectIdempotencyKeys(ctx)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("collector did not stop after cancellation")
+	}
 
 }
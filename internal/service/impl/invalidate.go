@@ -0,0 +1,47 @@
+package impl
+
+import (
+	"chatX/internal/models"
+	"context"
+)
+
+// invalidate evicts the chat from the local cache and tells the other instances to do the same.
+func (s *Service) invalidate(ctx context.Context, chatID int) {
+	s.cache.Delete(chatID)
+	s.invalidateOthers(ctx, chatID)
+}
+
+// invalidateOthers tells the other instances to evict the chat from their caches.
+//
+// The change is already persisted, so a failure to notify the other instances is only
+// logged; their entries become stale until evicted. The notification is sent even if
+// the caller has gone away in the meantime. A shared cache has already been changed for
+// all instances, so they are not told anything.
+func (s *Service) invalidateOthers(ctx context.Context, chatID int) {
+
+	if s.cache.Shared() {
+		return
+	}
+
+	if err := s.bus.Publish(context.WithoutCancel(ctx), chatID); err != nil {
+		s.logger.LogErrorContext(ctx, "service — failed to publish cache invalidation", err, "chatID", chatID, "layer", "service.impl")
+	}
+
+}
+
+// cachedMessage returns a new message the way storage returns it along with its chat:
+// with no reactions, and with attachments that do not expose their blob keys.
+func cachedMessage(message models.Message) models.Message {
+
+	attachments := make(models.Attachments, len(message.Attachments))
+	for i, attachment := range message.Attachments {
+		attachment.BlobKey = ""
+		attachments[i] = attachment
+	}
+
+	message.Reactions = models.Reactions{}
+	message.Attachments = attachments
+
+	return message
+
+}
@@ -0,0 +1,60 @@
+package impl
+
+import (
+	"chatX/internal/models"
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ListChats retrieves a page of summaries of the user's chats matching the filter.
+//
+// It validates the filter, limit and cursor, then loads one extra summary from
+// storage to find out whether another page follows. Along with the summaries it
+// returns the cursor of the next page, or an empty string if this is the last one.
+func (s *Service) ListChats(ctx context.Context, userID int, filter models.ChatFilter, limitStr, cursorStr string) ([]models.ChatSummary, string, error) {
+
+	ctx, span := startSpan(ctx, "ListChats", attribute.Int("user.id", userID))
+	defer span.End()
+
+	if err := validateFilter(&filter); err != nil {
+		return nil, "", err
+	}
+
+	limit, err := s.validateLimit(limitStr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cursor, err := validateCursor(cursorStr, "")
+	if err != nil {
+		return nil, "", err
+	}
+
+	filter.MemberID = userID
+
+	chats, err := s.storage.ListChats(ctx, filter, limit+1, cursor)
+	if err != nil {
+		s.logger.LogErrorContext(ctx, "service — failed to list chats", err, "layer", "service.impl")
+		return nil, "", err
+	}
+
+	if len(chats) <= limit {
+		return chats, "", nil
+	}
+
+	chats = chats[:limit]
+	last := chats[limit-1]
+
+	return chats, encodeCursor(sortKey(last, filter.Sort), last.ID), nil
+
+}
+
+// sortKey returns the timestamp by which the chat summary is ordered in listings.
+func sortKey(chat models.ChatSummary, sort models.ChatSort) time.Time {
+	if sort == models.SortByLastActivity && chat.LastMessageAt != nil {
+		return *chat.LastMessageAt
+	}
+	return chat.CreatedAt
+}
@@ -0,0 +1,28 @@
+package impl
+
+import (
+	"chatX/internal/models"
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ListMembers retrieves all members of a chat; any member may list them.
+func (s *Service) ListMembers(ctx context.Context, userID int, chatID int) ([]models.ChatMember, error) {
+
+	ctx, span := startSpan(ctx, "ListMembers", attribute.Int("user.id", userID), attribute.Int("chat.id", chatID))
+	defer span.End()
+
+	if _, err := s.authorize(ctx, userID, chatID, models.RoleReadOnly); err != nil {
+		return nil, err
+	}
+
+	members, err := s.storage.ListMembers(ctx, chatID)
+	if err != nil {
+		s.logger.LogErrorContext(ctx, "service — failed to list chat members", err, "chatID", chatID, "layer", "service.impl")
+		return nil, err
+	}
+
+	return members, nil
+
+}
@@ -0,0 +1,117 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+	"sync"
+)
+
+// chatLoads coalesces concurrent storage loads of the same chat, so a chat that
+// drops out of the cache is loaded once however many requests miss it at the same time.
+//
+// The zero value is ready to use.
+type chatLoads struct {
+	mu      sync.Mutex        // guards flights and the waiters of every load
+	flights map[int]*chatLoad // loads in progress by chat ID
+}
+
+// chatLoad is a storage load of a chat shared by all callers waiting for it.
+type chatLoad struct {
+	done    chan struct{}      // closed once the load has finished
+	chat    models.Chat        // loaded chat, set before done is closed
+	err     error              // load error, set before done is closed
+	waiters int                // callers still waiting for the result
+	cancel  context.CancelFunc // cancels the load once no caller waits for it
+}
+
+// do returns the result of load for the chat, joining a load already in progress for it.
+//
+// The load runs in its own goroutine with a context detached from the cancellation of the
+// callers, so a caller that goes away stops waiting and returns its context error without
+// failing the others. Once the last caller has gone away the load is cancelled and forgotten,
+// and the next caller starts a new one. Errors of the load are returned to all its callers.
+func (l *chatLoads) do(ctx context.Context, chatID int, load func(ctx context.Context) (models.Chat, error)) (models.Chat, error) {
+
+	l.mu.Lock()
+
+	f, ok := l.flights[chatID]
+	if !ok {
+		if l.flights == nil {
+			l.flights = make(map[int]*chatLoad)
+		}
+		loadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &chatLoad{done: make(chan struct{}), cancel: cancel}
+		l.flights[chatID] = f
+		go l.run(loadCtx, chatID, f, load)
+	}
+	f.waiters++
+
+	l.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.chat, f.err
+	case <-ctx.Done():
+		l.leave(chatID, f)
+		return models.Chat{}, ctx.Err()
+	}
+
+}
+
+// run performs a load and publishes its result to the waiting callers.
+func (l *chatLoads) run(ctx context.Context, chatID int, f *chatLoad, load func(ctx context.Context) (models.Chat, error)) {
+
+	f.chat, f.err = load(ctx)
+
+	l.mu.Lock()
+	if l.flights[chatID] == f {
+		delete(l.flights, chatID)
+	}
+	l.mu.Unlock()
+
+	close(f.done)
+	f.cancel()
+
+}
+
+// leave stops a caller from waiting for a load, cancelling the load if it was the last one.
+func (l *chatLoads) leave(chatID int, f *chatLoad) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f.waiters--
+	if f.waiters > 0 {
+		return
+	}
+
+	f.cancel()
+	if l.flights[chatID] == f {
+		delete(l.flights, chatID)
+	}
+
+}
+
+// loadChat loads a chat from storage with the maximum allowed messages and caches it.
+//
+// It runs once per coalesced load, so failures are logged once however many callers wait.
+// A load cancelled because all its callers have gone away is not an error worth logging.
+// A chat written after the load has read it has a tombstone in the cache by the time it is
+// stored, so the cache drops the stale chat instead.
+func (s *Service) loadChat(ctx context.Context, chatID int) (models.Chat, error) {
+
+	chat, err := s.storage.GetChat(ctx, chatID, s.config.GetLimitMax, nil)
+	if err != nil {
+		if !errors.Is(err, errs.ErrChatNotFound) && ctx.Err() == nil {
+			s.logger.LogErrorContext(ctx, "service — failed to get chat", err, "chatID", chatID, "layer", "service.impl")
+		}
+		return models.Chat{}, err
+	}
+
+	s.cache.Put(ctx, chatID, chat)
+
+	return chat, nil
+
+}
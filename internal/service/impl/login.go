@@ -0,0 +1,43 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash is compared against when the user does not exist, so that unknown
+// usernames take as long to reject as wrong passwords.
+const dummyHash = "$2a$10$vV.Az4Ho31D1mIm4OifW/.08.dE.SyxGrnklXQQfMCm1Nu/XIV7om"
+
+// Login checks the user's credentials and issues a bearer token.
+//
+// Unknown usernames and wrong passwords both return ErrInvalidCredentials.
+func (s *Service) Login(ctx context.Context, username, password string) (models.Token, error) {
+
+	ctx, span := startSpan(ctx, "Login")
+	defer span.End()
+
+	user, err := s.storage.GetUserByUsername(ctx, username)
+	if err != nil {
+		if !errors.Is(err, errs.ErrUserNotFound) {
+			s.logger.LogErrorContext(ctx, "service — failed to get user", err, "layer", "service.impl")
+			return models.Token{}, err
+		}
+		_ = bcrypt.CompareHashAndPassword([]byte(dummyHash), []byte(password))
+		return models.Token{}, errs.ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return models.Token{}, errs.ErrInvalidCredentials
+	}
+
+	expiresAt := time.Now().UTC().Add(s.config.TokenTTL).Truncate(time.Second)
+
+	return models.Token{Value: s.issueToken(user.ID, expiresAt), ExpiresAt: expiresAt}, nil
+
+}
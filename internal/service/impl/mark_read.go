@@ -0,0 +1,58 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// MarkRead advances the read marker of the user in a chat to the given message.
+//
+// Any member may mark messages as read. The marker never moves backwards, so marking
+// an older message keeps the current one. The returned receipt carries the resulting
+// marker and the number of messages that are still unread.
+func (s *Service) MarkRead(ctx context.Context, userID int, chatID int, messageID int) (models.ReadReceipt, error) {
+
+	ctx, span := startSpan(ctx, "MarkRead", attribute.Int("user.id", userID), attribute.Int("chat.id", chatID), attribute.Int("message.id", messageID))
+	defer span.End()
+
+	if _, err := s.authorize(ctx, userID, chatID, models.RoleReadOnly); err != nil {
+		return models.ReadReceipt{}, err
+	}
+
+	receipt := models.ReadReceipt{ChatID: chatID, UserID: userID, LastReadMessageID: messageID, UpdatedAt: time.Now().UTC()}
+
+	if err := s.storage.MarkRead(ctx, &receipt); err != nil {
+		if !errors.Is(err, errs.ErrMessageNotFound) {
+			s.logger.LogErrorContext(ctx, "service — failed to mark chat as read", err, "chatID", chatID, "messageID", messageID, "layer", "service.impl")
+		}
+		return models.ReadReceipt{}, err
+	}
+
+	unread, err := s.countUnread(ctx, userID, chatID)
+	if err != nil {
+		return models.ReadReceipt{}, err
+	}
+
+	receipt.UnreadCount = unread
+
+	return receipt, nil
+
+}
+
+// countUnread counts the messages of a chat the user has not read yet.
+func (s *Service) countUnread(ctx context.Context, userID int, chatID int) (int, error) {
+
+	unread, err := s.storage.CountUnread(ctx, chatID, userID)
+	if err != nil {
+		s.logger.LogErrorContext(ctx, "service — failed to count unread messages", err, "chatID", chatID, "layer", "service.impl")
+		return 0, err
+	}
+
+	return unread, nil
+
+}
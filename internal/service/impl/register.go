@@ -0,0 +1,46 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Register creates a new user account with a bcrypt-hashed password.
+//
+// Usernames are unique regardless of case; registering a taken one returns ErrUsernameTaken.
+func (s *Service) Register(ctx context.Context, username, password string) (models.User, error) {
+
+	ctx, span := startSpan(ctx, "Register")
+	defer span.End()
+
+	if err := s.validateCredentials(username, password); err != nil {
+		return models.User{}, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		s.logger.LogErrorContext(ctx, "service — failed to hash password", err, "layer", "service.impl")
+		return models.User{}, err
+	}
+
+	user := models.User{Username: username, PasswordHash: string(hash), CreatedAt: time.Now().UTC()}
+
+	if err := s.storage.CreateUser(ctx, &user); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return models.User{}, errs.ErrUsernameTaken
+		}
+		s.logger.LogErrorContext(ctx, "service — failed to create user", err, "layer", "service.impl")
+		return models.User{}, err
+	}
+
+	return user, nil
+
+}
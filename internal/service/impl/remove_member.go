@@ -0,0 +1,58 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RemoveMember removes a user from a chat.
+//
+// Any member but the owner may leave a chat by removing itself. Removing someone
+// else requires the admin role and a role above that of the removed member.
+func (s *Service) RemoveMember(ctx context.Context, userID int, chatID int, memberID int) error {
+
+	ctx, span := startSpan(ctx, "RemoveMember", attribute.Int("user.id", userID), attribute.Int("chat.id", chatID))
+	defer span.End()
+
+	role := models.RoleAdmin
+	if memberID == userID {
+		role = models.RoleReadOnly
+	}
+
+	actor, err := s.authorize(ctx, userID, chatID, role)
+	if err != nil {
+		return err
+	}
+
+	target := actor
+	if memberID != userID {
+		target, err = s.storage.GetMember(ctx, chatID, memberID)
+		if err != nil {
+			if !errors.Is(err, errs.ErrMemberNotFound) {
+				s.logger.LogErrorContext(ctx, "service — failed to get chat member", err, "chatID", chatID, "userID", memberID, "layer", "service.impl")
+			}
+			return err
+		}
+		if roleRanks[actor.Role] <= roleRanks[target.Role] {
+			return errs.ErrForbidden
+		}
+	}
+
+	if target.Role == models.RoleOwner {
+		return errs.ErrForbidden
+	}
+
+	if err := s.storage.RemoveMember(ctx, chatID, memberID); err != nil {
+		if !errors.Is(err, errs.ErrMemberNotFound) {
+			s.logger.LogErrorContext(ctx, "service — failed to remove chat member", err, "chatID", chatID, "userID", memberID, "layer", "service.impl")
+		}
+		return err
+	}
+
+	return nil
+
+}
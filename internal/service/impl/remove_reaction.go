@@ -0,0 +1,40 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RemoveReaction removes an emoji reaction of the user from a message and invalidates the chat's cache entry.
+//
+// Returns ErrReactionNotFound if the user has not reacted to the message with the emoji.
+func (s *Service) RemoveReaction(ctx context.Context, userID int, chatID int, messageID int, emoji string) error {
+
+	ctx, span := startSpan(ctx, "RemoveReaction", attribute.Int("user.id", userID), attribute.Int("chat.id", chatID), attribute.Int("message.id", messageID))
+	defer span.End()
+
+	if err := validateEmoji(&emoji); err != nil {
+		return err
+	}
+
+	if _, err := s.authorize(ctx, userID, chatID, models.RoleMember); err != nil {
+		return err
+	}
+
+	reaction := models.MessageReaction{MessageID: messageID, UserID: userID, Emoji: emoji}
+
+	if err := s.storage.RemoveReaction(ctx, chatID, reaction); err != nil {
+		if !errors.Is(err, errs.ErrReactionNotFound) {
+			s.logger.LogErrorContext(ctx, "service — failed to remove reaction", err, "messageID", messageID, "layer", "service.impl")
+		}
+		return err
+	}
+
+	s.invalidate(ctx, chatID)
+	return nil
+
+}
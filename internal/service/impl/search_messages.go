@@ -0,0 +1,58 @@
+package impl
+
+import (
+	"chatX/internal/models"
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SearchMessages retrieves a page of messages matching a full-text query, most relevant first.
+//
+// It validates the query, limit and cursor. When the query names a chat the user
+// must be allowed to read it; otherwise all chats the user belongs to are searched.
+// One extra hit is loaded to find out whether another page follows. Along with the
+// hits it returns the cursor of the next page, or an empty string if this is the last one.
+func (s *Service) SearchMessages(ctx context.Context, userID int, query models.SearchQuery, limitStr, cursorStr string) ([]models.SearchHit, string, error) {
+
+	ctx, span := startSpan(ctx, "SearchMessages", attribute.Int("user.id", userID))
+	defer span.End()
+
+	if err := validateSearchQuery(&query); err != nil {
+		return nil, "", err
+	}
+
+	limit, err := s.validateLimit(limitStr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cursor, err := decodeSearchCursor(cursorStr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if query.ChatID != 0 {
+		if _, err := s.authorize(ctx, userID, query.ChatID, models.RoleReadOnly); err != nil {
+			return nil, "", err
+		}
+	}
+
+	query.MemberID = userID
+
+	hits, err := s.storage.SearchMessages(ctx, query, limit+1, cursor)
+	if err != nil {
+		s.logger.LogErrorContext(ctx, "service — failed to search messages", err, "chatID", query.ChatID, "layer", "service.impl")
+		return nil, "", err
+	}
+
+	if len(hits) <= limit {
+		return hits, "", nil
+	}
+
+	hits = hits[:limit]
+	last := hits[limit-1]
+
+	return hits, encodeSearchCursor(last.Rank, last.Message.ID), nil
+
+}
@@ -0,0 +1,11 @@
+package impl
+
+import "chatX/internal/models"
+
+// Stats returns a snapshot of the business-level counters.
+func (s *Service) Stats() models.ServiceStats {
+	return models.ServiceStats{
+		ChatsCreated:    s.chatsCreated.Load(),
+		MessagesCreated: s.messagesCreated.Load(),
+	}
+}
@@ -0,0 +1,26 @@
+package impl
+
+import (
+	"chatX/internal/models"
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SubscribeChat subscribes to real-time events of a chat.
+//
+// The user must be a member of the chat, which also guarantees that the chat exists.
+// Returns the event channel and a function that ends the subscription.
+func (s *Service) SubscribeChat(ctx context.Context, userID int, chatID int) (<-chan models.Event, func(), error) {
+
+	ctx, span := startSpan(ctx, "SubscribeChat", attribute.Int("user.id", userID), attribute.Int("chat.id", chatID))
+	defer span.End()
+
+	if _, err := s.authorize(ctx, userID, chatID, models.RoleReadOnly); err != nil {
+		return nil, nil, err
+	}
+
+	events, unsubscribe := s.broker.Subscribe(chatID)
+	return events, unsubscribe, nil
+
+}
@@ -0,0 +1,17 @@
+package impl
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of service operations.
+var tracer = otel.Tracer("chatX/internal/service/impl")
+
+// startSpan starts a child span of a service operation with the given attributes.
+func startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "service."+operation, trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,41 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// UpdateChat renames an existing chat and invalidates its cache entry.
+//
+// Only admins and the owner of the chat may rename it.
+func (s *Service) UpdateChat(ctx context.Context, userID int, chat models.Chat) (models.Chat, error) {
+
+	ctx, span := startSpan(ctx, "UpdateChat", attribute.Int("user.id", userID), attribute.Int("chat.id", chat.ID))
+	defer span.End()
+
+	if err := s.validateChat(&chat); err != nil {
+		return models.Chat{}, err
+	}
+
+	if _, err := s.authorize(ctx, userID, chat.ID, models.RoleAdmin); err != nil {
+		return models.Chat{}, err
+	}
+
+	chat.UpdatedAt = time.Now().UTC()
+
+	if err := s.storage.UpdateChat(ctx, &chat); err != nil {
+		if !errors.Is(err, errs.ErrChatNotFound) {
+			s.logger.LogErrorContext(ctx, "service — failed to update chat", err, "chatID", chat.ID, "layer", "service.impl")
+		}
+		return models.Chat{}, err
+	}
+
+	s.invalidate(ctx, chat.ID)
+	return chat, nil
+
+}
@@ -0,0 +1,49 @@
+package impl
+
+import (
+	"chatX/internal/errs"
+	"chatX/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// UpdateMessage edits the text of an existing message and replaces it in the chat's cache entry.
+//
+// Only the author of the message may edit it, and only while allowed to write in the chat.
+func (s *Service) UpdateMessage(ctx context.Context, userID int, message models.Message) (models.Message, error) {
+
+	ctx, span := startSpan(ctx, "UpdateMessage", attribute.Int("user.id", userID), attribute.Int("chat.id", message.ChatID), attribute.Int("message.id", message.ID))
+	defer span.End()
+
+	if err := s.validateMessage(&message); err != nil {
+		return models.Message{}, err
+	}
+
+	member, err := s.authorize(ctx, userID, message.ChatID, models.RoleMember)
+	if err != nil {
+		return models.Message{}, err
+	}
+
+	if err := s.authorizeAuthor(ctx, member, message.ID); err != nil {
+		return models.Message{}, err
+	}
+
+	editedAt := time.Now().UTC()
+	message.EditedAt = &editedAt
+
+	if err := s.storage.UpdateMessage(ctx, &message); err != nil {
+		if !errors.Is(err, errs.ErrMessageNotFound) {
+			s.logger.LogErrorContext(ctx, "service — failed to update message", err, "messageID", message.ID, "layer", "service.impl")
+		}
+		return models.Message{}, err
+	}
+
+	// storage returns the message with its replies, reactions and attachments, as it is cached
+	s.cache.Update(ctx, message.ChatID, message)
+	s.invalidateOthers(ctx, message.ChatID)
+	return message, nil
+
+}
@@ -3,8 +3,11 @@ package impl
 import (
 	"chatX/internal/errs"
 	"chatX/internal/models"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -52,13 +55,39 @@ func (s *Service) validateMessage(message *models.Message) error {
 
 }
 
+// usernamePattern matches usernames of 3 to 32 letters, digits, underscores or hyphens.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,32}$`)
+
+// validateCredentials checks whether the provided username and password are valid for registration.
+//
+// The password must be at least the configured minimum length in runes and at most
+// 72 bytes, the longest input bcrypt takes into account.
+func (s *Service) validateCredentials(username, password string) error {
+
+	if !usernamePattern.MatchString(username) {
+		return errs.ErrUsernameInvalid
+	}
+
+	if utf8.RuneCountInString(password) < s.config.MinPasswordLength {
+		return errs.ErrPasswordTooShort
+	}
+
+	if len(password) > 72 {
+		return errs.ErrPasswordTooLong
+	}
+
+	return nil
+
+}
+
 // validateLimit parses and validates the limit string for retrieving messages.
 //
-// If the string is empty or "0", it returns the default limit. It ensures the
-// limit is a positive integer and does not exceed the configured maximum.
+// If the string is empty or parses to zero (including forms such as "00" or "-0"),
+// it returns the default limit. It ensures the limit is a positive integer and does
+// not exceed the configured maximum.
 func (s *Service) validateLimit(limitStr string) (int, error) {
 
-	if limitStr == "" || limitStr == "0" {
+	if limitStr == "" {
 		return s.config.GetLimitDefault, nil
 	}
 
@@ -71,6 +100,10 @@ func (s *Service) validateLimit(limitStr string) (int, error) {
 		return 0, errs.ErrLimitTooSmall
 	}
 
+	if limit == 0 {
+		return s.config.GetLimitDefault, nil
+	}
+
 	if limit > s.config.GetLimitMax {
 		return 0, errs.ErrLimitTooLarge
 	}
@@ -78,3 +111,161 @@ func (s *Service) validateLimit(limitStr string) (int, error) {
 	return limit, nil
 
 }
+
+// validateWait parses the long-polling wait duration for new messages.
+//
+// An empty string means no waiting. It ensures the duration is not negative and
+// does not exceed the configured maximum.
+func (s *Service) validateWait(waitStr string) (time.Duration, error) {
+
+	if waitStr == "" {
+		return 0, nil
+	}
+
+	wait, err := time.ParseDuration(waitStr)
+	if err != nil || wait < 0 {
+		return 0, errs.ErrInvalidWait
+	}
+
+	if wait > s.config.MaxWait {
+		return 0, errs.ErrWaitTooLong
+	}
+
+	return wait, nil
+
+}
+
+// validateCursor parses the before and after cursor strings for retrieving messages.
+//
+// At most one of them may be set. Returns a nil cursor if neither is provided,
+// which means the newest messages are requested.
+func validateCursor(before, after string) (*models.Cursor, error) {
+
+	switch {
+	case before != "" && after != "":
+		return nil, errs.ErrCursorConflict
+	case before != "":
+		return decodeCursor(before, models.Before)
+	case after != "":
+		return decodeCursor(after, models.After)
+	default:
+		return nil, nil
+	}
+
+}
+
+// validateFilter checks whether the provided chat listing filter is valid.
+//
+// It trims whitespace from the title substring, defaults the sort order to
+// creation time, and ensures the creation time range is not inverted.
+func validateFilter(filter *models.ChatFilter) error {
+
+	filter.Title = strings.TrimSpace(filter.Title)
+
+	switch filter.Sort {
+	case "":
+		filter.Sort = models.SortByCreatedAt
+	case models.SortByCreatedAt, models.SortByLastActivity:
+	default:
+		return errs.ErrInvalidSort
+	}
+
+	if !filter.CreatedAfter.IsZero() && !filter.CreatedBefore.IsZero() && !filter.CreatedAfter.Before(filter.CreatedBefore) {
+		return errs.ErrInvalidTimeRange
+	}
+
+	return nil
+
+}
+
+// validateRole checks whether the provided role can be granted to a new member.
+//
+// An empty role defaults to member. The owner role cannot be granted, since
+// every chat has exactly one owner, its creator.
+func validateRole(role *models.Role) error {
+
+	if *role == "" {
+		*role = models.RoleMember
+	}
+
+	switch *role {
+	case models.RoleAdmin, models.RoleMember, models.RoleReadOnly:
+		return nil
+	default:
+		return errs.ErrInvalidRole
+	}
+
+}
+
+// maxEmojiLength is the maximum length of a reaction emoji in bytes; it leaves room
+// for multi-codepoint sequences such as flags and ZWJ family emoji.
+const maxEmojiLength = 32
+
+// validateEmoji checks whether the provided reaction emoji is valid.
+//
+// It trims surrounding whitespace and ensures the emoji is valid UTF-8 of 1 to
+// maxEmojiLength bytes without spaces or control characters.
+func validateEmoji(emoji *string) error {
+
+	*emoji = strings.TrimSpace(*emoji)
+
+	if len(*emoji) == 0 || len(*emoji) > maxEmojiLength || !utf8.ValidString(*emoji) {
+		return errs.ErrInvalidEmoji
+	}
+
+	if strings.ContainsFunc(*emoji, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) {
+		return errs.ErrInvalidEmoji
+	}
+
+	return nil
+
+}
+
+// maxSearchQueryLength is the maximum length of a search query in runes.
+const maxSearchQueryLength = 256
+
+// validateSearchQuery checks whether the provided search query is valid.
+//
+// It trims whitespace from the search terms and ensures they are neither empty
+// nor longer than maxSearchQueryLength, and that the chat ID is not negative.
+func validateSearchQuery(query *models.SearchQuery) error {
+
+	query.Text = strings.TrimSpace(query.Text)
+	length := utf8.RuneCountInString(query.Text)
+
+	if length == 0 {
+		return errs.ErrSearchQueryEmpty
+	}
+
+	if length > maxSearchQueryLength {
+		return errs.ErrSearchQueryTooLong
+	}
+
+	if query.ChatID < 0 {
+		return errs.ErrInvalidChatID
+	}
+
+	return nil
+
+}
+
+// maxIdempotencyKeyLength is the maximum length of an Idempotency-Key header in bytes.
+const maxIdempotencyKeyLength = 255
+
+// validateIdempotencyKey checks whether the provided Idempotency-Key is valid.
+//
+// The key must be 1 to maxIdempotencyKeyLength printable ASCII characters, which
+// covers UUIDs and other random tokens clients typically generate.
+func validateIdempotencyKey(key string) error {
+
+	if len(key) == 0 || len(key) > maxIdempotencyKeyLength {
+		return errs.ErrInvalidIdempotencyKey
+	}
+
+	if strings.ContainsFunc(key, func(r rune) bool { return r < ' ' || r > '~' }) {
+		return errs.ErrInvalidIdempotencyKey
+	}
+
+	return nil
+
+}
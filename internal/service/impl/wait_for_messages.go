@@ -0,0 +1,138 @@
+package impl
+
+import (
+	"chatX/internal/models"
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// WaitForMessages retrieves messages of a chat the reader has not received yet,
+// waiting for one to arrive if there are none yet, along with the cursor of the next poll.
+//
+// The reader is positioned by an opaque cursor returned by a previous poll or, without one,
+// by the ID of the last message it has received. Message IDs are taken before their
+// transactions commit, so a message may become visible after one with a higher ID. Polls
+// with a cursor therefore also return the messages created at most the replay grace before
+// the last one received, if the reader has not received them yet.
+//
+// The wait string is a duration such as "30s"; an empty wait returns immediately.
+// Access is checked once up front. The waiter is registered before storage is queried, so a message created in
+// between is never missed. When woken up the messages are loaded again and returned
+// even if there are none, e.g. because the chat was deleted or the server is shutting
+// down; the caller simply polls again. An empty list is returned once the wait
+// elapses, and the context error if the caller goes away first.
+func (s *Service) WaitForMessages(ctx context.Context, userID int, chatID int, afterID int, cursorStr, waitStr string) ([]models.Message, string, error) {
+
+	ctx, span := startSpan(ctx, "WaitForMessages", attribute.Int("user.id", userID), attribute.Int("chat.id", chatID))
+	defer span.End()
+
+	wait, err := s.validateWait(waitStr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	position := models.PollCursor{AfterID: afterID}
+	if cursorStr != "" {
+		if position, err = decodePollCursor(cursorStr); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if _, err := s.authorize(ctx, userID, chatID, models.RoleReadOnly); err != nil {
+		return nil, "", err
+	}
+
+	ready, release := s.notifier.Wait(chatID)
+	defer release()
+
+	// a reader positioned by a message ID has received every message before it
+	caughtUp := cursorStr == ""
+
+	messages, next, err := s.pollMessages(ctx, chatID, position, caughtUp)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(messages) > 0 || wait == 0 {
+		return messages, encodePollCursor(next), nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ready:
+		messages, next, err := s.pollMessages(ctx, chatID, position, caughtUp)
+		if err != nil {
+			return nil, "", err
+		}
+		return messages, encodePollCursor(next), nil
+	case <-timer.C:
+		return messages, encodePollCursor(next), nil
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	}
+
+}
+
+// pollMessages loads the messages a long-polling reader at the given position has not
+// received yet, ordered by ID, along with its position once it has received them.
+//
+// If caughtUp is set, the reader has received every message with a lower ID than
+// position.AfterID, not only those in position.Seen.
+func (s *Service) pollMessages(ctx context.Context, chatID int, position models.PollCursor, caughtUp bool) ([]models.Message, models.PollCursor, error) {
+
+	window, err := s.storage.GetMessagesSince(ctx, chatID, position.AfterID, s.config.ReplayGrace, s.config.GetLimitMax)
+	if err != nil {
+		s.logger.LogErrorContext(ctx, "service — failed to get messages", err, "chatID", chatID, "layer", "service.impl")
+		return nil, position, err
+	}
+
+	seen := make(map[int]bool, len(position.Seen))
+	for _, id := range position.Seen {
+		seen[id] = true
+	}
+
+	messages := make([]models.Message, 0, len(window))
+	for _, message := range window {
+		if message.ID > position.AfterID || !caughtUp && !seen[message.ID] {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, s.advance(position, window), nil
+
+}
+
+// advance returns the position of a long-polling reader that has received the given messages,
+// which are those GetMessagesSince returns for its current position.
+func (s *Service) advance(position models.PollCursor, window []models.Message) models.PollCursor {
+
+	if len(window) == 0 {
+		return position
+	}
+
+	last := window[len(window)-1]
+
+	// without newer messages the window is still the one before position.AfterID
+	if last.ID < position.AfterID {
+		next := models.PollCursor{AfterID: position.AfterID}
+		for _, message := range window {
+			next.Seen = append(next.Seen, message.ID)
+		}
+		return next
+	}
+
+	next := models.PollCursor{AfterID: last.ID}
+	since := last.CreatedAt.Add(-s.config.ReplayGrace)
+	for _, message := range window[:len(window)-1] {
+		if !message.CreatedAt.Before(since) {
+			next.Seen = append(next.Seen, message.ID)
+		}
+	}
+
+	return next
+
+}
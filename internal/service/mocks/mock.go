@@ -12,6 +12,7 @@ package mocks
 import (
 	models "chatX/internal/models"
 	context "context"
+	io "io"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -41,61 +42,454 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// AddMember mocks base method.
+func (m *MockService) AddMember(ctx context.Context, userID int, member models.ChatMember) (models.ChatMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMember", ctx, userID, member)
+	ret0, _ := ret[0].(models.ChatMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddMember indicates an expected call of AddMember.
+func (mr *MockServiceMockRecorder) AddMember(ctx, userID, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMember", reflect.TypeOf((*MockService)(nil).AddMember), ctx, userID, member)
+}
+
+// AddReaction mocks base method.
+func (m *MockService) AddReaction(ctx context.Context, userID, chatID, messageID int, emoji string) (models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddReaction", ctx, userID, chatID, messageID, emoji)
+	ret0, _ := ret[0].(models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddReaction indicates an expected call of AddReaction.
+func (mr *MockServiceMockRecorder) AddReaction(ctx, userID, chatID, messageID, emoji any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReaction", reflect.TypeOf((*MockService)(nil).AddReaction), ctx, userID, chatID, messageID, emoji)
+}
+
+// Authenticate mocks base method.
+func (m *MockService) Authenticate(ctx context.Context, token string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, token)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockServiceMockRecorder) Authenticate(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockService)(nil).Authenticate), ctx, token)
+}
+
+// AuthenticateStream mocks base method.
+func (m *MockService) AuthenticateStream(ctx context.Context, token string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateStream", ctx, token)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateStream indicates an expected call of AuthenticateStream.
+func (mr *MockServiceMockRecorder) AuthenticateStream(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateStream", reflect.TypeOf((*MockService)(nil).AuthenticateStream), ctx, token)
+}
+
+// BeginIdempotent mocks base method.
+func (m *MockService) BeginIdempotent(ctx context.Context, userID int, key, requestHash string) (models.IdempotencyKey, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginIdempotent", ctx, userID, key, requestHash)
+	ret0, _ := ret[0].(models.IdempotencyKey)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// BeginIdempotent indicates an expected call of BeginIdempotent.
+func (mr *MockServiceMockRecorder) BeginIdempotent(ctx, userID, key, requestHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginIdempotent", reflect.TypeOf((*MockService)(nil).BeginIdempotent), ctx, userID, key, requestHash)
+}
+
+// CollectIdempotencyKeys mocks base method.
+func (m *MockService) CollectIdempotencyKeys(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CollectIdempotencyKeys", ctx)
+}
+
+// CollectIdempotencyKeys indicates an expected call of CollectIdempotencyKeys.
+func (mr *MockServiceMockRecorder) CollectIdempotencyKeys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CollectIdempotencyKeys", reflect.TypeOf((*MockService)(nil).CollectIdempotencyKeys), ctx)
+}
+
+// CompleteIdempotent mocks base method.
+func (m *MockService) CompleteIdempotent(ctx context.Context, record models.IdempotencyKey) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CompleteIdempotent", ctx, record)
+}
+
+// CompleteIdempotent indicates an expected call of CompleteIdempotent.
+func (mr *MockServiceMockRecorder) CompleteIdempotent(ctx, record any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotent", reflect.TypeOf((*MockService)(nil).CompleteIdempotent), ctx, record)
+}
+
 // CreateChat mocks base method.
-func (m *MockService) CreateChat(ctx context.Context, chat models.Chat) (models.Chat, error) {
+func (m *MockService) CreateChat(ctx context.Context, userID int, chat models.Chat) (models.Chat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateChat", ctx, chat)
+	ret := m.ctrl.Call(m, "CreateChat", ctx, userID, chat)
 	ret0, _ := ret[0].(models.Chat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateChat indicates an expected call of CreateChat.
-func (mr *MockServiceMockRecorder) CreateChat(ctx, chat any) *gomock.Call {
+func (mr *MockServiceMockRecorder) CreateChat(ctx, userID, chat any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChat", reflect.TypeOf((*MockService)(nil).CreateChat), ctx, chat)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChat", reflect.TypeOf((*MockService)(nil).CreateChat), ctx, userID, chat)
 }
 
 // CreateMessage mocks base method.
-func (m *MockService) CreateMessage(ctx context.Context, message models.Message) (models.Message, error) {
+func (m *MockService) CreateMessage(ctx context.Context, userID int, message models.Message, uploads ...models.Upload) (models.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateMessage", ctx, message)
+	varargs := []any{ctx, userID, message}
+	for _, a := range uploads {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateMessage", varargs...)
 	ret0, _ := ret[0].(models.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateMessage indicates an expected call of CreateMessage.
-func (mr *MockServiceMockRecorder) CreateMessage(ctx, message any) *gomock.Call {
+func (mr *MockServiceMockRecorder) CreateMessage(ctx, userID, message any, uploads ...any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockService)(nil).CreateMessage), ctx, message)
+	varargs := append([]any{ctx, userID, message}, uploads...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockService)(nil).CreateMessage), varargs...)
 }
 
 // DeleteChat mocks base method.
-func (m *MockService) DeleteChat(ctx context.Context, chatID int) error {
+func (m *MockService) DeleteChat(ctx context.Context, userID, chatID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteChat", ctx, chatID)
+	ret := m.ctrl.Call(m, "DeleteChat", ctx, userID, chatID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteChat indicates an expected call of DeleteChat.
-func (mr *MockServiceMockRecorder) DeleteChat(ctx, chatID any) *gomock.Call {
+func (mr *MockServiceMockRecorder) DeleteChat(ctx, userID, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChat", reflect.TypeOf((*MockService)(nil).DeleteChat), ctx, userID, chatID)
+}
+
+// DeleteMessage mocks base method.
+func (m *MockService) DeleteMessage(ctx context.Context, userID, chatID, messageID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMessage", ctx, userID, chatID, messageID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMessage indicates an expected call of DeleteMessage.
+func (mr *MockServiceMockRecorder) DeleteMessage(ctx, userID, chatID, messageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMessage", reflect.TypeOf((*MockService)(nil).DeleteMessage), ctx, userID, chatID, messageID)
+}
+
+// GetAttachment mocks base method.
+func (m *MockService) GetAttachment(ctx context.Context, userID, chatID, attachmentID int) (models.Attachment, io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttachment", ctx, userID, chatID, attachmentID)
+	ret0, _ := ret[0].(models.Attachment)
+	ret1, _ := ret[1].(io.ReadCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAttachment indicates an expected call of GetAttachment.
+func (mr *MockServiceMockRecorder) GetAttachment(ctx, userID, chatID, attachmentID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChat", reflect.TypeOf((*MockService)(nil).DeleteChat), ctx, chatID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttachment", reflect.TypeOf((*MockService)(nil).GetAttachment), ctx, userID, chatID, attachmentID)
 }
 
 // GetChat mocks base method.
-func (m *MockService) GetChat(ctx context.Context, chatID int, limit string) (models.Chat, error) {
+func (m *MockService) GetChat(ctx context.Context, userID, chatID int, limit, before, after string) (models.Chat, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetChat", ctx, chatID, limit)
+	ret := m.ctrl.Call(m, "GetChat", ctx, userID, chatID, limit, before, after)
 	ret0, _ := ret[0].(models.Chat)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetChat indicates an expected call of GetChat.
+func (mr *MockServiceMockRecorder) GetChat(ctx, userID, chatID, limit, before, after any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChat", reflect.TypeOf((*MockService)(nil).GetChat), ctx, userID, chatID, limit, before, after)
+}
+
+// GetMessagesAfter mocks base method.
+func (m *MockService) GetMessagesAfter(ctx context.Context, userID, chatID, afterID int) ([]models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessagesAfter", ctx, userID, chatID, afterID)
+	ret0, _ := ret[0].([]models.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetChat indicates an expected call of GetChat.
-func (mr *MockServiceMockRecorder) GetChat(ctx, chatID, limit any) *gomock.Call {
+// GetMessagesAfter indicates an expected call of GetMessagesAfter.
+func (mr *MockServiceMockRecorder) GetMessagesAfter(ctx, userID, chatID, afterID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessagesAfter", reflect.TypeOf((*MockService)(nil).GetMessagesAfter), ctx, userID, chatID, afterID)
+}
+
+// GetThread mocks base method.
+func (m *MockService) GetThread(ctx context.Context, userID, chatID, messageID int, limit, cursor string) (models.Thread, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThread", ctx, userID, chatID, messageID, limit, cursor)
+	ret0, _ := ret[0].(models.Thread)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetThread indicates an expected call of GetThread.
+func (mr *MockServiceMockRecorder) GetThread(ctx, userID, chatID, messageID, limit, cursor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThread", reflect.TypeOf((*MockService)(nil).GetThread), ctx, userID, chatID, messageID, limit, cursor)
+}
+
+// IssueStreamToken mocks base method.
+func (m *MockService) IssueStreamToken(ctx context.Context, userID int) (models.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueStreamToken", ctx, userID)
+	ret0, _ := ret[0].(models.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueStreamToken indicates an expected call of IssueStreamToken.
+func (mr *MockServiceMockRecorder) IssueStreamToken(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueStreamToken", reflect.TypeOf((*MockService)(nil).IssueStreamToken), ctx, userID)
+}
+
+// ListChats mocks base method.
+func (m *MockService) ListChats(ctx context.Context, userID int, filter models.ChatFilter, limit, cursor string) ([]models.ChatSummary, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChats", ctx, userID, filter, limit, cursor)
+	ret0, _ := ret[0].([]models.ChatSummary)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListChats indicates an expected call of ListChats.
+func (mr *MockServiceMockRecorder) ListChats(ctx, userID, filter, limit, cursor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChats", reflect.TypeOf((*MockService)(nil).ListChats), ctx, userID, filter, limit, cursor)
+}
+
+// ListMembers mocks base method.
+func (m *MockService) ListMembers(ctx context.Context, userID, chatID int) ([]models.ChatMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMembers", ctx, userID, chatID)
+	ret0, _ := ret[0].([]models.ChatMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMembers indicates an expected call of ListMembers.
+func (mr *MockServiceMockRecorder) ListMembers(ctx, userID, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMembers", reflect.TypeOf((*MockService)(nil).ListMembers), ctx, userID, chatID)
+}
+
+// Login mocks base method.
+func (m *MockService) Login(ctx context.Context, username, password string) (models.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx, username, password)
+	ret0, _ := ret[0].(models.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockServiceMockRecorder) Login(ctx, username, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockService)(nil).Login), ctx, username, password)
+}
+
+// MarkRead mocks base method.
+func (m *MockService) MarkRead(ctx context.Context, userID, chatID, messageID int) (models.ReadReceipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", ctx, userID, chatID, messageID)
+	ret0, _ := ret[0].(models.ReadReceipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockServiceMockRecorder) MarkRead(ctx, userID, chatID, messageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockService)(nil).MarkRead), ctx, userID, chatID, messageID)
+}
+
+// Register mocks base method.
+func (m *MockService) Register(ctx context.Context, username, password string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", ctx, username, password)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockServiceMockRecorder) Register(ctx, username, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockService)(nil).Register), ctx, username, password)
+}
+
+// RemoveMember mocks base method.
+func (m *MockService) RemoveMember(ctx context.Context, userID, chatID, memberID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", ctx, userID, chatID, memberID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockServiceMockRecorder) RemoveMember(ctx, userID, chatID, memberID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*MockService)(nil).RemoveMember), ctx, userID, chatID, memberID)
+}
+
+// RemoveReaction mocks base method.
+func (m *MockService) RemoveReaction(ctx context.Context, userID, chatID, messageID int, emoji string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveReaction", ctx, userID, chatID, messageID, emoji)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveReaction indicates an expected call of RemoveReaction.
+func (mr *MockServiceMockRecorder) RemoveReaction(ctx, userID, chatID, messageID, emoji any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveReaction", reflect.TypeOf((*MockService)(nil).RemoveReaction), ctx, userID, chatID, messageID, emoji)
+}
+
+// ReplayMessages mocks base method.
+func (m *MockService) ReplayMessages(ctx context.Context, userID, chatID, lastID int) ([]models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayMessages", ctx, userID, chatID, lastID)
+	ret0, _ := ret[0].([]models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplayMessages indicates an expected call of ReplayMessages.
+func (mr *MockServiceMockRecorder) ReplayMessages(ctx, userID, chatID, lastID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayMessages", reflect.TypeOf((*MockService)(nil).ReplayMessages), ctx, userID, chatID, lastID)
+}
+
+// SearchMessages mocks base method.
+func (m *MockService) SearchMessages(ctx context.Context, userID int, query models.SearchQuery, limit, cursor string) ([]models.SearchHit, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchMessages", ctx, userID, query, limit, cursor)
+	ret0, _ := ret[0].([]models.SearchHit)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchMessages indicates an expected call of SearchMessages.
+func (mr *MockServiceMockRecorder) SearchMessages(ctx, userID, query, limit, cursor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchMessages", reflect.TypeOf((*MockService)(nil).SearchMessages), ctx, userID, query, limit, cursor)
+}
+
+// Stats mocks base method.
+func (m *MockService) Stats() models.ServiceStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(models.ServiceStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockServiceMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockService)(nil).Stats))
+}
+
+// SubscribeChat mocks base method.
+func (m *MockService) SubscribeChat(ctx context.Context, userID, chatID int) (<-chan models.Event, func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeChat", ctx, userID, chatID)
+	ret0, _ := ret[0].(<-chan models.Event)
+	ret1, _ := ret[1].(func())
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SubscribeChat indicates an expected call of SubscribeChat.
+func (mr *MockServiceMockRecorder) SubscribeChat(ctx, userID, chatID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeChat", reflect.TypeOf((*MockService)(nil).SubscribeChat), ctx, userID, chatID)
+}
+
+// UpdateChat mocks base method.
+func (m *MockService) UpdateChat(ctx context.Context, userID int, chat models.Chat) (models.Chat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateChat", ctx, userID, chat)
+	ret0, _ := ret[0].(models.Chat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateChat indicates an expected call of UpdateChat.
+func (mr *MockServiceMockRecorder) UpdateChat(ctx, userID, chat any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateChat", reflect.TypeOf((*MockService)(nil).UpdateChat), ctx, userID, chat)
+}
+
+// UpdateMessage mocks base method.
+func (m *MockService) UpdateMessage(ctx context.Context, userID int, message models.Message) (models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMessage", ctx, userID, message)
+	ret0, _ := ret[0].(models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateMessage indicates an expected call of UpdateMessage.
+func (mr *MockServiceMockRecorder) UpdateMessage(ctx, userID, message any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMessage", reflect.TypeOf((*MockService)(nil).UpdateMessage), ctx, userID, message)
+}
+
+// WaitForMessages mocks base method.
+func (m *MockService) WaitForMessages(ctx context.Context, userID, chatID, afterID int, cursor, wait string) ([]models.Message, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForMessages", ctx, userID, chatID, afterID, cursor, wait)
+	ret0, _ := ret[0].([]models.Message)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// WaitForMessages indicates an expected call of WaitForMessages.
+func (mr *MockServiceMockRecorder) WaitForMessages(ctx, userID, chatID, afterID, cursor, wait any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChat", reflect.TypeOf((*MockService)(nil).GetChat), ctx, chatID, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForMessages", reflect.TypeOf((*MockService)(nil).WaitForMessages), ctx, userID, chatID, afterID, cursor, wait)
 }
@@ -3,24 +3,58 @@
 package service
 
 import (
+	"chatX/internal/blob"
+	"chatX/internal/broker"
 	"chatX/internal/cache"
 	"chatX/internal/config"
+	"chatX/internal/eventbus"
 	"chatX/internal/logger"
 	"chatX/internal/models"
+	"chatX/internal/notifier"
 	"chatX/internal/repository"
 	"chatX/internal/service/impl"
 	"context"
+	"io"
 )
 
 // Service defines the interface for chat-related business logic.
+//
+// Chat operations take the ID of the authenticated user and fail with ErrForbidden
+// unless the user's role in the chat permits them.
 type Service interface {
-	CreateChat(ctx context.Context, chat models.Chat) (models.Chat, error)             // CreateChat creates a new chat with the given chat data.
-	CreateMessage(ctx context.Context, message models.Message) (models.Message, error) // CreateMessage creates a new message in the specified chat.
-	GetChat(ctx context.Context, chatID int, limit string) (models.Chat, error)        // GetChat retrieves a chat by ID, optionally limiting the number of messages returned.
-	DeleteChat(ctx context.Context, chatID int) error                                  // DeleteChat deletes a chat by ID.
+	CreateChat(ctx context.Context, userID int, chat models.Chat) (models.Chat, error)                                                  // CreateChat creates a new chat owned by the user.
+	CreateMessage(ctx context.Context, userID int, message models.Message, uploads ...models.Upload) (models.Message, error)            // CreateMessage creates a new message of the user in the specified chat, storing the uploaded files as its attachments.
+	GetChat(ctx context.Context, userID int, chatID int, limit, before, after string) (models.Chat, string, error)                      // GetChat retrieves a chat by ID with a page of messages and the cursor of the next page.
+	ListChats(ctx context.Context, userID int, filter models.ChatFilter, limit, cursor string) ([]models.ChatSummary, string, error)    // ListChats retrieves a page of the user's chat summaries matching the filter and the cursor of the next page.
+	GetMessagesAfter(ctx context.Context, userID int, chatID int, afterID int) ([]models.Message, error)                                // GetMessagesAfter retrieves up to the maximum GET limit of messages newer than afterID, oldest first.
+	ReplayMessages(ctx context.Context, userID int, chatID int, lastID int) ([]models.Message, error)                                   // ReplayMessages retrieves up to the maximum GET limit of messages a stream resumed after lastID may have missed, by ID.
+	WaitForMessages(ctx context.Context, userID int, chatID int, afterID int, cursor, wait string) ([]models.Message, string, error)    // WaitForMessages retrieves messages the user has not received after afterID, or the cursor of a previous poll, waiting up to wait for one to arrive, and the cursor of the next poll.
+	GetThread(ctx context.Context, userID int, chatID int, messageID int, limit, cursor string) (models.Thread, string, error)          // GetThread retrieves a message with a page of its replies and the cursor of the next page.
+	UpdateMessage(ctx context.Context, userID int, message models.Message) (models.Message, error)                                      // UpdateMessage edits the text of an existing message of the user.
+	DeleteMessage(ctx context.Context, userID int, chatID int, messageID int) error                                                     // DeleteMessage deletes a single message from a chat.
+	SearchMessages(ctx context.Context, userID int, query models.SearchQuery, limit, cursor string) ([]models.SearchHit, string, error) // SearchMessages retrieves a page of messages in the user's chats matching a full-text query and the cursor of the next page.
+	GetAttachment(ctx context.Context, userID int, chatID int, attachmentID int) (models.Attachment, io.ReadCloser, error)              // GetAttachment retrieves an attachment of a chat message and opens its content.
+	MarkRead(ctx context.Context, userID int, chatID int, messageID int) (models.ReadReceipt, error)                                    // MarkRead advances the user's read marker in a chat to a message.
+	AddReaction(ctx context.Context, userID int, chatID int, messageID int, emoji string) (models.Message, error)                       // AddReaction adds an emoji reaction of the user to a message.
+	RemoveReaction(ctx context.Context, userID int, chatID int, messageID int, emoji string) error                                      // RemoveReaction removes an emoji reaction of the user from a message.
+	UpdateChat(ctx context.Context, userID int, chat models.Chat) (models.Chat, error)                                                  // UpdateChat renames an existing chat.
+	SubscribeChat(ctx context.Context, userID int, chatID int) (<-chan models.Event, func(), error)                                     // SubscribeChat subscribes to real-time events of an existing chat.
+	AddMember(ctx context.Context, userID int, member models.ChatMember) (models.ChatMember, error)                                     // AddMember adds a user to a chat with the given role.
+	RemoveMember(ctx context.Context, userID int, chatID int, memberID int) error                                                       // RemoveMember removes a user from a chat.
+	ListMembers(ctx context.Context, userID int, chatID int) ([]models.ChatMember, error)                                               // ListMembers retrieves all members of a chat.
+	Register(ctx context.Context, username, password string) (models.User, error)                                                       // Register creates a new user account.
+	Login(ctx context.Context, username, password string) (models.Token, error)                                                         // Login checks credentials and issues a bearer token.
+	Authenticate(ctx context.Context, token string) (int, error)                                                                        // Authenticate verifies a bearer token and returns the user ID.
+	IssueStreamToken(ctx context.Context, userID int) (models.Token, error)                                                             // IssueStreamToken issues a short-lived token the user may pass in the URL of an event stream.
+	AuthenticateStream(ctx context.Context, token string) (int, error)                                                                  // AuthenticateStream verifies a stream token and returns the user ID.
+	BeginIdempotent(ctx context.Context, userID int, key, requestHash string) (models.IdempotencyKey, bool, error)                      // BeginIdempotent reserves an idempotency key for a request, or returns the stored response of a completed one.
+	CompleteIdempotent(ctx context.Context, record models.IdempotencyKey)                                                               // CompleteIdempotent stores the response to a request with a reserved idempotency key.
+	CollectIdempotencyKeys(ctx context.Context)                                                                                         // CollectIdempotencyKeys periodically deletes expired idempotency keys until the context is cancelled.
+	DeleteChat(ctx context.Context, userID int, chatID int) error                                                                       // DeleteChat deletes a chat by ID.
+	Stats() models.ServiceStats                                                                                                         // Stats returns a snapshot of the business-level counters.
 }
 
 // NewService creates a new Service instance using the concrete implementation from the impl package.
-func NewService(logger logger.Logger, config config.Service, cache cache.Cache, storage repository.Storage) Service {
-	return impl.NewService(logger, config, cache, storage)
+func NewService(logger logger.Logger, config config.Service, cache cache.Cache, storage repository.Storage, broker broker.Broker, notifier notifier.Notifier, bus eventbus.EventBus, blobs blob.BlobStore) Service {
+	return impl.NewService(logger, config, cache, storage, broker, notifier, bus, blobs)
 }
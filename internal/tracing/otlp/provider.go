@@ -0,0 +1,90 @@
+// Package otlp provides a tracer provider exporting spans over OTLP/HTTP.
+package otlp
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/logger"
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// shutdownTimeout bounds the time spent flushing pending spans on shutdown.
+const shutdownTimeout = 5 * time.Second
+
+// Provider implements the tracing.Provider interface.
+//
+// With export disabled no SDK provider is installed and spans stay no-ops,
+// but the trace context of incoming requests is still propagated.
+type Provider struct {
+	provider *sdktrace.TracerProvider // SDK provider, nil if export is disabled
+	logger   logger.Logger            // Logger instance
+}
+
+// NewProvider installs the W3C trace context propagator and, if export is enabled,
+// a global tracer provider sending batches of spans to the configured OTLP collector.
+//
+// A collector that cannot be created is logged and leaves export disabled.
+func NewProvider(logger logger.Logger, config config.Tracing) *Provider {
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	p := &Provider{logger: logger}
+
+	if !config.Enabled {
+		logger.LogInfo("tracing — span export disabled", "layer", "tracing.otlp")
+		return p
+	}
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		logger.LogError("tracing — failed to create OTLP exporter, span export disabled", err, "layer", "tracing.otlp")
+		return p
+	}
+
+	p.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", config.ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(p.provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.LogWarn("tracing — failed to export spans", "err", err.Error(), "layer", "tracing.otlp")
+	}))
+
+	logger.LogInfo("tracing — exporting spans", "endpoint", config.Endpoint, "sample_ratio", config.SampleRatio, "layer", "tracing.otlp")
+
+	return p
+
+}
+
+// Shutdown flushes pending spans and stops exporting them.
+func (p *Provider) Shutdown() {
+
+	if p.provider == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := p.provider.Shutdown(ctx); err != nil {
+		p.logger.LogError("tracing — failed to flush spans", err, "layer", "tracing.otlp")
+		return
+	}
+
+	p.logger.LogInfo("tracing — provider shut down", "layer", "tracing.otlp")
+
+}
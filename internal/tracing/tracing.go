@@ -0,0 +1,21 @@
+// Package tracing provides an interface and factory function
+// for setting up OpenTelemetry tracing of the application.
+package tracing
+
+import (
+	"chatX/internal/config"
+	"chatX/internal/logger"
+	"chatX/internal/tracing/otlp"
+)
+
+// Provider defines the interface for the application-wide tracer provider.
+type Provider interface {
+	Shutdown() // Shutdown flushes pending spans and stops exporting them.
+}
+
+// NewProvider installs the global tracer provider and the W3C trace context propagator.
+//
+// Components create their spans through the global provider, so they need no reference to it.
+func NewProvider(logger logger.Logger, config config.Tracing) Provider {
+	return otlp.NewProvider(logger, config)
+}
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+UPDATE chats SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE chats ALTER COLUMN updated_at SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE chats DROP COLUMN IF EXISTS updated_at;
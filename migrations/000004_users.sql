@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS users (
+    id             INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    username       TEXT NOT NULL,
+    password_hash  TEXT NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users(lower(username));
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS author_id INTEGER;
+ALTER TABLE messages ADD CONSTRAINT fk_messages_author FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE messages DROP COLUMN IF EXISTS author_id;
+DROP TABLE IF EXISTS users CASCADE;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- Chats created before this migration get the author of their first message as owner and their other
+-- authors as members. Chats without authored messages have no owner and stay inaccessible until a member is added directly.
+CREATE TABLE IF NOT EXISTS chat_members (
+    chat_id     INTEGER NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    user_id     INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role        TEXT NOT NULL CHECK (role IN ('owner', 'admin', 'member', 'read-only')),
+    created_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (chat_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_members_user_id ON chat_members(user_id);
+
+INSERT INTO chat_members (chat_id, user_id, role, created_at)
+SELECT DISTINCT ON (chat_id) chat_id, author_id, 'owner', NOW()
+FROM messages
+WHERE author_id IS NOT NULL
+ORDER BY chat_id, created_at, id
+ON CONFLICT DO NOTHING;
+
+INSERT INTO chat_members (chat_id, user_id, role, created_at)
+SELECT DISTINCT chat_id, author_id, 'member', NOW()
+FROM messages
+WHERE author_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS chat_members;
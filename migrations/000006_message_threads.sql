@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS parent_id INTEGER;
+ALTER TABLE messages ADD CONSTRAINT fk_messages_parent FOREIGN KEY (parent_id) REFERENCES messages(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_messages_parent_id ON messages(parent_id, created_at, id) WHERE parent_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_messages_parent_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Contents live in the blob store; deleting a message drops the rows, and the service removes its blobs once the deletion commits.
+CREATE TABLE IF NOT EXISTS attachments (
+    id            INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    message_id    INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    blob_key      TEXT NOT NULL UNIQUE,
+    filename      TEXT NOT NULL,
+    content_type  TEXT NOT NULL,
+    size          BIGINT NOT NULL CHECK (size >= 0),
+    created_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_message_id ON attachments(message_id, id);
+
+-- +goose Down
+DROP TABLE IF EXISTS attachments;
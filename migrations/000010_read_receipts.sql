@@ -0,0 +1,15 @@
+-- +goose Up
+-- The read marker keeps the creation time of the last read message so that unread messages can be
+-- counted with the (chat_id, created_at) index, even after that message is deleted.
+CREATE TABLE IF NOT EXISTS read_receipts (
+    chat_id               INTEGER NOT NULL,
+    user_id               INTEGER NOT NULL,
+    last_read_message_id  INTEGER NOT NULL,
+    last_read_at          TIMESTAMPTZ NOT NULL,
+    updated_at            TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (chat_id, user_id),
+    FOREIGN KEY (chat_id, user_id) REFERENCES chat_members(chat_id, user_id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS read_receipts;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- A zero status_code marks a request that is still being processed.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id        INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key            TEXT NOT NULL,
+    request_hash   TEXT NOT NULL,
+    status_code    INTEGER NOT NULL DEFAULT 0,
+    response_body  BYTEA,
+    created_at     TIMESTAMPTZ NOT NULL,
+    expires_at     TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,52 @@
+-- +goose Up
+-- Message statistics are kept on the chat, so that chats are listed by last activity through an index
+-- instead of aggregating the messages of every chat. Triggers update them on every insert and delete
+-- of a message, including replies deleted along with their parent, at the cost of serialising the
+-- messages written to the same chat on its row.
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS message_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS last_message_at TIMESTAMPTZ;
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS last_activity TIMESTAMPTZ
+    GENERATED ALWAYS AS (COALESCE(last_message_at, created_at)) STORED;
+
+UPDATE chats SET message_count = stats.message_count, last_message_at = stats.last_message_at
+FROM (SELECT chat_id, COUNT(*) AS message_count, MAX(created_at) AS last_message_at FROM messages GROUP BY chat_id) AS stats
+WHERE chats.id = stats.chat_id;
+
+CREATE INDEX IF NOT EXISTS idx_chats_created_at ON chats(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_chats_last_activity ON chats(last_activity DESC, id DESC);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION count_chat_message() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE chats SET message_count = message_count + 1, last_message_at = GREATEST(last_message_at, NEW.created_at)
+    WHERE id = NEW.chat_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- The newest remaining message is found with the (chat_id, created_at) index.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION uncount_chat_message() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE chats SET message_count = message_count - 1,
+        last_message_at = (SELECT MAX(created_at) FROM messages WHERE chat_id = OLD.chat_id)
+    WHERE id = OLD.chat_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_messages_count AFTER INSERT ON messages FOR EACH ROW EXECUTE FUNCTION count_chat_message();
+CREATE TRIGGER trg_messages_uncount AFTER DELETE ON messages FOR EACH ROW EXECUTE FUNCTION uncount_chat_message();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS trg_messages_uncount ON messages;
+DROP TRIGGER IF EXISTS trg_messages_count ON messages;
+DROP FUNCTION IF EXISTS uncount_chat_message();
+DROP FUNCTION IF EXISTS count_chat_message();
+DROP INDEX IF EXISTS idx_chats_last_activity;
+DROP INDEX IF EXISTS idx_chats_created_at;
+ALTER TABLE chats DROP COLUMN IF EXISTS last_activity;
+ALTER TABLE chats DROP COLUMN IF EXISTS last_message_at;
+ALTER TABLE chats DROP COLUMN IF EXISTS message_count;